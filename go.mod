@@ -1,3 +1,10 @@
 module github.com/Ajna-inc/anoncreds-go
 
 go 1.24.3
+
+require (
+	golang.org/x/crypto v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.28.0 // indirect
@@ -12,6 +12,7 @@ package ffi
 import "C"
 import (
 	"fmt"
+	"strings"
 	"unsafe"
 )
 
@@ -92,6 +93,64 @@ func freeByteBuffer(bb C.struct_ByteBuffer) {
 	}
 }
 
+/// @dev Builds an FfiStrList of C strings; the returned function frees them
+func newFfiStrList(values []string) (C.FfiStrList, func()) {
+	list := C.FfiStrList{}
+	if len(values) == 0 {
+		return list, func() {}
+	}
+	cValues := make([]C.FfiStr, len(values))
+	for i, value := range values {
+		cValues[i] = C.FfiStr(C.CString(value))
+	}
+	list.count = C.size_t(len(cValues))
+	list.data = (*C.FfiStr)(unsafe.Pointer(&cValues[0]))
+	return list, func() {
+		for _, cValue := range cValues {
+			C.free(unsafe.Pointer(cValue))
+		}
+	}
+}
+
+/// @dev Copies a secret into C memory; the returned function zeroes the copy before freeing it
+func newSecretCString(secret []byte) (*C.char, func()) {
+	size := len(secret) + 1
+	ptr := (*C.char)(C.malloc(C.size_t(size)))
+	buffer := unsafe.Slice((*byte)(unsafe.Pointer(ptr)), size)
+	copy(buffer, secret)
+	buffer[len(secret)] = 0
+	return ptr, func() {
+		C.memset(unsafe.Pointer(ptr), 0, C.size_t(size))
+		C.free(unsafe.Pointer(ptr))
+	}
+}
+
+/// @dev Builds an FfiList_ObjectHandle from handles
+func newFfiHandleList(handles []*ObjectHandle) C.struct_FfiList_ObjectHandle {
+	list := C.struct_FfiList_ObjectHandle{}
+	if len(handles) == 0 {
+		return list
+	}
+	cHandles := make([]C.ObjectHandle, len(handles))
+	for i, handle := range handles {
+		cHandles[i] = handle.GetHandle()
+	}
+	list.count = C.size_t(len(cHandles))
+	list.data = (*C.ObjectHandle)(unsafe.Pointer(&cHandles[0]))
+	return list
+}
+
+/// @dev Splits a handle map into parallel ID and handle slices
+func splitHandles(handles map[string]*ObjectHandle) ([]string, []*ObjectHandle) {
+	ids := make([]string, 0, len(handles))
+	list := make([]*ObjectHandle, 0, len(handles))
+	for id, handle := range handles {
+		ids = append(ids, id)
+		list = append(list, handle)
+	}
+	return ids, list
+}
+
 // ObjectFromJSON creates an object from JSON
 func ObjectFromJSON(objType string, json string) (*ObjectHandle, error) {
 	bb := createByteBuffer(json)
@@ -115,14 +174,20 @@ func ObjectFromJSON(objType string, json string) (*ObjectHandle, error) {
 		code = C.anoncreds_key_correctness_proof_from_json(bb, &handle)
 	case "CredentialRequestMetadata":
 		code = C.anoncreds_credential_request_metadata_from_json(bb, &handle)
+	case "CredentialDefinitionPrivate":
+		code = C.anoncreds_credential_definition_private_from_json(bb, &handle)
 	case "RevocationRegistryDefinition":
 		code = C.anoncreds_revocation_registry_definition_from_json(bb, &handle)
+	case "RevocationRegistryDefinitionPrivate":
+		code = C.anoncreds_revocation_registry_definition_private_from_json(bb, &handle)
 	case "RevocationStatusList":
 		code = C.anoncreds_revocation_status_list_from_json(bb, &handle)
 	case "PresentationRequest":
 		code = C.anoncreds_presentation_request_from_json(bb, &handle)
 	case "Presentation":
 		code = C.anoncreds_presentation_from_json(bb, &handle)
+	case "RevocationState":
+		code = C.anoncreds_revocation_state_from_json(bb, &handle)
 	default:
 		return nil, fmt.Errorf("unknown object type: %s", objType)
 	}
@@ -158,6 +223,24 @@ func ObjectToJSON(handle *ObjectHandle) (string, error) {
 	return "", fmt.Errorf("failed to get JSON")
 }
 
+/// @notice Returns the type name the library reports for an object
+func ObjectTypeName(handle *ObjectHandle) (string, error) {
+	if handle == nil {
+		return "", fmt.Errorf("nil handle")
+	}
+
+	var namePtr *C.char
+	code := C.anoncreds_object_get_type_name(handle.handle, &namePtr)
+	if err := handleError(code); err != nil {
+		return "", err
+	}
+	if namePtr == nil {
+		return "", fmt.Errorf("failed to get type name")
+	}
+	defer C.anoncreds_string_free(namePtr)
+	return C.GoString(namePtr), nil
+}
+
 // GenerateNonce generates a new nonce
 func GenerateNonce() (string, error) {
 	var noncePtr *C.char
@@ -173,4 +256,31 @@ func GenerateNonce() (string, error) {
 	}
 	
 	return "", fmt.Errorf("failed to generate nonce")
-}
\ No newline at end of file
+}
+
+/// @notice Encodes raw attribute values the way credentials sign them
+/// @return One encoded value per raw value, in order
+func EncodeCredentialAttributes(rawValues []string) ([]string, error) {
+	if len(rawValues) == 0 {
+		return nil, nil
+	}
+	list, free := newFfiStrList(rawValues)
+	defer free()
+
+	var resultPtr *C.char
+	code := C.anoncreds_encode_credential_attributes(list, &resultPtr)
+	if err := handleError(code); err != nil {
+		return nil, err
+	}
+	if resultPtr == nil {
+		return nil, fmt.Errorf("failed to encode credential attributes")
+	}
+	defer C.anoncreds_string_free(resultPtr)
+
+	// Encoded values are decimal integers, so the comma-separated result splits unambiguously
+	encoded := strings.Split(C.GoString(resultPtr), ",")
+	if len(encoded) != len(rawValues) {
+		return nil, fmt.Errorf("expected %d encoded values, got %d", len(rawValues), len(encoded))
+	}
+	return encoded, nil
+}
@@ -217,9 +217,14 @@ func CreateCredential(
 	var encodedValuesList C.FfiStrList
 	
 	// Handle revocation config
-	var revConfigPtr unsafe.Pointer
+	var revConfigPtr *C.struct_FfiCredRevInfo
 	if revocationConfig != nil {
-		// Revocation config will be implemented in a future version
+		revConfigPtr = &C.struct_FfiCredRevInfo{
+			reg_def:         revocationConfig.RegistryDefinition.GetHandle(),
+			reg_def_private: revocationConfig.RegistryDefinitionPrivate.GetHandle(),
+			status_list:     revocationConfig.StatusList.GetHandle(),
+			reg_idx:         C.int64_t(revocationConfig.RegistryIndex),
+		}
 	}
 	
 	var credHandle C.ObjectHandle
@@ -231,7 +236,7 @@ func CreateCredential(
 		namesList,
 		rawValuesList,
 		encodedValuesList,
-		(*C.struct_FfiCredRevInfo)(revConfigPtr),
+		revConfigPtr,
 		&credHandle,
 	)
 	
@@ -12,23 +12,27 @@ import (
 )
 
 /// @notice Creates a new link secret for the prover
-/// @return The generated link secret string and any error encountered
-/// @dev Link secrets are used to maintain continuity between different credentials for the same identity
-func CreateLinkSecret() (string, error) {
+/// @return The generated link secret and any error encountered
+/// @dev Link secrets are used to maintain continuity between different credentials for the same identity.
+/// The library's copy is zeroed before it is freed
+func CreateLinkSecret() ([]byte, error) {
 	var linkSecretPtr *C.char
 	
 	code := C.anoncreds_create_link_secret(&linkSecretPtr)
 	
 	if err := handleError(code); err != nil {
-		return "", err
+		return nil, err
 	}
 	
 	if linkSecretPtr != nil {
-		defer C.anoncreds_string_free(linkSecretPtr)
-		return C.GoString(linkSecretPtr), nil
+		size := C.strlen(linkSecretPtr)
+		secret := C.GoBytes(unsafe.Pointer(linkSecretPtr), C.int(size))
+		C.memset(unsafe.Pointer(linkSecretPtr), 0, size)
+		C.anoncreds_string_free(linkSecretPtr)
+		return secret, nil
 	}
 	
-	return "", fmt.Errorf("failed to create link secret")
+	return nil, fmt.Errorf("failed to create link secret")
 }
 
 /// @notice Creates a credential request for the prover
@@ -37,7 +41,7 @@ func CreateCredentialRequest(
 	entropy string,
 	proverDid *string, // optional
 	credDef *ObjectHandle,
-	linkSecret string,
+	linkSecret []byte,
 	linkSecretId string,
 	credOffer *ObjectHandle,
 ) (*ObjectHandle, *ObjectHandle, error) {
@@ -57,8 +61,8 @@ func CreateCredentialRequest(
 	var credRequestHandle C.ObjectHandle
 	var credRequestMetadataHandle C.ObjectHandle
 	
-	cLinkSecret := C.CString(linkSecret)
-	defer C.free(unsafe.Pointer(cLinkSecret))
+	cLinkSecret, wipeLinkSecret := newSecretCString(linkSecret)
+	defer wipeLinkSecret()
 	
 	code := C.anoncreds_create_credential_request(
 		C.FfiStr(cEntropy),
@@ -85,7 +89,7 @@ func CreateCredentialRequest(
 func ProcessCredential(
 	credential *ObjectHandle,
 	credRequestMetadata *ObjectHandle,
-	linkSecret string,
+	linkSecret []byte,
 	credDef *ObjectHandle,
 	revRegDef *ObjectHandle, // optional
 ) (*ObjectHandle, error) {
@@ -96,8 +100,8 @@ func ProcessCredential(
 		revRegDefHandle = revRegDef.GetHandle()
 	}
 	
-	cLinkSecret := C.CString(linkSecret)
-	defer C.free(unsafe.Pointer(cLinkSecret))
+	cLinkSecret, wipeLinkSecret := newSecretCString(linkSecret)
+	defer wipeLinkSecret()
 	
 	code := C.anoncreds_process_credential(
 		credential.GetHandle(),
@@ -122,12 +126,88 @@ func CreatePresentation(
 	credentials []PresentCredential,
 	credDefs map[string]*ObjectHandle,
 	schemas map[string]*ObjectHandle,
-	linkSecret string,
+	linkSecret []byte,
 	credentialsProve []CredentialProve,
 	selfAttestedAttrs map[string]string,
 ) (*ObjectHandle, error) {
-	// Placeholder for presentation creation implementation
+	// Credential entries; a timestamp of -1 and a zero handle mean no revocation state
+	entryList := C.struct_FfiList_FfiCredentialEntry{}
+	if len(credentials) > 0 {
+		entries := make([]C.struct_FfiCredentialEntry, len(credentials))
+		for i, credential := range credentials {
+			entries[i].credential = credential.Credential.GetHandle()
+			entries[i].timestamp = -1
+			if credential.Timestamp != nil {
+				entries[i].timestamp = C.int32_t(*credential.Timestamp)
+			}
+			if credential.RevState != nil {
+				entries[i].rev_state = credential.RevState.GetHandle()
+			}
+		}
+		entryList.count = C.size_t(len(entries))
+		entryList.data = (*C.struct_FfiCredentialEntry)(unsafe.Pointer(&entries[0]))
+	}
+	
+	// Attribute and predicate assignments
+	proveList := C.struct_FfiList_FfiCredentialProve{}
+	if len(credentialsProve) > 0 {
+		proves := make([]C.struct_FfiCredentialProve, len(credentialsProve))
+		for i, prove := range credentialsProve {
+			cReferent := C.CString(prove.Referent)
+			defer C.free(unsafe.Pointer(cReferent))
+			proves[i].entry_idx = C.int64_t(prove.EntryIndex)
+			proves[i].referent = C.FfiStr(cReferent)
+			if prove.IsPredicate {
+				proves[i].is_predicate = 1
+			}
+			if prove.Reveal {
+				proves[i].reveal = 1
+			}
+		}
+		proveList.count = C.size_t(len(proves))
+		proveList.data = (*C.struct_FfiCredentialProve)(unsafe.Pointer(&proves[0]))
+	}
+	
+	selfAttestNames := make([]string, 0, len(selfAttestedAttrs))
+	selfAttestValues := make([]string, 0, len(selfAttestedAttrs))
+	for name, value := range selfAttestedAttrs {
+		selfAttestNames = append(selfAttestNames, name)
+		selfAttestValues = append(selfAttestValues, value)
+	}
+	selfAttestNameList, freeNames := newFfiStrList(selfAttestNames)
+	defer freeNames()
+	selfAttestValueList, freeValues := newFfiStrList(selfAttestValues)
+	defer freeValues()
+	
+	schemaIds, schemaHandles := splitHandles(schemas)
+	schemaIdList, freeSchemaIds := newFfiStrList(schemaIds)
+	defer freeSchemaIds()
+	
+	credDefIds, credDefHandles := splitHandles(credDefs)
+	credDefIdList, freeCredDefIds := newFfiStrList(credDefIds)
+	defer freeCredDefIds()
+	
+	cLinkSecret, wipeLinkSecret := newSecretCString(linkSecret)
+	defer wipeLinkSecret()
+	
 	var presentationHandle C.ObjectHandle
+	code := C.anoncreds_create_presentation(
+		presRequest.GetHandle(),
+		entryList,
+		proveList,
+		selfAttestNameList,
+		selfAttestValueList,
+		C.FfiStr(cLinkSecret),
+		newFfiHandleList(schemaHandles),
+		schemaIdList,
+		newFfiHandleList(credDefHandles),
+		credDefIdList,
+		&presentationHandle,
+	)
+	
+	if err := handleError(code); err != nil {
+		return nil, err
+	}
 	
 	return NewObjectHandle(presentationHandle), nil
 }
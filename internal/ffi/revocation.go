@@ -0,0 +1,201 @@
+package ffi
+
+/*
+#include "libanoncreds.h"
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+import (
+	"unsafe"
+)
+
+/// @notice Creates a revocation registry definition for a credential definition
+/// @param credDef Handle to the public credential definition
+/// @param credDefId The ID of the credential definition
+/// @param issuerId The ID of the issuer
+/// @param tag Tag distinguishing this registry from others for the same credential definition
+/// @param revRegType The registry type, "CL_ACCUM"
+/// @param maxCredNum Maximum number of credentials the registry can hold
+/// @param tailsDirPath Directory the tails file is written to
+/// @return Handles to the public and private registry definitions and any error encountered
+func CreateRevocationRegistryDefinition(
+	credDef *ObjectHandle,
+	credDefId string,
+	issuerId string,
+	tag string,
+	revRegType string,
+	maxCredNum int64,
+	tailsDirPath string,
+) (*ObjectHandle, *ObjectHandle, error) {
+	cCredDefId := C.CString(credDefId)
+	defer C.free(unsafe.Pointer(cCredDefId))
+
+	cIssuerId := C.CString(issuerId)
+	defer C.free(unsafe.Pointer(cIssuerId))
+
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	cRevRegType := C.CString(revRegType)
+	defer C.free(unsafe.Pointer(cRevRegType))
+
+	var cTailsDirPath C.FfiStr
+	if tailsDirPath != "" {
+		cPath := C.CString(tailsDirPath)
+		defer C.free(unsafe.Pointer(cPath))
+		cTailsDirPath = C.FfiStr(cPath)
+	}
+
+	var regDefHandle C.ObjectHandle
+	var regDefPrivateHandle C.ObjectHandle
+
+	code := C.anoncreds_create_revocation_registry_def(
+		credDef.GetHandle(),
+		C.FfiStr(cCredDefId),
+		C.FfiStr(cIssuerId),
+		C.FfiStr(cTag),
+		C.FfiStr(cRevRegType),
+		C.int64_t(maxCredNum),
+		cTailsDirPath,
+		&regDefHandle,
+		&regDefPrivateHandle,
+	)
+
+	if err := handleError(code); err != nil {
+		return nil, nil, err
+	}
+
+	return NewObjectHandle(regDefHandle),
+		NewObjectHandle(regDefPrivateHandle),
+		nil
+}
+
+/// @notice Creates the initial revocation status list of a registry
+/// @param issuanceByDefault Whether every index starts out as issued
+/// @param timestamp Optional unix timestamp of the list; nil leaves it unset
+/// @return A handle to the status list and any error encountered
+func CreateRevocationStatusList(
+	credDef *ObjectHandle,
+	revRegDefId string,
+	revRegDef *ObjectHandle,
+	revRegDefPrivate *ObjectHandle,
+	issuerId string,
+	issuanceByDefault bool,
+	timestamp *int64,
+) (*ObjectHandle, error) {
+	cRevRegDefId := C.CString(revRegDefId)
+	defer C.free(unsafe.Pointer(cRevRegDefId))
+
+	cIssuerId := C.CString(issuerId)
+	defer C.free(unsafe.Pointer(cIssuerId))
+
+	var byDefault C.int8_t = 0
+	if issuanceByDefault {
+		byDefault = 1
+	}
+
+	// -1 tells the library to leave the timestamp unset
+	var cTimestamp C.int64_t = -1
+	if timestamp != nil {
+		cTimestamp = C.int64_t(*timestamp)
+	}
+
+	var statusListHandle C.ObjectHandle
+	code := C.anoncreds_create_revocation_status_list(
+		credDef.GetHandle(),
+		C.FfiStr(cRevRegDefId),
+		revRegDef.GetHandle(),
+		revRegDefPrivate.GetHandle(),
+		C.FfiStr(cIssuerId),
+		byDefault,
+		cTimestamp,
+		&statusListHandle,
+	)
+
+	if err := handleError(code); err != nil {
+		return nil, err
+	}
+
+	return NewObjectHandle(statusListHandle), nil
+}
+
+/// @notice Applies issuance and revocation changes to a status list
+/// @param credDef Handle to the public credential definition
+/// @param revRegDef Handle to the registry definition
+/// @param revRegDefPrivate Handle to the private registry definition
+/// @param currentList Handle to the status list being updated
+/// @param issued Indices to mark as issued
+/// @param revoked Indices to mark as revoked
+/// @param timestamp Optional unix timestamp of the new list; nil keeps the current one
+/// @return A handle to the new status list and any error encountered
+func UpdateRevocationStatusList(
+	credDef *ObjectHandle,
+	revRegDef *ObjectHandle,
+	revRegDefPrivate *ObjectHandle,
+	currentList *ObjectHandle,
+	issued []int32,
+	revoked []int32,
+	timestamp *int64,
+) (*ObjectHandle, error) {
+	issuedList := C.struct_FfiList_i32{}
+	if len(issued) > 0 {
+		cIssued := make([]C.int32_t, len(issued))
+		for i, index := range issued {
+			cIssued[i] = C.int32_t(index)
+		}
+		issuedList.count = C.size_t(len(cIssued))
+		issuedList.data = (*C.int32_t)(unsafe.Pointer(&cIssued[0]))
+	}
+
+	revokedList := C.struct_FfiList_i32{}
+	if len(revoked) > 0 {
+		cRevoked := make([]C.int32_t, len(revoked))
+		for i, index := range revoked {
+			cRevoked[i] = C.int32_t(index)
+		}
+		revokedList.count = C.size_t(len(cRevoked))
+		revokedList.data = (*C.int32_t)(unsafe.Pointer(&cRevoked[0]))
+	}
+
+	// -1 tells the library to keep the timestamp of the current list
+	var cTimestamp C.int64_t = -1
+	if timestamp != nil {
+		cTimestamp = C.int64_t(*timestamp)
+	}
+
+	var statusListHandle C.ObjectHandle
+	code := C.anoncreds_update_revocation_status_list(
+		credDef.GetHandle(),
+		revRegDef.GetHandle(),
+		revRegDefPrivate.GetHandle(),
+		currentList.GetHandle(),
+		issuedList,
+		revokedList,
+		cTimestamp,
+		&statusListHandle,
+	)
+
+	if err := handleError(code); err != nil {
+		return nil, err
+	}
+
+	return NewObjectHandle(statusListHandle), nil
+}
+
+/// @notice Copies a status list with only its timestamp changed
+/// @return A handle to the new status list and any error encountered
+func UpdateRevocationStatusListTimestampOnly(timestamp int64, currentList *ObjectHandle) (*ObjectHandle, error) {
+	var statusListHandle C.ObjectHandle
+	code := C.anoncreds_update_revocation_status_list_timestamp_only(
+		C.int64_t(timestamp),
+		currentList.GetHandle(),
+		&statusListHandle,
+	)
+
+	if err := handleError(code); err != nil {
+		return nil, err
+	}
+
+	return NewObjectHandle(statusListHandle), nil
+}
@@ -0,0 +1,84 @@
+package ffi
+
+/*
+#include "libanoncreds.h"
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+import (
+	"unsafe"
+)
+
+/// @dev Overrides the non-revoked interval of a registry when the ledger has no list at the requested time
+type NonRevokedIntervalOverride struct {
+	RevRegDefId                 string
+	RequestedFromTimestamp      int32
+	OverrideStatusListTimestamp int32
+}
+
+/// @notice Verifies a presentation against its request
+/// @param presentation Handle to the presentation
+/// @param presRequest Handle to the presentation request
+/// @param schemas Schemas keyed by ID
+/// @param credDefs Credential definitions keyed by ID
+/// @param revRegDefs Revocation registry definitions keyed by ID
+/// @param statusLists Status lists of the registries at the timestamps used in the presentation
+/// @param overrides Optional non-revoked interval overrides
+/// @return Whether the presentation is valid and any error encountered
+func VerifyPresentation(
+	presentation *ObjectHandle,
+	presRequest *ObjectHandle,
+	schemas map[string]*ObjectHandle,
+	credDefs map[string]*ObjectHandle,
+	revRegDefs map[string]*ObjectHandle,
+	statusLists []*ObjectHandle,
+	overrides []NonRevokedIntervalOverride,
+) (bool, error) {
+	schemaIds, schemaHandles := splitHandles(schemas)
+	schemaIdList, freeSchemaIds := newFfiStrList(schemaIds)
+	defer freeSchemaIds()
+
+	credDefIds, credDefHandles := splitHandles(credDefs)
+	credDefIdList, freeCredDefIds := newFfiStrList(credDefIds)
+	defer freeCredDefIds()
+
+	revRegDefIds, revRegDefHandles := splitHandles(revRegDefs)
+	revRegDefIdList, freeRevRegDefIds := newFfiStrList(revRegDefIds)
+	defer freeRevRegDefIds()
+
+	overrideList := C.struct_FfiList_FfiNonrevokedIntervalOverride{}
+	if len(overrides) > 0 {
+		cOverrides := make([]C.struct_FfiNonrevokedIntervalOverride, len(overrides))
+		for i, override := range overrides {
+			cRevRegDefId := C.CString(override.RevRegDefId)
+			defer C.free(unsafe.Pointer(cRevRegDefId))
+			cOverrides[i].rev_reg_def_id = C.FfiStr(cRevRegDefId)
+			cOverrides[i].requested_from_ts = C.int32_t(override.RequestedFromTimestamp)
+			cOverrides[i].override_rev_status_list_ts = C.int32_t(override.OverrideStatusListTimestamp)
+		}
+		overrideList.count = C.size_t(len(cOverrides))
+		overrideList.data = (*C.struct_FfiNonrevokedIntervalOverride)(unsafe.Pointer(&cOverrides[0]))
+	}
+
+	var result C.int8_t
+	code := C.anoncreds_verify_presentation(
+		presentation.GetHandle(),
+		presRequest.GetHandle(),
+		newFfiHandleList(schemaHandles),
+		schemaIdList,
+		newFfiHandleList(credDefHandles),
+		credDefIdList,
+		newFfiHandleList(revRegDefHandles),
+		revRegDefIdList,
+		newFfiHandleList(statusLists),
+		overrideList,
+		&result,
+	)
+
+	if err := handleError(code); err != nil {
+		return false, err
+	}
+
+	return result == 1, nil
+}
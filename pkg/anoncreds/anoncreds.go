@@ -64,4 +64,52 @@ func (o *ObjectHandle) ToJSONString() (string, error) {
 	}
 	
 	return ffi.ObjectToJSON(o.handle)
-}
\ No newline at end of file
+}
+
+/// @notice Encodes raw attribute values the way credentials sign them
+/// @param rawValues The raw values
+/// @return One encoded value per raw value, in order, and any error encountered
+/// @dev Matches encodeCredentialAttributes of the Node.js API
+func EncodeCredentialAttributes(rawValues []string) ([]string, error) {
+	return ffi.EncodeCredentialAttributes(rawValues)
+}
+
+/// @notice Returns the type name the native library reports for the object
+/// @return The type name and any error encountered
+func (o *ObjectHandle) TypeName() (string, error) {
+	if o == nil || o.handle == nil {
+		return "", fmt.Errorf("nil handle")
+	}
+	return ffi.ObjectTypeName(o.handle)
+}
+
+/// @notice Object types accepted by ObjectFromJSON
+var ObjectTypes = []string{
+	"Schema",
+	"CredentialDefinition",
+	"CredentialDefinitionPrivate",
+	"KeyCorrectnessProof",
+	"CredentialOffer",
+	"CredentialRequest",
+	"CredentialRequestMetadata",
+	"Credential",
+	"RevocationRegistryDefinition",
+	"RevocationRegistryDefinitionPrivate",
+	"RevocationStatusList",
+	"RevocationState",
+	"PresentationRequest",
+	"Presentation",
+}
+
+/// @notice Parses an object of a type named in ObjectTypes
+/// @param objectType The object type
+/// @param jsonData The JSON encoded object
+/// @return An untyped object handle and any error encountered
+/// @dev For tools handling objects of unknown type; use the typed FromJSON functions otherwise
+func ObjectFromJSON(objectType string, jsonData []byte) (*ObjectHandle, error) {
+	handle, err := ffi.ObjectFromJSON(objectType, string(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectHandle{handle: handle}, nil
+}
@@ -25,7 +25,16 @@ type CreateCredentialOptions struct {
 	CredentialRequest          *CredentialRequest
 	AttributeRawValues         map[string]string
 	AttributeEncodedValues     map[string]string
-	RevocationConfig           *ffi.RevocationConfig
+	RevocationConfig           *CredentialRevocationConfig
+}
+
+/// @notice Revocation registry details needed to issue a revocable credential
+/// @dev RegistryIndex must be unused in the registry and between 1 and its maxCredNum
+type CredentialRevocationConfig struct {
+	RegistryDefinition        *RevocationRegistryDefinition
+	RegistryDefinitionPrivate *RevocationRegistryDefinitionPrivate
+	StatusList                *RevocationStatusList
+	RegistryIndex             uint32
 }
 
 /// @notice Creates a new credential using the provided options
@@ -46,6 +55,19 @@ func CreateCredential(options CreateCredentialOptions) (*Credential, error) {
 		return nil, fmt.Errorf("credential request is required")
 	}
 	
+	var revocationConfig *ffi.RevocationConfig
+	if rc := options.RevocationConfig; rc != nil {
+		if rc.RegistryDefinition == nil || rc.RegistryDefinitionPrivate == nil || rc.StatusList == nil {
+			return nil, fmt.Errorf("revocation config requires registry definition, private part and status list")
+		}
+		revocationConfig = &ffi.RevocationConfig{
+			RegistryDefinition:        rc.RegistryDefinition.handle,
+			RegistryDefinitionPrivate: rc.RegistryDefinitionPrivate.handle,
+			StatusList:                rc.StatusList.handle,
+			RegistryIndex:             rc.RegistryIndex,
+		}
+	}
+	
 	handle, err := ffi.CreateCredential(
 		options.CredentialDefinition.handle,
 		options.CredentialDefinitionPrivate.handle,
@@ -53,7 +75,7 @@ func CreateCredential(options CreateCredentialOptions) (*Credential, error) {
 		options.CredentialRequest.handle,
 		options.AttributeRawValues,
 		options.AttributeEncodedValues,
-		options.RevocationConfig,
+		revocationConfig,
 	)
 	if err != nil {
 		return nil, err
@@ -75,7 +97,8 @@ type ProcessCredentialOptions struct {
 }
 
 /// @notice Processes a received credential for storage
-/// @dev Validates and prepares the credential for secure storage
+/// @dev Validates and prepares the credential for secure storage. The link secret is wiped when
+/// the library returns, whether or not the call succeeded
 func ProcessCredential(options ProcessCredentialOptions) (*Credential, error) {
 	if options.Credential == nil {
 		return nil, fmt.Errorf("credential is required")
@@ -83,8 +106,8 @@ func ProcessCredential(options ProcessCredentialOptions) (*Credential, error) {
 	if options.CredentialRequestMetadata == nil {
 		return nil, fmt.Errorf("credential request metadata is required")
 	}
-	if options.LinkSecret == nil {
-		return nil, fmt.Errorf("link secret is required")
+	if err := options.LinkSecret.check(); err != nil {
+		return nil, err
 	}
 	if options.CredentialDefinition == nil {
 		return nil, fmt.Errorf("credential definition is required")
@@ -98,10 +121,11 @@ func ProcessCredential(options ProcessCredentialOptions) (*Credential, error) {
 	handle, err := ffi.ProcessCredential(
 		options.Credential.handle,
 		options.CredentialRequestMetadata.handle,
-		options.LinkSecret.Value,
+		options.LinkSecret.secret,
 		options.CredentialDefinition.handle,
 		revRegDef,
 	)
+	options.LinkSecret.Wipe()
 	if err != nil {
 		return nil, err
 	}
@@ -95,4 +95,36 @@ func CredentialDefinitionFromJSON(jsonData interface{}) (*CredentialDefinition,
 	return &CredentialDefinition{
 		ObjectHandle: &ObjectHandle{handle: handle},
 	}, nil
+}
+
+/// @notice Creates a private credential definition from its JSON representation
+/// @param jsonData The JSON data as string, map, or byte array
+/// @return A private credential definition object and any error encountered
+/// @dev Used to reload issuer key material that was persisted with ToJSONString
+func CredentialDefinitionPrivateFromJSON(jsonData interface{}) (*CredentialDefinitionPrivate, error) {
+	var jsonStr string
+	
+	switch data := jsonData.(type) {
+	case string:
+		jsonStr = data
+	case map[string]interface{}:
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		jsonStr = string(bytes)
+	case []byte:
+		jsonStr = string(data)
+	default:
+		return nil, fmt.Errorf("invalid JSON data type")
+	}
+	
+	handle, err := ffi.ObjectFromJSON("CredentialDefinitionPrivate", jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &CredentialDefinitionPrivate{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
 }
\ No newline at end of file
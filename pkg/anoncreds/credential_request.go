@@ -43,13 +43,14 @@ type CreateCredentialRequestResult struct {
 /// @notice Creates a new credential request using the provided options
 /// @param options Configuration options for the credential request
 /// @return Result containing both request and metadata, and any error encountered
-/// @dev Validates all required fields before creating the request
+/// @dev Validates all required fields before creating the request. The link secret is wiped when
+/// the library returns, whether or not the call succeeded
 func CreateCredentialRequest(options CreateCredentialRequestOptions) (*CreateCredentialRequestResult, error) {
 	if options.CredentialDefinition == nil {
 		return nil, fmt.Errorf("credential definition is required")
 	}
-	if options.LinkSecret == nil {
-		return nil, fmt.Errorf("link secret is required")
+	if err := options.LinkSecret.check(); err != nil {
+		return nil, err
 	}
 	if options.CredentialOffer == nil {
 		return nil, fmt.Errorf("credential offer is required")
@@ -59,10 +60,11 @@ func CreateCredentialRequest(options CreateCredentialRequestOptions) (*CreateCre
 		options.Entropy,
 		options.ProverDID,
 		options.CredentialDefinition.handle,
-		options.LinkSecret.Value,
+		options.LinkSecret.secret,
 		options.LinkSecretID,
 		options.CredentialOffer.handle,
 	)
+	options.LinkSecret.Wipe()
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package anoncreds
 
 /// @notice Transforms xr_cap from array format to object format for compatibility
 /// @param offerData The credential offer data containing key_correctness_proof
+/// @dev Deprecated: use interop.IndySDK.Apply, which also converts identifiers and the issuer field
 func TransformXrCapToObject(offerData map[string]interface{}) {
 	if kcp, ok := offerData["key_correctness_proof"].(map[string]interface{}); ok {
 		if xrCapArray, ok := kcp["xr_cap"].([]interface{}); ok {
@@ -21,6 +22,7 @@ func TransformXrCapToObject(offerData map[string]interface{}) {
 /// @notice Creates a credential offer compatible with Credo-TS format
 /// @param options The options for creating the credential offer
 /// @return A map containing the credential offer data and any error encountered
+/// @dev Deprecated: the offer is returned unchanged; use interop.CredoTS.Encode to rewrite objects for Credo-TS
 func CreateCredentialOfferForCredoTS(options CreateCredentialOfferOptions) (map[string]interface{}, error) {
 	// Create the offer using the C API
 	offer, err := CreateCredentialOffer(options)
@@ -1,16 +1,24 @@
 package anoncreds
 
 import (
+	"fmt"
+	"log/slog"
+
 	"github.com/Ajna-inc/anoncreds-go/internal/ffi"
 )
 
 /// @title Link Secret Types and Operations
 /// @dev Core functionality for managing link secrets (master secrets)
 
+/// @notice Text printed in place of a link secret
+const redactedLinkSecret = "[redacted]"
+
 /// @notice Represents a link secret used to maintain continuity between credentials
-/// @dev Stores the secret value as a string for simplicity
+/// @dev The value is kept in a byte slice so it can be wiped, and is wiped once an FFI call that
+/// used it returns. Formatting, logging and JSON encoding print a placeholder instead of the
+/// value, whether the link secret is held by pointer or by value
 type LinkSecret struct {
-	Value string /// @notice The actual link secret value
+	secret []byte
 }
 
 /// @notice Creates a new random link secret
@@ -23,16 +31,80 @@ func CreateLinkSecret() (*LinkSecret, error) {
 	}
 	
 	return &LinkSecret{
-		Value: secret,
+		secret: secret,
 	}, nil
 }
 
 /// @notice Creates a link secret from an existing value
 /// @param value The link secret value as a string
 /// @return A new link secret object wrapping the provided value
-/// @dev Use this when you have an existing link secret value
+/// @dev Go strings cannot be wiped; prefer LinkSecretFromBytes when the value comes from storage
 func LinkSecretFromValue(value string) *LinkSecret {
 	return &LinkSecret{
-		Value: value,
+		secret: []byte(value),
+	}
+}
+
+/// @notice Creates a link secret from an existing value
+/// @param value The link secret value; it is copied, so the caller may wipe it afterwards
+/// @return A new link secret object holding a copy of the value
+func LinkSecretFromBytes(value []byte) *LinkSecret {
+	return &LinkSecret{
+		secret: append([]byte(nil), value...),
 	}
-}
\ No newline at end of file
+}
+
+/// @notice Returns a copy of the secret value, e.g. to persist it
+/// @dev The caller owns the copy and should wipe it when done
+func (l *LinkSecret) Bytes() []byte {
+	return append([]byte(nil), l.secret...)
+}
+
+/// @notice Overwrites the secret value with zeros; the link secret cannot be used afterwards
+func (l *LinkSecret) Wipe() {
+	for i := range l.secret {
+		l.secret[i] = 0
+	}
+	l.secret = nil
+}
+
+/// @notice Reports whether the secret has been wiped
+func (l *LinkSecret) Wiped() bool {
+	return len(l.secret) == 0
+}
+
+/// @notice Returns a placeholder; the value is never printed
+func (l LinkSecret) String() string {
+	return redactedLinkSecret
+}
+
+/// @notice Returns a placeholder for %#v
+func (l LinkSecret) GoString() string {
+	return redactedLinkSecret
+}
+
+/// @notice Prints a placeholder for every fmt verb, including %x and %d
+func (l LinkSecret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redactedLinkSecret)
+}
+
+/// @notice Logs a placeholder with log/slog
+func (l LinkSecret) LogValue() slog.Value {
+	return slog.StringValue(redactedLinkSecret)
+}
+
+/// @notice Encodes a placeholder so the value cannot leak through JSON
+func (l LinkSecret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redactedLinkSecret + `"`), nil
+}
+
+/// @dev Checks that a link secret was supplied and has not been wiped
+func (l *LinkSecret) check() error {
+	if l == nil {
+		return fmt.Errorf("link secret is required")
+	}
+	if l.Wiped() {
+		return fmt.Errorf("link secret has been wiped")
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package anoncreds
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ajna-inc/anoncreds-go/internal/ffi"
+)
+
+/// @title Presentation Types and Operations
+/// @dev Core functionality for building presentations from stored credentials
+
+/// @notice Represents a verifier's request for a presentation
+/// @dev Wraps the underlying FFI object handle for presentation requests
+type PresentationRequest struct {
+	*ObjectHandle
+}
+
+/// @notice Represents a presentation created by a prover
+/// @dev Wraps the underlying FFI object handle for presentations
+type Presentation struct {
+	*ObjectHandle
+}
+
+/// @notice Represents a prover's revocation state for one credential
+/// @dev Wraps the underlying FFI object handle for revocation states
+type CredentialRevocationState struct {
+	*ObjectHandle
+}
+
+/// @notice A credential included in a presentation
+/// @dev Timestamp and RevocationState are only needed to prove non-revocation
+type PresentCredential struct {
+	Credential      *Credential                /// @notice The processed credential
+	Timestamp       *int64                     /// @notice Timestamp of the status list the revocation state was built from
+	RevocationState *CredentialRevocationState /// @notice Optional revocation state
+}
+
+/// @notice Assigns a requested attribute or predicate to a credential entry
+type CredentialProve struct {
+	EntryIndex  int    /// @notice Index into CreatePresentationOptions.Credentials
+	Referent    string /// @notice Referent of the requested attribute or predicate
+	IsPredicate bool   /// @notice Whether the referent names a requested predicate
+	Reveal      bool   /// @notice Whether the attribute value is revealed
+}
+
+/// @notice Configuration options for creating a presentation
+/// @dev Schemas and CredentialDefinitions are keyed by ID and must cover every credential used
+type CreatePresentationOptions struct {
+	PresentationRequest    *PresentationRequest
+	Credentials            []PresentCredential
+	CredentialsProve       []CredentialProve
+	SelfAttestedAttributes map[string]string
+	LinkSecret             *LinkSecret
+	Schemas                map[string]*Schema
+	CredentialDefinitions  map[string]*CredentialDefinition
+}
+
+/// @notice Creates a presentation satisfying a presentation request
+/// @param options Configuration options for the presentation
+/// @return A new presentation object and any error encountered
+/// @dev The link secret is wiped when the library returns, whether or not the call succeeded
+func CreatePresentation(options CreatePresentationOptions) (*Presentation, error) {
+	if options.PresentationRequest == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	if err := options.LinkSecret.check(); err != nil {
+		return nil, err
+	}
+
+	credentials := make([]ffi.PresentCredential, len(options.Credentials))
+	for i, credential := range options.Credentials {
+		if credential.Credential == nil {
+			return nil, fmt.Errorf("credential entry %d has no credential", i)
+		}
+		credentials[i] = ffi.PresentCredential{
+			Credential: credential.Credential.handle,
+			Timestamp:  credential.Timestamp,
+		}
+		if credential.RevocationState != nil {
+			credentials[i].RevState = credential.RevocationState.handle
+		}
+	}
+
+	credentialsProve := make([]ffi.CredentialProve, len(options.CredentialsProve))
+	for i, prove := range options.CredentialsProve {
+		if prove.EntryIndex < 0 || prove.EntryIndex >= len(credentials) {
+			return nil, fmt.Errorf("referent %s points to missing credential entry %d", prove.Referent, prove.EntryIndex)
+		}
+		credentialsProve[i] = ffi.CredentialProve{
+			EntryIndex:  prove.EntryIndex,
+			Referent:    prove.Referent,
+			IsPredicate: prove.IsPredicate,
+			Reveal:      prove.Reveal,
+		}
+	}
+
+	schemas := make(map[string]*ffi.ObjectHandle, len(options.Schemas))
+	for id, schema := range options.Schemas {
+		schemas[id] = schema.handle
+	}
+	credDefs := make(map[string]*ffi.ObjectHandle, len(options.CredentialDefinitions))
+	for id, credDef := range options.CredentialDefinitions {
+		credDefs[id] = credDef.handle
+	}
+
+	handle, err := ffi.CreatePresentation(
+		options.PresentationRequest.handle,
+		credentials,
+		credDefs,
+		schemas,
+		options.LinkSecret.secret,
+		credentialsProve,
+		options.SelfAttestedAttributes,
+	)
+	options.LinkSecret.Wipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Presentation{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @notice Creates a presentation request from its JSON representation
+/// @param jsonData The JSON data as string, map, or byte array
+/// @return A presentation request object and any error encountered
+func PresentationRequestFromJSON(jsonData interface{}) (*PresentationRequest, error) {
+	jsonStr, err := jsonInput(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := ffi.ObjectFromJSON("PresentationRequest", jsonStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresentationRequest{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @notice Creates a presentation from its JSON representation
+/// @param jsonData The JSON data as string, map, or byte array
+/// @return A presentation object and any error encountered
+func PresentationFromJSON(jsonData interface{}) (*Presentation, error) {
+	jsonStr, err := jsonInput(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := ffi.ObjectFromJSON("Presentation", jsonStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Presentation{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @notice Creates a revocation state from its JSON representation
+/// @param jsonData The JSON data as string, map, or byte array
+/// @return A revocation state object and any error encountered
+func CredentialRevocationStateFromJSON(jsonData interface{}) (*CredentialRevocationState, error) {
+	jsonStr, err := jsonInput(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := ffi.ObjectFromJSON("RevocationState", jsonStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CredentialRevocationState{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @dev Normalizes the JSON inputs accepted by the FromJSON functions to a string
+func jsonInput(jsonData interface{}) (string, error) {
+	switch data := jsonData.(type) {
+	case string:
+		return data, nil
+	case map[string]interface{}:
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	case []byte:
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("invalid JSON data type")
+	}
+}
@@ -0,0 +1,145 @@
+package anoncreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+/// @title Presentation Request Contents
+/// @dev Typed view of presentation request JSON for code that needs to inspect a request
+
+/// @notice Predicate types supported by anoncreds
+const (
+	PredicateGE = ">="
+	PredicateGT = ">"
+	PredicateLE = "<="
+	PredicateLT = "<"
+)
+
+/// @notice The decoded contents of a presentation request
+type PresentationRequestContents struct {
+	Name                string                        `json:"name"`
+	Version             string                        `json:"version"`
+	Nonce               string                        `json:"nonce"`
+	RequestedAttributes map[string]RequestedAttribute `json:"requested_attributes"`
+	RequestedPredicates map[string]RequestedPredicate `json:"requested_predicates"`
+	NonRevoked          *NonRevokedInterval           `json:"non_revoked,omitempty"`
+	Ver                 string                        `json:"ver,omitempty"`
+}
+
+/// @notice A requested attribute or attribute group
+/// @dev Exactly one of Name and Names is set
+type RequestedAttribute struct {
+	Name         string              `json:"name,omitempty"`
+	Names        []string            `json:"names,omitempty"`
+	Restrictions interface{}         `json:"restrictions,omitempty"`
+	NonRevoked   *NonRevokedInterval `json:"non_revoked,omitempty"`
+}
+
+/// @notice A requested predicate over an integer attribute
+type RequestedPredicate struct {
+	Name         string              `json:"name"`
+	PType        string              `json:"p_type"`
+	PValue       int64               `json:"p_value"`
+	Restrictions interface{}         `json:"restrictions,omitempty"`
+	NonRevoked   *NonRevokedInterval `json:"non_revoked,omitempty"`
+}
+
+/// @notice An interval in which credentials must not have been revoked
+type NonRevokedInterval struct {
+	From *int64 `json:"from,omitempty"`
+	To   *int64 `json:"to,omitempty"`
+}
+
+/// @notice Decodes the contents of the presentation request
+/// @return The decoded request and any error encountered
+func (r *PresentationRequest) Contents() (*PresentationRequestContents, error) {
+	jsonStr, err := r.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	return ParsePresentationRequest([]byte(jsonStr))
+}
+
+/// @notice Decodes presentation request JSON without loading it into anoncreds-rs
+/// @param data The presentation request JSON
+/// @return The decoded request and any error encountered
+func ParsePresentationRequest(data []byte) (*PresentationRequestContents, error) {
+	var contents PresentationRequestContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("invalid presentation request: %w", err)
+	}
+	for referent, attribute := range contents.RequestedAttributes {
+		if (attribute.Name == "") == (len(attribute.Names) == 0) {
+			return nil, fmt.Errorf("requested attribute %s must have exactly one of name and names", referent)
+		}
+	}
+	for referent, predicate := range contents.RequestedPredicates {
+		switch predicate.PType {
+		case PredicateGE, PredicateGT, PredicateLE, PredicateLT:
+		default:
+			return nil, fmt.Errorf("requested predicate %s has unknown type %q", referent, predicate.PType)
+		}
+	}
+	return &contents, nil
+}
+
+/// @notice Returns the attribute referents in sorted order
+func (c *PresentationRequestContents) AttributeReferents() []string {
+	referents := make([]string, 0, len(c.RequestedAttributes))
+	for referent := range c.RequestedAttributes {
+		referents = append(referents, referent)
+	}
+	sort.Strings(referents)
+	return referents
+}
+
+/// @notice Returns the predicate referents in sorted order
+func (c *PresentationRequestContents) PredicateReferents() []string {
+	referents := make([]string, 0, len(c.RequestedPredicates))
+	for referent := range c.RequestedPredicates {
+		referents = append(referents, referent)
+	}
+	sort.Strings(referents)
+	return referents
+}
+
+/// @notice Returns the non-revocation interval that applies to an attribute or predicate
+/// @dev A per-referent interval overrides the request-level one
+func (c *PresentationRequestContents) EffectiveNonRevoked(interval *NonRevokedInterval) *NonRevokedInterval {
+	if interval != nil {
+		return interval
+	}
+	return c.NonRevoked
+}
+
+/// @notice Returns the names of the requested attribute or attribute group
+func (a RequestedAttribute) AttributeNames() []string {
+	if len(a.Names) > 0 {
+		return a.Names
+	}
+	return []string{a.Name}
+}
+
+/// @notice Reports whether a raw attribute value satisfies the predicate
+/// @return The result and an error if the value is not an integer
+func (p RequestedPredicate) SatisfiedBy(raw string) (bool, error) {
+	value, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return false, fmt.Errorf("attribute %s value %q is not a 32-bit integer", p.Name, raw)
+	}
+	switch p.PType {
+	case PredicateGE:
+		return value >= p.PValue, nil
+	case PredicateGT:
+		return value > p.PValue, nil
+	case PredicateLE:
+		return value <= p.PValue, nil
+	case PredicateLT:
+		return value < p.PValue, nil
+	default:
+		return false, fmt.Errorf("unknown predicate type %q", p.PType)
+	}
+}
@@ -0,0 +1,158 @@
+package anoncreds
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/// @title Revealed Presentation Contents
+/// @dev Typed view of the requested_proof and identifiers sections of a presentation
+
+/// @notice The credential a proven referent came from
+type PresentationIdentifier struct {
+	SchemaID               string `json:"schema_id"`
+	CredentialDefinitionID string `json:"cred_def_id"`
+	RevocationRegistryID   string `json:"rev_reg_id,omitempty"`
+	Timestamp              *int64 `json:"timestamp,omitempty"`
+}
+
+/// @notice A revealed attribute value
+type RevealedValue struct {
+	Raw     string `json:"raw"`
+	Encoded string `json:"encoded"`
+}
+
+/// @notice A revealed attribute
+type RevealedAttribute struct {
+	Referent      string
+	Value         RevealedValue
+	SubProofIndex int
+	Identifier    PresentationIdentifier
+}
+
+/// @notice A revealed attribute group
+type RevealedAttributeGroup struct {
+	Referent      string
+	Values        map[string]RevealedValue
+	SubProofIndex int
+	Identifier    PresentationIdentifier
+}
+
+/// @notice A referent proven without disclosing values: a predicate or an unrevealed attribute
+type ProvenReferent struct {
+	Referent      string
+	SubProofIndex int
+	Identifier    PresentationIdentifier
+}
+
+/// @notice Everything a presentation discloses, keyed by referent
+type RevealedPresentation struct {
+	Attributes      map[string]RevealedAttribute
+	AttributeGroups map[string]RevealedAttributeGroup
+	SelfAttested    map[string]string
+	Unrevealed      map[string]ProvenReferent
+	Predicates      map[string]ProvenReferent
+	Identifiers     []PresentationIdentifier
+}
+
+/// @dev Wire format of a presentation's requested_proof and identifiers
+type presentationContents struct {
+	RequestedProof struct {
+		RevealedAttrs map[string]struct {
+			SubProofIndex int    `json:"sub_proof_index"`
+			Raw           string `json:"raw"`
+			Encoded       string `json:"encoded"`
+		} `json:"revealed_attrs"`
+		RevealedAttrGroups map[string]struct {
+			SubProofIndex int                      `json:"sub_proof_index"`
+			Values        map[string]RevealedValue `json:"values"`
+		} `json:"revealed_attr_groups"`
+		SelfAttestedAttrs map[string]string `json:"self_attested_attrs"`
+		UnrevealedAttrs   map[string]struct {
+			SubProofIndex int `json:"sub_proof_index"`
+		} `json:"unrevealed_attrs"`
+		Predicates map[string]struct {
+			SubProofIndex int `json:"sub_proof_index"`
+		} `json:"predicates"`
+	} `json:"requested_proof"`
+	Identifiers []PresentationIdentifier `json:"identifiers"`
+}
+
+/// @notice Extracts the revealed values of every referent together with its source credential
+/// @return The revealed contents and an error if the presentation is malformed
+func (p *Presentation) Revealed() (*RevealedPresentation, error) {
+	jsonStr, err := p.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	return ParseRevealedPresentation([]byte(jsonStr))
+}
+
+/// @notice Extracts the revealed contents of presentation JSON
+/// @param data The presentation JSON
+/// @return The revealed contents and an error if the presentation is malformed
+func ParseRevealedPresentation(data []byte) (*RevealedPresentation, error) {
+	var contents presentationContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("invalid presentation: %w", err)
+	}
+	proof := contents.RequestedProof
+
+	identifier := func(referent string, index int) (PresentationIdentifier, error) {
+		if index < 0 || index >= len(contents.Identifiers) {
+			return PresentationIdentifier{}, fmt.Errorf("referent %s points to missing identifier %d", referent, index)
+		}
+		return contents.Identifiers[index], nil
+	}
+
+	revealed := &RevealedPresentation{
+		Attributes:      make(map[string]RevealedAttribute, len(proof.RevealedAttrs)),
+		AttributeGroups: make(map[string]RevealedAttributeGroup, len(proof.RevealedAttrGroups)),
+		SelfAttested:    make(map[string]string, len(proof.SelfAttestedAttrs)),
+		Unrevealed:      make(map[string]ProvenReferent, len(proof.UnrevealedAttrs)),
+		Predicates:      make(map[string]ProvenReferent, len(proof.Predicates)),
+		Identifiers:     contents.Identifiers,
+	}
+	for referent, attr := range proof.RevealedAttrs {
+		id, err := identifier(referent, attr.SubProofIndex)
+		if err != nil {
+			return nil, err
+		}
+		revealed.Attributes[referent] = RevealedAttribute{
+			Referent:      referent,
+			Value:         RevealedValue{Raw: attr.Raw, Encoded: attr.Encoded},
+			SubProofIndex: attr.SubProofIndex,
+			Identifier:    id,
+		}
+	}
+	for referent, group := range proof.RevealedAttrGroups {
+		id, err := identifier(referent, group.SubProofIndex)
+		if err != nil {
+			return nil, err
+		}
+		revealed.AttributeGroups[referent] = RevealedAttributeGroup{
+			Referent:      referent,
+			Values:        group.Values,
+			SubProofIndex: group.SubProofIndex,
+			Identifier:    id,
+		}
+	}
+	for referent, value := range proof.SelfAttestedAttrs {
+		revealed.SelfAttested[referent] = value
+	}
+	for referent, attr := range proof.UnrevealedAttrs {
+		id, err := identifier(referent, attr.SubProofIndex)
+		if err != nil {
+			return nil, err
+		}
+		revealed.Unrevealed[referent] = ProvenReferent{Referent: referent, SubProofIndex: attr.SubProofIndex, Identifier: id}
+	}
+	for referent, predicate := range proof.Predicates {
+		id, err := identifier(referent, predicate.SubProofIndex)
+		if err != nil {
+			return nil, err
+		}
+		revealed.Predicates[referent] = ProvenReferent{Referent: referent, SubProofIndex: predicate.SubProofIndex, Identifier: id}
+	}
+	return revealed, nil
+}
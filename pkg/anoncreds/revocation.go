@@ -3,6 +3,7 @@ package anoncreds
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	
 	"github.com/Ajna-inc/anoncreds-go/internal/ffi"
 )
@@ -28,6 +29,192 @@ type RevocationStatusList struct {
 	*ObjectHandle
 }
 
+/// @notice Configuration options for creating a revocation registry definition
+/// @dev TailsDirectoryPath may be empty to use the library's temporary directory
+type CreateRevocationRegistryDefinitionOptions struct {
+	CredentialDefinition    *CredentialDefinition `json:"-"`
+	CredentialDefinitionID  string                `json:"cred_def_id"`
+	IssuerID                string                `json:"issuer_id"`
+	Tag                     string                `json:"tag"`
+	RevocationRegistryType  string                `json:"revocation_registry_type"`
+	MaximumCredentialNumber uint32                `json:"max_cred_num"`
+	TailsDirectoryPath      string                `json:"tails_directory_path"`
+}
+
+/// @notice Result structure returned after creating a revocation registry definition
+/// @dev Contains both public and private components of the registry
+type CreateRevocationRegistryDefinitionResult struct {
+	RevocationRegistryDefinition        *RevocationRegistryDefinition        /// @notice Public registry definition
+	RevocationRegistryDefinitionPrivate *RevocationRegistryDefinitionPrivate /// @notice Private key material
+}
+
+/// @notice Creates a new revocation registry definition and writes its tails file
+/// @param options Configuration options for the registry
+/// @return Result containing both public and private components, and any error encountered
+/// @dev RevocationRegistryType defaults to CL_ACCUM
+func CreateRevocationRegistryDefinition(options CreateRevocationRegistryDefinitionOptions) (*CreateRevocationRegistryDefinitionResult, error) {
+	if options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("credential definition is required")
+	}
+	if options.MaximumCredentialNumber == 0 {
+		return nil, fmt.Errorf("maximum credential number is required")
+	}
+	revRegType := options.RevocationRegistryType
+	if revRegType == "" {
+		revRegType = "CL_ACCUM"
+	}
+	
+	regDef, regDefPrivate, err := ffi.CreateRevocationRegistryDefinition(
+		options.CredentialDefinition.handle,
+		options.CredentialDefinitionID,
+		options.IssuerID,
+		options.Tag,
+		revRegType,
+		int64(options.MaximumCredentialNumber),
+		options.TailsDirectoryPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &CreateRevocationRegistryDefinitionResult{
+		RevocationRegistryDefinition:        &RevocationRegistryDefinition{ObjectHandle: &ObjectHandle{handle: regDef}},
+		RevocationRegistryDefinitionPrivate: &RevocationRegistryDefinitionPrivate{ObjectHandle: &ObjectHandle{handle: regDefPrivate}},
+	}, nil
+}
+
+/// @notice Configuration options for creating a revocation status list
+/// @dev Timestamp is optional; the list can be timestamped when it is published
+type CreateRevocationStatusListOptions struct {
+	CredentialDefinition                *CredentialDefinition                `json:"-"`
+	RevocationRegistryDefinitionID      string                               `json:"rev_reg_def_id"`
+	RevocationRegistryDefinition        *RevocationRegistryDefinition        `json:"-"`
+	RevocationRegistryDefinitionPrivate *RevocationRegistryDefinitionPrivate `json:"-"`
+	IssuerID                            string                               `json:"issuer_id"`
+	IssuanceByDefault                   bool                                 `json:"issuance_by_default"`
+	Timestamp                           *int64                               `json:"timestamp,omitempty"`
+}
+
+/// @notice Creates the initial status list of a revocation registry
+/// @param options Configuration options for the status list
+/// @return A new status list object and any error encountered
+func CreateRevocationStatusList(options CreateRevocationStatusListOptions) (*RevocationStatusList, error) {
+	if options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("credential definition is required")
+	}
+	if options.RevocationRegistryDefinition == nil {
+		return nil, fmt.Errorf("revocation registry definition is required")
+	}
+	if options.RevocationRegistryDefinitionPrivate == nil {
+		return nil, fmt.Errorf("revocation registry definition private is required")
+	}
+	
+	handle, err := ffi.CreateRevocationStatusList(
+		options.CredentialDefinition.handle,
+		options.RevocationRegistryDefinitionID,
+		options.RevocationRegistryDefinition.handle,
+		options.RevocationRegistryDefinitionPrivate.handle,
+		options.IssuerID,
+		options.IssuanceByDefault,
+		options.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &RevocationStatusList{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @notice Configuration options for updating a revocation status list
+/// @dev Issued and Revoked are registry indices; Timestamp defaults to the current list's timestamp
+type UpdateRevocationStatusListOptions struct {
+	CredentialDefinition                *CredentialDefinition                `json:"-"`
+	RevocationRegistryDefinition        *RevocationRegistryDefinition        `json:"-"`
+	RevocationRegistryDefinitionPrivate *RevocationRegistryDefinitionPrivate `json:"-"`
+	CurrentStatusList                   *RevocationStatusList                `json:"-"`
+	Issued                              []uint32                             `json:"issued,omitempty"`
+	Revoked                             []uint32                             `json:"revoked,omitempty"`
+	Timestamp                           *int64                               `json:"timestamp,omitempty"`
+}
+
+/// @notice Applies issuance and revocation changes to a status list
+/// @param options Configuration options for the update
+/// @return A new status list object and any error encountered
+/// @dev The current list is left untouched
+func UpdateRevocationStatusList(options UpdateRevocationStatusListOptions) (*RevocationStatusList, error) {
+	if options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("credential definition is required")
+	}
+	if options.RevocationRegistryDefinition == nil {
+		return nil, fmt.Errorf("revocation registry definition is required")
+	}
+	if options.RevocationRegistryDefinitionPrivate == nil {
+		return nil, fmt.Errorf("revocation registry definition private is required")
+	}
+	if options.CurrentStatusList == nil {
+		return nil, fmt.Errorf("current status list is required")
+	}
+
+	issued, err := registryIndices(options.Issued)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := registryIndices(options.Revoked)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := ffi.UpdateRevocationStatusList(
+		options.CredentialDefinition.handle,
+		options.RevocationRegistryDefinition.handle,
+		options.RevocationRegistryDefinitionPrivate.handle,
+		options.CurrentStatusList.handle,
+		issued,
+		revoked,
+		options.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevocationStatusList{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @notice Copies a status list with a new timestamp and no other changes
+/// @param statusList The current status list
+/// @param timestamp Unix timestamp of the new list
+/// @return A new status list object and any error encountered
+func UpdateRevocationStatusListTimestamp(statusList *RevocationStatusList, timestamp int64) (*RevocationStatusList, error) {
+	if statusList == nil {
+		return nil, fmt.Errorf("status list is required")
+	}
+
+	handle, err := ffi.UpdateRevocationStatusListTimestampOnly(timestamp, statusList.handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevocationStatusList{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
+/// @dev Converts registry indices to the signed form the library expects
+func registryIndices(indices []uint32) ([]int32, error) {
+	out := make([]int32, len(indices))
+	for i, index := range indices {
+		if index == 0 || index > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid registry index %d", index)
+		}
+		out[i] = int32(index)
+	}
+	return out, nil
+}
+
 /// @notice Creates a revocation registry definition from its JSON representation
 /// @param jsonData The JSON data as string, map, or byte array
 /// @return A revocation registry definition object and any error encountered
@@ -60,6 +247,38 @@ func RevocationRegistryDefinitionFromJSON(jsonData interface{}) (*RevocationRegi
 	}, nil
 }
 
+/// @notice Creates a private revocation registry definition from its JSON representation
+/// @param jsonData The JSON data as string, map, or byte array
+/// @return A private revocation registry definition object and any error encountered
+/// @dev Used to reload issuer key material that was persisted with ToJSONString
+func RevocationRegistryDefinitionPrivateFromJSON(jsonData interface{}) (*RevocationRegistryDefinitionPrivate, error) {
+	var jsonStr string
+	
+	switch data := jsonData.(type) {
+	case string:
+		jsonStr = data
+	case map[string]interface{}:
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		jsonStr = string(bytes)
+	case []byte:
+		jsonStr = string(data)
+	default:
+		return nil, fmt.Errorf("invalid JSON data type")
+	}
+	
+	handle, err := ffi.ObjectFromJSON("RevocationRegistryDefinitionPrivate", jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &RevocationRegistryDefinitionPrivate{
+		ObjectHandle: &ObjectHandle{handle: handle},
+	}, nil
+}
+
 /// @notice Creates a revocation status list from its JSON representation
 /// @param jsonData The JSON data as string, map, or byte array
 /// @return A revocation status list object and any error encountered
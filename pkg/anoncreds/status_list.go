@@ -0,0 +1,185 @@
+package anoncreds
+
+import (
+	"fmt"
+	"strings"
+)
+
+/// @title Revocation Status List Analysis
+/// @dev Read-only views over the revocationList of a status list. Positions in the list are
+/// registry indices, and a set bit means the credential at that index is revoked
+
+/// @notice A decoded view of a status list's revocationList
+type RevocationBitmap struct {
+	RevocationRegistryDefinitionID string /// @notice The registry the list belongs to, if recorded in the list
+	Timestamp                      *int64 /// @notice The timestamp of the list, if set
+
+	bits []bool
+}
+
+/// @notice The indices whose status changed between two status lists of the same registry
+type StatusListDiff struct {
+	RevocationRegistryDefinitionID string
+	FromTimestamp                  *int64
+	ToTimestamp                    *int64
+	Issued                         []uint32 /// @notice Indices revoked in the old list and issued in the new one
+	Revoked                        []uint32 /// @notice Indices issued in the old list and revoked in the new one
+}
+
+/// @notice Decodes the revocationList of the status list
+/// @return A bitmap view and any error encountered
+func (s *RevocationStatusList) Bitmap() (*RevocationBitmap, error) {
+	statusList, err := s.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	return RevocationBitmapFromJSON(statusList)
+}
+
+/// @notice Builds a bitmap view from status list JSON
+/// @param statusList The status list as returned by ToJSON
+/// @return A bitmap view and any error encountered
+func RevocationBitmapFromJSON(statusList map[string]interface{}) (*RevocationBitmap, error) {
+	list, ok := statusList["revocationList"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("status list is missing revocationList")
+	}
+	bits := make([]bool, len(list))
+	for idx, entry := range list {
+		value, ok := entry.(float64)
+		if !ok || (value != 0 && value != 1) {
+			return nil, fmt.Errorf("invalid revocationList entry at index %d", idx)
+		}
+		bits[idx] = value == 1
+	}
+
+	bitmap := &RevocationBitmap{bits: bits}
+	bitmap.RevocationRegistryDefinitionID, _ = statusList["revRegDefId"].(string)
+	if timestamp, ok := statusList["timestamp"].(float64); ok {
+		value := int64(timestamp)
+		bitmap.Timestamp = &value
+	}
+	return bitmap, nil
+}
+
+/// @notice Returns the number of indices in the list
+func (b *RevocationBitmap) Size() int {
+	return len(b.bits)
+}
+
+/// @notice Reports whether the credential at an index is revoked
+/// @return The revocation status and an error if the index is outside the list
+func (b *RevocationBitmap) IsRevoked(index uint32) (bool, error) {
+	if int(index) >= len(b.bits) {
+		return false, fmt.Errorf("index %d is outside the status list of size %d", index, len(b.bits))
+	}
+	return b.bits[index], nil
+}
+
+/// @notice Returns the number of revoked indices
+func (b *RevocationBitmap) RevokedCount() int {
+	count := 0
+	for _, bit := range b.bits {
+		if bit {
+			count++
+		}
+	}
+	return count
+}
+
+/// @notice Returns the number of indices that are not revoked
+func (b *RevocationBitmap) IssuedCount() int {
+	return len(b.bits) - b.RevokedCount()
+}
+
+/// @notice Returns the revoked indices in ascending order
+func (b *RevocationBitmap) RevokedIndices() []uint32 {
+	indices := []uint32{}
+	for idx, bit := range b.bits {
+		if bit {
+			indices = append(indices, uint32(idx))
+		}
+	}
+	return indices
+}
+
+/// @notice Exports the bitmap packed eight indices per byte
+/// @dev Index 0 is the most significant bit of the first byte; unused trailing bits are zero
+func (b *RevocationBitmap) Bytes() []byte {
+	packed := make([]byte, (len(b.bits)+7)/8)
+	for idx, bit := range b.bits {
+		if bit {
+			packed[idx/8] |= 0x80 >> (idx % 8)
+		}
+	}
+	return packed
+}
+
+/// @notice Exports the bitmap as a string of '0' and '1' characters, one per index
+func (b *RevocationBitmap) String() string {
+	var builder strings.Builder
+	builder.Grow(len(b.bits))
+	for _, bit := range b.bits {
+		if bit {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	return builder.String()
+}
+
+/// @notice Returns the indices newly issued and newly revoked between two status lists
+/// @param old The earlier status list
+/// @param new The later status list
+/// @return The differences and an error if the lists belong to different registries
+func DiffStatusLists(old, new *RevocationStatusList) (*StatusListDiff, error) {
+	if old == nil || new == nil {
+		return nil, fmt.Errorf("both status lists are required")
+	}
+	oldBitmap, err := old.Bitmap()
+	if err != nil {
+		return nil, err
+	}
+	newBitmap, err := new.Bitmap()
+	if err != nil {
+		return nil, err
+	}
+	return DiffBitmaps(oldBitmap, newBitmap)
+}
+
+/// @notice Returns the indices newly issued and newly revoked between two decoded status lists
+/// @dev Lists without a registry ID are compared by size alone
+func DiffBitmaps(old, new *RevocationBitmap) (*StatusListDiff, error) {
+	if old == nil || new == nil {
+		return nil, fmt.Errorf("both bitmaps are required")
+	}
+	if old.RevocationRegistryDefinitionID != "" && new.RevocationRegistryDefinitionID != "" &&
+		old.RevocationRegistryDefinitionID != new.RevocationRegistryDefinitionID {
+		return nil, fmt.Errorf("status lists belong to different registries: %s and %s",
+			old.RevocationRegistryDefinitionID, new.RevocationRegistryDefinitionID)
+	}
+	if len(old.bits) != len(new.bits) {
+		return nil, fmt.Errorf("status lists have different sizes: %d and %d", len(old.bits), len(new.bits))
+	}
+
+	diff := &StatusListDiff{
+		RevocationRegistryDefinitionID: new.RevocationRegistryDefinitionID,
+		FromTimestamp:                  old.Timestamp,
+		ToTimestamp:                    new.Timestamp,
+		Issued:                         []uint32{},
+		Revoked:                        []uint32{},
+	}
+	if diff.RevocationRegistryDefinitionID == "" {
+		diff.RevocationRegistryDefinitionID = old.RevocationRegistryDefinitionID
+	}
+	for idx := range new.bits {
+		switch {
+		case new.bits[idx] && !old.bits[idx]:
+			diff.Revoked = append(diff.Revoked, uint32(idx))
+		case !new.bits[idx] && old.bits[idx]:
+			diff.Issued = append(diff.Issued, uint32(idx))
+		}
+	}
+	return diff, nil
+}
@@ -0,0 +1,275 @@
+package anoncreds
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Ajna-inc/anoncreds-go/internal/ffi"
+)
+
+/// @title Presentation Verification
+/// @dev Verifies presentations and explains the outcome per referent
+
+/// @notice Statuses of a referent in a verification report
+const (
+	ReferentVerified = "verified" /// @notice Proven and the presentation verified
+	ReferentFailed   = "failed"   /// @notice Proven but a check failed; see Reason
+	ReferentMissing  = "missing"  /// @notice Requested but not present in the presentation
+)
+
+/// @notice Kinds of referents in a verification report
+const (
+	ReferentKindAttribute      = "attribute"
+	ReferentKindAttributeGroup = "attribute_group"
+	ReferentKindUnrevealed     = "unrevealed"
+	ReferentKindSelfAttested   = "self_attested"
+	ReferentKindPredicate      = "predicate"
+)
+
+/// @notice Accepts a status list older than the requested interval for one registry
+type NonRevokedIntervalOverride struct {
+	RevocationRegistryDefinitionID string
+	RequestedFromTimestamp         int64 /// @notice The from timestamp in the presentation request
+	OverrideStatusListTimestamp    int64 /// @notice The earlier status list timestamp the verifier accepts
+}
+
+/// @notice Configuration options for verifying a presentation
+/// @dev Revocation fields are only needed when the request asks for non-revocation
+type VerifyPresentationOptions struct {
+	Presentation                  *Presentation
+	PresentationRequest           *PresentationRequest
+	Schemas                       map[string]*Schema
+	CredentialDefinitions         map[string]*CredentialDefinition
+	RevocationRegistryDefinitions map[string]*RevocationRegistryDefinition
+	RevocationStatusLists         []*RevocationStatusList
+	NonRevokedIntervalOverrides   []NonRevokedIntervalOverride
+}
+
+/// @notice The verification outcome of one requested referent
+type ReferentReport struct {
+	Referent             string                  `json:"referent"`
+	Kind                 string                  `json:"kind"`
+	Status               string                  `json:"status"`
+	Identifier           *PresentationIdentifier `json:"identifier,omitempty"`
+	Timestamp            *int64                  `json:"timestamp,omitempty"`
+	RevocationRegistryID string                  `json:"rev_reg_id,omitempty"`
+	RevocationChecked    bool                    `json:"revocation_checked"`
+	Reason               string                  `json:"reason,omitempty"`
+}
+
+/// @notice The outcome of verifying a presentation
+type VerificationReport struct {
+	Verified                    bool             `json:"verified"`
+	Reason                      string           `json:"reason,omitempty"`
+	Referents                   []ReferentReport `json:"referents"`
+	Timestamps                  []int64          `json:"timestamps"`
+	RevocationRegistriesChecked []string         `json:"rev_regs_checked"`
+}
+
+/// @notice Verifies a presentation against its request
+/// @param options Configuration options for verification
+/// @return Whether the presentation is valid and any error encountered
+func VerifyPresentation(options VerifyPresentationOptions) (bool, error) {
+	if options.Presentation == nil {
+		return false, fmt.Errorf("presentation is required")
+	}
+	if options.PresentationRequest == nil {
+		return false, fmt.Errorf("presentation request is required")
+	}
+
+	schemas := make(map[string]*ffi.ObjectHandle, len(options.Schemas))
+	for id, schema := range options.Schemas {
+		schemas[id] = schema.handle
+	}
+	credDefs := make(map[string]*ffi.ObjectHandle, len(options.CredentialDefinitions))
+	for id, credDef := range options.CredentialDefinitions {
+		credDefs[id] = credDef.handle
+	}
+	revRegDefs := make(map[string]*ffi.ObjectHandle, len(options.RevocationRegistryDefinitions))
+	for id, revRegDef := range options.RevocationRegistryDefinitions {
+		revRegDefs[id] = revRegDef.handle
+	}
+	statusLists := make([]*ffi.ObjectHandle, len(options.RevocationStatusLists))
+	for i, statusList := range options.RevocationStatusLists {
+		statusLists[i] = statusList.handle
+	}
+	overrides := make([]ffi.NonRevokedIntervalOverride, len(options.NonRevokedIntervalOverrides))
+	for i, override := range options.NonRevokedIntervalOverrides {
+		if override.RequestedFromTimestamp > math.MaxInt32 || override.OverrideStatusListTimestamp > math.MaxInt32 {
+			return false, fmt.Errorf("override timestamps for %s are out of range", override.RevocationRegistryDefinitionID)
+		}
+		overrides[i] = ffi.NonRevokedIntervalOverride{
+			RevRegDefId:                 override.RevocationRegistryDefinitionID,
+			RequestedFromTimestamp:      int32(override.RequestedFromTimestamp),
+			OverrideStatusListTimestamp: int32(override.OverrideStatusListTimestamp),
+		}
+	}
+
+	return ffi.VerifyPresentation(
+		options.Presentation.handle,
+		options.PresentationRequest.handle,
+		schemas,
+		credDefs,
+		revRegDefs,
+		statusLists,
+		overrides,
+	)
+}
+
+/// @notice Verifies a presentation and explains the outcome per referent
+/// @param options Configuration options for verification
+/// @return The report and an error only if the inputs cannot be read
+/// @dev Missing referents and objects are reported before the cryptographic check runs
+func VerifyPresentationWithReport(options VerifyPresentationOptions) (*VerificationReport, error) {
+	if options.Presentation == nil {
+		return nil, fmt.Errorf("presentation is required")
+	}
+	if options.PresentationRequest == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	request, err := options.PresentationRequest.Contents()
+	if err != nil {
+		return nil, err
+	}
+	revealed, err := options.Presentation.Revealed()
+	if err != nil {
+		return nil, err
+	}
+
+	// Status lists the verifier supplied, by registry and timestamp
+	statusLists := map[string]map[int64]bool{}
+	for _, statusList := range options.RevocationStatusLists {
+		bitmap, err := statusList.Bitmap()
+		if err != nil {
+			return nil, err
+		}
+		if bitmap.Timestamp == nil {
+			continue
+		}
+		if statusLists[bitmap.RevocationRegistryDefinitionID] == nil {
+			statusLists[bitmap.RevocationRegistryDefinitionID] = map[int64]bool{}
+		}
+		statusLists[bitmap.RevocationRegistryDefinitionID][*bitmap.Timestamp] = true
+	}
+
+	report := &VerificationReport{
+		Referents:                   []ReferentReport{},
+		Timestamps:                  []int64{},
+		RevocationRegistriesChecked: []string{},
+	}
+	timestamps := map[int64]bool{}
+	registries := map[string]bool{}
+
+	check := func(entry *ReferentReport, identifier PresentationIdentifier, interval *NonRevokedInterval) {
+		entry.Identifier = &identifier
+		entry.Timestamp = identifier.Timestamp
+		entry.RevocationRegistryID = identifier.RevocationRegistryID
+		entry.Status = ReferentVerified
+		if _, ok := options.Schemas[identifier.SchemaID]; !ok {
+			entry.Status, entry.Reason = ReferentFailed, fmt.Sprintf("schema %s was not provided", identifier.SchemaID)
+			return
+		}
+		if _, ok := options.CredentialDefinitions[identifier.CredentialDefinitionID]; !ok {
+			entry.Status, entry.Reason = ReferentFailed, fmt.Sprintf("credential definition %s was not provided", identifier.CredentialDefinitionID)
+			return
+		}
+		if identifier.Timestamp != nil {
+			timestamps[*identifier.Timestamp] = true
+		}
+		if interval == nil || identifier.RevocationRegistryID == "" {
+			return
+		}
+		switch {
+		case identifier.Timestamp == nil:
+			entry.Status, entry.Reason = ReferentFailed, "non-revocation was requested but no timestamp was proven"
+		case options.RevocationRegistryDefinitions[identifier.RevocationRegistryID] == nil:
+			entry.Status, entry.Reason = ReferentFailed, fmt.Sprintf("revocation registry definition %s was not provided", identifier.RevocationRegistryID)
+		case !statusLists[identifier.RevocationRegistryID][*identifier.Timestamp]:
+			entry.Status, entry.Reason = ReferentFailed, fmt.Sprintf("no status list of %s at timestamp %d was provided", identifier.RevocationRegistryID, *identifier.Timestamp)
+		default:
+			entry.RevocationChecked = true
+			registries[identifier.RevocationRegistryID] = true
+		}
+	}
+
+	for _, referent := range request.AttributeReferents() {
+		attribute := request.RequestedAttributes[referent]
+		interval := request.EffectiveNonRevoked(attribute.NonRevoked)
+		entry := ReferentReport{Referent: referent}
+		if attr, ok := revealed.Attributes[referent]; ok {
+			entry.Kind = ReferentKindAttribute
+			check(&entry, attr.Identifier, interval)
+		} else if group, ok := revealed.AttributeGroups[referent]; ok {
+			entry.Kind = ReferentKindAttributeGroup
+			check(&entry, group.Identifier, interval)
+		} else if unrevealed, ok := revealed.Unrevealed[referent]; ok {
+			entry.Kind = ReferentKindUnrevealed
+			check(&entry, unrevealed.Identifier, interval)
+		} else if _, ok := revealed.SelfAttested[referent]; ok {
+			entry.Kind = ReferentKindSelfAttested
+			entry.Status = ReferentVerified
+			if attribute.Restrictions != nil {
+				entry.Status, entry.Reason = ReferentFailed, "attribute has restrictions but was self-attested"
+			}
+		} else {
+			entry.Kind = ReferentKindAttribute
+			if len(attribute.Names) > 0 {
+				entry.Kind = ReferentKindAttributeGroup
+			}
+			entry.Status, entry.Reason = ReferentMissing, "referent is not in the presentation"
+		}
+		report.Referents = append(report.Referents, entry)
+	}
+	for _, referent := range request.PredicateReferents() {
+		predicate := request.RequestedPredicates[referent]
+		entry := ReferentReport{Referent: referent, Kind: ReferentKindPredicate}
+		if proven, ok := revealed.Predicates[referent]; ok {
+			check(&entry, proven.Identifier, request.EffectiveNonRevoked(predicate.NonRevoked))
+		} else {
+			entry.Status, entry.Reason = ReferentMissing, "referent is not in the presentation"
+		}
+		report.Referents = append(report.Referents, entry)
+	}
+
+	for timestamp := range timestamps {
+		report.Timestamps = append(report.Timestamps, timestamp)
+	}
+	sort.Slice(report.Timestamps, func(i, j int) bool { return report.Timestamps[i] < report.Timestamps[j] })
+	for registry := range registries {
+		report.RevocationRegistriesChecked = append(report.RevocationRegistriesChecked, registry)
+	}
+	sort.Strings(report.RevocationRegistriesChecked)
+
+	failed := 0
+	for _, entry := range report.Referents {
+		if entry.Status != ReferentVerified {
+			if failed == 0 {
+				report.Reason = fmt.Sprintf("%s: %s", entry.Referent, entry.Reason)
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		if failed > 1 {
+			report.Reason = fmt.Sprintf("%d referents failed; first %s", failed, report.Reason)
+		}
+		return report, nil
+	}
+
+	verified, err := VerifyPresentation(options)
+	switch {
+	case err != nil:
+		report.Reason = err.Error()
+	case !verified:
+		report.Reason = "presentation proof is invalid"
+	default:
+		report.Verified = true
+		return report, nil
+	}
+	for i := range report.Referents {
+		report.Referents[i].Status = ReferentFailed
+		report.Referents[i].Reason = report.Reason
+	}
+	return report, nil
+}
@@ -0,0 +1,515 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/protocol"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/verifier"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+/// @title Encrypted Export Bundles
+/// @dev Moves holder and issuer state between stores. A bundle is a JSON document whose records
+/// are encrypted with AES-256-GCM under a key derived from a passphrase with Argon2id; the
+/// header is authenticated as additional data. Objects sealed in a keystore.KeyStore stay sealed,
+/// so the destination needs the same KEK to open them. Tails files are not part of a bundle
+
+/// @notice Format name and version written to every bundle
+const (
+	FormatName    = "anoncreds-go/bundle"
+	FormatVersion = 1
+)
+
+/// @notice A group of records exported together
+type Scope string
+
+/// @notice Export scopes
+const (
+	ScopeCredentials Scope = "credentials"  /// @notice Holder credentials
+	ScopeLinkSecrets Scope = "link_secrets" /// @notice Link secrets and the default pointer
+	ScopeIssuer      Scope = "issuer"       /// @notice Schemas, credential definitions with their private parts, offers and issued credentials
+	ScopeRevocation  Scope = "revocation"   /// @notice Revocation registries, their private parts and pending revocations
+	ScopeProtocol    Scope = "protocol"     /// @notice Issuance and presentation exchanges and verifier nonces
+)
+
+/// @notice Every scope, in bundle order
+var AllScopes = []Scope{ScopeCredentials, ScopeLinkSecrets, ScopeIssuer, ScopeRevocation, ScopeProtocol}
+
+/// @dev Storage categories of each scope
+var scopeCategories = map[Scope][]string{
+	ScopeCredentials: {wallet.CategoryCredential},
+	ScopeLinkSecrets: {wallet.CategoryLinkSecret, wallet.CategoryDefaultLinkSecret},
+	ScopeIssuer:      {issuer.CategorySchema, issuer.CategoryCredentialDefinition, issuer.CategoryOffer, issuer.CategoryIssuedCredential},
+	ScopeRevocation: {
+		issuer.CategoryRevocationRegistry, issuer.CategoryRevocationPointer, issuer.CategoryRevocationIndex,
+		issuer.CategoryRevocationQueue, issuer.CategoryRevocationPublication,
+	},
+	ScopeProtocol: {
+		protocol.CategoryIssuerExchange, protocol.CategoryHolderExchange,
+		protocol.CategoryProverExchange, protocol.CategoryVerifierExchange, verifier.CategoryNonce,
+	},
+}
+
+/// @dev Scope of each type of sealed object
+var sealedObjectScopes = map[string]Scope{
+	keystore.ObjectTypeLinkSecret:                          ScopeLinkSecrets,
+	keystore.ObjectTypeCredentialDefinitionPrivate:         ScopeIssuer,
+	keystore.ObjectTypeRevocationRegistryDefinitionPrivate: ScopeRevocation,
+}
+
+/// @notice What to do when an imported record already exists
+type ConflictPolicy string
+
+/// @notice Conflict policies
+const (
+	ConflictFail      ConflictPolicy = "fail"      /// @notice Import nothing if any record exists; the default
+	ConflictSkip      ConflictPolicy = "skip"      /// @notice Keep the existing record
+	ConflictOverwrite ConflictPolicy = "overwrite" /// @notice Replace the existing record, except a KEK
+)
+
+/// @notice Highest Argon2id cost a bundle may ask for
+/// @dev The header is read before it can be authenticated, so a higher cost is rejected rather than derived
+const (
+	MaxArgon2idTime    = 16
+	MaxArgon2idMemory  = 1024 * 1024 /// @notice 1 GiB in KiB
+	MaxArgon2idThreads = 64
+)
+
+/// @notice Returned when the passphrase is wrong or the bundle was modified
+var ErrDecrypt = errors.New("wrong passphrase or corrupted bundle")
+
+/// @notice Returned by ConflictFail imports when records already exist
+var ErrConflict = errors.New("records already exist")
+
+/// @notice Lists the records of a bundle without revealing them
+type Manifest struct {
+	CreatedAt int64           `json:"created_at"`
+	Scopes    []Scope         `json:"scopes"`
+	Entries   []ManifestEntry `json:"entries"`
+	Digest    string          `json:"digest"` /// @notice SHA-256 over the entries
+}
+
+/// @notice A record listed in a manifest
+type ManifestEntry struct {
+	Scope    Scope  `json:"scope"`
+	Category string `json:"category"`
+	ID       string `json:"id"`
+	SHA256   string `json:"sha256"` /// @notice Hash of the record's category, ID, value and tags
+}
+
+/// @notice Returns the number of records per scope
+func (m *Manifest) Counts() map[Scope]int {
+	counts := make(map[Scope]int)
+	for _, entry := range m.Entries {
+		counts[entry.Scope]++
+	}
+	return counts
+}
+
+/// @notice Configuration of an export
+type ExportOptions struct {
+	/// @notice Passphrase the bundle key is derived from
+	Passphrase []byte
+
+	/// @notice Scopes to export; defaults to AllScopes
+	Scopes []Scope
+
+	/// @notice Cost of the key derivation; defaults to keystore.Argon2id defaults and may not exceed the Max constants
+	KDF keystore.Argon2id
+
+	/// @notice Clock used for the creation time; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Configuration of an import
+type ImportOptions struct {
+	/// @notice Passphrase the bundle was exported with
+	Passphrase []byte
+
+	/// @notice Scopes to import; defaults to every scope in the bundle
+	Scopes []Scope
+
+	/// @notice Defaults to ConflictFail
+	Conflict ConflictPolicy
+}
+
+/// @notice Outcome of an import
+type ImportReport struct {
+	Manifest    *Manifest
+	Imported    int
+	Overwritten int
+	Unchanged   int             /// @notice Records already stored with identical contents
+	Skipped     []ManifestEntry /// @notice Records kept because they already existed
+}
+
+/// @dev The outer, unencrypted document
+type bundle struct {
+	Header     bundleHeader `json:"header"`
+	Ciphertext []byte       `json:"ciphertext"` /// @dev nonce || AES-256-GCM ciphertext of the payload
+}
+
+/// @dev Authenticated as additional data
+type bundleHeader struct {
+	Format    string  `json:"format"`
+	Version   int     `json:"version"`
+	KDF       string  `json:"kdf"`
+	Salt      []byte  `json:"salt"`
+	Scopes    []Scope `json:"scopes"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+/// @dev The encrypted payload
+type bundlePayload struct {
+	Manifest Manifest          `json:"manifest"`
+	Records  []*storage.Record `json:"records"`
+}
+
+/// @notice Writes the records of the selected scopes as an encrypted bundle
+/// @param store The store to export
+/// @param w Where to write the bundle
+/// @param options Passphrase and scopes
+/// @return The manifest of the bundle and any error encountered
+func Export(store storage.Store, w io.Writer, options ExportOptions) (*Manifest, error) {
+	if len(options.Passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	scopes, err := selectScopes(options.Scopes, AllScopes)
+	if err != nil {
+		return nil, err
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	payload := bundlePayload{Manifest: Manifest{CreatedAt: options.Now().Unix(), Scopes: scopes}}
+	add := func(scope Scope, record *storage.Record) {
+		payload.Records = append(payload.Records, record)
+		payload.Manifest.Entries = append(payload.Manifest.Entries, ManifestEntry{
+			Scope: scope, Category: record.Category, ID: record.ID, SHA256: recordHash(record),
+		})
+	}
+	selected := make(map[Scope]bool, len(scopes))
+	for _, scope := range scopes {
+		selected[scope] = true
+		for _, category := range scopeCategories[scope] {
+			records, err := store.List(category)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range records {
+				add(scope, record)
+			}
+		}
+	}
+
+	// Sealed objects go with their scope, along with the parameters of the KEKs that wrapped them
+	sealed, err := store.List(keystore.CategorySealedObject)
+	if err != nil {
+		return nil, err
+	}
+	keks := make(map[string]Scope)
+	for _, record := range sealed {
+		scope, ok := sealedObjectScopes[record.Tags["type"]]
+		if !ok || !selected[scope] {
+			continue
+		}
+		add(scope, record)
+		if _, ok := keks[record.Tags["kek_id"]]; !ok {
+			keks[record.Tags["kek_id"]] = scope
+		}
+	}
+	kekIDs := make([]string, 0, len(keks))
+	for id := range keks {
+		kekIDs = append(kekIDs, id)
+	}
+	sort.Strings(kekIDs)
+	for _, id := range kekIDs {
+		record, err := store.Get(keystore.CategoryKEK, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Local and KMS KEKs keep nothing in the store
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		add(keks[id], record)
+	}
+	payload.Manifest.Digest = manifestDigest(payload.Manifest.Entries)
+
+	header := bundleHeader{
+		Format:    FormatName,
+		Version:   FormatVersion,
+		KDF:       options.KDF.Name(),
+		Salt:      make([]byte, 16),
+		Scopes:    scopes,
+		CreatedAt: payload.Manifest.CreatedAt,
+	}
+	if _, err := rand.Read(header.Salt); err != nil {
+		return nil, err
+	}
+	aead, aad, err := bundleCipher(header, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(bundle{Header: header, Ciphertext: aead.Seal(nonce, nonce, plaintext, aad)})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(encoded); err != nil {
+		return nil, err
+	}
+	return &payload.Manifest, nil
+}
+
+/// @notice Decrypts a bundle and returns its manifest without importing anything
+func Inspect(r io.Reader, passphrase []byte) (*Manifest, error) {
+	payload, err := readBundle(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.Manifest, nil
+}
+
+/// @notice Writes the records of a bundle into a store
+/// @param r The bundle
+/// @param store The store to import into
+/// @param options Passphrase, scopes and conflict policy
+/// @return What was imported and any error encountered
+/// @dev Conflicts are checked before the first write, so ConflictFail imports all or nothing.
+/// Records already stored with identical contents are not conflicts. A KEK stored with different
+/// parameters fails the import under every policy
+func Import(r io.Reader, store storage.Store, options ImportOptions) (*ImportReport, error) {
+	payload, err := readBundle(r, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := selectScopes(options.Scopes, payload.Manifest.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[Scope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !containsScope(payload.Manifest.Scopes, scope) {
+			return nil, fmt.Errorf("bundle does not contain scope %s", scope)
+		}
+		selected[scope] = true
+	}
+	policy := options.Conflict
+	if policy == "" {
+		policy = ConflictFail
+	}
+	if policy != ConflictFail && policy != ConflictSkip && policy != ConflictOverwrite {
+		return nil, fmt.Errorf("unknown conflict policy %q", policy)
+	}
+
+	report := &ImportReport{Manifest: &payload.Manifest}
+	var conflicts []ManifestEntry
+	exists := make([]bool, len(payload.Records))
+	// KEK parameters are listed under the first scope that needed them but serve every sealed object
+	sealed := false
+	for _, entry := range payload.Manifest.Entries {
+		sealed = sealed || (selected[entry.Scope] && entry.Category == keystore.CategorySealedObject)
+	}
+	wanted := func(entry ManifestEntry) bool {
+		return selected[entry.Scope] || (sealed && entry.Category == keystore.CategoryKEK)
+	}
+	unchanged := make([]bool, len(payload.Records))
+	for i, entry := range payload.Manifest.Entries {
+		if !wanted(entry) {
+			continue
+		}
+		existing, err := store.Get(entry.Category, entry.ID)
+		switch {
+		case err == nil && recordHash(existing) == entry.SHA256:
+			unchanged[i] = true
+		case err == nil && entry.Category == keystore.CategoryKEK:
+			// Replacing or keeping a KEK with another salt or KDF leaves some sealed objects unreadable
+			return nil, fmt.Errorf("%w: kek %s has different parameters in the store", ErrConflict, entry.ID)
+		case err == nil:
+			exists[i] = true
+			conflicts = append(conflicts, entry)
+		case !errors.Is(err, storage.ErrNotFound):
+			return nil, err
+		}
+	}
+	if policy == ConflictFail && len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %d records, e.g. %s/%s", ErrConflict, len(conflicts), conflicts[0].Category, conflicts[0].ID)
+	}
+
+	for i, entry := range payload.Manifest.Entries {
+		if !wanted(entry) {
+			continue
+		}
+		if unchanged[i] {
+			report.Unchanged++
+			continue
+		}
+		if exists[i] && policy == ConflictSkip {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+		if err := store.Put(payload.Records[i]); err != nil {
+			return report, err
+		}
+		if exists[i] {
+			report.Overwritten++
+		}
+		report.Imported++
+	}
+	return report, nil
+}
+
+/// @dev Decrypts a bundle and checks its records against the manifest
+func readBundle(r io.Reader, passphrase []byte) (*bundlePayload, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	var document bundle
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if document.Header.Format != FormatName {
+		return nil, fmt.Errorf("not an %s bundle", FormatName)
+	}
+	if document.Header.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", document.Header.Version)
+	}
+	aead, aad, err := bundleCipher(document.Header, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(document.Ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := document.Ciphertext[:aead.NonceSize()], document.Ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	var payload bundlePayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, fmt.Errorf("invalid bundle payload: %w", err)
+	}
+	manifest := &payload.Manifest
+	if len(manifest.Entries) != len(payload.Records) || manifestDigest(manifest.Entries) != manifest.Digest {
+		return nil, fmt.Errorf("bundle manifest does not match its records")
+	}
+	for i, entry := range manifest.Entries {
+		record := payload.Records[i]
+		if record == nil || record.Category != entry.Category || record.ID != entry.ID || recordHash(record) != entry.SHA256 {
+			return nil, fmt.Errorf("bundle record %s/%s does not match the manifest", entry.Category, entry.ID)
+		}
+	}
+	return &payload, nil
+}
+
+/// @dev Derives the bundle key and returns the cipher and the header as additional data
+func bundleCipher(header bundleHeader, passphrase []byte) (cipher.AEAD, []byte, error) {
+	kdf, err := keystore.ParseKDF(header.KDF)
+	if err != nil {
+		return nil, nil, err
+	}
+	argon, ok := kdf.(keystore.Argon2id)
+	if !ok {
+		return nil, nil, fmt.Errorf("bundles require argon2id, not %s", header.KDF)
+	}
+	if argon.Time > MaxArgon2idTime || argon.Memory > MaxArgon2idMemory || argon.Threads > MaxArgon2idThreads {
+		return nil, nil, fmt.Errorf("bundle kdf %s exceeds the maximum cost", header.KDF)
+	}
+	key, err := kdf.Derive(passphrase, header.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	aad, err := json.Marshal(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, aad, nil
+}
+
+/// @dev Validates requested scopes, defaulting to the available ones
+func selectScopes(requested, available []Scope) ([]Scope, error) {
+	if len(requested) == 0 {
+		return append([]Scope(nil), available...), nil
+	}
+	for _, scope := range requested {
+		if _, ok := scopeCategories[scope]; !ok {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return append([]Scope(nil), requested...), nil
+}
+
+func containsScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Hashes everything a record carries, with tags in key order
+func recordHash(record *storage.Record) string {
+	hash := sha256.New()
+	write := func(value string) {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	write(record.Category)
+	write(record.ID)
+	write(string(record.Value))
+	keys := make([]string, 0, len(record.Tags))
+	for key := range record.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		write(key)
+		write(record.Tags[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+/// @dev Hashes the manifest entries in order
+func manifestDigest(entries []ManifestEntry) string {
+	hash := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s\x00", entry.Scope, entry.Category, entry.ID, entry.SHA256)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/// @title anoncreds Command-Line Tool
+/// @dev Issuer, holder and verifier operations over JSON files, for scripting, fixtures and
+/// debugging. Every object is read from and written to a file; "-" names stdin or stdout.
+/// cmd/anoncreds is a thin wrapper around Run
+
+/// @notice Exit codes of Run
+const (
+	ExitOK      = 0 /// @notice The command succeeded
+	ExitFailure = 1 /// @notice The command failed, a presentation did not verify or an object is invalid
+	ExitUsage   = 2 /// @notice The command line was invalid
+)
+
+/// @dev Returned for invalid command lines
+var errUsage = errors.New("usage")
+
+/// @dev The streams a command runs with
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+/// @dev A subcommand
+type command struct {
+	name    string /// @dev One or two words, e.g. "schema create"
+	summary string
+	run     func(e *env, args []string) error
+}
+
+/// @dev Every subcommand, in help order
+var commands []command
+
+func init() {
+	commands = []command{
+		{"schema create", "Create a schema", runSchemaCreate},
+		{"creddef create", "Create a credential definition with its private part and key correctness proof", runCredDefCreate},
+		{"offer create", "Create a credential offer", runOfferCreate},
+		{"link-secret create", "Create a link secret", runLinkSecretCreate},
+		{"request create", "Create a credential request and its metadata", runRequestCreate},
+		{"credential issue", "Issue a credential for a request", runCredentialIssue},
+		{"credential process", "Process a received credential with the holder's link secret", runCredentialProcess},
+		{"presentation create", "Create a presentation for a presentation request", runPresentationCreate},
+		{"presentation verify", "Verify a presentation and print the verification report", runPresentationVerify},
+		{"revreg create", "Create a revocation registry and its initial status list", runRevRegCreate},
+		{"revoke", "Revoke credentials in a status list", runRevoke},
+		{"nonce", "Generate a nonce", runNonce},
+		{"encode", "Encode raw attribute values", runEncode},
+		{"inspect", "Detect, validate and summarize an object", runInspect},
+		{"serve", "Serve the admin REST API over a data directory", runServe},
+	}
+}
+
+/// @notice Runs the tool
+/// @param args The arguments after the program name
+/// @return The exit code
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		e.usage()
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "anoncreds: unknown command %q\n", strings.Join(args[:min(2, len(args))], " "))
+		e.usage()
+		return ExitUsage
+	}
+	err := cmd.run(e, rest)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errUsage):
+		if msg := strings.TrimPrefix(err.Error(), errUsage.Error()+": "); msg != errUsage.Error() {
+			fmt.Fprintf(stderr, "anoncreds %s: %s\n", cmd.name, msg)
+		}
+		return ExitUsage
+	case errors.Is(err, errNotVerified), errors.Is(err, errInvalidObject):
+		return ExitFailure
+	default:
+		fmt.Fprintf(stderr, "anoncreds %s: %v\n", cmd.name, err)
+		return ExitFailure
+	}
+}
+
+/// @dev Finds the command named by the leading arguments
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == commands[i].name {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func (e *env) usage() {
+	fmt.Fprintln(e.stderr, "Usage: anoncreds <command> [flags]")
+	fmt.Fprintln(e.stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(e.stderr, "  %-22s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(e.stderr, "\nRun 'anoncreds <command> -h' for the flags of a command. \"-\" reads stdin or writes stdout.")
+}
+
+/// @dev Creates the flag set of a command
+func (e *env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("anoncreds "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+/// @dev Parses flags, mapping parse errors to errUsage
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+/// @dev Reports flags that must be set
+func required(values map[string]string) error {
+	var missing []string
+	for name, value := range values {
+		if value == "" {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%w: %s required", errUsage, strings.Join(missing, ", "))
+}
+
+/// @dev A repeatable flag
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+/// @dev A repeatable KEY=VALUE flag
+type mapFlag map[string]string
+
+func (m mapFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m mapFlag) Set(value string) error {
+	key, file, ok := strings.Cut(value, "=")
+	if !ok || key == "" || file == "" {
+		return fmt.Errorf("expected KEY=FILE, got %q", value)
+	}
+	m[key] = file
+	return nil
+}
+
+/// @dev Reads a file, or stdin for "-"
+func (e *env) read(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+/// @dev Reads a JSON file into value
+func (e *env) readJSON(path string, value interface{}) error {
+	data, err := e.read(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+/// @dev Writes indented JSON to a file, or stdout for "" and "-"
+func (e *env) writeJSON(path string, data []byte, secret bool) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return fmt.Errorf("invalid JSON output: %w", err)
+	}
+	indented.WriteByte('\n')
+	return e.write(path, indented.Bytes(), secret)
+}
+
+/// @dev Writes to a file, or stdout for "" and "-"; secrets are only readable by the owner
+func (e *env) write(path string, data []byte, secret bool) error {
+	if path == "" || path == "-" {
+		_, err := e.stdout.Write(data)
+		return err
+	}
+	if !secret {
+		return os.WriteFile(path, data, 0o644)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	// The mode only applies to new files, so an existing one is restricted before the secret goes in
+	if err := file.Chmod(0o600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/// @dev An object that can be serialized
+type jsonObject interface {
+	ToJSONString() (string, error)
+}
+
+/// @dev Writes an object as JSON
+func (e *env) writeObject(path string, object jsonObject, secret bool) error {
+	data, err := object.ToJSONString()
+	if err != nil {
+		return err
+	}
+	return e.writeJSON(path, []byte(data), secret)
+}
+
+/// @dev Writes objects to fixed file names in a directory, which is created if needed
+func (e *env) writeObjects(dir string, objects map[string]jsonObject, secrets map[string]bool) error {
+	if dir == "" {
+		return fmt.Errorf("%w: -out-dir required", errUsage)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := e.writeObject(path, objects[name], secrets[name]); err != nil {
+			return err
+		}
+		fmt.Fprintln(e.stderr, "wrote", path)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Holder Commands
+
+func runLinkSecretCreate(e *env, args []string) error {
+	fs := e.flags("link-secret create")
+	out := fs.String("o", "-", "output file; written readable by the owner only")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	secret, err := anoncreds.CreateLinkSecret()
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe()
+	value := secret.Bytes()
+	defer func() {
+		for i := range value {
+			value[i] = 0
+		}
+	}()
+	return e.write(*out, append(value, '\n'), true)
+}
+
+func runRequestCreate(e *env, args []string) error {
+	fs := e.flags("request create")
+	credDefPath := fs.String("cred-def", "", "credential definition file")
+	offerPath := fs.String("offer", "", "credential offer file")
+	linkSecretPath := fs.String("link-secret", "", "link secret file")
+	linkSecretID := fs.String("link-secret-id", "default", "name of the link secret")
+	entropy := fs.String("entropy", "", "prover entropy; required unless -prover-did is set")
+	proverDID := fs.String("prover-did", "", "legacy prover DID")
+	outDir := fs.String("out-dir", "", "directory for "+FileCredentialRequest+" and "+FileCredentialRequestMetadata)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"cred-def": *credDefPath, "offer": *offerPath, "link-secret": *linkSecretPath, "out-dir": *outDir}); err != nil {
+		return err
+	}
+	if *entropy == "" && *proverDID == "" {
+		return fmt.Errorf("%w: -entropy or -prover-did required", errUsage)
+	}
+
+	credDef, err := loadCredentialDefinition(e, *credDefPath)
+	if err != nil {
+		return err
+	}
+	defer credDef.Clear()
+	offer, err := loadOffer(e, *offerPath)
+	if err != nil {
+		return err
+	}
+	defer offer.Clear()
+	linkSecret, err := loadLinkSecret(e, *linkSecretPath)
+	if err != nil {
+		return err
+	}
+	defer linkSecret.Wipe()
+
+	options := anoncreds.CreateCredentialRequestOptions{
+		Entropy:              *entropy,
+		CredentialDefinition: credDef,
+		LinkSecret:           linkSecret,
+		LinkSecretID:         *linkSecretID,
+		CredentialOffer:      offer,
+	}
+	if *proverDID != "" {
+		options.ProverDID = proverDID
+	}
+	result, err := anoncreds.CreateCredentialRequest(options)
+	if err != nil {
+		return err
+	}
+	defer result.CredentialRequest.Clear()
+	defer result.CredentialRequestMetadata.Clear()
+	return e.writeObjects(*outDir, map[string]jsonObject{
+		FileCredentialRequest:         result.CredentialRequest,
+		FileCredentialRequestMetadata: result.CredentialRequestMetadata,
+	}, nil)
+}
+
+func runCredentialProcess(e *env, args []string) error {
+	fs := e.flags("credential process")
+	credentialPath := fs.String("credential", "", "received credential file")
+	metadataPath := fs.String("request-metadata", "", "credential request metadata file")
+	linkSecretPath := fs.String("link-secret", "", "link secret file")
+	credDefPath := fs.String("cred-def", "", "credential definition file")
+	revRegDefPath := fs.String("rev-reg-def", "", "revocation registry definition file, for revocable credentials")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"credential": *credentialPath, "request-metadata": *metadataPath, "link-secret": *linkSecretPath, "cred-def": *credDefPath}); err != nil {
+		return err
+	}
+
+	credential, err := loadCredential(e, *credentialPath)
+	if err != nil {
+		return err
+	}
+	defer credential.Clear()
+	metadataJSON, err := e.read(*metadataPath)
+	if err != nil {
+		return err
+	}
+	metadata, err := anoncreds.CredentialRequestMetadataFromJSON(metadataJSON)
+	if err != nil {
+		return err
+	}
+	defer metadata.Clear()
+	credDef, err := loadCredentialDefinition(e, *credDefPath)
+	if err != nil {
+		return err
+	}
+	defer credDef.Clear()
+	linkSecret, err := loadLinkSecret(e, *linkSecretPath)
+	if err != nil {
+		return err
+	}
+	defer linkSecret.Wipe()
+
+	options := anoncreds.ProcessCredentialOptions{
+		Credential:                credential,
+		CredentialRequestMetadata: metadata,
+		LinkSecret:                linkSecret,
+		CredentialDefinition:      credDef,
+	}
+	if *revRegDefPath != "" {
+		revRegDef, err := loadRevocationRegistryDefinition(e, *revRegDefPath)
+		if err != nil {
+			return err
+		}
+		defer revRegDef.Clear()
+		options.RevocationRegistryDefinition = revRegDef
+	}
+	processed, err := anoncreds.ProcessCredential(options)
+	if err != nil {
+		return err
+	}
+	defer processed.Clear()
+	return e.writeObject(*out, processed, false)
+}
+
+/// @notice The requested credentials file of presentation create, in the indy-sdk format
+/// @dev cred_id names a -credential flag; a timestamp requires a -revocation-state for it
+type RequestedCredentials struct {
+	SelfAttestedAttributes map[string]string                   `json:"self_attested_attributes,omitempty"`
+	RequestedAttributes    map[string]RequestedCredentialEntry `json:"requested_attributes,omitempty"`
+	RequestedPredicates    map[string]RequestedCredentialEntry `json:"requested_predicates,omitempty"`
+}
+
+/// @notice The credential chosen for a requested attribute or predicate
+type RequestedCredentialEntry struct {
+	CredentialID string `json:"cred_id"`
+	Revealed     *bool  `json:"revealed,omitempty"` /// @notice Attributes only; defaults to true
+	Timestamp    *int64 `json:"timestamp,omitempty"`
+}
+
+func runPresentationCreate(e *env, args []string) error {
+	fs := e.flags("presentation create")
+	requestPath := fs.String("request", "", "presentation request file")
+	requestedPath := fs.String("requested", "", "requested credentials file")
+	linkSecretPath := fs.String("link-secret", "", "link secret file")
+	credentialFiles, stateFiles, schemaFiles, credDefFiles := mapFlag{}, mapFlag{}, mapFlag{}, mapFlag{}
+	fs.Var(credentialFiles, "credential", "KEY=FILE of a credential named by cred_id; repeatable")
+	fs.Var(stateFiles, "revocation-state", "KEY=FILE of the revocation state of a credential; repeatable")
+	fs.Var(schemaFiles, "schema", "ID=FILE of a schema; repeatable")
+	fs.Var(credDefFiles, "cred-def", "ID=FILE of a credential definition; repeatable")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"request": *requestPath, "requested": *requestedPath, "link-secret": *linkSecretPath}); err != nil {
+		return err
+	}
+
+	var requested RequestedCredentials
+	if err := e.readJSON(*requestedPath, &requested); err != nil {
+		return err
+	}
+	requestJSON, err := e.read(*requestPath)
+	if err != nil {
+		return err
+	}
+	request, err := anoncreds.PresentationRequestFromJSON(requestJSON)
+	if err != nil {
+		return err
+	}
+	defer request.Clear()
+	schemas, releaseSchemas, err := loadSchemas(e, schemaFiles)
+	if err != nil {
+		return err
+	}
+	defer releaseSchemas()
+	credDefs, releaseCredDefs, err := loadCredentialDefinitions(e, credDefFiles)
+	if err != nil {
+		return err
+	}
+	defer releaseCredDefs()
+	linkSecret, err := loadLinkSecret(e, *linkSecretPath)
+	if err != nil {
+		return err
+	}
+	defer linkSecret.Wipe()
+
+	// One presentation entry per credential and timestamp, as anoncreds-rs requires
+	type entryKey struct {
+		credentialID string
+		timestamp    int64
+	}
+	entries := map[entryKey]int{}
+	options := anoncreds.CreatePresentationOptions{
+		PresentationRequest:    request,
+		SelfAttestedAttributes: requested.SelfAttestedAttributes,
+		LinkSecret:             linkSecret,
+		Schemas:                schemas,
+		CredentialDefinitions:  credDefs,
+	}
+	defer func() {
+		for _, credential := range options.Credentials {
+			credential.Credential.Clear()
+			if credential.RevocationState != nil {
+				credential.RevocationState.Clear()
+			}
+		}
+	}()
+	entry := func(choice RequestedCredentialEntry) (int, error) {
+		key := entryKey{credentialID: choice.CredentialID}
+		if choice.Timestamp != nil {
+			key.timestamp = *choice.Timestamp
+		}
+		if index, ok := entries[key]; ok {
+			return index, nil
+		}
+		path, ok := credentialFiles[choice.CredentialID]
+		if !ok {
+			return 0, fmt.Errorf("%w: no -credential %s=FILE", errUsage, choice.CredentialID)
+		}
+		credential, err := loadCredential(e, path)
+		if err != nil {
+			return 0, err
+		}
+		present := anoncreds.PresentCredential{Credential: credential, Timestamp: choice.Timestamp}
+		if choice.Timestamp != nil {
+			statePath, ok := stateFiles[choice.CredentialID]
+			if !ok {
+				credential.Clear()
+				return 0, fmt.Errorf("%w: no -revocation-state %s=FILE for the timestamp", errUsage, choice.CredentialID)
+			}
+			data, err := e.read(statePath)
+			if err != nil {
+				credential.Clear()
+				return 0, err
+			}
+			if present.RevocationState, err = anoncreds.CredentialRevocationStateFromJSON(data); err != nil {
+				credential.Clear()
+				return 0, err
+			}
+		}
+		options.Credentials = append(options.Credentials, present)
+		entries[key] = len(options.Credentials) - 1
+		return entries[key], nil
+	}
+
+	for _, referent := range sortedKeys(requested.RequestedAttributes) {
+		choice := requested.RequestedAttributes[referent]
+		index, err := entry(choice)
+		if err != nil {
+			return err
+		}
+		reveal := choice.Revealed == nil || *choice.Revealed
+		options.CredentialsProve = append(options.CredentialsProve, anoncreds.CredentialProve{EntryIndex: index, Referent: referent, Reveal: reveal})
+	}
+	for _, referent := range sortedKeys(requested.RequestedPredicates) {
+		index, err := entry(requested.RequestedPredicates[referent])
+		if err != nil {
+			return err
+		}
+		options.CredentialsProve = append(options.CredentialsProve, anoncreds.CredentialProve{EntryIndex: index, Referent: referent, IsPredicate: true})
+	}
+
+	presentation, err := anoncreds.CreatePresentation(options)
+	if err != nil {
+		return err
+	}
+	defer presentation.Clear()
+	return e.writeObject(*out, presentation, false)
+}
+
+func loadCredential(e *env, path string) (*anoncreds.Credential, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialFromJSON(data)
+}
+
+/// @dev Returns the keys of requested credential entries in order, so output is reproducible
+func sortedKeys(entries map[string]RequestedCredentialEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
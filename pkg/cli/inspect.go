@@ -0,0 +1,897 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Object Inspection
+/// @dev Detects the type of an AnonCreds object, validates its shape and summarizes it.
+/// Candidate types are ranked by how many of their required fields the document has, then
+/// confirmed with the native parser and anoncreds_object_get_type_name
+
+/// @dev Returned when an inspected object is invalid; the report has already been printed
+var errInvalidObject = errors.New("invalid object")
+
+/// @notice A problem found in an inspected object
+type ValidationError struct {
+	Path    string `json:"path"` /// @notice JSON path of the offending value, e.g. $.value.primary.n
+	Message string `json:"message"`
+}
+
+/// @notice The output of inspect
+type Inspection struct {
+	Type               string                 `json:"type"`                  /// @notice One of anoncreds.ObjectTypes, or empty if unrecognized
+	NativeType         string                 `json:"native_type,omitempty"` /// @notice The type name the native library reports
+	Parsed             bool                   `json:"parsed"`                /// @notice Whether the native parser accepted the object
+	ParseError         string                 `json:"parse_error,omitempty"`
+	IDs                map[string]string      `json:"ids,omitempty"`
+	Attributes         []string               `json:"attributes,omitempty"`
+	SupportsRevocation *bool                  `json:"supports_revocation,omitempty"`
+	KeySizes           map[string]int         `json:"key_sizes,omitempty"` /// @notice Bit lengths of RSA moduli and primes
+	Nonce              string                 `json:"nonce,omitempty"`
+	Details            map[string]interface{} `json:"details,omitempty"`
+	Errors             []ValidationError      `json:"errors"`
+}
+
+/// @notice Whether the object parsed and has no validation errors
+func (i *Inspection) Valid() bool {
+	return i.Parsed && len(i.Errors) == 0
+}
+
+/// @dev Kinds of JSON values a field must hold
+type fieldKind int
+
+const (
+	kindAny     fieldKind = iota
+	kindString            // A non-empty string
+	kindDecimal           // A string of decimal digits, as used for big integers
+	kindNumber
+	kindObject
+	kindArray
+)
+
+/// @dev A field an object type requires; "*" in a path matches every member or element
+type field struct {
+	path     string
+	kind     fieldKind
+	optional bool
+}
+
+/// @dev The shape and summary of an object type
+type objectShape struct {
+	fields    []field
+	aliases   []string /// @dev Other native type names of the type
+	check     func(doc map[string]interface{}) []ValidationError
+	summarize func(doc map[string]interface{}, inspection *Inspection)
+}
+
+/// @dev Shapes by object type, following the anoncreds-rs serializations
+var objectShapes = map[string]objectShape{
+	"Schema": {
+		fields: []field{
+			{path: "name", kind: kindString},
+			{path: "version", kind: kindString},
+			{path: "attrNames", kind: kindArray},
+			{path: "attrNames.*", kind: kindString},
+			{path: "issuerId", kind: kindString},
+		},
+		check: checkSchema,
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "issuerId")
+			inspection.addDetails(doc, "name", "version")
+			inspection.Attributes = stringList(doc["attrNames"])
+		},
+	},
+	"CredentialDefinition": {
+		fields: []field{
+			{path: "schemaId", kind: kindString},
+			{path: "type", kind: kindString},
+			{path: "tag", kind: kindString},
+			{path: "value", kind: kindObject},
+			{path: "value.primary", kind: kindObject},
+			{path: "value.primary.n", kind: kindDecimal},
+			{path: "value.primary.s", kind: kindDecimal},
+			{path: "value.primary.r", kind: kindObject},
+			{path: "value.primary.r.*", kind: kindDecimal},
+			{path: "value.primary.rctxt", kind: kindDecimal},
+			{path: "value.primary.z", kind: kindDecimal},
+			{path: "value.revocation", kind: kindObject, optional: true},
+			{path: "issuerId", kind: kindString},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "schemaId", "issuerId")
+			inspection.addDetails(doc, "type", "tag")
+			attributes := map[string]bool{}
+			for _, name := range memberNames(lookup(doc, "value.primary.r")) {
+				attributes[name] = true
+			}
+			delete(attributes, "master_secret")
+			inspection.Attributes = sortedSet(attributes)
+			supports := lookup(doc, "value.revocation") != nil
+			inspection.SupportsRevocation = &supports
+			inspection.addKeySize("primary.n", lookup(doc, "value.primary.n"))
+		},
+	},
+	"CredentialDefinitionPrivate": {
+		fields: []field{
+			{path: "value", kind: kindObject},
+			{path: "value.p_key", kind: kindObject},
+			{path: "value.p_key.p", kind: kindDecimal},
+			{path: "value.p_key.q", kind: kindDecimal},
+			{path: "value.r_key", kind: kindObject, optional: true},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			supports := lookup(doc, "value.r_key") != nil
+			inspection.SupportsRevocation = &supports
+			inspection.addKeySize("p_key.p", lookup(doc, "value.p_key.p"))
+			inspection.addKeySize("p_key.q", lookup(doc, "value.p_key.q"))
+		},
+	},
+	"KeyCorrectnessProof": {
+		fields: []field{
+			{path: "c", kind: kindDecimal},
+			{path: "xz_cap", kind: kindDecimal},
+			{path: "xr_cap", kind: kindAny},
+		},
+		aliases: []string{"CredentialKeyCorrectnessProof"},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.Attributes = xrCapNames(doc["xr_cap"])
+		},
+	},
+	"CredentialOffer": {
+		fields: []field{
+			{path: "schema_id", kind: kindString},
+			{path: "cred_def_id", kind: kindString},
+			{path: "key_correctness_proof", kind: kindObject},
+			{path: "key_correctness_proof.c", kind: kindDecimal},
+			{path: "key_correctness_proof.xz_cap", kind: kindDecimal},
+			{path: "key_correctness_proof.xr_cap", kind: kindAny},
+			{path: "nonce", kind: kindDecimal},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "schema_id", "cred_def_id")
+			inspection.addDetails(doc, "method_name")
+			inspection.Attributes = xrCapNames(lookup(doc, "key_correctness_proof.xr_cap"))
+			inspection.Nonce = stringValue(doc["nonce"])
+		},
+	},
+	"CredentialRequest": {
+		fields: []field{
+			{path: "cred_def_id", kind: kindString},
+			{path: "blinded_ms", kind: kindObject},
+			{path: "blinded_ms_correctness_proof", kind: kindObject},
+			{path: "nonce", kind: kindDecimal},
+			{path: "entropy", kind: kindString, optional: true},
+			{path: "prover_did", kind: kindString, optional: true},
+		},
+		check: func(doc map[string]interface{}) []ValidationError {
+			if doc["entropy"] == nil && doc["prover_did"] == nil {
+				return []ValidationError{{Path: "$.entropy", Message: "one of entropy and prover_did is required"}}
+			}
+			return nil
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "cred_def_id", "prover_did")
+			inspection.addDetails(doc, "entropy")
+			inspection.Nonce = stringValue(doc["nonce"])
+		},
+	},
+	"CredentialRequestMetadata": {
+		fields: []field{
+			{path: "link_secret_blinding_data", kind: kindObject},
+			{path: "nonce", kind: kindDecimal},
+			{path: "link_secret_name", kind: kindString},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addDetails(doc, "link_secret_name")
+			inspection.Nonce = stringValue(doc["nonce"])
+		},
+	},
+	"Credential": {
+		fields: []field{
+			{path: "schema_id", kind: kindString},
+			{path: "cred_def_id", kind: kindString},
+			{path: "values", kind: kindObject},
+			{path: "values.*", kind: kindObject},
+			{path: "values.*.raw", kind: kindAny},
+			{path: "values.*.encoded", kind: kindDecimal},
+			{path: "signature", kind: kindObject},
+			{path: "signature_correctness_proof", kind: kindObject},
+			{path: "rev_reg_id", kind: kindString, optional: true},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "schema_id", "cred_def_id", "rev_reg_id")
+			inspection.Attributes = memberNames(doc["values"])
+			supports := stringValue(doc["rev_reg_id"]) != ""
+			inspection.SupportsRevocation = &supports
+		},
+	},
+	"RevocationRegistryDefinition": {
+		fields: []field{
+			{path: "issuerId", kind: kindString},
+			{path: "revocDefType", kind: kindString},
+			{path: "credDefId", kind: kindString},
+			{path: "tag", kind: kindString},
+			{path: "value", kind: kindObject},
+			{path: "value.publicKeys", kind: kindObject},
+			{path: "value.maxCredNum", kind: kindNumber},
+			{path: "value.tailsLocation", kind: kindString},
+			{path: "value.tailsHash", kind: kindString},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "issuerId", "credDefId")
+			inspection.addDetails(doc, "revocDefType", "tag", "value.maxCredNum", "value.tailsLocation", "value.tailsHash")
+			supports := true
+			inspection.SupportsRevocation = &supports
+		},
+	},
+	"RevocationRegistryDefinitionPrivate": {
+		fields: []field{
+			{path: "value", kind: kindObject},
+			{path: "value.gamma", kind: kindString},
+		},
+	},
+	"RevocationStatusList": {
+		fields: []field{
+			{path: "revRegDefId", kind: kindString},
+			{path: "revocationList", kind: kindArray},
+			{path: "revocationList.*", kind: kindNumber},
+			{path: "currentAccumulator", kind: kindString},
+			{path: "timestamp", kind: kindNumber, optional: true},
+			{path: "issuerId", kind: kindString},
+		},
+		check: checkStatusList,
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addIDs(doc, "revRegDefId", "issuerId")
+			inspection.addDetails(doc, "timestamp")
+			if list, ok := doc["revocationList"].([]interface{}); ok {
+				revoked := 0
+				for _, entry := range list {
+					if fmt.Sprint(entry) == "1" {
+						revoked++
+					}
+				}
+				inspection.setDetail("size", len(list))
+				inspection.setDetail("revoked", revoked)
+			}
+		},
+	},
+	"RevocationState": {
+		fields: []field{
+			{path: "witness", kind: kindObject},
+			{path: "rev_reg", kind: kindObject},
+			{path: "timestamp", kind: kindNumber},
+		},
+		aliases: []string{"CredentialRevocationState"},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addDetails(doc, "timestamp")
+		},
+	},
+	"PresentationRequest": {
+		fields: []field{
+			{path: "nonce", kind: kindDecimal},
+			{path: "name", kind: kindString},
+			{path: "version", kind: kindString},
+			{path: "requested_attributes", kind: kindObject},
+			{path: "requested_predicates", kind: kindObject, optional: true},
+			{path: "requested_predicates.*.name", kind: kindString},
+			{path: "requested_predicates.*.p_type", kind: kindString},
+			{path: "requested_predicates.*.p_value", kind: kindNumber},
+			{path: "non_revoked", kind: kindObject, optional: true},
+		},
+		check: checkPresentationRequest,
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			inspection.addDetails(doc, "name", "version")
+			inspection.Nonce = stringValue(doc["nonce"])
+			attributes := map[string]bool{}
+			supports := doc["non_revoked"] != nil
+			for _, entry := range objectMembers(doc["requested_attributes"]) {
+				if name := stringValue(entry["name"]); name != "" {
+					attributes[name] = true
+				}
+				for _, name := range stringList(entry["names"]) {
+					attributes[name] = true
+				}
+				supports = supports || entry["non_revoked"] != nil
+			}
+			var predicates []string
+			for _, referent := range memberNames(doc["requested_predicates"]) {
+				entry := objectMembers(doc["requested_predicates"])[referent]
+				predicates = append(predicates, fmt.Sprintf("%s %s %v", stringValue(entry["name"]), stringValue(entry["p_type"]), entry["p_value"]))
+				supports = supports || entry["non_revoked"] != nil
+			}
+			inspection.Attributes = sortedSet(attributes)
+			if len(predicates) > 0 {
+				inspection.setDetail("predicates", predicates)
+			}
+			inspection.SupportsRevocation = &supports
+		},
+	},
+	"Presentation": {
+		fields: []field{
+			{path: "proof", kind: kindObject},
+			{path: "requested_proof", kind: kindObject},
+			{path: "identifiers", kind: kindArray},
+			{path: "identifiers.*.schema_id", kind: kindString},
+			{path: "identifiers.*.cred_def_id", kind: kindString},
+		},
+		summarize: func(doc map[string]interface{}, inspection *Inspection) {
+			var identifiers []map[string]string
+			supports := false
+			list, _ := doc["identifiers"].([]interface{})
+			for _, entry := range list {
+				identifier := map[string]string{}
+				for _, key := range []string{"schema_id", "cred_def_id", "rev_reg_id"} {
+					if value := stringValue(lookup(entry, key)); value != "" {
+						identifier[key] = value
+					}
+				}
+				supports = supports || identifier["rev_reg_id"] != ""
+				identifiers = append(identifiers, identifier)
+			}
+			if len(identifiers) > 0 {
+				inspection.setDetail("identifiers", identifiers)
+			}
+			revealed := map[string]bool{}
+			for _, key := range []string{"requested_proof.revealed_attrs", "requested_proof.revealed_attr_groups", "requested_proof.self_attested_attrs"} {
+				for _, referent := range memberNames(lookup(doc, key)) {
+					revealed[referent] = true
+				}
+			}
+			inspection.Attributes = sortedSet(revealed)
+			inspection.SupportsRevocation = &supports
+		},
+	},
+}
+
+func checkSchema(doc map[string]interface{}) []ValidationError {
+	var problems []ValidationError
+	seen := map[string]bool{}
+	names, _ := doc["attrNames"].([]interface{})
+	for i, name := range names {
+		value, _ := name.(string)
+		key := strings.ToLower(strings.ReplaceAll(value, " ", ""))
+		if value != "" && seen[key] {
+			problems = append(problems, ValidationError{Path: fmt.Sprintf("$.attrNames[%d]", i), Message: fmt.Sprintf("duplicate attribute %q", value)})
+		}
+		seen[key] = true
+	}
+	return problems
+}
+
+func checkStatusList(doc map[string]interface{}) []ValidationError {
+	var problems []ValidationError
+	list, _ := doc["revocationList"].([]interface{})
+	for i, entry := range list {
+		if value := fmt.Sprint(entry); value != "0" && value != "1" {
+			problems = append(problems, ValidationError{Path: fmt.Sprintf("$.revocationList[%d]", i), Message: "expected 0 or 1"})
+		}
+	}
+	return problems
+}
+
+func checkPresentationRequest(doc map[string]interface{}) []ValidationError {
+	var problems []ValidationError
+	attributes := objectMembers(doc["requested_attributes"])
+	for _, referent := range memberNames(doc["requested_attributes"]) {
+		entry := attributes[referent]
+		_, hasName := entry["name"]
+		_, hasNames := entry["names"]
+		if hasName == hasNames {
+			problems = append(problems, ValidationError{Path: "$.requested_attributes" + pathKey(referent), Message: "exactly one of name and names is required"})
+		}
+	}
+	predicates := objectMembers(doc["requested_predicates"])
+	for _, referent := range memberNames(doc["requested_predicates"]) {
+		switch pType := stringValue(predicates[referent]["p_type"]); pType {
+		case "", ">=", ">", "<=", "<":
+		default:
+			problems = append(problems, ValidationError{Path: "$.requested_predicates" + pathKey(referent) + ".p_type", Message: fmt.Sprintf("unknown predicate type %q", pType)})
+		}
+	}
+	return problems
+}
+
+/// @notice Inspects a JSON encoded AnonCreds object
+/// @param data The JSON document
+/// @return The inspection; Type is empty if the document matches no object type
+func Inspect(data []byte) *Inspection {
+	inspection := &Inspection{Errors: []ValidationError{}}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		inspection.Errors = append(inspection.Errors, ValidationError{Path: "$", Message: "invalid JSON: " + err.Error()})
+		return inspection
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		inspection.Errors = append(inspection.Errors, ValidationError{Path: "$", Message: "invalid JSON: trailing data"})
+		return inspection
+	}
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		inspection.Errors = append(inspection.Errors, ValidationError{Path: "$", Message: "expected an object"})
+		return inspection
+	}
+
+	candidates := rankObjectTypes(doc)
+	if len(candidates) == 0 {
+		inspection.Errors = append(inspection.Errors, ValidationError{Path: "$", Message: "does not match any AnonCreds object type"})
+		return inspection
+	}
+
+	// The best candidate the native library accepts; the best structural match otherwise
+	inspection.Type = candidates[0]
+	for _, candidate := range candidates {
+		handle, err := anoncreds.ObjectFromJSON(candidate, data)
+		if err != nil {
+			if candidate == inspection.Type {
+				inspection.ParseError = err.Error()
+			}
+			continue
+		}
+		name, err := handle.TypeName()
+		handle.Clear()
+		if err != nil || !objectShapes[candidate].matches(candidate, name) {
+			continue
+		}
+		inspection.Type, inspection.NativeType, inspection.Parsed, inspection.ParseError = candidate, name, true, ""
+		break
+	}
+
+	shape := objectShapes[inspection.Type]
+	inspection.Errors = append(inspection.Errors, shape.validate(doc)...)
+	if shape.check != nil {
+		inspection.Errors = append(inspection.Errors, shape.check(doc)...)
+	}
+	if shape.summarize != nil {
+		shape.summarize(doc, inspection)
+	}
+	return inspection
+}
+
+/// @dev Orders object types by how many of their top-level required fields the document has.
+/// Types missing more than half are not candidates; ties go to the type missing fewer, then
+/// to the type with fewer validation errors
+func rankObjectTypes(doc map[string]interface{}) []string {
+	type rank struct {
+		name    string
+		present int
+		fit     float64
+		errors  int
+	}
+	var ranks []rank
+	for name, shape := range objectShapes {
+		required, present := 0, 0
+		for _, f := range shape.fields {
+			if f.optional || strings.Contains(f.path, ".") {
+				continue
+			}
+			required++
+			if doc[f.path] != nil {
+				present++
+			}
+		}
+		fit := float64(present) / float64(required)
+		if fit < 0.5 {
+			continue
+		}
+		ranks = append(ranks, rank{name: name, present: present, fit: fit, errors: len(shape.validate(doc))})
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		a, b := ranks[i], ranks[j]
+		switch {
+		case a.present != b.present:
+			return a.present > b.present
+		case a.fit != b.fit:
+			return a.fit > b.fit
+		case a.errors != b.errors:
+			return a.errors < b.errors
+		default:
+			return a.name < b.name
+		}
+	})
+	names := make([]string, len(ranks))
+	for i, r := range ranks {
+		names[i] = r.name
+	}
+	return names
+}
+
+/// @dev Whether a native type name denotes the object type; names compare without case or underscores
+func (s objectShape) matches(objectType, native string) bool {
+	normalize := func(name string) string { return strings.ToLower(strings.ReplaceAll(name, "_", "")) }
+	if normalize(native) == normalize(objectType) {
+		return true
+	}
+	for _, alias := range s.aliases {
+		if normalize(native) == normalize(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Checks the required fields of the shape
+func (s objectShape) validate(doc map[string]interface{}) []ValidationError {
+	var problems []ValidationError
+	for _, f := range s.fields {
+		problems = append(problems, f.validate("$", doc, strings.Split(f.path, "."))...)
+	}
+	return problems
+}
+
+func (f field) validate(path string, value interface{}, segments []string) []ValidationError {
+	if len(segments) == 0 {
+		if message := f.kind.check(value); message != "" {
+			return []ValidationError{{Path: path, Message: message}}
+		}
+		return nil
+	}
+	var problems []ValidationError
+	switch segment := segments[0]; {
+	case segment == "*":
+		// A container of the wrong kind is reported by its own field
+		switch container := value.(type) {
+		case map[string]interface{}:
+			for _, key := range memberNames(container) {
+				problems = append(problems, f.validate(path+pathKey(key), container[key], segments[1:])...)
+			}
+		case []interface{}:
+			for i, element := range container {
+				problems = append(problems, f.validate(fmt.Sprintf("%s[%d]", path, i), element, segments[1:])...)
+			}
+		}
+	default:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		child, ok := object[segment]
+		if !ok || child == nil {
+			if !f.optional && len(segments) == 1 {
+				message := "missing"
+				if variant := otherCase(segment); object[variant] != nil {
+					message = fmt.Sprintf("missing; found %q instead", variant)
+				}
+				problems = append(problems, ValidationError{Path: path + pathKey(segment), Message: message})
+			}
+			return problems
+		}
+		problems = append(problems, f.validate(path+pathKey(segment), child, segments[1:])...)
+	}
+	return problems
+}
+
+/// @dev Converts a snake_case key to camelCase and back, to point out keys other
+/// implementations spell differently
+func otherCase(key string) string {
+	var converted strings.Builder
+	if strings.Contains(key, "_") {
+		upper := false
+		for _, r := range key {
+			switch {
+			case r == '_':
+				upper = true
+			case upper:
+				converted.WriteString(strings.ToUpper(string(r)))
+				upper = false
+			default:
+				converted.WriteRune(r)
+			}
+		}
+		return converted.String()
+	}
+	for _, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			converted.WriteByte('_')
+			r += 'a' - 'A'
+		}
+		converted.WriteRune(r)
+	}
+	return converted.String()
+}
+
+var decimalPattern = regexp.MustCompile(`^[0-9]+$`)
+
+/// @dev Describes why a value is not of the kind, or returns "" if it is
+func (k fieldKind) check(value interface{}) string {
+	switch k {
+	case kindString:
+		if s, ok := value.(string); !ok {
+			return "expected a string"
+		} else if s == "" {
+			return "must not be empty"
+		}
+	case kindDecimal:
+		if s, ok := value.(string); !ok || !decimalPattern.MatchString(s) {
+			return "expected a decimal string"
+		}
+	case kindNumber:
+		if _, ok := value.(json.Number); !ok {
+			return "expected a number"
+		}
+	case kindObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return "expected an object"
+		}
+	case kindArray:
+		if _, ok := value.([]interface{}); !ok {
+			return "expected an array"
+		}
+	}
+	return ""
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+/// @dev Formats a member access for a JSON path, quoting keys that are not identifiers
+func pathKey(key string) string {
+	if identifierPattern.MatchString(key) {
+		return "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return "[" + string(quoted) + "]"
+}
+
+/// @dev Follows a dotted path through nested objects
+func lookup(value interface{}, path string) interface{} {
+	for _, segment := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[segment]
+	}
+	return value
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+func stringList(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var values []string
+	for _, entry := range list {
+		if s, ok := entry.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+/// @dev Returns the sorted member names of an object
+func memberNames(value interface{}) []string {
+	object, _ := value.(map[string]interface{})
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/// @dev Returns the members of an object that are objects themselves
+func objectMembers(value interface{}) map[string]map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	members := make(map[string]map[string]interface{}, len(object))
+	for name, member := range object {
+		if entry, ok := member.(map[string]interface{}); ok {
+			members[name] = entry
+		}
+	}
+	return members
+}
+
+/// @dev Returns attribute names of a key correctness proof, which lists them as pairs or an object
+func xrCapNames(value interface{}) []string {
+	names := map[string]bool{}
+	if pairs, ok := value.([]interface{}); ok {
+		for _, pair := range pairs {
+			if entry, ok := pair.([]interface{}); ok && len(entry) == 2 {
+				names[stringValue(entry[0])] = true
+			}
+		}
+	} else {
+		for _, name := range memberNames(value) {
+			names[name] = true
+		}
+	}
+	delete(names, "master_secret")
+	delete(names, "")
+	return sortedSet(names)
+}
+
+func sortedSet(set map[string]bool) []string {
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func (i *Inspection) addIDs(doc map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if value := stringValue(doc[key]); value != "" {
+			if i.IDs == nil {
+				i.IDs = map[string]string{}
+			}
+			i.IDs[key] = value
+		}
+	}
+}
+
+func (i *Inspection) addDetails(doc map[string]interface{}, paths ...string) {
+	for _, path := range paths {
+		if value := lookup(doc, path); value != nil {
+			i.setDetail(path[strings.LastIndex(path, ".")+1:], value)
+		}
+	}
+}
+
+func (i *Inspection) setDetail(key string, value interface{}) {
+	if i.Details == nil {
+		i.Details = map[string]interface{}{}
+	}
+	i.Details[key] = value
+}
+
+/// @dev Records the bit length of a decimal big integer
+func (i *Inspection) addKeySize(name string, value interface{}) {
+	n, ok := new(big.Int).SetString(stringValue(value), 10)
+	if !ok {
+		return
+	}
+	if i.KeySizes == nil {
+		i.KeySizes = map[string]int{}
+	}
+	i.KeySizes[name] = n.BitLen()
+}
+
+func runInspect(e *env, args []string) error {
+	fs := e.flags("inspect")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: anoncreds inspect [-format text|json] <file|->")
+		fs.PrintDefaults()
+	}
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	// Allow flags after the file name
+	rest := fs.Args()
+	if len(rest) > 1 {
+		if err := parse(fs, rest[1:]); err != nil {
+			return err
+		}
+		rest = append(rest[:1], fs.Args()...)
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("%w: expected one file", errUsage)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("%w: unknown format %q", errUsage, *format)
+	}
+
+	data, err := e.read(rest[0])
+	if err != nil {
+		return err
+	}
+	inspection := Inspect(data)
+	if *format == "json" {
+		encoded, err := json.Marshal(inspection)
+		if err != nil {
+			return err
+		}
+		if err := e.writeJSON("-", encoded, false); err != nil {
+			return err
+		}
+	} else {
+		printInspection(e.stdout, inspection)
+	}
+	if !inspection.Valid() {
+		return errInvalidObject
+	}
+	return nil
+}
+
+func printInspection(w io.Writer, inspection *Inspection) {
+	typeName := inspection.Type
+	if typeName == "" {
+		typeName = "unknown"
+	}
+	fmt.Fprintf(w, "Type:        %s\n", typeName)
+	if inspection.Type != "" {
+		parsed := "yes"
+		if !inspection.Parsed {
+			parsed = "no"
+			if inspection.ParseError != "" {
+				parsed += ": " + inspection.ParseError
+			}
+		}
+		fmt.Fprintf(w, "Parsed:      %s\n", parsed)
+	}
+	if len(inspection.IDs) > 0 {
+		fmt.Fprintln(w, "IDs:")
+		for _, key := range sortedKeysOf(inspection.IDs) {
+			fmt.Fprintf(w, "  %-20s %s\n", key, inspection.IDs[key])
+		}
+	}
+	if len(inspection.Attributes) > 0 {
+		fmt.Fprintf(w, "Attributes:  %s\n", strings.Join(inspection.Attributes, ", "))
+	}
+	if inspection.SupportsRevocation != nil {
+		revocation := "not supported"
+		if *inspection.SupportsRevocation {
+			revocation = "supported"
+		}
+		fmt.Fprintf(w, "Revocation:  %s\n", revocation)
+	}
+	if len(inspection.KeySizes) > 0 {
+		fmt.Fprintln(w, "Key sizes:")
+		names := make([]string, 0, len(inspection.KeySizes))
+		for name := range inspection.KeySizes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %-20s %d bits\n", name, inspection.KeySizes[name])
+		}
+	}
+	if inspection.Nonce != "" {
+		fmt.Fprintf(w, "Nonce:       %s\n", inspection.Nonce)
+	}
+	if len(inspection.Details) > 0 {
+		fmt.Fprintln(w, "Details:")
+		keys := make([]string, 0, len(inspection.Details))
+		for key := range inspection.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := inspection.Details[key]
+			if _, scalar := value.(string); !scalar {
+				if _, number := value.(json.Number); !number {
+					encoded, _ := json.Marshal(value)
+					value = string(encoded)
+				}
+			}
+			fmt.Fprintf(w, "  %-20s %v\n", key, value)
+		}
+	}
+	if len(inspection.Errors) == 0 {
+		fmt.Fprintln(w, "Errors:      none")
+		return
+	}
+	fmt.Fprintln(w, "Errors:")
+	for _, problem := range inspection.Errors {
+		fmt.Fprintf(w, "  %s: %s\n", problem.Path, problem.Message)
+	}
+}
+
+func sortedKeysOf(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
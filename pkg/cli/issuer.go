@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Issuer Commands
+
+/// @notice File names written by commands with several outputs
+const (
+	FileCredentialDefinition                = "cred_def.json"
+	FileCredentialDefinitionPrivate         = "cred_def_private.json"
+	FileKeyCorrectnessProof                 = "key_correctness_proof.json"
+	FileCredentialRequest                   = "request.json"
+	FileCredentialRequestMetadata           = "request_metadata.json"
+	FileRevocationRegistryDefinition        = "rev_reg_def.json"
+	FileRevocationRegistryDefinitionPrivate = "rev_reg_def_private.json"
+	FileRevocationStatusList                = "status_list.json"
+)
+
+func runSchemaCreate(e *env, args []string) error {
+	fs := e.flags("schema create")
+	name := fs.String("name", "", "schema name")
+	version := fs.String("version", "", "schema version")
+	issuerID := fs.String("issuer", "", "issuer ID")
+	var attrs listFlag
+	fs.Var(&attrs, "attr", "attribute name; repeat or separate with commas")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"name": *name, "version": *version, "issuer": *issuerID, "attr": attrs.String()}); err != nil {
+		return err
+	}
+	var names []string
+	for _, attr := range attrs {
+		for _, name := range strings.Split(attr, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	schema, err := anoncreds.CreateSchema(anoncreds.CreateSchemaOptions{
+		Name:           *name,
+		Version:        *version,
+		IssuerID:       *issuerID,
+		AttributeNames: names,
+	})
+	if err != nil {
+		return err
+	}
+	defer schema.Clear()
+	return e.writeObject(*out, schema, false)
+}
+
+func runCredDefCreate(e *env, args []string) error {
+	fs := e.flags("creddef create")
+	schemaPath := fs.String("schema", "", "schema file")
+	schemaID := fs.String("schema-id", "", "schema ID")
+	issuerID := fs.String("issuer", "", "issuer ID")
+	tag := fs.String("tag", "default", "credential definition tag")
+	revocable := fs.Bool("revocable", false, "support revocation")
+	outDir := fs.String("out-dir", "", "directory for "+FileCredentialDefinition+", "+FileCredentialDefinitionPrivate+" and "+FileKeyCorrectnessProof)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"schema": *schemaPath, "schema-id": *schemaID, "issuer": *issuerID, "out-dir": *outDir}); err != nil {
+		return err
+	}
+
+	schema, err := loadSchema(e, *schemaPath)
+	if err != nil {
+		return err
+	}
+	defer schema.Clear()
+	result, err := anoncreds.CreateCredentialDefinition(anoncreds.CreateCredentialDefinitionOptions{
+		SchemaID:          *schemaID,
+		Schema:            schema,
+		IssuerID:          *issuerID,
+		Tag:               *tag,
+		SignatureType:     "CL",
+		SupportRevocation: *revocable,
+	})
+	if err != nil {
+		return err
+	}
+	defer result.CredentialDefinition.Clear()
+	defer result.CredentialDefinitionPrivate.Clear()
+	defer result.KeyCorrectnessProof.Clear()
+	return e.writeObjects(*outDir, map[string]jsonObject{
+		FileCredentialDefinition:        result.CredentialDefinition,
+		FileCredentialDefinitionPrivate: result.CredentialDefinitionPrivate,
+		FileKeyCorrectnessProof:         result.KeyCorrectnessProof,
+	}, map[string]bool{FileCredentialDefinitionPrivate: true})
+}
+
+func runOfferCreate(e *env, args []string) error {
+	fs := e.flags("offer create")
+	schemaID := fs.String("schema-id", "", "schema ID")
+	credDefID := fs.String("cred-def-id", "", "credential definition ID")
+	kcpPath := fs.String("kcp", "", "key correctness proof file")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"schema-id": *schemaID, "cred-def-id": *credDefID, "kcp": *kcpPath}); err != nil {
+		return err
+	}
+
+	kcpJSON, err := e.read(*kcpPath)
+	if err != nil {
+		return err
+	}
+	kcp, err := anoncreds.KeyCorrectnessProofFromJSON(kcpJSON)
+	if err != nil {
+		return err
+	}
+	defer kcp.Clear()
+	offer, err := anoncreds.CreateCredentialOffer(anoncreds.CreateCredentialOfferOptions{
+		SchemaID:               *schemaID,
+		CredentialDefinitionID: *credDefID,
+		KeyCorrectnessProof:    kcp,
+	})
+	if err != nil {
+		return err
+	}
+	defer offer.Clear()
+	return e.writeObject(*out, offer, false)
+}
+
+func runCredentialIssue(e *env, args []string) error {
+	fs := e.flags("credential issue")
+	credDefPath := fs.String("cred-def", "", "credential definition file")
+	privatePath := fs.String("cred-def-private", "", "credential definition private file")
+	offerPath := fs.String("offer", "", "credential offer file")
+	requestPath := fs.String("request", "", "credential request file")
+	valuesPath := fs.String("values", "", "file with a JSON object of raw attribute values")
+	revRegDefPath := fs.String("rev-reg-def", "", "revocation registry definition file, for revocable credentials")
+	revRegPrivatePath := fs.String("rev-reg-def-private", "", "revocation registry definition private file")
+	statusListPath := fs.String("status-list", "", "current revocation status list file")
+	index := fs.Uint("index", 0, "unused registry index of the credential")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"cred-def": *credDefPath, "cred-def-private": *privatePath, "offer": *offerPath, "request": *requestPath, "values": *valuesPath}); err != nil {
+		return err
+	}
+
+	var values map[string]string
+	if err := e.readJSON(*valuesPath, &values); err != nil {
+		return err
+	}
+	credDef, err := loadCredentialDefinition(e, *credDefPath)
+	if err != nil {
+		return err
+	}
+	defer credDef.Clear()
+	privateJSON, err := e.read(*privatePath)
+	if err != nil {
+		return err
+	}
+	private, err := anoncreds.CredentialDefinitionPrivateFromJSON(privateJSON)
+	if err != nil {
+		return err
+	}
+	defer private.Clear()
+	offer, err := loadOffer(e, *offerPath)
+	if err != nil {
+		return err
+	}
+	defer offer.Clear()
+	requestJSON, err := e.read(*requestPath)
+	if err != nil {
+		return err
+	}
+	request, err := anoncreds.CredentialRequestFromJSON(requestJSON)
+	if err != nil {
+		return err
+	}
+	defer request.Clear()
+
+	options := anoncreds.CreateCredentialOptions{
+		CredentialDefinition:        credDef,
+		CredentialDefinitionPrivate: private,
+		CredentialOffer:             offer,
+		CredentialRequest:           request,
+		AttributeRawValues:          values,
+	}
+	if *revRegDefPath != "" {
+		if err := required(map[string]string{"rev-reg-def-private": *revRegPrivatePath, "status-list": *statusListPath}); err != nil {
+			return err
+		}
+		if *index == 0 {
+			return fmt.Errorf("%w: -index is required for revocable credentials", errUsage)
+		}
+		registry, err := loadRegistry(e, *revRegDefPath, *revRegPrivatePath)
+		if err != nil {
+			return err
+		}
+		defer registry.Clear()
+		statusList, err := loadStatusList(e, *statusListPath)
+		if err != nil {
+			return err
+		}
+		defer statusList.Clear()
+		options.RevocationConfig = &anoncreds.CredentialRevocationConfig{
+			RegistryDefinition:        registry.RevocationRegistryDefinition,
+			RegistryDefinitionPrivate: registry.RevocationRegistryDefinitionPrivate,
+			StatusList:                statusList,
+			RegistryIndex:             uint32(*index),
+		}
+	}
+
+	credential, err := anoncreds.CreateCredential(options)
+	if err != nil {
+		return err
+	}
+	defer credential.Clear()
+	return e.writeObject(*out, credential, false)
+}
+
+func runRevRegCreate(e *env, args []string) error {
+	fs := e.flags("revreg create")
+	credDefPath := fs.String("cred-def", "", "credential definition file")
+	credDefID := fs.String("cred-def-id", "", "credential definition ID")
+	revRegDefID := fs.String("rev-reg-def-id", "", "ID the registry will be published under")
+	issuerID := fs.String("issuer", "", "issuer ID")
+	tag := fs.String("tag", "0", "registry tag")
+	maxCredNum := fs.Uint("max", 100, "maximum number of credentials")
+	tailsDir := fs.String("tails-dir", ".", "directory for the tails file")
+	issuanceByDefault := fs.Bool("issuance-by-default", true, "start with every index issued")
+	timestamp := fs.Int64("timestamp", 0, "timestamp of the status list; defaults to now")
+	outDir := fs.String("out-dir", "", "directory for "+FileRevocationRegistryDefinition+", "+FileRevocationRegistryDefinitionPrivate+" and "+FileRevocationStatusList)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"cred-def": *credDefPath, "cred-def-id": *credDefID, "rev-reg-def-id": *revRegDefID, "issuer": *issuerID, "out-dir": *outDir}); err != nil {
+		return err
+	}
+
+	credDef, err := loadCredentialDefinition(e, *credDefPath)
+	if err != nil {
+		return err
+	}
+	defer credDef.Clear()
+	registry, err := anoncreds.CreateRevocationRegistryDefinition(anoncreds.CreateRevocationRegistryDefinitionOptions{
+		CredentialDefinition:    credDef,
+		CredentialDefinitionID:  *credDefID,
+		IssuerID:                *issuerID,
+		Tag:                     *tag,
+		MaximumCredentialNumber: uint32(*maxCredNum),
+		TailsDirectoryPath:      *tailsDir,
+	})
+	if err != nil {
+		return err
+	}
+	defer registry.RevocationRegistryDefinition.Clear()
+	defer registry.RevocationRegistryDefinitionPrivate.Clear()
+
+	if *timestamp == 0 {
+		*timestamp = time.Now().Unix()
+	}
+	statusList, err := anoncreds.CreateRevocationStatusList(anoncreds.CreateRevocationStatusListOptions{
+		CredentialDefinition:                credDef,
+		RevocationRegistryDefinitionID:      *revRegDefID,
+		RevocationRegistryDefinition:        registry.RevocationRegistryDefinition,
+		RevocationRegistryDefinitionPrivate: registry.RevocationRegistryDefinitionPrivate,
+		IssuerID:                            *issuerID,
+		IssuanceByDefault:                   *issuanceByDefault,
+		Timestamp:                           timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	defer statusList.Clear()
+	return e.writeObjects(*outDir, map[string]jsonObject{
+		FileRevocationRegistryDefinition:        registry.RevocationRegistryDefinition,
+		FileRevocationRegistryDefinitionPrivate: registry.RevocationRegistryDefinitionPrivate,
+		FileRevocationStatusList:                statusList,
+	}, map[string]bool{FileRevocationRegistryDefinitionPrivate: true})
+}
+
+func runRevoke(e *env, args []string) error {
+	fs := e.flags("revoke")
+	credDefPath := fs.String("cred-def", "", "credential definition file")
+	revRegDefPath := fs.String("rev-reg-def", "", "revocation registry definition file")
+	revRegPrivatePath := fs.String("rev-reg-def-private", "", "revocation registry definition private file")
+	statusListPath := fs.String("status-list", "", "current revocation status list file")
+	var indexes listFlag
+	fs.Var(&indexes, "index", "registry index to revoke; repeat or separate with commas")
+	timestamp := fs.Int64("timestamp", 0, "timestamp of the new status list; defaults to now")
+	out := fs.String("o", "-", "output file for the updated status list")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"cred-def": *credDefPath, "rev-reg-def": *revRegDefPath, "rev-reg-def-private": *revRegPrivatePath, "status-list": *statusListPath, "index": indexes.String()}); err != nil {
+		return err
+	}
+	revoked, err := parseIndexes(indexes)
+	if err != nil {
+		return err
+	}
+
+	credDef, err := loadCredentialDefinition(e, *credDefPath)
+	if err != nil {
+		return err
+	}
+	defer credDef.Clear()
+	registry, err := loadRegistry(e, *revRegDefPath, *revRegPrivatePath)
+	if err != nil {
+		return err
+	}
+	defer registry.Clear()
+	current, err := loadStatusList(e, *statusListPath)
+	if err != nil {
+		return err
+	}
+	defer current.Clear()
+
+	if *timestamp == 0 {
+		*timestamp = time.Now().Unix()
+	}
+	updated, err := anoncreds.UpdateRevocationStatusList(anoncreds.UpdateRevocationStatusListOptions{
+		CredentialDefinition:                credDef,
+		RevocationRegistryDefinition:        registry.RevocationRegistryDefinition,
+		RevocationRegistryDefinitionPrivate: registry.RevocationRegistryDefinitionPrivate,
+		CurrentStatusList:                   current,
+		Revoked:                             revoked,
+		Timestamp:                           timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	defer updated.Clear()
+	return e.writeObject(*out, updated, false)
+}
+
+/// @dev Parses registry indexes given as repeated or comma-separated flags
+func parseIndexes(values []string) ([]uint32, error) {
+	var indexes []uint32
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			index, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil || index == 0 {
+				return nil, fmt.Errorf("%w: invalid registry index %q", errUsage, field)
+			}
+			indexes = append(indexes, uint32(index))
+		}
+	}
+	return indexes, nil
+}
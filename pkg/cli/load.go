@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Object Loaders
+/// @dev Read anoncreds objects from files; the caller owns and clears the result
+
+func loadSchema(e *env, path string) (*anoncreds.Schema, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.SchemaFromJSON(data)
+}
+
+func loadCredentialDefinition(e *env, path string) (*anoncreds.CredentialDefinition, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialDefinitionFromJSON(data)
+}
+
+func loadOffer(e *env, path string) (*anoncreds.CredentialOffer, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialOfferFromJSON(data)
+}
+
+func loadRevocationRegistryDefinition(e *env, path string) (*anoncreds.RevocationRegistryDefinition, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.RevocationRegistryDefinitionFromJSON(data)
+}
+
+func loadStatusList(e *env, path string) (*anoncreds.RevocationStatusList, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.RevocationStatusListFromJSON(data)
+}
+
+/// @dev A revocation registry definition with its private part
+type registry struct {
+	anoncreds.CreateRevocationRegistryDefinitionResult
+}
+
+func (r *registry) Clear() {
+	r.RevocationRegistryDefinition.Clear()
+	r.RevocationRegistryDefinitionPrivate.Clear()
+}
+
+func loadRegistry(e *env, definitionPath, privatePath string) (*registry, error) {
+	definition, err := loadRevocationRegistryDefinition(e, definitionPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := e.read(privatePath)
+	if err != nil {
+		definition.Clear()
+		return nil, err
+	}
+	private, err := anoncreds.RevocationRegistryDefinitionPrivateFromJSON(data)
+	if err != nil {
+		definition.Clear()
+		return nil, err
+	}
+	return &registry{anoncreds.CreateRevocationRegistryDefinitionResult{
+		RevocationRegistryDefinition:        definition,
+		RevocationRegistryDefinitionPrivate: private,
+	}}, nil
+}
+
+/// @dev Loads schemas keyed by ID from ID=FILE flags
+func loadSchemas(e *env, files mapFlag) (map[string]*anoncreds.Schema, func(), error) {
+	schemas := make(map[string]*anoncreds.Schema, len(files))
+	release := func() {
+		for _, schema := range schemas {
+			schema.Clear()
+		}
+	}
+	for id, path := range files {
+		schema, err := loadSchema(e, path)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		schemas[id] = schema
+	}
+	return schemas, release, nil
+}
+
+/// @dev Loads credential definitions keyed by ID from ID=FILE flags
+func loadCredentialDefinitions(e *env, files mapFlag) (map[string]*anoncreds.CredentialDefinition, func(), error) {
+	credDefs := make(map[string]*anoncreds.CredentialDefinition, len(files))
+	release := func() {
+		for _, credDef := range credDefs {
+			credDef.Clear()
+		}
+	}
+	for id, path := range files {
+		credDef, err := loadCredentialDefinition(e, path)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		credDefs[id] = credDef
+	}
+	return credDefs, release, nil
+}
+
+/// @dev Reads a link secret file holding the secret value
+func loadLinkSecret(e *env, path string) (*anoncreds.LinkSecret, error) {
+	data, err := e.read(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := anoncreds.LinkSecretFromBytes(bytes.TrimSpace(data))
+	for i := range data {
+		data[i] = 0
+	}
+	return secret, nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/server"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+/// @title Admin API Server Command
+
+/// @notice Environment variable holding the API key, unless -api-key-env names another
+const EnvAPIKey = "ANONCREDS_API_KEY"
+
+/// @notice Environment variable holding the passphrase that seals private objects in the data directory
+const EnvPassphrase = "ANONCREDS_PASSPHRASE"
+
+/// @dev Name of the passphrase-derived KEK in the data directory
+const serverKEKID = "server"
+
+func runServe(e *env, args []string) error {
+	fs := e.flags("serve")
+	addr := fs.String("addr", "127.0.0.1:8031", "listen address")
+	dataDir := fs.String("data", "", "directory holding issuer and wallet state")
+	tailsDir := fs.String("tails-dir", "", "directory for tails files; defaults to tails under -data")
+	apiKeyEnv := fs.String("api-key-env", EnvAPIKey, "environment variable holding the API key")
+	insecure := fs.Bool("insecure", false, "serve without an API key, e.g. behind an authenticating proxy")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"data": *dataDir}); err != nil {
+		return err
+	}
+	apiKey := os.Getenv(*apiKeyEnv)
+	if apiKey == "" && !*insecure {
+		return fmt.Errorf("%w: set %s or pass -insecure", errUsage, *apiKeyEnv)
+	}
+	passphrase := os.Getenv(EnvPassphrase)
+	if passphrase == "" {
+		return fmt.Errorf("%w: set %s", errUsage, EnvPassphrase)
+	}
+	if *tailsDir == "" {
+		*tailsDir = filepath.Join(*dataDir, "tails")
+	}
+	if err := os.MkdirAll(*tailsDir, 0o700); err != nil {
+		return err
+	}
+
+	store, err := storage.NewFileStore(*dataDir)
+	if err != nil {
+		return err
+	}
+	kek, err := keystore.PassphraseKEK(store, serverKEKID, []byte(passphrase), keystore.Argon2id{})
+	if err != nil {
+		return err
+	}
+	keys, err := keystore.NewKeyStore(keystore.KeyStoreOptions{Store: store, KEK: kek})
+	if err != nil {
+		return err
+	}
+	service, err := issuer.NewService(issuer.ServiceOptions{Store: store, KeyStore: keys, TailsDirectoryPath: *tailsDir})
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	credentials := wallet.NewRecordStore(store)
+	linkSecrets, err := wallet.NewLinkSecretManager(wallet.LinkSecretManagerOptions{Store: store, KeyStore: keys, Credentials: credentials})
+	if err != nil {
+		return err
+	}
+	options := server.Options{
+		Issuer:      service,
+		LinkSecrets: linkSecrets,
+		Credentials: credentials,
+		Store:       store,
+		Insecure:    *insecure,
+	}
+	if apiKey != "" {
+		options.APIKeys = []string{apiKey}
+	}
+	handler, err := server.New(options)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	fmt.Fprintf(e.stderr, "anoncreds serve: listening on http://%s\n", listener.Addr())
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
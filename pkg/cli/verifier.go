@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Verifier and Utility Commands
+
+/// @dev Returned when a presentation did not verify; the report has already been printed
+var errNotVerified = errors.New("presentation not verified")
+
+func runPresentationVerify(e *env, args []string) error {
+	fs := e.flags("presentation verify")
+	presentationPath := fs.String("presentation", "", "presentation file")
+	requestPath := fs.String("request", "", "presentation request file")
+	schemaFiles, credDefFiles, revRegDefFiles := mapFlag{}, mapFlag{}, mapFlag{}
+	var statusListFiles listFlag
+	fs.Var(schemaFiles, "schema", "ID=FILE of a schema; repeatable")
+	fs.Var(credDefFiles, "cred-def", "ID=FILE of a credential definition; repeatable")
+	fs.Var(revRegDefFiles, "rev-reg-def", "ID=FILE of a revocation registry definition; repeatable")
+	fs.Var(&statusListFiles, "status-list", "revocation status list file; repeatable")
+	out := fs.String("o", "-", "output file for the verification report")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := required(map[string]string{"presentation": *presentationPath, "request": *requestPath}); err != nil {
+		return err
+	}
+
+	data, err := e.read(*presentationPath)
+	if err != nil {
+		return err
+	}
+	presentation, err := anoncreds.PresentationFromJSON(data)
+	if err != nil {
+		return err
+	}
+	defer presentation.Clear()
+	if data, err = e.read(*requestPath); err != nil {
+		return err
+	}
+	request, err := anoncreds.PresentationRequestFromJSON(data)
+	if err != nil {
+		return err
+	}
+	defer request.Clear()
+	schemas, releaseSchemas, err := loadSchemas(e, schemaFiles)
+	if err != nil {
+		return err
+	}
+	defer releaseSchemas()
+	credDefs, releaseCredDefs, err := loadCredentialDefinitions(e, credDefFiles)
+	if err != nil {
+		return err
+	}
+	defer releaseCredDefs()
+
+	options := anoncreds.VerifyPresentationOptions{
+		Presentation:                  presentation,
+		PresentationRequest:           request,
+		Schemas:                       schemas,
+		CredentialDefinitions:         credDefs,
+		RevocationRegistryDefinitions: map[string]*anoncreds.RevocationRegistryDefinition{},
+	}
+	defer func() {
+		for _, revRegDef := range options.RevocationRegistryDefinitions {
+			revRegDef.Clear()
+		}
+		for _, statusList := range options.RevocationStatusLists {
+			statusList.Clear()
+		}
+	}()
+	for id, path := range revRegDefFiles {
+		revRegDef, err := loadRevocationRegistryDefinition(e, path)
+		if err != nil {
+			return err
+		}
+		options.RevocationRegistryDefinitions[id] = revRegDef
+	}
+	for _, path := range statusListFiles {
+		statusList, err := loadStatusList(e, path)
+		if err != nil {
+			return err
+		}
+		options.RevocationStatusLists = append(options.RevocationStatusLists, statusList)
+	}
+
+	report, err := anoncreds.VerifyPresentationWithReport(options)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if err := e.writeJSON(*out, encoded, false); err != nil {
+		return err
+	}
+	if !report.Verified {
+		fmt.Fprintf(e.stderr, "anoncreds presentation verify: not verified: %s\n", report.Reason)
+		return errNotVerified
+	}
+	return nil
+}
+
+func runNonce(e *env, args []string) error {
+	fs := e.flags("nonce")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	nonce, err := anoncreds.New().GenerateNonce()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(e.stdout, nonce)
+	return err
+}
+
+/// @notice One line of encode output
+type EncodedAttribute struct {
+	Raw     string `json:"raw"`
+	Encoded string `json:"encoded"`
+}
+
+func runEncode(e *env, args []string) error {
+	fs := e.flags("encode")
+	input := fs.String("i", "", "JSON file with an array of raw values; the arguments are used when unset")
+	out := fs.String("o", "-", "output file")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	raw := fs.Args()
+	if *input != "" {
+		if len(raw) > 0 {
+			return fmt.Errorf("%w: -i and value arguments are exclusive", errUsage)
+		}
+		if err := e.readJSON(*input, &raw); err != nil {
+			return err
+		}
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("%w: no values to encode", errUsage)
+	}
+	encoded, err := anoncreds.EncodeCredentialAttributes(raw)
+	if err != nil {
+		return err
+	}
+	if len(encoded) != len(raw) {
+		return fmt.Errorf("expected %d encoded values, got %d", len(raw), len(encoded))
+	}
+	values := make([]EncodedAttribute, len(raw))
+	for i := range raw {
+		values[i] = EncodedAttribute{Raw: raw[i], Encoded: encoded[i]}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return e.writeJSON(*out, data, false)
+}
@@ -0,0 +1,133 @@
+package didcomm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/// @title DIDComm Attachments
+/// @dev Aries RFC 0017 attachment decorators and the formats entries of Issue Credential v2 and
+/// Present Proof v2 messages
+
+/// @notice How an attachment carries its payload
+type Encoding string
+
+/// @notice Supported attachment encodings
+const (
+	EncodingBase64 Encoding = "base64" /// @notice data.base64, the form Credo and ACA-Py send
+	EncodingJSON   Encoding = "json"   /// @notice data.json
+)
+
+/// @notice MIME type of AnonCreds attachments
+const MimeTypeJSON = "application/json"
+
+/// @notice An attachment decorator
+type Attachment struct {
+	ID       string         `json:"@id"`
+	MimeType string         `json:"mime-type,omitempty"`
+	Data     AttachmentData `json:"data"`
+}
+
+/// @notice The payload of an attachment; exactly one field is set
+type AttachmentData struct {
+	Base64 string          `json:"base64,omitempty"`
+	JSON   json.RawMessage `json:"json,omitempty"`
+}
+
+/// @notice A formats entry naming the format of one attachment
+type AttachmentFormat struct {
+	AttachID string `json:"attach_id"`
+	Format   string `json:"format"`
+}
+
+/// @notice Creates an attachment carrying a JSON payload
+/// @param id The attachment ID referenced by the formats entry
+/// @param payload The JSON payload
+/// @param encoding How to carry the payload; defaults to base64
+func NewAttachment(id string, payload []byte, encoding Encoding) (*Attachment, error) {
+	if id == "" {
+		return nil, fmt.Errorf("attachment id is required")
+	}
+	if !json.Valid(payload) {
+		return nil, fmt.Errorf("attachment %s payload is not valid JSON", id)
+	}
+	attachment := &Attachment{ID: id, MimeType: MimeTypeJSON}
+	switch encoding {
+	case EncodingBase64, "":
+		attachment.Data.Base64 = base64.StdEncoding.EncodeToString(payload)
+	case EncodingJSON:
+		attachment.Data.JSON = append(json.RawMessage(nil), payload...)
+	default:
+		return nil, fmt.Errorf("unknown attachment encoding %q", encoding)
+	}
+	return attachment, nil
+}
+
+/// @notice Returns the encoding the attachment uses
+func (a *Attachment) Encoding() Encoding {
+	if len(a.Data.JSON) > 0 {
+		return EncodingJSON
+	}
+	return EncodingBase64
+}
+
+/// @notice Decodes the attachment payload
+/// @return The JSON payload and any error encountered
+/// @dev Base64 is accepted padded or unpadded, in the standard or URL-safe alphabet
+func (a *Attachment) Payload() ([]byte, error) {
+	switch {
+	case len(a.Data.JSON) > 0 && a.Data.Base64 != "":
+		return nil, fmt.Errorf("attachment %s carries both json and base64 data", a.ID)
+	case len(a.Data.JSON) > 0:
+		return append([]byte(nil), a.Data.JSON...), nil
+	case a.Data.Base64 != "":
+		payload, err := decodeBase64(a.Data.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", a.ID, err)
+		}
+		if !json.Valid(payload) {
+			return nil, fmt.Errorf("attachment %s payload is not valid JSON", a.ID)
+		}
+		return payload, nil
+	}
+	return nil, fmt.Errorf("attachment %s has no json or base64 data", a.ID)
+}
+
+/// @dev Decodes base64 in any of the alphabets and paddings agents send
+func decodeBase64(data string) ([]byte, error) {
+	data = strings.TrimSpace(data)
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(data, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(data, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	payload, err := encoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data: %w", err)
+	}
+	return payload, nil
+}
+
+/// @notice Finds the attachment a formats entry points to
+/// @param formats The formats entries of a message
+/// @param attachments The attachments of a message, e.g. offers~attach
+/// @param accept Reports whether a format is wanted
+/// @return The first accepted entry and its attachment, or an error if none is present
+func FindAttachment(formats []AttachmentFormat, attachments []Attachment, accept func(format string) bool) (*AttachmentFormat, *Attachment, error) {
+	for i := range formats {
+		if !accept(formats[i].Format) {
+			continue
+		}
+		for j := range attachments {
+			if attachments[j].ID == formats[i].AttachID {
+				return &formats[i], &attachments[j], nil
+			}
+		}
+		return nil, nil, fmt.Errorf("formats entry %s names missing attachment %s", formats[i].Format, formats[i].AttachID)
+	}
+	return nil, nil, fmt.Errorf("no attachment in a supported format")
+}
@@ -0,0 +1,236 @@
+package didcomm
+
+import (
+	"fmt"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title AnonCreds Attachment Formats
+/// @dev Maps the anoncreds/*@v1.0 and legacy hlindy/*@v2.0 attachment formats to anoncreds objects.
+/// Both families carry the same JSON, so either decodes into the same object
+
+/// @notice The message payload an attachment format carries
+type Kind string
+
+/// @notice Payload kinds of the credential exchange
+const (
+	KindCredentialOffer   Kind = "credential-offer"
+	KindCredentialRequest Kind = "credential-request"
+	KindCredential        Kind = "credential"
+	KindProofRequest      Kind = "proof-request"
+	KindProof             Kind = "proof"
+	KindCredentialFilter  Kind = "credential-filter" /// @notice The filter of a credential proposal
+)
+
+/// @notice A family of attachment formats
+type Family string
+
+/// @notice Supported format families
+const (
+	FamilyAnonCreds Family = "anoncreds" /// @notice anoncreds/*@v1.0
+	FamilyIndy      Family = "hlindy"    /// @notice The legacy hlindy/*@v2.0 formats
+)
+
+/// @notice AnonCreds attachment formats
+const (
+	FormatAnonCredsCredentialOffer   = "anoncreds/credential-offer@v1.0"
+	FormatAnonCredsCredentialRequest = "anoncreds/credential-request@v1.0"
+	FormatAnonCredsCredential        = "anoncreds/credential@v1.0"
+	FormatAnonCredsProofRequest      = "anoncreds/proof-request@v1.0"
+	FormatAnonCredsProof             = "anoncreds/proof@v1.0"
+	FormatAnonCredsCredentialFilter  = "anoncreds/credential-filter@v1.0"
+)
+
+/// @notice Legacy Indy attachment formats
+const (
+	FormatIndyCredentialOffer   = "hlindy/cred-abstract@v2.0"
+	FormatIndyCredentialRequest = "hlindy/cred-req@v2.0"
+	FormatIndyCredential        = "hlindy/cred@v2.0"
+	FormatIndyProofRequest      = "hlindy/proof-req@v2.0"
+	FormatIndyProof             = "hlindy/proof@v2.0"
+	FormatIndyCredentialFilter  = "hlindy/cred-filter@v2.0"
+)
+
+/// @dev Kind and family of a format
+type formatInfo struct {
+	kind   Kind
+	family Family
+}
+
+/// @dev Every supported format
+var knownFormats = map[string]formatInfo{
+	FormatAnonCredsCredentialOffer:   {KindCredentialOffer, FamilyAnonCreds},
+	FormatAnonCredsCredentialRequest: {KindCredentialRequest, FamilyAnonCreds},
+	FormatAnonCredsCredential:        {KindCredential, FamilyAnonCreds},
+	FormatAnonCredsProofRequest:      {KindProofRequest, FamilyAnonCreds},
+	FormatAnonCredsProof:             {KindProof, FamilyAnonCreds},
+	FormatAnonCredsCredentialFilter:  {KindCredentialFilter, FamilyAnonCreds},
+	FormatIndyCredentialOffer:        {KindCredentialOffer, FamilyIndy},
+	FormatIndyCredentialRequest:      {KindCredentialRequest, FamilyIndy},
+	FormatIndyCredential:             {KindCredential, FamilyIndy},
+	FormatIndyProofRequest:           {KindProofRequest, FamilyIndy},
+	FormatIndyProof:                  {KindProof, FamilyIndy},
+	FormatIndyCredentialFilter:       {KindCredentialFilter, FamilyIndy},
+}
+
+/// @notice Returns the kind and family of a format
+func ParseFormat(format string) (Kind, Family, error) {
+	info, ok := knownFormats[format]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported attachment format %q", format)
+	}
+	return info.kind, info.family, nil
+}
+
+/// @notice Returns the format carrying a payload kind in a family
+func FormatOf(kind Kind, family Family) (string, error) {
+	for format, info := range knownFormats {
+		if info.kind == kind && info.family == family {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("no %s format for %s", family, kind)
+}
+
+/// @notice Returns a FindAttachment filter accepting every format of a kind
+func Accept(kind Kind) func(format string) bool {
+	return func(format string) bool {
+		return knownFormats[format].kind == kind
+	}
+}
+
+/// @dev An anoncreds object that can be serialized
+type jsonObject interface {
+	ToJSONString() (string, error)
+}
+
+/// @notice Encodes an anoncreds object as an attachment and its formats entry
+/// @param id The attachment ID
+/// @param kind The payload kind
+/// @param family The format family the peer expects
+/// @param object The object to attach
+/// @param encoding How to carry the payload; defaults to base64
+func Encode(id string, kind Kind, family Family, object jsonObject, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	format, err := FormatOf(kind, family)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := object.ToJSONString()
+	if err != nil {
+		return nil, nil, err
+	}
+	attachment, err := NewAttachment(id, []byte(payload), encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &AttachmentFormat{AttachID: id, Format: format}, attachment, nil
+}
+
+/// @notice Decodes the payload of an attachment after checking its format carries the kind
+func DecodePayload(format *AttachmentFormat, attachment *Attachment, kind Kind) ([]byte, error) {
+	if format == nil || attachment == nil {
+		return nil, fmt.Errorf("formats entry and attachment are required")
+	}
+	if format.AttachID != attachment.ID {
+		return nil, fmt.Errorf("formats entry names attachment %s, not %s", format.AttachID, attachment.ID)
+	}
+	actual, _, err := ParseFormat(format.Format)
+	if err != nil {
+		return nil, err
+	}
+	if actual != kind {
+		return nil, fmt.Errorf("format %s carries a %s, not a %s", format.Format, actual, kind)
+	}
+	return attachment.Payload()
+}
+
+/// @notice Encodes a credential offer
+func EncodeCredentialOffer(id string, offer *anoncreds.CredentialOffer, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if offer == nil {
+		return nil, nil, fmt.Errorf("credential offer is required")
+	}
+	return Encode(id, KindCredentialOffer, family, offer, encoding)
+}
+
+/// @notice Decodes a credential offer
+/// @return An object owned by the caller and any error encountered
+func DecodeCredentialOffer(format *AttachmentFormat, attachment *Attachment) (*anoncreds.CredentialOffer, error) {
+	payload, err := DecodePayload(format, attachment, KindCredentialOffer)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialOfferFromJSON(payload)
+}
+
+/// @notice Encodes a credential request
+func EncodeCredentialRequest(id string, request *anoncreds.CredentialRequest, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if request == nil {
+		return nil, nil, fmt.Errorf("credential request is required")
+	}
+	return Encode(id, KindCredentialRequest, family, request, encoding)
+}
+
+/// @notice Decodes a credential request
+/// @return An object owned by the caller and any error encountered
+func DecodeCredentialRequest(format *AttachmentFormat, attachment *Attachment) (*anoncreds.CredentialRequest, error) {
+	payload, err := DecodePayload(format, attachment, KindCredentialRequest)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialRequestFromJSON(payload)
+}
+
+/// @notice Encodes a credential
+func EncodeCredential(id string, credential *anoncreds.Credential, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if credential == nil {
+		return nil, nil, fmt.Errorf("credential is required")
+	}
+	return Encode(id, KindCredential, family, credential, encoding)
+}
+
+/// @notice Decodes a credential
+/// @return An object owned by the caller and any error encountered
+func DecodeCredential(format *AttachmentFormat, attachment *Attachment) (*anoncreds.Credential, error) {
+	payload, err := DecodePayload(format, attachment, KindCredential)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialFromJSON(payload)
+}
+
+/// @notice Encodes a presentation request
+func EncodePresentationRequest(id string, request *anoncreds.PresentationRequest, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if request == nil {
+		return nil, nil, fmt.Errorf("presentation request is required")
+	}
+	return Encode(id, KindProofRequest, family, request, encoding)
+}
+
+/// @notice Decodes a presentation request
+/// @return An object owned by the caller and any error encountered
+func DecodePresentationRequest(format *AttachmentFormat, attachment *Attachment) (*anoncreds.PresentationRequest, error) {
+	payload, err := DecodePayload(format, attachment, KindProofRequest)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.PresentationRequestFromJSON(payload)
+}
+
+/// @notice Encodes a presentation
+func EncodePresentation(id string, presentation *anoncreds.Presentation, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if presentation == nil {
+		return nil, nil, fmt.Errorf("presentation is required")
+	}
+	return Encode(id, KindProof, family, presentation, encoding)
+}
+
+/// @notice Decodes a presentation
+/// @return An object owned by the caller and any error encountered
+func DecodePresentation(format *AttachmentFormat, attachment *Attachment) (*anoncreds.Presentation, error) {
+	payload, err := DecodePayload(format, attachment, KindProof)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.PresentationFromJSON(payload)
+}
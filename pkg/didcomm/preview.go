@@ -0,0 +1,175 @@
+package didcomm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Credential Preview
+/// @dev The credential_preview of Issue Credential v2 proposals and offers. AnonCreds credentials
+/// carry every schema attribute as a string, so a preview maps one-to-one onto the raw values
+/// passed to CreateCredential
+
+/// @notice Message type of an Issue Credential v2 credential preview
+const CredentialPreviewType = "https://didcomm.org/issue-credential/2.0/credential-preview"
+
+/// @notice MIME type of a plain attribute value
+const MimeTypeText = "text/plain"
+
+/// @notice One attribute of a credential preview
+type PreviewAttribute struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime-type,omitempty"` /// @notice Defaults to text/plain
+	Value    string `json:"value"`
+}
+
+/// @notice A credential preview
+type CredentialPreview struct {
+	Type       string             `json:"@type"`
+	Attributes []PreviewAttribute `json:"attributes"`
+}
+
+/// @notice Builds a preview from raw attribute values
+/// @param values The raw values, e.g. CreateCredentialOptions.AttributeRawValues
+/// @return A preview listing the attributes in name order
+func NewCredentialPreview(values map[string]string) *CredentialPreview {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	preview := &CredentialPreview{Type: CredentialPreviewType, Attributes: make([]PreviewAttribute, 0, len(names))}
+	for _, name := range names {
+		preview.Attributes = append(preview.Attributes, PreviewAttribute{Name: name, MimeType: MimeTypeText, Value: values[name]})
+	}
+	return preview
+}
+
+/// @notice Returns the attribute names of the preview in message order
+func (p *CredentialPreview) Names() []string {
+	names := make([]string, len(p.Attributes))
+	for i, attribute := range p.Attributes {
+		names[i] = attribute.Name
+	}
+	return names
+}
+
+/// @notice Converts the preview into raw attribute values
+/// @return The values to pass as CreateCredentialOptions.AttributeRawValues and any error encountered
+/// @dev Values are taken verbatim whatever their MIME type, as AnonCreds only signs strings
+func (p *CredentialPreview) RawValues() (map[string]string, error) {
+	values := make(map[string]string, len(p.Attributes))
+	for _, attribute := range p.Attributes {
+		if attribute.Name == "" {
+			return nil, fmt.Errorf("credential preview attribute name is required")
+		}
+		if _, ok := values[attribute.Name]; ok {
+			return nil, fmt.Errorf("credential preview lists attribute %s more than once", attribute.Name)
+		}
+		values[attribute.Name] = attribute.Value
+	}
+	return values, nil
+}
+
+/// @notice Reports how a preview differs from the attributes of a schema
+type PreviewMismatchError struct {
+	Missing      []string /// @notice Schema attributes absent from the preview
+	Extra        []string /// @notice Preview attributes absent from the schema
+	CaseMismatch []string /// @notice Preview attributes matching a schema attribute only when ignoring case
+	Duplicate    []string /// @notice Preview attributes listed more than once
+}
+
+func (e *PreviewMismatchError) Error() string {
+	var parts []string
+	for _, part := range []struct {
+		label string
+		names []string
+	}{
+		{"missing", e.Missing},
+		{"extra", e.Extra},
+		{"case mismatch", e.CaseMismatch},
+		{"duplicate", e.Duplicate},
+	} {
+		if len(part.names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", part.label, strings.Join(part.names, ", ")))
+		}
+	}
+	return "credential preview does not match schema (" + strings.Join(parts, "; ") + ")"
+}
+
+/// @notice Checks the preview names exactly the given schema attributes
+/// @param attrNames The attrNames of the schema
+/// @return A *PreviewMismatchError listing every difference, or nil
+func (p *CredentialPreview) ValidateAttributeNames(attrNames []string) error {
+	expected := make(map[string]bool, len(attrNames))
+	folded := make(map[string]string, len(attrNames))
+	for _, name := range attrNames {
+		expected[name] = true
+		folded[strings.ToLower(name)] = name
+	}
+
+	mismatch := &PreviewMismatchError{}
+	seen := make(map[string]bool, len(p.Attributes))
+	for _, name := range p.Names() {
+		if seen[name] {
+			mismatch.Duplicate = append(mismatch.Duplicate, name)
+			continue
+		}
+		seen[name] = true
+		if expected[name] {
+			continue
+		}
+		if schemaName, ok := folded[strings.ToLower(name)]; ok {
+			mismatch.CaseMismatch = append(mismatch.CaseMismatch, name)
+			// Reported once as a case mismatch rather than also as missing
+			seen[schemaName] = true
+			continue
+		}
+		mismatch.Extra = append(mismatch.Extra, name)
+	}
+	for _, name := range attrNames {
+		if !seen[name] {
+			mismatch.Missing = append(mismatch.Missing, name)
+		}
+	}
+	if len(mismatch.Missing)+len(mismatch.Extra)+len(mismatch.CaseMismatch)+len(mismatch.Duplicate) == 0 {
+		return nil
+	}
+	sort.Strings(mismatch.Missing)
+	sort.Strings(mismatch.Extra)
+	sort.Strings(mismatch.CaseMismatch)
+	sort.Strings(mismatch.Duplicate)
+	return mismatch
+}
+
+/// @notice Checks the preview names exactly the attributes of a schema
+/// @return A *PreviewMismatchError listing every difference, or any error reading the schema
+func (p *CredentialPreview) Validate(schema *anoncreds.Schema) error {
+	if schema == nil {
+		return fmt.Errorf("schema is required")
+	}
+	attrNames, err := schemaAttributeNames(schema)
+	if err != nil {
+		return err
+	}
+	return p.ValidateAttributeNames(attrNames)
+}
+
+/// @dev Reads the attrNames of a schema
+func schemaAttributeNames(schema *anoncreds.Schema) ([]string, error) {
+	schemaJSON, err := schema.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	var contents struct {
+		AttrNames []string `json:"attrNames"`
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &contents); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return contents.AttrNames, nil
+}
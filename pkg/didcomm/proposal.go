@@ -0,0 +1,192 @@
+package didcomm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+)
+
+/// @title Credential Proposals
+/// @dev A holder proposes a credential with a preview and a filter naming the schema and issuer
+/// it wants. The issuer matches the filter against the credential definitions it can offer
+
+/// @notice The filter of a credential proposal; empty fields match anything
+/// @dev hlindy/cred-filter@v2.0 names the issuer fields schema_issuer_did and issuer_did, both are read
+type CredentialFilter struct {
+	SchemaIssuerID         string `json:"schema_issuer_id,omitempty"`
+	SchemaName             string `json:"schema_name,omitempty"`
+	SchemaVersion          string `json:"schema_version,omitempty"`
+	SchemaID               string `json:"schema_id,omitempty"`
+	IssuerID               string `json:"issuer_id,omitempty"`
+	CredentialDefinitionID string `json:"cred_def_id,omitempty"`
+}
+
+/// @dev The legacy Indy field names
+type indyCredentialFilter struct {
+	SchemaIssuerDID        string `json:"schema_issuer_did,omitempty"`
+	SchemaName             string `json:"schema_name,omitempty"`
+	SchemaVersion          string `json:"schema_version,omitempty"`
+	SchemaID               string `json:"schema_id,omitempty"`
+	IssuerDID              string `json:"issuer_did,omitempty"`
+	CredentialDefinitionID string `json:"cred_def_id,omitempty"`
+}
+
+/// @notice Reads a filter in either the anoncreds or the hlindy field names
+func (f *CredentialFilter) UnmarshalJSON(data []byte) error {
+	type plain CredentialFilter
+	var filter plain
+	if err := json.Unmarshal(data, &filter); err != nil {
+		return err
+	}
+	var legacy indyCredentialFilter
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	if filter.SchemaIssuerID == "" {
+		filter.SchemaIssuerID = legacy.SchemaIssuerDID
+	}
+	if filter.IssuerID == "" {
+		filter.IssuerID = legacy.IssuerDID
+	}
+	*f = CredentialFilter(filter)
+	return nil
+}
+
+/// @notice A candidate credential definition and the schema it is built on
+type OfferableCredentialDefinition struct {
+	CredentialDefinitionID string
+	IssuerID               string
+	SchemaID               string
+	SchemaName             string
+	SchemaVersion          string
+	SchemaIssuerID         string
+	AttributeNames         []string
+}
+
+/// @notice Reports whether a credential definition satisfies the filter
+func (f *CredentialFilter) Matches(candidate *OfferableCredentialDefinition) bool {
+	for _, field := range [][2]string{
+		{f.CredentialDefinitionID, candidate.CredentialDefinitionID},
+		{f.IssuerID, candidate.IssuerID},
+		{f.SchemaID, candidate.SchemaID},
+		{f.SchemaName, candidate.SchemaName},
+		{f.SchemaVersion, candidate.SchemaVersion},
+		{f.SchemaIssuerID, candidate.SchemaIssuerID},
+	} {
+		if field[0] != "" && field[0] != field[1] {
+			return false
+		}
+	}
+	return true
+}
+
+/// @notice Encodes a filter as an attachment in the field names of a family
+func EncodeCredentialFilter(id string, filter *CredentialFilter, family Family, encoding Encoding) (*AttachmentFormat, *Attachment, error) {
+	if filter == nil {
+		return nil, nil, fmt.Errorf("credential filter is required")
+	}
+	format, err := FormatOf(KindCredentialFilter, family)
+	if err != nil {
+		return nil, nil, err
+	}
+	var payload []byte
+	if family == FamilyIndy {
+		payload, err = json.Marshal(indyCredentialFilter{
+			SchemaIssuerDID:        filter.SchemaIssuerID,
+			SchemaName:             filter.SchemaName,
+			SchemaVersion:          filter.SchemaVersion,
+			SchemaID:               filter.SchemaID,
+			IssuerDID:              filter.IssuerID,
+			CredentialDefinitionID: filter.CredentialDefinitionID,
+		})
+	} else {
+		payload, err = json.Marshal(filter)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	attachment, err := NewAttachment(id, payload, encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &AttachmentFormat{AttachID: id, Format: format}, attachment, nil
+}
+
+/// @notice Decodes a credential filter
+func DecodeCredentialFilter(format *AttachmentFormat, attachment *Attachment) (*CredentialFilter, error) {
+	payload, err := DecodePayload(format, attachment, KindCredentialFilter)
+	if err != nil {
+		return nil, err
+	}
+	var filter CredentialFilter
+	if err := json.Unmarshal(payload, &filter); err != nil {
+		return nil, fmt.Errorf("invalid credential filter: %w", err)
+	}
+	return &filter, nil
+}
+
+/// @notice A credential proposal
+type CredentialProposal struct {
+	Comment           string             `json:"comment,omitempty"`
+	CredentialPreview *CredentialPreview `json:"credential_preview,omitempty"`
+	Filter            CredentialFilter   `json:"-"` /// @notice Carried as a filters~attach attachment
+}
+
+/// @notice Returns the candidates matching the proposal
+/// @param candidates The credential definitions that can be offered
+/// @return Every candidate satisfying the filter whose schema the preview matches exactly,
+/// or an error if none does
+func (p *CredentialProposal) Match(candidates []*OfferableCredentialDefinition) ([]*OfferableCredentialDefinition, error) {
+	var matched []*OfferableCredentialDefinition
+	var lastMismatch error
+	for _, candidate := range candidates {
+		if !p.Filter.Matches(candidate) {
+			continue
+		}
+		if p.CredentialPreview != nil {
+			if err := p.CredentialPreview.ValidateAttributeNames(candidate.AttributeNames); err != nil {
+				lastMismatch = fmt.Errorf("%s: %w", candidate.CredentialDefinitionID, err)
+				continue
+			}
+		}
+		matched = append(matched, candidate)
+	}
+	if len(matched) == 0 {
+		if lastMismatch != nil {
+			return nil, lastMismatch
+		}
+		return nil, fmt.Errorf("no offered credential definition matches the proposal")
+	}
+	return matched, nil
+}
+
+/// @notice Lists the credential definitions an issuer service can offer
+/// @return One candidate per stored credential definition, with the details of its schema
+func OfferableCredentialDefinitions(service *issuer.Service) ([]*OfferableCredentialDefinition, error) {
+	records, err := service.CredentialDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[string]*issuer.SchemaRecord)
+	candidates := make([]*OfferableCredentialDefinition, 0, len(records))
+	for _, record := range records {
+		schema, ok := schemas[record.SchemaID]
+		if !ok {
+			if schema, err = service.Schema(record.SchemaID); err != nil {
+				return nil, fmt.Errorf("credential definition %s: %w", record.ID, err)
+			}
+			schemas[record.SchemaID] = schema
+		}
+		candidates = append(candidates, &OfferableCredentialDefinition{
+			CredentialDefinitionID: record.ID,
+			IssuerID:               record.IssuerID,
+			SchemaID:               schema.ID,
+			SchemaName:             schema.Name,
+			SchemaVersion:          schema.Version,
+			SchemaIssuerID:         schema.IssuerID,
+			AttributeNames:         schema.AttributeNames,
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,109 @@
+package interop
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+)
+
+/// @title Identifier Forms
+/// @dev Converts between legacy Indy identifiers and the did:indy AnonCreds identifiers of
+/// https://hyperledger.github.io/indy-did-method/#anoncreds-objects
+
+/// @notice Prefix of did:indy identifiers
+const didIndyPrefix = "did:indy:"
+
+/// @notice Object types in the path of a did:indy AnonCreds identifier
+const (
+	qualifiedSchema  = "SCHEMA"
+	qualifiedCredDef = "CLAIM_DEF"
+	qualifiedRevReg  = "REV_REG_DEF"
+)
+
+/// @notice Reports whether an identifier is a did:indy identifier
+func IsQualified(id string) bool {
+	return strings.HasPrefix(id, didIndyPrefix)
+}
+
+/// @notice Converts a legacy Indy identifier into its did:indy form
+/// @param id A legacy DID, schema, credential definition or revocation registry ID
+/// @param namespace The did:indy namespace of the ledger, e.g. sovrin or sovrin:staging
+/// @return The qualified identifier; did:indy identifiers are returned unchanged
+func Qualify(id, namespace string) (string, error) {
+	if IsQualified(id) {
+		return id, nil
+	}
+	if namespace == "" {
+		return "", fmt.Errorf("a did:indy namespace is required to qualify %s", id)
+	}
+	issuer := func(did string) string { return didIndyPrefix + namespace + ":" + did }
+	switch {
+	case !strings.Contains(id, ":"):
+		return issuer(id), nil
+	case strings.Contains(id, ":4:"):
+		did, credDefID, tag, err := ledger.ParseLegacyRevocationRegistryID(id)
+		if err != nil {
+			return "", err
+		}
+		_, seqNo, credDefTag, err := ledger.ParseLegacyCredentialDefinitionID(credDefID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/anoncreds/v0/%s/%d/%s/%s", issuer(did), qualifiedRevReg, seqNo, credDefTag, tag), nil
+	case strings.Contains(id, ":3:"):
+		did, seqNo, tag, err := ledger.ParseLegacyCredentialDefinitionID(id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/anoncreds/v0/%s/%d/%s", issuer(did), qualifiedCredDef, seqNo, tag), nil
+	default:
+		did, name, version, err := ledger.ParseLegacySchemaID(id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/anoncreds/v0/%s/%s/%s", issuer(did), qualifiedSchema, name, version), nil
+	}
+}
+
+/// @notice Converts a did:indy identifier into its legacy Indy form
+/// @return The legacy identifier; identifiers that are not did:indy are returned unchanged
+func Unqualify(id string) (string, error) {
+	if !IsQualified(id) {
+		return id, nil
+	}
+	issuer, path := id, ""
+	if i := strings.Index(id, "/"); i >= 0 {
+		issuer, path = id[:i], id[i+1:]
+	}
+	did := issuer[strings.LastIndex(issuer, ":")+1:]
+	if len(issuer) <= len(didIndyPrefix) || did == "" || !strings.Contains(issuer[len(didIndyPrefix):], ":") {
+		return "", fmt.Errorf("invalid did:indy identifier: %s", id)
+	}
+	if path == "" {
+		return did, nil
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[0] != "anoncreds" || parts[1] != "v0" {
+		return "", fmt.Errorf("unsupported did:indy object identifier: %s", id)
+	}
+	switch args := parts[3:]; {
+	case parts[2] == qualifiedSchema && len(args) == 2:
+		return ledger.LegacySchemaID(did, args[0], args[1]), nil
+	case parts[2] == qualifiedCredDef && len(args) == 2:
+		seqNo, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid schema sequence number in %s", id)
+		}
+		return ledger.LegacyCredentialDefinitionID(did, seqNo, args[1]), nil
+	case parts[2] == qualifiedRevReg && len(args) == 3:
+		seqNo, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid schema sequence number in %s", id)
+		}
+		return ledger.LegacyRevocationRegistryID(did, ledger.LegacyCredentialDefinitionID(did, seqNo, args[1]), args[2]), nil
+	}
+	return "", fmt.Errorf("unsupported did:indy object identifier: %s", id)
+}
@@ -0,0 +1,276 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/// @title Peer Normalization Profiles
+/// @dev Agents serialize the same AnonCreds objects with small differences. A Profile describes
+/// the form one peer sends and expects; Normalize reads any known variant into the form the
+/// anoncreds FromJSON constructors accept and Profile.Encode writes it back in the peer's form
+
+/// @notice How a key correctness proof carries xr_cap
+type XrCapForm string
+
+/// @notice Known xr_cap forms
+const (
+	XrCapArray  XrCapForm = "array"  /// @notice [["name","123"], ...] as written by anoncreds-rs
+	XrCapObject XrCapForm = "object" /// @notice {"name":"123", ...} as written by indy-sdk
+)
+
+/// @notice The field naming the issuer of schemas, credential definitions and revocation registries
+const (
+	IssuerIDCamel   = "issuerId"  /// @notice As written by anoncreds-rs, Credo-TS and ACA-Py
+	IssuerIDSnake   = "issuer_id" /// @notice Accepted from peers that snake-case every field
+	IssuerIDOmitted = ""          /// @notice indy-sdk objects carry no issuer field; it is derived from their ID
+)
+
+/// @notice How a peer writes ledger identifiers
+type IdentifierForm string
+
+/// @notice Known identifier forms
+const (
+	IdentifiersAsIs      IdentifierForm = ""          /// @notice Identifiers are passed through
+	IdentifiersLegacy    IdentifierForm = "legacy"    /// @notice Unqualified Indy identifiers, e.g. <did>:3:CL:<seqNo>:<tag>
+	IdentifiersQualified IdentifierForm = "qualified" /// @notice did:indy identifiers
+)
+
+/// @notice The serialization conventions of a peer
+type Profile struct {
+	Name        string
+	XrCap       XrCapForm
+	IssuerID    string         /// @notice IssuerIDCamel, IssuerIDSnake or IssuerIDOmitted
+	Identifiers IdentifierForm /// @notice Form identifiers are rewritten into
+	Namespace   string         /// @notice did:indy namespace used when qualifying legacy identifiers
+}
+
+/// @notice Built-in profiles
+var (
+	/// @notice The form accepted by the anoncreds FromJSON constructors
+	Canonical = Profile{Name: "anoncreds-rs", XrCap: XrCapArray, IssuerID: IssuerIDCamel}
+
+	/// @notice Credo-TS with did:indy identifiers; set Namespace to qualify legacy identifiers
+	CredoTS = Profile{Name: "credo-ts", XrCap: XrCapArray, IssuerID: IssuerIDCamel, Identifiers: IdentifiersQualified}
+
+	/// @notice ACA-Py with an Indy ledger
+	ACAPy = Profile{Name: "aca-py", XrCap: XrCapArray, IssuerID: IssuerIDCamel, Identifiers: IdentifiersLegacy}
+
+	/// @notice The legacy indy-sdk, whose key correctness proofs carry xr_cap as an object
+	/// @dev The ver, id and seqNo fields of its ledger objects are dropped by the other profiles and
+	/// cannot be restored when encoding for it
+	IndySDK = Profile{Name: "indy-sdk", XrCap: XrCapObject, IssuerID: IssuerIDOmitted, Identifiers: IdentifiersLegacy}
+)
+
+/// @notice Returns a built-in profile by name
+func ProfileByName(name string) (Profile, error) {
+	for _, profile := range []Profile{Canonical, CredoTS, ACAPy, IndySDK} {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("unknown interop profile %q", name)
+}
+
+/// @notice Returns a copy of the profile qualifying identifiers in a did:indy namespace
+func (p Profile) WithNamespace(namespace string) Profile {
+	p.Namespace = namespace
+	return p
+}
+
+/// @dev Fields holding ledger identifiers, at any depth
+var identifierFields = map[string]bool{
+	"id":                true,
+	"issuerId":          true,
+	"issuer_id":         true,
+	"issuer_did":        true,
+	"schema_id":         true,
+	"schemaId":          true,
+	"schema_issuer_id":  true,
+	"schema_issuer_did": true,
+	"cred_def_id":       true,
+	"credDefId":         true,
+	"rev_reg_id":        true,
+	"rev_reg_def_id":    true,
+	"revRegDefId":       true,
+	"revocRegDefId":     true,
+}
+
+/// @dev Fields indy-sdk writes around ledger objects; anoncreds-rs neither reads nor writes them
+var ledgerEnvelopeFields = []string{"ver", "id", "seqNo"}
+
+/// @notice Rewrites a JSON object into the form the anoncreds FromJSON constructors accept
+/// @param data An object in the form of any profile
+/// @return The normalized JSON and any error encountered
+func Normalize(data []byte) ([]byte, error) {
+	return Canonical.Encode(data)
+}
+
+/// @notice Rewrites a JSON object into the form of this profile
+/// @param data An object in the form of any profile
+/// @return The rewritten JSON and any error encountered
+func (p Profile) Encode(data []byte) ([]byte, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid JSON object: %w", err)
+	}
+	if err := p.Apply(object); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+/// @notice Rewrites a decoded JSON object in place into the form of this profile
+func (p Profile) Apply(object map[string]interface{}) error {
+	p.renameIssuerID(object)
+	if p.IssuerID != IssuerIDOmitted && isLedgerObject(object) {
+		for _, field := range ledgerEnvelopeFields {
+			delete(object, field)
+		}
+	}
+	if err := p.convertXrCap(object); err != nil {
+		return err
+	}
+	if kcp, ok := object["key_correctness_proof"].(map[string]interface{}); ok {
+		if err := p.convertXrCap(kcp); err != nil {
+			return err
+		}
+	}
+	if p.Identifiers == IdentifiersAsIs {
+		return nil
+	}
+	_, err := p.convertIdentifiers(object, "")
+	return err
+}
+
+/// @dev Renames the top-level issuer field; nested issuer_id fields are restriction keys and kept
+func (p Profile) renameIssuerID(object map[string]interface{}) {
+	value, ok := object[IssuerIDCamel]
+	if !ok {
+		value, ok = object[IssuerIDSnake]
+	}
+	if !ok {
+		value, ok = legacyIssuer(object)
+	}
+	delete(object, IssuerIDCamel)
+	delete(object, IssuerIDSnake)
+	if ok && p.IssuerID != IssuerIDOmitted {
+		object[p.IssuerID] = value
+	}
+}
+
+/// @dev Derives the issuer of a ledger object from its ID, as indy-sdk objects carry none
+func legacyIssuer(object map[string]interface{}) (interface{}, bool) {
+	id, _ := object["id"].(string)
+	if !isIndyIdentifier(id) || !isLedgerObject(object) {
+		return nil, false
+	}
+	if IsQualified(id) {
+		if i := strings.Index(id, "/"); i >= 0 {
+			return id[:i], true
+		}
+		return nil, false
+	}
+	return id[:strings.Index(id+":", ":")], true
+}
+
+/// @dev Reports whether an object is a schema, credential definition or revocation registry definition
+func isLedgerObject(object map[string]interface{}) bool {
+	for _, field := range []string{"attrNames", "schemaId", "credDefId", "revocDefType"} {
+		if _, ok := object[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Converts the xr_cap field of a key correctness proof
+func (p Profile) convertXrCap(kcp map[string]interface{}) error {
+	switch xrCap := kcp["xr_cap"].(type) {
+	case []interface{}:
+		if p.XrCap != XrCapObject {
+			return nil
+		}
+		converted := make(map[string]interface{}, len(xrCap))
+		for _, item := range xrCap {
+			pair, ok := item.([]interface{})
+			if !ok || len(pair) != 2 {
+				return fmt.Errorf("invalid xr_cap entry %v", item)
+			}
+			name, ok := pair[0].(string)
+			if !ok {
+				return fmt.Errorf("invalid xr_cap attribute name %v", pair[0])
+			}
+			converted[name] = pair[1]
+		}
+		kcp["xr_cap"] = converted
+	case map[string]interface{}:
+		if p.XrCap == XrCapObject {
+			return nil
+		}
+		// The object form carries no order, so attributes are listed by name
+		names := make([]string, 0, len(xrCap))
+		for name := range xrCap {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		converted := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			converted = append(converted, []interface{}{name, xrCap[name]})
+		}
+		kcp["xr_cap"] = converted
+	}
+	return nil
+}
+
+/// @dev Rewrites identifier fields below value into the form of the profile
+func (p Profile) convertIdentifiers(value interface{}, field string) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			converted, err := p.convertIdentifiers(item, key)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = converted
+		}
+	case []interface{}:
+		for i, item := range value {
+			converted, err := p.convertIdentifiers(item, field)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = converted
+		}
+	case string:
+		if !identifierFields[field] || !isIndyIdentifier(value) {
+			return value, nil
+		}
+		if p.Identifiers == IdentifiersQualified {
+			return Qualify(value, p.Namespace)
+		}
+		return Unqualify(value)
+	}
+	return value, nil
+}
+
+/// @dev Reports whether a value is a legacy or did:indy identifier rather than some other DID or ID
+func isIndyIdentifier(value string) bool {
+	if IsQualified(value) {
+		return true
+	}
+	if value == "" || strings.HasPrefix(value, "did:") {
+		return false
+	}
+	if !strings.Contains(value, ":") {
+		// A bare Indy DID is 21 or 22 base58 characters
+		return (len(value) == 21 || len(value) == 22) && strings.Trim(value, base58Alphabet) == ""
+	}
+	parts := strings.Split(value, ":")
+	return len(parts) >= 4 && (parts[1] == "2" || parts[1] == "3" || parts[1] == "4")
+}
+
+/// @dev The base58 alphabet of Indy DIDs
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
@@ -0,0 +1,369 @@
+package issuer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Revocation Queue
+/// @dev Collects revocations per registry so they can be published as a single status list update.
+/// A publication is journaled before any bookkeeping changes, so an interrupted publish is
+/// completed when the queue is next opened
+
+/// @notice Storage categories used by the queue
+const (
+	CategoryRevocationQueue       = "revocation_pending"
+	CategoryRevocationPublication = "revocation_publication"
+)
+
+/// @notice A revocation waiting to be published
+type PendingRevocation struct {
+	CredentialID                   string `json:"credential_id"`
+	CredentialDefinitionID         string `json:"cred_def_id"`
+	RevocationRegistryDefinitionID string `json:"rev_reg_def_id"`
+	RegistryIndex                  uint32 `json:"rev_reg_index"`
+	QueuedAt                       int64  `json:"queued_at"`
+}
+
+/// @notice Configuration of a revocation queue
+type RevocationQueueOptions struct {
+	/// @notice Manager of the credential definition's registries; its Store also holds the queue
+	Manager *RevocationRegistryManager
+
+	/// @notice Public credential definition, needed to update status lists
+	CredentialDefinition *anoncreds.CredentialDefinition
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Options for a single publication
+type PublishOptions struct {
+	/// @notice Unix timestamp of the new status list; defaults to the current time
+	Timestamp *int64
+
+	/// @notice Computes the new status list without changing any state
+	DryRun bool
+}
+
+/// @notice Result of folding the pending revocations of a registry into a new status list
+/// @dev The caller owns StatusList and must Clear it once it has been published
+type RevocationPublication struct {
+	RevocationRegistryDefinitionID string
+	Timestamp                      int64
+	Revoked                        []uint32
+	CredentialIDs                  []string
+	StatusList                     *anoncreds.RevocationStatusList
+	DryRun                         bool
+}
+
+/// @notice Issuer-side queue of pending revocations
+/// @dev Safe for concurrent use
+type RevocationQueue struct {
+	options RevocationQueueOptions
+	store   storage.Store
+	mu      sync.Mutex
+}
+
+/// @dev Journal entry written before a publication is applied
+type publicationRecord struct {
+	RevocationRegistryDefinitionID string          `json:"rev_reg_def_id"`
+	CredentialIDs                  []string        `json:"credential_ids"`
+	Revoked                        []uint32        `json:"revoked"`
+	Timestamp                      int64           `json:"timestamp"`
+	StatusList                     json.RawMessage `json:"status_list"`
+}
+
+/// @notice Opens the queue of a manager's credential definition, completing any interrupted publication
+func NewRevocationQueue(options RevocationQueueOptions) (*RevocationQueue, error) {
+	if options.Manager == nil {
+		return nil, fmt.Errorf("revocation registry manager is required")
+	}
+	if options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("credential definition is required")
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	q := &RevocationQueue{
+		options: options,
+		store:   options.Manager.options.Store,
+	}
+	if err := q.Recover(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+/// @notice Queues a credential for revocation
+/// @dev Queuing a credential twice returns the existing entry
+/// @return The pending entry and any error encountered
+func (q *RevocationQueue) Revoke(credentialID string) (*PendingRevocation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	location, err := q.options.Manager.Locate(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if location.Revoked {
+		return nil, fmt.Errorf("credential %s is already revoked", credentialID)
+	}
+
+	if existing, err := q.get(credentialID); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	pending := &PendingRevocation{
+		CredentialID:                   credentialID,
+		CredentialDefinitionID:         location.CredentialDefinitionID,
+		RevocationRegistryDefinitionID: location.RevocationRegistryDefinitionID,
+		RegistryIndex:                  location.RegistryIndex,
+		QueuedAt:                       q.options.Now().Unix(),
+	}
+	value, err := json.Marshal(pending)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.store.Put(&storage.Record{
+		Category: CategoryRevocationQueue,
+		ID:       credentialID,
+		Value:    value,
+		Tags: map[string]string{
+			"cred_def_id":    pending.CredentialDefinitionID,
+			"rev_reg_def_id": pending.RevocationRegistryDefinitionID,
+			"rev_reg_index":  strconv.FormatUint(uint64(pending.RegistryIndex), 10),
+		},
+	}); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+/// @notice Removes a credential from the queue before it is published
+func (q *RevocationQueue) Cancel(credentialID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.get(credentialID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("credential %s has no pending revocation", credentialID)
+		}
+		return err
+	}
+	return q.store.Delete(CategoryRevocationQueue, credentialID)
+}
+
+/// @notice Returns the pending revocations of a registry, ordered by registry index
+/// @param revRegDefID The registry to list; empty lists every registry of the credential definition
+func (q *RevocationQueue) Pending(revRegDefID string) ([]*PendingRevocation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending(revRegDefID)
+}
+
+/// @notice Folds the pending revocations of a registry into one status list update
+/// @param revRegDefID The registry to publish
+/// @param options Timestamp and dry-run settings
+/// @return The new status list for publication and any error encountered
+/// @dev Unless DryRun is set, the new list is stored with the registry and the entries leave the queue
+func (q *RevocationQueue) Publish(revRegDefID string, options PublishOptions) (*RevocationPublication, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending, err := q.pending(revRegDefID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, fmt.Errorf("no pending revocations for registry %s", revRegDefID)
+	}
+
+	timestamp := q.options.Now().Unix()
+	if options.Timestamp != nil {
+		timestamp = *options.Timestamp
+	}
+	publication := &RevocationPublication{
+		RevocationRegistryDefinitionID: revRegDefID,
+		Timestamp:                      timestamp,
+		DryRun:                         options.DryRun,
+	}
+	for _, entry := range pending {
+		publication.Revoked = insertIndex(publication.Revoked, entry.RegistryIndex)
+		publication.CredentialIDs = append(publication.CredentialIDs, entry.CredentialID)
+	}
+
+	statusList, err := q.updateStatusList(revRegDefID, publication.Revoked, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	publication.StatusList = statusList
+	if options.DryRun {
+		return publication, nil
+	}
+
+	listJSON, err := statusList.ToJSONString()
+	if err != nil {
+		statusList.Clear()
+		return nil, err
+	}
+	journal := &publicationRecord{
+		RevocationRegistryDefinitionID: revRegDefID,
+		CredentialIDs:                  publication.CredentialIDs,
+		Revoked:                        publication.Revoked,
+		Timestamp:                      timestamp,
+		StatusList:                     json.RawMessage(listJSON),
+	}
+	value, err := json.Marshal(journal)
+	if err != nil {
+		statusList.Clear()
+		return nil, err
+	}
+	if err := q.store.Put(&storage.Record{
+		Category: CategoryRevocationPublication,
+		ID:       revRegDefID,
+		Value:    value,
+		Tags:     map[string]string{"cred_def_id": q.options.Manager.options.CredentialDefinitionID},
+	}); err != nil {
+		statusList.Clear()
+		return nil, err
+	}
+	if err := q.apply(journal); err != nil {
+		statusList.Clear()
+		return nil, err
+	}
+	return publication, nil
+}
+
+/// @notice Completes publications that were journaled but not fully applied, e.g. after a crash
+/// @dev Called by NewRevocationQueue; safe to call again at any time
+func (q *RevocationQueue) Recover() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	records, err := q.store.List(CategoryRevocationPublication)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		var journal publicationRecord
+		if err := json.Unmarshal(record.Value, &journal); err != nil {
+			return fmt.Errorf("corrupt revocation publication record %s: %w", record.ID, err)
+		}
+		if _, err := q.options.Manager.Registry(journal.RevocationRegistryDefinitionID); err != nil {
+			// Belongs to another credential definition
+			continue
+		}
+		if err := q.apply(&journal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/// @dev Computes the registry's next status list with anoncreds-rs
+func (q *RevocationQueue) updateStatusList(revRegDefID string, revoked []uint32, timestamp int64) (*anoncreds.RevocationStatusList, error) {
+	registry, err := q.options.Manager.Registry(revRegDefID)
+	if err != nil {
+		return nil, err
+	}
+	regDef, err := anoncreds.RevocationRegistryDefinitionFromJSON([]byte(registry.RevocationRegistryDefinition))
+	if err != nil {
+		return nil, err
+	}
+	defer regDef.Clear()
+	regDefPrivate, err := q.options.Manager.registryPrivate(registry)
+	if err != nil {
+		return nil, err
+	}
+	defer regDefPrivate.Clear()
+	current, err := anoncreds.RevocationStatusListFromJSON([]byte(registry.StatusList))
+	if err != nil {
+		return nil, err
+	}
+	defer current.Clear()
+
+	return anoncreds.UpdateRevocationStatusList(anoncreds.UpdateRevocationStatusListOptions{
+		CredentialDefinition:                q.options.CredentialDefinition,
+		RevocationRegistryDefinition:        regDef,
+		RevocationRegistryDefinitionPrivate: regDefPrivate,
+		CurrentStatusList:                   current,
+		Revoked:                             revoked,
+		Timestamp:                           &timestamp,
+	})
+}
+
+/// @dev Applies a journaled publication. Every step is idempotent, so a partially applied
+/// journal can be replayed. Callers must hold q.mu
+func (q *RevocationQueue) apply(journal *publicationRecord) error {
+	manager := q.options.Manager
+	if err := manager.setStatusList(journal.RevocationRegistryDefinitionID, string(journal.StatusList)); err != nil {
+		return err
+	}
+	for _, credentialID := range journal.CredentialIDs {
+		if _, err := manager.MarkRevoked(credentialID); err != nil {
+			return err
+		}
+		if err := q.store.Delete(CategoryRevocationQueue, credentialID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	err := q.store.Delete(CategoryRevocationPublication, journal.RevocationRegistryDefinitionID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+/// @dev Loads the queued entries of the credential definition. Callers must hold q.mu
+func (q *RevocationQueue) pending(revRegDefID string) ([]*PendingRevocation, error) {
+	records, err := q.store.List(CategoryRevocationQueue)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*PendingRevocation, 0, len(records))
+	for _, record := range records {
+		var entry PendingRevocation
+		if err := json.Unmarshal(record.Value, &entry); err != nil {
+			return nil, fmt.Errorf("corrupt pending revocation record %s: %w", record.ID, err)
+		}
+		if entry.CredentialDefinitionID != q.options.Manager.options.CredentialDefinitionID {
+			continue
+		}
+		if revRegDefID != "" && entry.RevocationRegistryDefinitionID != revRegDefID {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].RevocationRegistryDefinitionID != entries[j].RevocationRegistryDefinitionID {
+			return entries[i].RevocationRegistryDefinitionID < entries[j].RevocationRegistryDefinitionID
+		}
+		return entries[i].RegistryIndex < entries[j].RegistryIndex
+	})
+	return entries, nil
+}
+
+/// @dev Loads a single queued entry
+func (q *RevocationQueue) get(credentialID string) (*PendingRevocation, error) {
+	record, err := q.store.Get(CategoryRevocationQueue, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	var entry PendingRevocation
+	if err := json.Unmarshal(record.Value, &entry); err != nil {
+		return nil, fmt.Errorf("corrupt pending revocation record %s: %w", credentialID, err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,781 @@
+package issuer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Revocation Registry Manager
+/// @dev Tracks index allocation for the revocation registries of one credential definition and
+/// rotates to a fresh registry when the active one fills up
+
+/// @notice Lifecycle states of a managed registry
+const (
+	RegistryStatePending = "pending" /// @notice Created ahead of time, not yet issuing
+	RegistryStateActive  = "active"  /// @notice Currently handing out indices
+	RegistryStateFull    = "full"    /// @notice Every index has been handed out
+)
+
+/// @notice Storage categories used by the manager
+const (
+	CategoryRevocationRegistry = "revocation_registry"
+	CategoryRevocationPointer  = "revocation_registry_active"
+	CategoryRevocationIndex    = "revocation_credential"
+)
+
+/// @notice Default fraction of a registry that must be used before the next one is pre-created
+const DefaultFillThreshold = 0.8
+
+/// @notice Persisted state of a revocation registry
+type RevocationRegistryRecord struct {
+	ID                                  string          `json:"id"`
+	CredentialDefinitionID              string          `json:"cred_def_id"`
+	Tag                                 string          `json:"tag"`
+	State                               string          `json:"state"`
+	MaxCredNum                          uint32          `json:"max_cred_num"`
+	NextIndex                           uint32          `json:"next_index"`
+	Issued                              []uint32        `json:"issued"`
+	Released                            []uint32        `json:"released,omitempty"` /// @notice Indices handed back by Release, reused before NextIndex
+	Revoked                             []uint32        `json:"revoked"`
+	RevocationRegistryDefinition        json.RawMessage `json:"rev_reg_def"`
+	RevocationRegistryDefinitionPrivate json.RawMessage `json:"rev_reg_def_private,omitempty"` /// @notice Empty when sealed in a KeyStore
+	StatusList                          json.RawMessage `json:"status_list"`
+	CreatedAt                           int64           `json:"created_at"`
+}
+
+/// @notice Persisted mapping from an issued credential to its registry and index
+type CredentialRevocationRecord struct {
+	CredentialID                   string `json:"credential_id"`
+	CredentialDefinitionID         string `json:"cred_def_id"`
+	RevocationRegistryDefinitionID string `json:"rev_reg_def_id"`
+	RegistryIndex                  uint32 `json:"rev_reg_index"`
+	Revoked                        bool   `json:"revoked"`
+	IssuedAt                       int64  `json:"issued_at"`
+}
+
+/// @notice A newly created registry handed to the manager by RevocationRegistryManagerOptions.CreateRegistry
+type NewRevocationRegistry struct {
+	ID                                  string
+	RevocationRegistryDefinition        *anoncreds.RevocationRegistryDefinition
+	RevocationRegistryDefinitionPrivate *anoncreds.RevocationRegistryDefinitionPrivate
+	StatusList                          *anoncreds.RevocationStatusList
+}
+
+/// @notice Configuration of a revocation registry manager
+/// @dev CredentialDefinition, IssuerID and MaxCredNum are only needed when CreateRegistry is nil
+type RevocationRegistryManagerOptions struct {
+	Store                  storage.Store
+	CredentialDefinitionID string
+	CredentialDefinition   *anoncreds.CredentialDefinition
+	IssuerID               string
+	MaxCredNum             uint32
+	TailsDirectoryPath     string
+
+	/// @notice Seals private registry definitions instead of storing them in plaintext records
+	KeyStore *keystore.KeyStore
+
+	/// @notice Fraction of the active registry after which the next one is pre-created; DefaultFillThreshold when zero
+	FillThreshold float64
+
+	/// @notice Derives a registry ID from a tag; defaults to the legacy Indy format for legacy credential definition IDs
+	RegistryID func(tag string) (string, error)
+
+	/// @notice Overrides registry creation entirely; the manager takes ownership of the returned objects
+	CreateRegistry func(tag string) (*NewRevocationRegistry, error)
+
+	/// @notice Called after a registry is created and before it is persisted, e.g. to publish it
+	OnCreated func(record *RevocationRegistryRecord) error
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice A registry index handed out for a credential
+/// @dev Call RevocationConfig to load the registry objects for anoncreds.CreateCredential and Clear afterwards
+type RevocationAllocation struct {
+	CredentialID                   string
+	RevocationRegistryDefinitionID string
+	RegistryIndex                  uint32
+
+	registry RevocationRegistryRecord
+	keys     *keystore.KeyStore
+	config   *anoncreds.CredentialRevocationConfig
+}
+
+/// @notice Issuer-side allocator of revocation registry indices
+/// @dev Safe for concurrent use; all state is persisted through the configured Store
+type RevocationRegistryManager struct {
+	options RevocationRegistryManagerOptions
+
+	mu         sync.Mutex
+	createMu   sync.Mutex
+	active     string
+	pending    string
+	registries map[string]*RevocationRegistryRecord
+}
+
+/// @dev Persisted pointer to the active and pending registries of a credential definition
+type registryPointer struct {
+	Active  string `json:"active"`
+	Pending string `json:"pending"`
+}
+
+/// @notice Creates a manager and restores any state persisted for the credential definition
+func NewRevocationRegistryManager(options RevocationRegistryManagerOptions) (*RevocationRegistryManager, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.CredentialDefinitionID == "" {
+		return nil, fmt.Errorf("credential definition id is required")
+	}
+	if options.CreateRegistry == nil {
+		if options.CredentialDefinition == nil {
+			return nil, fmt.Errorf("credential definition is required")
+		}
+		if options.MaxCredNum == 0 {
+			return nil, fmt.Errorf("max credential number is required")
+		}
+	}
+	if options.FillThreshold <= 0 || options.FillThreshold > 1 {
+		options.FillThreshold = DefaultFillThreshold
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	m := &RevocationRegistryManager{
+		options:    options,
+		registries: make(map[string]*RevocationRegistryRecord),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+/// @notice Hands out the next free index for a credential, rotating registries as needed
+/// @param credentialID Caller-chosen ID used to find the credential again when revoking
+/// @return The allocation and any error encountered
+func (m *RevocationRegistryManager) Allocate(credentialID string) (*RevocationAllocation, error) {
+	if credentialID == "" {
+		return nil, fmt.Errorf("credential id is required")
+	}
+
+	for {
+		m.mu.Lock()
+		allocation, precreate, err := m.tryAllocate(credentialID)
+		m.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if allocation != nil {
+			if precreate {
+				// Best effort: a failure here is retried on the next allocation and, at the
+				// latest, when the active registry is full
+				m.ensurePending(false)
+			}
+			return allocation, nil
+		}
+		if err := m.ensurePending(true); err != nil {
+			return nil, err
+		}
+	}
+}
+
+/// @notice Returns the registry and index a credential was issued with
+func (m *RevocationRegistryManager) Locate(credentialID string) (*CredentialRevocationRecord, error) {
+	record, err := m.options.Store.Get(CategoryRevocationIndex, credentialID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("credential %s has no revocation index", credentialID)
+		}
+		return nil, err
+	}
+	var location CredentialRevocationRecord
+	if err := json.Unmarshal(record.Value, &location); err != nil {
+		return nil, err
+	}
+	if location.CredentialDefinitionID != m.options.CredentialDefinitionID {
+		return nil, fmt.Errorf("credential %s belongs to credential definition %s", credentialID, location.CredentialDefinitionID)
+	}
+	return &location, nil
+}
+
+/// @notice Hands back the index of an allocation whose credential was never issued
+/// @dev Call it when issuance fails after Allocate. The credential ID may be allocated again and
+/// the index is reused by a later allocation from the same registry
+func (m *RevocationRegistryManager) Release(allocation *RevocationAllocation) error {
+	if allocation == nil {
+		return fmt.Errorf("allocation is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	location, err := m.Locate(allocation.CredentialID)
+	if err != nil {
+		return err
+	}
+	if location.RevocationRegistryDefinitionID != allocation.RevocationRegistryDefinitionID || location.RegistryIndex != allocation.RegistryIndex {
+		return fmt.Errorf("credential %s is not at index %d of %s", allocation.CredentialID, allocation.RegistryIndex, allocation.RevocationRegistryDefinitionID)
+	}
+	if location.Revoked {
+		return fmt.Errorf("credential %s has been revoked", allocation.CredentialID)
+	}
+	registry, ok := m.registries[location.RevocationRegistryDefinitionID]
+	if !ok {
+		return fmt.Errorf("unknown revocation registry %s", location.RevocationRegistryDefinitionID)
+	}
+
+	// The location goes first: if saving the registry then fails the index is merely left
+	// unused, whereas the reverse order could hand one index to two credentials
+	if err := m.options.Store.Delete(CategoryRevocationIndex, location.CredentialID); err != nil {
+		return err
+	}
+	updated := *registry.clone()
+	updated.Issued = removeIndex(registry.Issued, location.RegistryIndex)
+	if location.RegistryIndex+1 == registry.NextIndex {
+		updated.NextIndex = location.RegistryIndex
+	} else {
+		updated.Released = insertIndex(registry.Released, location.RegistryIndex)
+	}
+	if updated.ID == m.active {
+		updated.State = RegistryStateActive
+	}
+	if err := m.saveRegistry(&updated); err != nil {
+		return err
+	}
+	m.registries[updated.ID] = &updated
+	return nil
+}
+
+/// @notice Records that a credential has been revoked
+/// @dev This only updates bookkeeping; publishing a new status list is a separate step
+func (m *RevocationRegistryManager) MarkRevoked(credentialID string) (*CredentialRevocationRecord, error) {
+	location, err := m.Locate(credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	registry, ok := m.registries[location.RevocationRegistryDefinitionID]
+	if !ok {
+		return nil, fmt.Errorf("unknown revocation registry %s", location.RevocationRegistryDefinitionID)
+	}
+	updated := *registry
+	updated.Revoked = insertIndex(registry.Revoked, location.RegistryIndex)
+	if err := m.saveRegistry(&updated); err != nil {
+		return nil, err
+	}
+	m.registries[updated.ID] = &updated
+
+	location.Revoked = true
+	if err := m.saveLocation(location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+/// @notice Replaces the stored status list of a registry, e.g. after publishing revocations
+func (m *RevocationRegistryManager) UpdateStatusList(revRegDefID string, statusList *anoncreds.RevocationStatusList) error {
+	if statusList == nil {
+		return fmt.Errorf("status list is required")
+	}
+	listJSON, err := statusList.ToJSONString()
+	if err != nil {
+		return err
+	}
+	return m.setStatusList(revRegDefID, listJSON)
+}
+
+/// @notice Returns a copy of a registry's state
+func (m *RevocationRegistryManager) Registry(revRegDefID string) (*RevocationRegistryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registry, ok := m.registries[revRegDefID]
+	if !ok {
+		return nil, fmt.Errorf("unknown revocation registry %s", revRegDefID)
+	}
+	return registry.clone(), nil
+}
+
+/// @notice Returns copies of every registry, oldest first
+func (m *RevocationRegistryManager) Registries() []*RevocationRegistryRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registries := make([]*RevocationRegistryRecord, 0, len(m.registries))
+	for _, registry := range m.registries {
+		registries = append(registries, registry.clone())
+	}
+	sort.Slice(registries, func(i, j int) bool {
+		if registries[i].CreatedAt != registries[j].CreatedAt {
+			return registries[i].CreatedAt < registries[j].CreatedAt
+		}
+		return registries[i].ID < registries[j].ID
+	})
+	return registries
+}
+
+/// @notice Returns the ID of the registry currently handing out indices, if any
+func (m *RevocationRegistryManager) ActiveRegistryID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+/// @notice Returns the ID of the pre-created registry waiting to take over, if any
+func (m *RevocationRegistryManager) PendingRegistryID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pending
+}
+
+/// @notice Loads the registry objects needed to issue the credential
+/// @return Revocation config for anoncreds.CreateCredentialOptions, owned by the allocation
+func (a *RevocationAllocation) RevocationConfig() (*anoncreds.CredentialRevocationConfig, error) {
+	if a.config != nil {
+		return a.config, nil
+	}
+	regDef, err := anoncreds.RevocationRegistryDefinitionFromJSON([]byte(a.registry.RevocationRegistryDefinition))
+	if err != nil {
+		return nil, err
+	}
+	regDefPrivate, err := loadRegistryPrivate(&a.registry, a.keys)
+	if err != nil {
+		regDef.Clear()
+		return nil, err
+	}
+	statusList, err := anoncreds.RevocationStatusListFromJSON([]byte(a.registry.StatusList))
+	if err != nil {
+		regDef.Clear()
+		regDefPrivate.Clear()
+		return nil, err
+	}
+	a.config = &anoncreds.CredentialRevocationConfig{
+		RegistryDefinition:        regDef,
+		RegistryDefinitionPrivate: regDefPrivate,
+		StatusList:                statusList,
+		RegistryIndex:             a.RegistryIndex,
+	}
+	return a.config, nil
+}
+
+/// @notice Frees the registry objects loaded by RevocationConfig
+func (a *RevocationAllocation) Clear() {
+	if a.config == nil {
+		return
+	}
+	a.config.RegistryDefinition.Clear()
+	a.config.RegistryDefinitionPrivate.Clear()
+	a.config.StatusList.Clear()
+	a.config = nil
+}
+
+/// @dev Allocates from the active registry; returns a nil allocation when a new registry is needed.
+/// Callers must hold m.mu
+func (m *RevocationRegistryManager) tryAllocate(credentialID string) (*RevocationAllocation, bool, error) {
+	if _, err := m.options.Store.Get(CategoryRevocationIndex, credentialID); err == nil {
+		return nil, false, fmt.Errorf("credential %s already has a revocation index", credentialID)
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, false, err
+	}
+
+	registry := m.registries[m.active]
+	if registry == nil || (registry.NextIndex > registry.MaxCredNum && len(registry.Released) == 0) {
+		if m.pending == "" {
+			return nil, false, nil
+		}
+		if err := m.switchToPending(); err != nil {
+			return nil, false, err
+		}
+		registry = m.registries[m.active]
+	}
+
+	updated := *registry
+	var index uint32
+	if len(registry.Released) > 0 {
+		index = registry.Released[0]
+		updated.Released = append([]uint32(nil), registry.Released[1:]...)
+	} else {
+		index = registry.NextIndex
+		updated.NextIndex = index + 1
+	}
+	updated.Issued = insertIndex(registry.Issued, index)
+	if updated.NextIndex > updated.MaxCredNum && len(updated.Released) == 0 {
+		updated.State = RegistryStateFull
+	}
+	if err := m.saveRegistry(&updated); err != nil {
+		return nil, false, err
+	}
+	m.registries[updated.ID] = &updated
+
+	if err := m.saveLocation(&CredentialRevocationRecord{
+		CredentialID:                   credentialID,
+		CredentialDefinitionID:         m.options.CredentialDefinitionID,
+		RevocationRegistryDefinitionID: updated.ID,
+		RegistryIndex:                  index,
+		IssuedAt:                       m.options.Now().Unix(),
+	}); err != nil {
+		return nil, false, err
+	}
+
+	used := float64(updated.NextIndex-1) / float64(updated.MaxCredNum)
+	precreate := m.pending == "" && used >= m.options.FillThreshold
+
+	return &RevocationAllocation{
+		CredentialID:                   credentialID,
+		RevocationRegistryDefinitionID: updated.ID,
+		RegistryIndex:                  index,
+		registry:                       *updated.clone(),
+		keys:                           m.options.KeyStore,
+	}, precreate, nil
+}
+
+/// @dev Promotes the pending registry to active in a single pointer update. Callers must hold m.mu
+func (m *RevocationRegistryManager) switchToPending() error {
+	pointer := registryPointer{Active: m.pending}
+	if err := m.savePointer(pointer); err != nil {
+		return err
+	}
+	previous := m.active
+	m.active, m.pending = pointer.Active, pointer.Pending
+
+	// Registry states are informational; the pointer above is authoritative
+	if registry, ok := m.registries[previous]; ok && registry.State != RegistryStateFull {
+		updated := *registry
+		updated.State = RegistryStateFull
+		if err := m.saveRegistry(&updated); err == nil {
+			m.registries[previous] = &updated
+		}
+	}
+	if registry, ok := m.registries[m.active]; ok {
+		updated := *registry
+		updated.State = RegistryStateActive
+		if err := m.saveRegistry(&updated); err == nil {
+			m.registries[m.active] = &updated
+		}
+	}
+	return nil
+}
+
+/// @dev Creates the next registry unless one is already pending. With wait unset, gives up
+/// immediately if another goroutine is already creating it
+func (m *RevocationRegistryManager) ensurePending(wait bool) error {
+	if wait {
+		m.createMu.Lock()
+	} else if !m.createMu.TryLock() {
+		return nil
+	}
+	defer m.createMu.Unlock()
+
+	m.mu.Lock()
+	if m.pending != "" {
+		m.mu.Unlock()
+		return nil
+	}
+	tag := strconv.Itoa(len(m.registries))
+	m.mu.Unlock()
+
+	record, err := m.createRegistry(tag)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pointer := registryPointer{Active: m.active, Pending: record.ID}
+	if pointer.Active == "" {
+		pointer = registryPointer{Active: record.ID}
+		record.State = RegistryStateActive
+	}
+	if err := m.saveRegistry(record); err != nil {
+		return err
+	}
+	m.registries[record.ID] = record
+	if err := m.savePointer(pointer); err != nil {
+		return err
+	}
+	m.active, m.pending = pointer.Active, pointer.Pending
+	return nil
+}
+
+/// @dev Creates a registry through the configured hook or anoncreds-rs
+func (m *RevocationRegistryManager) createRegistry(tag string) (*RevocationRegistryRecord, error) {
+	var created *NewRevocationRegistry
+	var err error
+	if m.options.CreateRegistry != nil {
+		created, err = m.options.CreateRegistry(tag)
+	} else {
+		created, err = m.defaultCreateRegistry(tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer created.RevocationRegistryDefinition.Clear()
+	defer created.RevocationRegistryDefinitionPrivate.Clear()
+	defer created.StatusList.Clear()
+
+	regDefJSON, err := created.RevocationRegistryDefinition.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	regDefPrivateJSON, err := created.RevocationRegistryDefinitionPrivate.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	statusListJSON, err := created.StatusList.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	if m.options.KeyStore != nil {
+		if err := m.options.KeyStore.SealRevocationRegistryDefinitionPrivate(created.ID, created.RevocationRegistryDefinitionPrivate); err != nil {
+			return nil, err
+		}
+		regDefPrivateJSON = ""
+	}
+	regDefMap, err := created.RevocationRegistryDefinition.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	value, _ := regDefMap["value"].(map[string]interface{})
+	maxCredNum, _ := value["maxCredNum"].(float64)
+	if maxCredNum < 1 {
+		return nil, fmt.Errorf("revocation registry %s has no capacity", created.ID)
+	}
+
+	record := &RevocationRegistryRecord{
+		ID:                                  created.ID,
+		CredentialDefinitionID:              m.options.CredentialDefinitionID,
+		Tag:                                 tag,
+		State:                               RegistryStatePending,
+		MaxCredNum:                          uint32(maxCredNum),
+		NextIndex:                           1,
+		Issued:                              []uint32{},
+		Revoked:                             []uint32{},
+		RevocationRegistryDefinition:        json.RawMessage(regDefJSON),
+		RevocationRegistryDefinitionPrivate: rawOrNil(regDefPrivateJSON),
+		StatusList:                          json.RawMessage(statusListJSON),
+		CreatedAt:                           m.options.Now().UnixNano(),
+	}
+	if m.options.OnCreated != nil {
+		if err := m.options.OnCreated(record); err != nil {
+			return nil, fmt.Errorf("revocation registry %s: %w", record.ID, err)
+		}
+	}
+	return record, nil
+}
+
+/// @dev Creates a registry definition and its initial status list with anoncreds-rs
+func (m *RevocationRegistryManager) defaultCreateRegistry(tag string) (*NewRevocationRegistry, error) {
+	id, err := m.registryID(tag)
+	if err != nil {
+		return nil, err
+	}
+	result, err := anoncreds.CreateRevocationRegistryDefinition(anoncreds.CreateRevocationRegistryDefinitionOptions{
+		CredentialDefinition:    m.options.CredentialDefinition,
+		CredentialDefinitionID:  m.options.CredentialDefinitionID,
+		IssuerID:                m.options.IssuerID,
+		Tag:                     tag,
+		MaximumCredentialNumber: m.options.MaxCredNum,
+		TailsDirectoryPath:      m.options.TailsDirectoryPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	timestamp := m.options.Now().Unix()
+	statusList, err := anoncreds.CreateRevocationStatusList(anoncreds.CreateRevocationStatusListOptions{
+		CredentialDefinition:                m.options.CredentialDefinition,
+		RevocationRegistryDefinitionID:      id,
+		RevocationRegistryDefinition:        result.RevocationRegistryDefinition,
+		RevocationRegistryDefinitionPrivate: result.RevocationRegistryDefinitionPrivate,
+		IssuerID:                            m.options.IssuerID,
+		IssuanceByDefault:                   true,
+		Timestamp:                           &timestamp,
+	})
+	if err != nil {
+		result.RevocationRegistryDefinition.Clear()
+		result.RevocationRegistryDefinitionPrivate.Clear()
+		return nil, err
+	}
+	return &NewRevocationRegistry{
+		ID:                                  id,
+		RevocationRegistryDefinition:        result.RevocationRegistryDefinition,
+		RevocationRegistryDefinitionPrivate: result.RevocationRegistryDefinitionPrivate,
+		StatusList:                          statusList,
+	}, nil
+}
+
+/// @dev Loads the private definition of a registry from its record or the key store
+func (m *RevocationRegistryManager) registryPrivate(registry *RevocationRegistryRecord) (*anoncreds.RevocationRegistryDefinitionPrivate, error) {
+	return loadRegistryPrivate(registry, m.options.KeyStore)
+}
+
+func loadRegistryPrivate(registry *RevocationRegistryRecord, keys *keystore.KeyStore) (*anoncreds.RevocationRegistryDefinitionPrivate, error) {
+	if len(registry.RevocationRegistryDefinitionPrivate) == 0 {
+		if keys == nil {
+			return nil, fmt.Errorf("revocation registry %s is sealed but no key store is configured", registry.ID)
+		}
+		return keys.OpenRevocationRegistryDefinitionPrivate(registry.ID)
+	}
+	return anoncreds.RevocationRegistryDefinitionPrivateFromJSON([]byte(registry.RevocationRegistryDefinitionPrivate))
+}
+
+/// @dev Returns nil for an empty string so omitempty drops the field
+func rawOrNil(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
+
+/// @dev Derives the ID of a new registry
+func (m *RevocationRegistryManager) registryID(tag string) (string, error) {
+	if m.options.RegistryID != nil {
+		return m.options.RegistryID(tag)
+	}
+	did, _, _, err := ledger.ParseLegacyCredentialDefinitionID(m.options.CredentialDefinitionID)
+	if err != nil {
+		return "", fmt.Errorf("RegistryID is required for non-legacy credential definition %s", m.options.CredentialDefinitionID)
+	}
+	return ledger.LegacyRevocationRegistryID(did, m.options.CredentialDefinitionID, tag), nil
+}
+
+/// @dev Restores the pointer and registries of the credential definition from storage
+func (m *RevocationRegistryManager) load() error {
+	records, err := m.options.Store.List(CategoryRevocationRegistry)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		var registry RevocationRegistryRecord
+		if err := json.Unmarshal(record.Value, &registry); err != nil {
+			return fmt.Errorf("corrupt revocation registry record %s: %w", record.ID, err)
+		}
+		if registry.CredentialDefinitionID == m.options.CredentialDefinitionID {
+			m.registries[registry.ID] = &registry
+		}
+	}
+
+	record, err := m.options.Store.Get(CategoryRevocationPointer, m.options.CredentialDefinitionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pointer registryPointer
+	if err := json.Unmarshal(record.Value, &pointer); err != nil {
+		return fmt.Errorf("corrupt revocation registry pointer: %w", err)
+	}
+	m.active, m.pending = pointer.Active, pointer.Pending
+	return nil
+}
+
+/// @dev Replaces the stored status list JSON of a registry
+func (m *RevocationRegistryManager) setStatusList(revRegDefID string, listJSON string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	registry, ok := m.registries[revRegDefID]
+	if !ok {
+		return fmt.Errorf("unknown revocation registry %s", revRegDefID)
+	}
+	updated := *registry
+	updated.StatusList = json.RawMessage(listJSON)
+	if err := m.saveRegistry(&updated); err != nil {
+		return err
+	}
+	m.registries[revRegDefID] = &updated
+	return nil
+}
+
+/// @dev Persists a registry record
+func (m *RevocationRegistryManager) saveRegistry(registry *RevocationRegistryRecord) error {
+	value, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Put(&storage.Record{
+		Category: CategoryRevocationRegistry,
+		ID:       registry.ID,
+		Value:    value,
+		Tags: map[string]string{
+			"cred_def_id": registry.CredentialDefinitionID,
+			"state":       registry.State,
+		},
+	})
+}
+
+/// @dev Persists the active/pending pointer
+func (m *RevocationRegistryManager) savePointer(pointer registryPointer) error {
+	value, err := json.Marshal(pointer)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Put(&storage.Record{
+		Category: CategoryRevocationPointer,
+		ID:       m.options.CredentialDefinitionID,
+		Value:    value,
+	})
+}
+
+/// @dev Persists a credential's registry location
+func (m *RevocationRegistryManager) saveLocation(location *CredentialRevocationRecord) error {
+	value, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Put(&storage.Record{
+		Category: CategoryRevocationIndex,
+		ID:       location.CredentialID,
+		Value:    value,
+		Tags: map[string]string{
+			"cred_def_id":    location.CredentialDefinitionID,
+			"rev_reg_def_id": location.RevocationRegistryDefinitionID,
+			"rev_reg_index":  strconv.FormatUint(uint64(location.RegistryIndex), 10),
+		},
+	})
+}
+
+/// @dev Returns a deep copy of the record
+func (r *RevocationRegistryRecord) clone() *RevocationRegistryRecord {
+	clone := *r
+	clone.Issued = append([]uint32(nil), r.Issued...)
+	clone.Released = append([]uint32(nil), r.Released...)
+	clone.Revoked = append([]uint32(nil), r.Revoked...)
+	return &clone
+}
+
+/// @dev Inserts an index into a sorted set, returning a new slice
+func insertIndex(set []uint32, index uint32) []uint32 {
+	pos := sort.Search(len(set), func(i int) bool { return set[i] >= index })
+	if pos < len(set) && set[pos] == index {
+		return append([]uint32(nil), set...)
+	}
+	out := make([]uint32, 0, len(set)+1)
+	out = append(out, set[:pos]...)
+	out = append(out, index)
+	return append(out, set[pos:]...)
+}
+
+/// @dev Removes an index from a sorted set, returning a new slice
+func removeIndex(set []uint32, index uint32) []uint32 {
+	out := make([]uint32, 0, len(set))
+	for _, value := range set {
+		if value != index {
+			out = append(out, value)
+		}
+	}
+	return out
+}
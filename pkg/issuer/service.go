@@ -0,0 +1,713 @@
+package issuer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Issuer Service
+/// @dev Persists issuer state around the stateless anoncreds primitives: schemas, credential
+/// definitions with their private parts, outstanding offers and issued credentials
+
+/// @notice Storage categories used by the service
+const (
+	CategorySchema               = "issuer_schema"
+	CategoryCredentialDefinition = "issuer_cred_def"
+	CategoryOffer                = "issuer_offer"
+	CategoryIssuedCredential     = "issuer_credential"
+)
+
+/// @notice Lifecycle states of an offer
+const (
+	OfferStateOffered = "offered" /// @notice Waiting for a credential request
+	OfferStateIssued  = "issued"  /// @notice A credential was issued against the offer
+)
+
+/// @notice Default lifetime of an offer
+const DefaultOfferTTL = 24 * time.Hour
+
+/// @notice Errors returned when an offer cannot be used
+var (
+	ErrOfferNotFound   = fmt.Errorf("offer %w", storage.ErrNotFound)
+	ErrOfferExpired    = errors.New("offer has expired")
+	ErrOfferConsumed   = errors.New("a credential was already issued against this offer")
+	ErrRequestMismatch = errors.New("credential request does not match the offer")
+)
+
+/// @notice A persisted schema
+type SchemaRecord struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Version        string          `json:"version"`
+	IssuerID       string          `json:"issuer_id"`
+	AttributeNames []string        `json:"attr_names"`
+	Schema         json.RawMessage `json:"schema"`
+	CreatedAt      int64           `json:"created_at"`
+}
+
+/// @notice A persisted credential definition, including its private key material
+type CredentialDefinitionRecord struct {
+	ID                          string          `json:"id"`
+	SchemaID                    string          `json:"schema_id"`
+	IssuerID                    string          `json:"issuer_id"`
+	Tag                         string          `json:"tag"`
+	SupportRevocation           bool            `json:"support_revocation"`
+	MaxCredNum                  uint32          `json:"max_cred_num,omitempty"` /// @notice Size of each revocation registry
+	CredentialDefinition        json.RawMessage `json:"cred_def"`
+	CredentialDefinitionPrivate json.RawMessage `json:"cred_def_private,omitempty"` /// @notice Empty when sealed in a KeyStore
+	KeyCorrectnessProof         json.RawMessage `json:"key_correctness_proof"`
+	CreatedAt                   int64           `json:"created_at"`
+}
+
+/// @notice A persisted credential offer
+type CredentialOfferRecord struct {
+	ID                     string          `json:"id"`
+	CredentialDefinitionID string          `json:"cred_def_id"`
+	SchemaID               string          `json:"schema_id"`
+	Nonce                  string          `json:"nonce"`
+	State                  string          `json:"state"`
+	Offer                  json.RawMessage `json:"offer"`
+	CredentialID           string          `json:"credential_id,omitempty"` /// @notice Set once issued
+	CreatedAt              int64           `json:"created_at"`
+	ExpiresAt              int64           `json:"expires_at"`
+}
+
+/// @notice A persisted record of an issued credential
+/// @dev Attribute values are not kept; only their names are
+type IssuedCredentialRecord struct {
+	ID                      string   `json:"id"`
+	OfferID                 string   `json:"offer_id"`
+	CredentialDefinitionID  string   `json:"cred_def_id"`
+	SchemaID                string   `json:"schema_id"`
+	AttributeNames          []string `json:"attr_names"`
+	RevocationRegistryID    string   `json:"rev_reg_id,omitempty"`
+	RevocationRegistryIndex *uint32  `json:"rev_reg_index,omitempty"`
+	IssuedAt                int64    `json:"issued_at"`
+}
+
+/// @notice Options for creating a credential definition through the service
+type CredentialDefinitionOptions struct {
+	ID                string /// @notice Credential definition ID, e.g. as registered on a ledger
+	SchemaID          string
+	IssuerID          string
+	Tag               string
+	SignatureType     string /// @notice Defaults to CL
+	SupportRevocation bool
+	MaxCredNum        uint32 /// @notice Size of each revocation registry; required with SupportRevocation
+}
+
+/// @notice Configuration of an issuer service
+type ServiceOptions struct {
+	Store storage.Store
+
+	/// @notice Lifetime of offers; defaults to DefaultOfferTTL
+	OfferTTL time.Duration
+
+	/// @notice Seals private credential and registry definitions instead of storing them in plaintext records
+	KeyStore *keystore.KeyStore
+
+	/// @notice Directory for tails files of revocable credential definitions
+	TailsDirectoryPath string
+
+	/// @notice Derives registry IDs for non-legacy credential definitions
+	RegistryID func(credDefID, tag string) (string, error)
+
+	/// @notice Called after a revocation registry is created, e.g. to publish it
+	OnRegistryCreated func(record *RevocationRegistryRecord) error
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Issuer facade keeping all issuer state in a storage.Store
+/// @dev Safe for concurrent use; Close frees the revocation managers' objects
+type Service struct {
+	options  ServiceOptions
+	mu       sync.Mutex
+	managers map[string]*serviceManager
+}
+
+/// @dev A revocation manager together with the credential definition object it uses
+type serviceManager struct {
+	manager *RevocationRegistryManager
+	credDef *anoncreds.CredentialDefinition
+	queue   *RevocationQueue /// @dev Opened on first use
+}
+
+/// @notice Creates an issuer service
+func NewService(options ServiceOptions) (*Service, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.OfferTTL <= 0 {
+		options.OfferTTL = DefaultOfferTTL
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &Service{options: options, managers: make(map[string]*serviceManager)}, nil
+}
+
+/// @notice Frees objects held by the service
+func (s *Service) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, managed := range s.managers {
+		managed.credDef.Clear()
+		delete(s.managers, id)
+	}
+}
+
+/// @notice Creates and persists a schema
+/// @param schemaID The schema ID; defaults to the legacy Indy ID derived from the options
+/// @param options Configuration options for the schema
+/// @return The stored record and any error encountered
+func (s *Service) CreateSchema(schemaID string, options anoncreds.CreateSchemaOptions) (*SchemaRecord, error) {
+	schema, err := anoncreds.CreateSchema(options)
+	if err != nil {
+		return nil, err
+	}
+	defer schema.Clear()
+	if schemaID == "" {
+		schemaID = ledger.LegacySchemaID(options.IssuerID, options.Name, options.Version)
+	}
+	return s.AddSchema(schemaID, schema)
+}
+
+/// @notice Persists an existing schema
+/// @return The stored record and any error encountered
+func (s *Service) AddSchema(schemaID string, schema *anoncreds.Schema) (*SchemaRecord, error) {
+	if schemaID == "" || schema == nil {
+		return nil, fmt.Errorf("schema and schema id are required")
+	}
+	schemaJSON, err := schema.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	var contents struct {
+		Name      string   `json:"name"`
+		Version   string   `json:"version"`
+		IssuerID  string   `json:"issuerId"`
+		AttrNames []string `json:"attrNames"`
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &contents); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	record := &SchemaRecord{
+		ID:             schemaID,
+		Name:           contents.Name,
+		Version:        contents.Version,
+		IssuerID:       contents.IssuerID,
+		AttributeNames: contents.AttrNames,
+		Schema:         json.RawMessage(schemaJSON),
+		CreatedAt:      s.options.Now().Unix(),
+	}
+	if err := s.put(CategorySchema, record.ID, record, map[string]string{"name": record.Name, "version": record.Version}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Returns a stored schema
+func (s *Service) Schema(schemaID string) (*SchemaRecord, error) {
+	var record SchemaRecord
+	if err := s.get(CategorySchema, schemaID, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+/// @notice Creates and persists a credential definition for a stored schema
+/// @param options Configuration options for the credential definition
+/// @return The stored record and any error encountered
+func (s *Service) CreateCredentialDefinition(options CredentialDefinitionOptions) (*CredentialDefinitionRecord, error) {
+	if options.ID == "" {
+		return nil, fmt.Errorf("credential definition id is required")
+	}
+	if options.SignatureType == "" {
+		options.SignatureType = "CL"
+	}
+	schemaRecord, err := s.Schema(options.SchemaID)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := anoncreds.SchemaFromJSON([]byte(schemaRecord.Schema))
+	if err != nil {
+		return nil, err
+	}
+	defer schema.Clear()
+
+	result, err := anoncreds.CreateCredentialDefinition(anoncreds.CreateCredentialDefinitionOptions{
+		SchemaID:          options.SchemaID,
+		Schema:            schema,
+		IssuerID:          options.IssuerID,
+		Tag:               options.Tag,
+		SignatureType:     options.SignatureType,
+		SupportRevocation: options.SupportRevocation,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.CredentialDefinition.Clear()
+	defer result.CredentialDefinitionPrivate.Clear()
+	defer result.KeyCorrectnessProof.Clear()
+	return s.AddCredentialDefinition(options, result)
+}
+
+/// @notice Persists an existing credential definition with its private part and key correctness proof
+/// @param options The ID, schema ID and revocation settings of the credential definition
+/// @param credDef The credential definition objects; the caller keeps ownership
+/// @return The stored record and any error encountered
+func (s *Service) AddCredentialDefinition(options CredentialDefinitionOptions, credDef *anoncreds.CreateCredentialDefinitionResult) (*CredentialDefinitionRecord, error) {
+	if options.ID == "" || options.SchemaID == "" {
+		return nil, fmt.Errorf("credential definition id and schema id are required")
+	}
+	if credDef == nil || credDef.CredentialDefinition == nil || credDef.CredentialDefinitionPrivate == nil || credDef.KeyCorrectnessProof == nil {
+		return nil, fmt.Errorf("credential definition, private part and key correctness proof are required")
+	}
+	if options.SupportRevocation && options.MaxCredNum == 0 {
+		return nil, fmt.Errorf("max credential number is required for revocable credential definitions")
+	}
+	credDefJSON, err := credDef.CredentialDefinition.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	var privateJSON json.RawMessage
+	if s.options.KeyStore != nil {
+		if err := s.options.KeyStore.SealCredentialDefinitionPrivate(options.ID, credDef.CredentialDefinitionPrivate); err != nil {
+			return nil, err
+		}
+	} else {
+		jsonStr, err := credDef.CredentialDefinitionPrivate.ToJSONString()
+		if err != nil {
+			return nil, err
+		}
+		privateJSON = json.RawMessage(jsonStr)
+	}
+	kcpJSON, err := credDef.KeyCorrectnessProof.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	record := &CredentialDefinitionRecord{
+		ID:                          options.ID,
+		SchemaID:                    options.SchemaID,
+		IssuerID:                    options.IssuerID,
+		Tag:                         options.Tag,
+		SupportRevocation:           options.SupportRevocation,
+		MaxCredNum:                  options.MaxCredNum,
+		CredentialDefinition:        json.RawMessage(credDefJSON),
+		CredentialDefinitionPrivate: privateJSON,
+		KeyCorrectnessProof:         json.RawMessage(kcpJSON),
+		CreatedAt:                   s.options.Now().Unix(),
+	}
+	if err := s.put(CategoryCredentialDefinition, record.ID, record, map[string]string{"schema_id": record.SchemaID}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Returns a stored credential definition
+func (s *Service) CredentialDefinition(credDefID string) (*CredentialDefinitionRecord, error) {
+	var record CredentialDefinitionRecord
+	if err := s.get(CategoryCredentialDefinition, credDefID, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+/// @notice Returns every stored credential definition
+func (s *Service) CredentialDefinitions() ([]*CredentialDefinitionRecord, error) {
+	stored, err := s.options.Store.List(CategoryCredentialDefinition)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*CredentialDefinitionRecord, 0, len(stored))
+	for _, entry := range stored {
+		var record CredentialDefinitionRecord
+		if err := json.Unmarshal(entry.Value, &record); err != nil {
+			return nil, fmt.Errorf("corrupt %s record %s: %w", CategoryCredentialDefinition, entry.ID, err)
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+/// @notice Creates and persists an offer for a stored credential definition
+/// @param credDefID The credential definition to offer
+/// @return The stored offer; its Offer field is the JSON to send to the holder
+func (s *Service) Offer(credDefID string) (*CredentialOfferRecord, error) {
+	credDef, err := s.CredentialDefinition(credDefID)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := anoncreds.CreateCredentialOffer(anoncreds.CreateCredentialOfferOptions{
+		SchemaID:               credDef.SchemaID,
+		CredentialDefinitionID: credDef.ID,
+		KeyCorrectnessProof:    string(credDef.KeyCorrectnessProof),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer offer.Clear()
+	offerJSON, err := offer.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	var contents struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal([]byte(offerJSON), &contents); err != nil {
+		return nil, fmt.Errorf("invalid credential offer: %w", err)
+	}
+
+	id, err := newRecordID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.options.Now()
+	record := &CredentialOfferRecord{
+		ID:                     id,
+		CredentialDefinitionID: credDef.ID,
+		SchemaID:               credDef.SchemaID,
+		Nonce:                  contents.Nonce,
+		State:                  OfferStateOffered,
+		Offer:                  json.RawMessage(offerJSON),
+		CreatedAt:              now.Unix(),
+		ExpiresAt:              now.Add(s.options.OfferTTL).Unix(),
+	}
+	if err := s.putOffer(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Returns a stored offer
+func (s *Service) CredentialOffer(offerID string) (*CredentialOfferRecord, error) {
+	var record CredentialOfferRecord
+	if err := s.get(CategoryOffer, offerID, &record); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrOfferNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+/// @notice Issues a credential against a live offer
+/// @param offerID The offer the holder answered
+/// @param request The holder's credential request
+/// @param values Raw attribute values; must cover the schema's attributes exactly
+/// @return The credential, owned by the caller, its issuance record and any error encountered
+/// @dev The offer is consumed before the credential is created, so a crash never allows a second issuance
+func (s *Service) Issue(offerID string, request *anoncreds.CredentialRequest, values map[string]string) (*anoncreds.Credential, *IssuedCredentialRecord, error) {
+	if request == nil {
+		return nil, nil, fmt.Errorf("credential request is required")
+	}
+	requestJSON, err := request.ToJSONString()
+	if err != nil {
+		return nil, nil, err
+	}
+	var requestContents struct {
+		CredentialDefinitionID string `json:"cred_def_id"`
+	}
+	if err := json.Unmarshal([]byte(requestJSON), &requestContents); err != nil {
+		return nil, nil, fmt.Errorf("invalid credential request: %w", err)
+	}
+
+	offer, err := s.claimOffer(offerID, requestContents.CredentialDefinitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	credential, issued, err := s.issue(offer, request, values)
+	if err != nil {
+		// Nothing was issued, so the offer can be answered again
+		offer.State, offer.CredentialID = OfferStateOffered, ""
+		if releaseErr := s.putOffer(offer); releaseErr != nil {
+			return nil, nil, fmt.Errorf("%w (releasing offer: %v)", err, releaseErr)
+		}
+		return nil, nil, err
+	}
+	return credential, issued, nil
+}
+
+/// @notice Returns a stored issuance record
+func (s *Service) IssuedCredential(credentialID string) (*IssuedCredentialRecord, error) {
+	var record IssuedCredentialRecord
+	if err := s.get(CategoryIssuedCredential, credentialID, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+/// @notice Returns the revocation registry manager of a revocable credential definition
+/// @dev The service owns the manager; it remains valid until Close
+func (s *Service) RevocationManager(credDefID string) (*RevocationRegistryManager, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revocationManager(credDefID)
+}
+
+/// @notice Returns the revocation queue of a revocable credential definition
+/// @dev The service owns the queue; it remains valid until Close
+func (s *Service) RevocationQueue(credDefID string) (*RevocationQueue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.revocationManager(credDefID); err != nil {
+		return nil, err
+	}
+	managed := s.managers[credDefID]
+	if managed.queue == nil {
+		queue, err := NewRevocationQueue(RevocationQueueOptions{
+			Manager:              managed.manager,
+			CredentialDefinition: managed.credDef,
+			Now:                  s.options.Now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		managed.queue = queue
+	}
+	return managed.queue, nil
+}
+
+/// @dev Marks a live offer as issued after checking the request targets its credential definition
+func (s *Service) claimOffer(offerID, credDefID string) (*CredentialOfferRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offer, err := s.CredentialOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case offer.State != OfferStateOffered:
+		return nil, ErrOfferConsumed
+	case s.options.Now().Unix() >= offer.ExpiresAt:
+		return nil, ErrOfferExpired
+	case credDefID != offer.CredentialDefinitionID:
+		return nil, fmt.Errorf("%w: requested %s, offered %s", ErrRequestMismatch, credDefID, offer.CredentialDefinitionID)
+	}
+
+	credentialID, err := newRecordID()
+	if err != nil {
+		return nil, err
+	}
+	offer.State, offer.CredentialID = OfferStateIssued, credentialID
+	if err := s.putOffer(offer); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+/// @dev Creates the credential for a claimed offer and records it
+func (s *Service) issue(offer *CredentialOfferRecord, request *anoncreds.CredentialRequest, values map[string]string) (*anoncreds.Credential, *IssuedCredentialRecord, error) {
+	credDefRecord, err := s.CredentialDefinition(offer.CredentialDefinitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := s.Schema(credDefRecord.SchemaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkValues(schema.AttributeNames, values); err != nil {
+		return nil, nil, err
+	}
+
+	credDef, err := anoncreds.CredentialDefinitionFromJSON([]byte(credDefRecord.CredentialDefinition))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer credDef.Clear()
+	credDefPrivate, err := s.credentialDefinitionPrivate(credDefRecord)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer credDefPrivate.Clear()
+	credOffer, err := anoncreds.CredentialOfferFromJSON([]byte(offer.Offer))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer credOffer.Clear()
+
+	options := anoncreds.CreateCredentialOptions{
+		CredentialDefinition:        credDef,
+		CredentialDefinitionPrivate: credDefPrivate,
+		CredentialOffer:             credOffer,
+		CredentialRequest:           request,
+		AttributeRawValues:          values,
+	}
+	issued := &IssuedCredentialRecord{
+		ID:                     offer.CredentialID,
+		OfferID:                offer.ID,
+		CredentialDefinitionID: offer.CredentialDefinitionID,
+		SchemaID:               offer.SchemaID,
+		AttributeNames:         append([]string(nil), schema.AttributeNames...),
+	}
+	recorded := false
+	if credDefRecord.SupportRevocation {
+		s.mu.Lock()
+		manager, err := s.revocationManager(credDefRecord.ID)
+		s.mu.Unlock()
+		if err != nil {
+			return nil, nil, err
+		}
+		allocation, err := manager.Allocate(offer.CredentialID)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer allocation.Clear()
+		// The index goes back to the registry unless the credential is issued and recorded.
+		// A failed release only leaves the index unused
+		defer func() {
+			if !recorded {
+				manager.Release(allocation)
+			}
+		}()
+		if options.RevocationConfig, err = allocation.RevocationConfig(); err != nil {
+			return nil, nil, err
+		}
+		index := allocation.RegistryIndex
+		issued.RevocationRegistryID = allocation.RevocationRegistryDefinitionID
+		issued.RevocationRegistryIndex = &index
+	}
+
+	credential, err := anoncreds.CreateCredential(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	issued.IssuedAt = s.options.Now().Unix()
+	if err := s.put(CategoryIssuedCredential, issued.ID, issued, map[string]string{
+		"offer_id":    issued.OfferID,
+		"cred_def_id": issued.CredentialDefinitionID,
+	}); err != nil {
+		credential.Clear()
+		return nil, nil, err
+	}
+	recorded = true
+	return credential, issued, nil
+}
+
+/// @dev Returns the cached manager of a credential definition, creating it on first use. Callers must hold s.mu
+func (s *Service) revocationManager(credDefID string) (*RevocationRegistryManager, error) {
+	if managed, ok := s.managers[credDefID]; ok {
+		return managed.manager, nil
+	}
+	record, err := s.CredentialDefinition(credDefID)
+	if err != nil {
+		return nil, err
+	}
+	if !record.SupportRevocation {
+		return nil, fmt.Errorf("credential definition %s does not support revocation", credDefID)
+	}
+	credDef, err := anoncreds.CredentialDefinitionFromJSON([]byte(record.CredentialDefinition))
+	if err != nil {
+		return nil, err
+	}
+	options := RevocationRegistryManagerOptions{
+		Store:                  s.options.Store,
+		CredentialDefinitionID: credDefID,
+		CredentialDefinition:   credDef,
+		IssuerID:               record.IssuerID,
+		MaxCredNum:             record.MaxCredNum,
+		TailsDirectoryPath:     s.options.TailsDirectoryPath,
+		KeyStore:               s.options.KeyStore,
+		OnCreated:              s.options.OnRegistryCreated,
+		Now:                    s.options.Now,
+	}
+	if s.options.RegistryID != nil {
+		options.RegistryID = func(tag string) (string, error) {
+			return s.options.RegistryID(credDefID, tag)
+		}
+	}
+	manager, err := NewRevocationRegistryManager(options)
+	if err != nil {
+		credDef.Clear()
+		return nil, err
+	}
+	s.managers[credDefID] = &serviceManager{manager: manager, credDef: credDef}
+	return manager, nil
+}
+
+/// @dev Loads the private credential definition from its record or the key store
+func (s *Service) credentialDefinitionPrivate(record *CredentialDefinitionRecord) (*anoncreds.CredentialDefinitionPrivate, error) {
+	if len(record.CredentialDefinitionPrivate) == 0 {
+		if s.options.KeyStore == nil {
+			return nil, fmt.Errorf("credential definition %s is sealed but no key store is configured", record.ID)
+		}
+		return s.options.KeyStore.OpenCredentialDefinitionPrivate(record.ID)
+	}
+	return anoncreds.CredentialDefinitionPrivateFromJSON([]byte(record.CredentialDefinitionPrivate))
+}
+
+/// @dev Checks that values name every schema attribute and nothing else
+func checkValues(attributeNames []string, values map[string]string) error {
+	expected := make(map[string]bool, len(attributeNames))
+	for _, name := range attributeNames {
+		expected[name] = true
+	}
+	var missing, unknown []string
+	for _, name := range attributeNames {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for name := range values {
+		if !expected[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	switch {
+	case len(missing) > 0:
+		return fmt.Errorf("missing values for attributes %v", missing)
+	case len(unknown) > 0:
+		return fmt.Errorf("values for unknown attributes %v", unknown)
+	}
+	return nil
+}
+
+func (s *Service) putOffer(offer *CredentialOfferRecord) error {
+	return s.put(CategoryOffer, offer.ID, offer, map[string]string{
+		"cred_def_id": offer.CredentialDefinitionID,
+		"state":       offer.State,
+	})
+}
+
+func (s *Service) put(category, id string, value interface{}, tags map[string]string) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.options.Store.Put(&storage.Record{Category: category, ID: id, Value: encoded, Tags: tags})
+}
+
+func (s *Service) get(category, id string, value interface{}) error {
+	record, err := s.options.Store.Get(category, id)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(record.Value, value); err != nil {
+		return fmt.Errorf("corrupt %s record %s: %w", category, id, err)
+	}
+	return nil
+}
+
+/// @dev Returns a random 128-bit hex identifier
+func newRecordID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,277 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"golang.org/x/crypto/argon2"
+)
+
+/// @title Key-Encryption Keys
+/// @dev KEKs wrap the per-object data keys of a KeyStore. Local keys wrap with AES-256-GCM;
+/// a KMS can take their place by implementing KeyEncryptionKey
+
+/// @notice Storage category holding passphrase KEK parameters
+const CategoryKEK = "keystore_kek"
+
+/// @notice Size in bytes of data keys and local KEKs
+const KeySize = 32
+
+/// @notice Returned when a passphrase does not match the one a KEK was created with
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+/// @notice Wraps and unwraps data keys, e.g. through a KMS
+/// @dev ID must be stable: sealed objects name the KEK that wrapped their data key
+type KeyEncryptionKey interface {
+	ID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+/// @notice A KEK held in process memory
+type LocalKEK struct {
+	id   string
+	aead cipher.AEAD
+}
+
+/// @notice Creates a KEK from 32 bytes of key material
+/// @param id Stable identifier recorded with every object the KEK wraps
+/// @param key The key material; the caller may wipe it afterwards
+func NewLocalKEK(id string, key []byte) (*LocalKEK, error) {
+	if id == "" {
+		return nil, fmt.Errorf("kek id is required")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("kek must be %d bytes, got %d", KeySize, len(key))
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKEK{id: id, aead: aead}, nil
+}
+
+/// @notice Returns the KEK's identifier
+func (k *LocalKEK) ID() string { return k.id }
+
+/// @notice Encrypts a data key, binding it to the KEK ID
+func (k *LocalKEK) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, []byte("kek:"+k.id))
+}
+
+/// @notice Decrypts a data key wrapped by this KEK
+func (k *LocalKEK) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped, []byte("kek:"+k.id))
+}
+
+/// @notice Derives key material from a passphrase
+type KDF interface {
+	/// @notice Name recorded with the KEK so the same KDF is used to re-derive it
+	Name() string
+	/// @notice Derives KeySize bytes from the passphrase and salt
+	Derive(passphrase, salt []byte) ([]byte, error)
+}
+
+/// @notice PBKDF2-HMAC-SHA256, for deployments restricted to FIPS-approved KDFs
+type PBKDF2 struct {
+	Iterations int /// @notice Defaults to DefaultPBKDF2Iterations
+}
+
+/// @notice Default PBKDF2 iteration count, following the OWASP recommendation for SHA-256
+const DefaultPBKDF2Iterations = 600000
+
+/// @notice Returns the KDF name including its cost
+func (p PBKDF2) Name() string {
+	return fmt.Sprintf("pbkdf2-sha256:%d", p.iterations())
+}
+
+/// @notice Derives KeySize bytes from the passphrase and salt
+func (p PBKDF2) Derive(passphrase, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(passphrase), salt, p.iterations(), KeySize)
+}
+
+func (p PBKDF2) iterations() int {
+	if p.Iterations <= 0 {
+		return DefaultPBKDF2Iterations
+	}
+	return p.Iterations
+}
+
+/// @notice Argon2id with the cost recorded in its name, the default passphrase KDF
+type Argon2id struct {
+	Time    uint32 /// @notice Passes over memory; defaults to DefaultArgon2idTime
+	Memory  uint32 /// @notice Memory in KiB; defaults to DefaultArgon2idMemory
+	Threads uint8  /// @notice Lanes; defaults to DefaultArgon2idThreads
+}
+
+/// @notice Default Argon2id cost, the second RFC 9106 recommendation
+const (
+	DefaultArgon2idTime    = 3
+	DefaultArgon2idMemory  = 64 * 1024
+	DefaultArgon2idThreads = 4
+)
+
+/// @notice Returns the KDF name including its cost
+func (a Argon2id) Name() string {
+	a = a.withDefaults()
+	return fmt.Sprintf("argon2id:%d:%d:%d", a.Time, a.Memory, a.Threads)
+}
+
+/// @notice Derives KeySize bytes from the passphrase and salt
+func (a Argon2id) Derive(passphrase, salt []byte) ([]byte, error) {
+	a = a.withDefaults()
+	return argon2.IDKey(passphrase, salt, a.Time, a.Memory, a.Threads, KeySize), nil
+}
+
+func (a Argon2id) withDefaults() Argon2id {
+	if a.Time == 0 {
+		a.Time = DefaultArgon2idTime
+	}
+	if a.Memory == 0 {
+		a.Memory = DefaultArgon2idMemory
+	}
+	if a.Threads == 0 {
+		a.Threads = DefaultArgon2idThreads
+	}
+	return a
+}
+
+/// @notice Returns the KDF a name returned by Name refers to
+func ParseKDF(name string) (KDF, error) {
+	var kdf KDF
+	switch {
+	case strings.HasPrefix(name, "pbkdf2-sha256:"):
+		var p PBKDF2
+		if _, err := fmt.Sscanf(name, "pbkdf2-sha256:%d", &p.Iterations); err == nil && p.Iterations > 0 {
+			kdf = p
+		}
+	case strings.HasPrefix(name, "argon2id:"):
+		var a Argon2id
+		if _, err := fmt.Sscanf(name, "argon2id:%d:%d:%d", &a.Time, &a.Memory, &a.Threads); err == nil && a.Time > 0 && a.Memory > 0 && a.Threads > 0 {
+			kdf = a
+		}
+	}
+	if kdf == nil || kdf.Name() != name {
+		return nil, fmt.Errorf("unsupported kdf %q", name)
+	}
+	return kdf, nil
+}
+
+/// @dev Persisted parameters of a passphrase KEK
+type passphraseRecord struct {
+	KDF   string `json:"kdf"`
+	Salt  []byte `json:"salt"`
+	Check []byte `json:"check"` /// @dev A wrapped constant that detects a wrong passphrase
+}
+
+/// @dev Plaintext of the passphrase check value
+var passphraseCheck = []byte("anoncreds-go keystore kek check")
+
+/// @notice Derives a KEK from a passphrase, creating its salt on first use
+/// @param store Where the salt and KDF name are kept
+/// @param id Identifier of the KEK
+/// @param passphrase The passphrase
+/// @param kdf The KDF; must match the one the KEK was created with. Defaults to Argon2id
+/// @return The KEK, or ErrWrongPassphrase if the passphrase does not match
+func PassphraseKEK(store storage.Store, id string, passphrase []byte, kdf KDF) (*LocalKEK, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase is required")
+	}
+	if kdf == nil {
+		kdf = Argon2id{}
+	}
+
+	var record passphraseRecord
+	stored, err := store.Get(CategoryKEK, id)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		record = passphraseRecord{KDF: kdf.Name(), Salt: make([]byte, 16)}
+		if _, err := rand.Read(record.Salt); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(stored.Value, &record); err != nil {
+			return nil, fmt.Errorf("corrupt kek record %s: %w", id, err)
+		}
+		if record.KDF != kdf.Name() {
+			return nil, fmt.Errorf("kek %s was derived with %s, not %s", id, record.KDF, kdf.Name())
+		}
+	}
+
+	key, err := kdf.Derive(passphrase, record.Salt)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(key)
+	kek, err := NewLocalKEK(id, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Check != nil {
+		check, err := kek.Unwrap(record.Check)
+		if err != nil || subtle.ConstantTimeCompare(check, passphraseCheck) != 1 {
+			return nil, ErrWrongPassphrase
+		}
+		return kek, nil
+	}
+	if record.Check, err = kek.Wrap(passphraseCheck); err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Put(&storage.Record{Category: CategoryKEK, ID: id, Value: value, Tags: map[string]string{"kdf": record.KDF}}); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/// @dev Encrypts with a random nonce, returning nonce || ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+/// @dev Decrypts nonce || ciphertext
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+/// @dev Overwrites a secret buffer with zeros
+func wipe(buffer []byte) {
+	for i := range buffer {
+		buffer[i] = 0
+	}
+}
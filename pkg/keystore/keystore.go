@@ -0,0 +1,385 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Key Store
+/// @dev Envelope encryption for issuer private material. Each object is sealed with its own
+/// AES-256-GCM data key, and the data key is wrapped by a key-encryption key. Rotating the KEK
+/// re-wraps the data keys without touching the sealed objects. Records are keyed by type and ID,
+/// so objects of different types may share an ID
+
+/// @notice Storage category holding sealed objects
+const CategorySealedObject = "keystore_object"
+
+/// @notice Object types sealed by the typed helpers
+const (
+	ObjectTypeCredentialDefinitionPrivate         = "CredentialDefinitionPrivate"
+	ObjectTypeRevocationRegistryDefinitionPrivate = "RevocationRegistryDefinitionPrivate"
+	ObjectTypeLinkSecret                          = "LinkSecret"
+)
+
+/// @notice Returned when an object does not exist; matches storage.ErrNotFound with errors.Is
+var ErrObjectNotFound = fmt.Errorf("sealed object %w", storage.ErrNotFound)
+
+/// @dev Persisted form of a sealed object
+type sealedObject struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	KEKID      string `json:"kek_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+	CreatedAt  int64  `json:"created_at"`
+	RotatedAt  int64  `json:"rotated_at,omitempty"`
+}
+
+/// @notice Audit entry describing a sealed object without revealing it
+type AuditEntry struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	KEKID     string `json:"kek_id"`
+	Size      int    `json:"size"` /// @notice Ciphertext length in bytes
+	CreatedAt int64  `json:"created_at"`
+	RotatedAt int64  `json:"rotated_at,omitempty"`
+}
+
+/// @notice Configuration of a key store
+type KeyStoreOptions struct {
+	Store storage.Store
+
+	/// @notice KEK used to wrap new data keys
+	KEK KeyEncryptionKey
+
+	/// @notice Older KEKs that may still wrap some objects, e.g. during rotation
+	PreviousKEKs []KeyEncryptionKey
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Encrypted at-rest storage for private objects
+/// @dev Safe for concurrent use
+type KeyStore struct {
+	options KeyStoreOptions
+	mu      sync.RWMutex
+	current KeyEncryptionKey
+	keys    map[string]KeyEncryptionKey
+}
+
+/// @notice Creates a key store
+func NewKeyStore(options KeyStoreOptions) (*KeyStore, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.KEK == nil {
+		return nil, fmt.Errorf("kek is required")
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	k := &KeyStore{options: options, current: options.KEK, keys: map[string]KeyEncryptionKey{}}
+	for _, kek := range options.PreviousKEKs {
+		k.keys[kek.ID()] = kek
+	}
+	k.keys[options.KEK.ID()] = options.KEK
+	return k, nil
+}
+
+/// @notice Returns the ID of the KEK that wraps new objects
+func (k *KeyStore) KEKID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current.ID()
+}
+
+/// @notice Seals and stores an object, replacing any object with the same ID and type
+/// @param id Identifier of the object, e.g. its credential definition ID; unique per type
+/// @param objectType Type recorded for auditing and checked on Open
+/// @param plaintext The object; the caller may wipe it afterwards
+func (k *KeyStore) Seal(id, objectType string, plaintext []byte) error {
+	if id == "" || objectType == "" {
+		return fmt.Errorf("object id and type are required")
+	}
+	k.mu.RLock()
+	kek := k.current
+	k.mu.RUnlock()
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	defer wipe(dataKey)
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := seal(aead, plaintext, objectAAD(id, objectType))
+	if err != nil {
+		return err
+	}
+	wrapped, err := kek.Wrap(dataKey)
+	if err != nil {
+		return err
+	}
+	return k.put(&sealedObject{
+		ID:         id,
+		Type:       objectType,
+		KEKID:      kek.ID(),
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+		CreatedAt:  k.options.Now().Unix(),
+	})
+}
+
+/// @notice Reports whether an object of the given type is stored under an ID
+func (k *KeyStore) Has(id, objectType string) (bool, error) {
+	_, err := k.get(id, objectType)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+/// @notice Decrypts a stored object
+/// @param id Identifier of the object
+/// @param objectType The expected type
+/// @return The plaintext, which the caller should wipe when done, and any error encountered
+func (k *KeyStore) Open(id, objectType string) ([]byte, error) {
+	object, err := k.get(id, objectType)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := k.unwrap(object)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dataKey)
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, object.Ciphertext, objectAAD(object.ID, object.Type))
+}
+
+/// @notice Removes a sealed object
+/// @param id Identifier of the object
+/// @param objectType Its type; objects of other types under the same ID are kept
+func (k *KeyStore) Delete(id, objectType string) error {
+	err := k.options.Store.Delete(CategorySealedObject, objectRecordID(id, objectType))
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrObjectNotFound
+	}
+	return err
+}
+
+/// @notice Switches to a new KEK and re-wraps every data key with it
+/// @param kek The new KEK
+/// @return The number of objects re-wrapped and any error encountered
+/// @dev Objects already under the new KEK are skipped, so an interrupted rotation can be rerun
+func (k *KeyStore) Rotate(kek KeyEncryptionKey) (int, error) {
+	if kek == nil {
+		return 0, fmt.Errorf("kek is required")
+	}
+	k.mu.Lock()
+	k.keys[kek.ID()] = kek
+	k.current = kek
+	k.mu.Unlock()
+
+	records, err := k.options.Store.List(CategorySealedObject)
+	if err != nil {
+		return 0, err
+	}
+	rewrapped := 0
+	for _, record := range records {
+		object, err := decodeSealedObject(record)
+		if err != nil {
+			return rewrapped, err
+		}
+		if object.KEKID == kek.ID() {
+			continue
+		}
+		dataKey, err := k.unwrap(object)
+		if err != nil {
+			return rewrapped, err
+		}
+		object.WrappedKey, err = kek.Wrap(dataKey)
+		wipe(dataKey)
+		if err != nil {
+			return rewrapped, err
+		}
+		object.KEKID = kek.ID()
+		object.RotatedAt = k.options.Now().Unix()
+		if err := k.put(object); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, nil
+}
+
+/// @notice Lists the sealed objects without decrypting them
+/// @return Audit entries ordered by ID and type and any error encountered
+func (k *KeyStore) Audit() ([]AuditEntry, error) {
+	records, err := k.options.Store.List(CategorySealedObject)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]AuditEntry, 0, len(records))
+	for _, record := range records {
+		object, err := decodeSealedObject(record)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, AuditEntry{
+			ID:        object.ID,
+			Type:      object.Type,
+			KEKID:     object.KEKID,
+			Size:      len(object.Ciphertext),
+			CreatedAt: object.CreatedAt,
+			RotatedAt: object.RotatedAt,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ID != entries[j].ID {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].Type < entries[j].Type
+	})
+	return entries, nil
+}
+
+/// @notice Seals a private credential definition
+func (k *KeyStore) SealCredentialDefinitionPrivate(credDefID string, private *anoncreds.CredentialDefinitionPrivate) error {
+	if private == nil {
+		return fmt.Errorf("credential definition private is required")
+	}
+	return k.sealObject(credDefID, ObjectTypeCredentialDefinitionPrivate, private.ObjectHandle)
+}
+
+/// @notice Loads a sealed private credential definition
+/// @return An object owned by the caller and any error encountered
+func (k *KeyStore) OpenCredentialDefinitionPrivate(credDefID string) (*anoncreds.CredentialDefinitionPrivate, error) {
+	plaintext, err := k.Open(credDefID, ObjectTypeCredentialDefinitionPrivate)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(plaintext)
+	return anoncreds.CredentialDefinitionPrivateFromJSON(plaintext)
+}
+
+/// @notice Seals a private revocation registry definition
+func (k *KeyStore) SealRevocationRegistryDefinitionPrivate(revRegDefID string, private *anoncreds.RevocationRegistryDefinitionPrivate) error {
+	if private == nil {
+		return fmt.Errorf("revocation registry definition private is required")
+	}
+	return k.sealObject(revRegDefID, ObjectTypeRevocationRegistryDefinitionPrivate, private.ObjectHandle)
+}
+
+/// @notice Loads a sealed private revocation registry definition
+/// @return An object owned by the caller and any error encountered
+func (k *KeyStore) OpenRevocationRegistryDefinitionPrivate(revRegDefID string) (*anoncreds.RevocationRegistryDefinitionPrivate, error) {
+	plaintext, err := k.Open(revRegDefID, ObjectTypeRevocationRegistryDefinitionPrivate)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(plaintext)
+	return anoncreds.RevocationRegistryDefinitionPrivateFromJSON(plaintext)
+}
+
+/// @notice Seals a holder link secret
+func (k *KeyStore) SealLinkSecret(id string, secret *anoncreds.LinkSecret) error {
+	if secret == nil || secret.Wiped() {
+		return fmt.Errorf("link secret is required")
+	}
+	plaintext := secret.Bytes()
+	defer wipe(plaintext)
+	return k.Seal(id, ObjectTypeLinkSecret, plaintext)
+}
+
+/// @notice Loads a sealed link secret
+/// @return A link secret the caller should wipe when done and any error encountered
+func (k *KeyStore) OpenLinkSecret(id string) (*anoncreds.LinkSecret, error) {
+	plaintext, err := k.Open(id, ObjectTypeLinkSecret)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(plaintext)
+	return anoncreds.LinkSecretFromBytes(plaintext), nil
+}
+
+func (k *KeyStore) sealObject(id, objectType string, handle *anoncreds.ObjectHandle) error {
+	jsonStr, err := handle.ToJSONString()
+	if err != nil {
+		return err
+	}
+	plaintext := []byte(jsonStr)
+	defer wipe(plaintext)
+	return k.Seal(id, objectType, plaintext)
+}
+
+/// @dev Unwraps an object's data key with the KEK that wrapped it
+func (k *KeyStore) unwrap(object *sealedObject) ([]byte, error) {
+	k.mu.RLock()
+	kek, ok := k.keys[object.KEKID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("object %s is wrapped by unknown kek %s", object.ID, object.KEKID)
+	}
+	dataKey, err := kek.Unwrap(object.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", object.ID, err)
+	}
+	return dataKey, nil
+}
+
+func (k *KeyStore) get(id, objectType string) (*sealedObject, error) {
+	record, err := k.options.Store.Get(CategorySealedObject, objectRecordID(id, objectType))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return decodeSealedObject(record)
+}
+
+func (k *KeyStore) put(object *sealedObject) error {
+	value, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return k.options.Store.Put(&storage.Record{
+		Category: CategorySealedObject,
+		ID:       objectRecordID(object.ID, object.Type),
+		Value:    value,
+		Tags:     map[string]string{"type": object.Type, "kek_id": object.KEKID},
+	})
+}
+
+func decodeSealedObject(record *storage.Record) (*sealedObject, error) {
+	var object sealedObject
+	if err := json.Unmarshal(record.Value, &object); err != nil {
+		return nil, fmt.Errorf("corrupt sealed object %s: %w", record.ID, err)
+	}
+	return &object, nil
+}
+
+/// @dev Storage record ID of an object
+func objectRecordID(id, objectType string) string {
+	return objectType + "/" + id
+}
+
+/// @dev Binds ciphertext to its ID and type so records cannot be swapped
+func objectAAD(id, objectType string) []byte {
+	return []byte(objectType + "\x00" + id)
+}
@@ -0,0 +1,145 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/// @title Hyperledger Indy Ledger Transactions
+/// @dev Builds Indy ledger requests from AnonCreds objects and converts ledger replies
+/// back into the JSON accepted by the anoncreds FromJSON constructors
+
+/// @notice Indy transaction type codes used by the AnonCreds objects
+const (
+	TxnTypeGetTransaction = "3"
+	TxnTypeSchema         = "101"
+	TxnTypeCredDef        = "102"
+	TxnTypeGetSchema      = "107"
+	TxnTypeGetCredDef     = "108"
+	TxnTypeRevRegDef      = "113"
+	TxnTypeRevRegEntry    = "114"
+	TxnTypeGetRevRegDef   = "115"
+	TxnTypeGetRevRegDelta = "117"
+)
+
+/// @notice Ledger identifiers accepted by GET_TXN requests
+const (
+	LedgerPool   = 0
+	LedgerDomain = 1
+	LedgerConfig = 2
+)
+
+/// @notice Revocation registry constants understood by Indy nodes
+const (
+	RevocationDefTypeCLAccum = "CL_ACCUM"
+	IssuanceByDefault        = "ISSUANCE_BY_DEFAULT"
+	IssuanceOnDemand         = "ISSUANCE_ON_DEMAND"
+)
+
+/// @notice Protocol version sent with every request
+const ProtocolVersion = 2
+
+/// @notice An unsigned Indy ledger request
+/// @dev Signing and submission are left to the pool client; the mock ledger accepts requests as-is
+type Request struct {
+	ReqID           int64                  `json:"reqId"`
+	Identifier      string                 `json:"identifier"`
+	ProtocolVersion int                    `json:"protocolVersion"`
+	Operation       map[string]interface{} `json:"operation"`
+}
+
+/// @notice Anything that can submit a serialized request to a ledger and return the raw reply
+/// @dev Implemented by MockLedger; pool clients such as indy-vdr bindings can satisfy it as well
+type Submitter interface {
+	Submit(request []byte) ([]byte, error)
+}
+
+/// @notice Creates a request for the given submitter and operation
+/// @dev The request ID is derived from the current time, matching indy-vdr
+func NewRequest(submitterDID string, operation map[string]interface{}) *Request {
+	return &Request{
+		ReqID:           time.Now().UnixNano(),
+		Identifier:      submitterDID,
+		ProtocolVersion: ProtocolVersion,
+		Operation:       operation,
+	}
+}
+
+/// @notice Returns the transaction type of the request
+func (r *Request) Type() string {
+	if r == nil || r.Operation == nil {
+		return ""
+	}
+	txnType, _ := r.Operation["type"].(string)
+	return txnType
+}
+
+/// @notice Serializes the request to JSON
+func (r *Request) ToJSON() ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil request")
+	}
+	return json.Marshal(r)
+}
+
+/// @notice Parses a serialized request
+func RequestFromJSON(data []byte) (*Request, error) {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid ledger request: %w", err)
+	}
+	if req.Operation == nil {
+		return nil, fmt.Errorf("ledger request has no operation")
+	}
+	return &req, nil
+}
+
+/// @notice Builds a legacy Indy schema ID of the form <did>:2:<name>:<version>
+func LegacySchemaID(did, name, version string) string {
+	return fmt.Sprintf("%s:2:%s:%s", did, name, version)
+}
+
+/// @notice Builds a legacy Indy credential definition ID of the form <did>:3:CL:<schemaSeqNo>:<tag>
+/// @dev Indy references schemas by their ledger sequence number rather than their ID
+func LegacyCredentialDefinitionID(did string, schemaSeqNo int64, tag string) string {
+	return fmt.Sprintf("%s:3:CL:%d:%s", did, schemaSeqNo, tag)
+}
+
+/// @notice Builds a legacy Indy revocation registry ID of the form <did>:4:<credDefID>:CL_ACCUM:<tag>
+func LegacyRevocationRegistryID(did, credDefID, tag string) string {
+	return fmt.Sprintf("%s:4:%s:%s:%s", did, credDefID, RevocationDefTypeCLAccum, tag)
+}
+
+/// @notice Splits a legacy schema ID into its DID, name and version
+func ParseLegacySchemaID(schemaID string) (did, name, version string, err error) {
+	parts := strings.Split(schemaID, ":")
+	if len(parts) != 4 || parts[1] != "2" {
+		return "", "", "", fmt.Errorf("invalid legacy schema id: %s", schemaID)
+	}
+	return parts[0], parts[2], parts[3], nil
+}
+
+/// @notice Splits a legacy credential definition ID into its DID, schema sequence number and tag
+func ParseLegacyCredentialDefinitionID(credDefID string) (did string, schemaSeqNo int64, tag string, err error) {
+	parts := strings.Split(credDefID, ":")
+	if len(parts) != 5 || parts[1] != "3" || parts[2] != "CL" {
+		return "", 0, "", fmt.Errorf("invalid legacy credential definition id: %s", credDefID)
+	}
+	seqNo, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("invalid schema sequence number in credential definition id: %s", credDefID)
+	}
+	return parts[0], seqNo, parts[4], nil
+}
+
+/// @notice Splits a legacy revocation registry ID into its DID, credential definition ID and tag
+func ParseLegacyRevocationRegistryID(revRegID string) (did, credDefID, tag string, err error) {
+	parts := strings.Split(revRegID, ":")
+	if len(parts) != 9 || parts[1] != "4" || parts[7] != RevocationDefTypeCLAccum {
+		return "", "", "", fmt.Errorf("invalid legacy revocation registry id: %s", revRegID)
+	}
+	return parts[0], strings.Join(parts[2:7], ":"), parts[8], nil
+}
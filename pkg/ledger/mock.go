@@ -0,0 +1,437 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+/// @title In-Process Mock Ledger
+/// @dev Accepts the requests produced by this package and answers with Indy-shaped replies so
+/// issuance and verification can be exercised end to end without a network
+
+/// @notice A transaction recorded by the mock ledger
+type MockTransaction struct {
+	SeqNo   int64
+	TxnTime int64
+	Type    string
+	From    string
+	ReqID   int64
+	Data    map[string]interface{}
+}
+
+/// @notice An in-memory Indy ledger with deterministic sequence numbers
+/// @dev Sequence numbers start at 1 and increase by one per accepted write, in submission order
+type MockLedger struct {
+	/// @notice Clock used for txnTime; defaults to the wall clock when nil
+	Now func() int64
+
+	mu         sync.Mutex
+	txns       []*MockTransaction
+	schemas    map[string]int64
+	credDefs   map[string]int64
+	revRegDefs map[string]int64
+	revEntries map[string][]int64
+}
+
+/// @notice Creates an empty mock ledger
+func NewMockLedger() *MockLedger {
+	return &MockLedger{
+		schemas:    make(map[string]int64),
+		credDefs:   make(map[string]int64),
+		revRegDefs: make(map[string]int64),
+		revEntries: make(map[string][]int64),
+	}
+}
+
+/// @notice Submits a serialized request and returns the serialized reply
+/// @dev Requests the ledger would refuse produce a REJECT reply rather than a Go error
+func (l *MockLedger) Submit(request []byte) ([]byte, error) {
+	req, err := RequestFromJSON(request)
+	if err != nil {
+		return nil, err
+	}
+	return l.submit(req)
+}
+
+/// @notice Submits a request and returns the serialized reply
+/// @dev The request is serialized and parsed back first, so the ledger stores operations in their
+/// wire form, e.g. numbers as float64, exactly as if they had arrived through Submit
+func (l *MockLedger) SubmitRequest(req *Request) ([]byte, error) {
+	if req == nil {
+		return nil, fmt.Errorf("nil request")
+	}
+	request, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return l.Submit(request)
+}
+
+func (l *MockLedger) submit(req *Request) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result map[string]interface{}
+	var err error
+	switch req.Type() {
+	case TxnTypeSchema, TxnTypeCredDef, TxnTypeRevRegDef, TxnTypeRevRegEntry:
+		result, err = l.write(req)
+	case TxnTypeGetSchema:
+		result, err = l.getSchema(req)
+	case TxnTypeGetCredDef:
+		result, err = l.getCredentialDefinition(req)
+	case TxnTypeGetRevRegDef:
+		result, err = l.getRevocationRegistryDefinition(req)
+	case TxnTypeGetRevRegDelta:
+		result, err = l.getRevocationRegistryDelta(req)
+	case TxnTypeGetTransaction:
+		result, err = l.getTransaction(req)
+	default:
+		err = fmt.Errorf("unsupported transaction type %q", req.Type())
+	}
+	if err != nil {
+		return json.Marshal(map[string]interface{}{
+			"op":         "REJECT",
+			"reqId":      req.ReqID,
+			"identifier": req.Identifier,
+			"reason":     err.Error(),
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"op":     "REPLY",
+		"result": result,
+	})
+}
+
+/// @notice Returns the transaction with the given sequence number
+func (l *MockLedger) Transaction(seqNo int64) (*MockTransaction, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	txn := l.transaction(seqNo)
+	return txn, txn != nil
+}
+
+/// @notice Returns the number of transactions written so far
+func (l *MockLedger) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.txns)
+}
+
+/// @dev Validates and records a write request
+func (l *MockLedger) write(req *Request) (map[string]interface{}, error) {
+	if req.Identifier == "" {
+		return nil, fmt.Errorf("write requests need an identifier")
+	}
+	data := make(map[string]interface{}, len(req.Operation))
+	for key, value := range req.Operation {
+		if key != "type" {
+			data[key] = value
+		}
+	}
+
+	var txnID string
+	var index func(seqNo int64)
+	switch req.Type() {
+	case TxnTypeSchema:
+		schemaData, _ := data["data"].(map[string]interface{})
+		name, _ := schemaData["name"].(string)
+		version, _ := schemaData["version"].(string)
+		if name == "" || version == "" {
+			return nil, fmt.Errorf("schema needs a name and version")
+		}
+		txnID = LegacySchemaID(req.Identifier, name, version)
+		if _, exists := l.schemas[txnID]; exists {
+			return nil, fmt.Errorf("schema %s already exists", txnID)
+		}
+		index = func(seqNo int64) { l.schemas[txnID] = seqNo }
+	case TxnTypeCredDef:
+		ref := int64Field(data, "ref")
+		if schema := l.transaction(ref); schema == nil || schema.Type != TxnTypeSchema {
+			return nil, fmt.Errorf("schema with seqNo %d does not exist", ref)
+		}
+		tag, _ := data["tag"].(string)
+		txnID = LegacyCredentialDefinitionID(req.Identifier, ref, tag)
+		if _, exists := l.credDefs[txnID]; exists {
+			return nil, fmt.Errorf("credential definition %s already exists", txnID)
+		}
+		index = func(seqNo int64) { l.credDefs[txnID] = seqNo }
+	case TxnTypeRevRegDef:
+		txnID, _ = data["id"].(string)
+		did, credDefID, _, err := ParseLegacyRevocationRegistryID(txnID)
+		if err != nil {
+			return nil, err
+		}
+		if did != req.Identifier {
+			return nil, fmt.Errorf("revocation registry %s is not owned by %s", txnID, req.Identifier)
+		}
+		if _, exists := l.credDefs[credDefID]; !exists {
+			return nil, fmt.Errorf("credential definition %s does not exist", credDefID)
+		}
+		if _, exists := l.revRegDefs[txnID]; exists {
+			return nil, fmt.Errorf("revocation registry %s already exists", txnID)
+		}
+		index = func(seqNo int64) { l.revRegDefs[txnID] = seqNo }
+	case TxnTypeRevRegEntry:
+		revRegDefID, _ := data["revocRegDefId"].(string)
+		if _, exists := l.revRegDefs[revRegDefID]; !exists {
+			return nil, fmt.Errorf("revocation registry %s does not exist", revRegDefID)
+		}
+		value, _ := data["value"].(map[string]interface{})
+		if value == nil || value["accum"] == nil {
+			return nil, fmt.Errorf("revocation registry entry needs an accumulator")
+		}
+		if entries := l.revEntries[revRegDefID]; len(entries) > 0 {
+			last := l.transaction(entries[len(entries)-1])
+			lastValue, _ := last.Data["value"].(map[string]interface{})
+			prevAccum, _ := value["prevAccum"].(string)
+			lastAccum, _ := lastValue["accum"].(string)
+			if prevAccum != lastAccum {
+				return nil, fmt.Errorf("prevAccum does not match the current accumulator of %s", revRegDefID)
+			}
+		}
+		txnID = fmt.Sprintf("5:%s", revRegDefID)
+		index = func(seqNo int64) { l.revEntries[revRegDefID] = append(l.revEntries[revRegDefID], seqNo) }
+	}
+
+	txn := &MockTransaction{
+		SeqNo:   int64(len(l.txns)) + 1,
+		TxnTime: l.now(),
+		Type:    req.Type(),
+		From:    req.Identifier,
+		ReqID:   req.ReqID,
+		Data:    data,
+	}
+	l.txns = append(l.txns, txn)
+	index(txn.SeqNo)
+
+	result := txn.ledgerForm()
+	result["txnMetadata"].(map[string]interface{})["txnId"] = txnID
+	return result, nil
+}
+
+/// @dev Answers GET_SCHEMA
+func (l *MockLedger) getSchema(req *Request) (map[string]interface{}, error) {
+	dest, _ := req.Operation["dest"].(string)
+	query, _ := req.Operation["data"].(map[string]interface{})
+	name, _ := query["name"].(string)
+	version, _ := query["version"].(string)
+
+	result := readResult(req)
+	result["dest"] = dest
+	result["data"] = map[string]interface{}{"name": name, "version": version}
+	result["seqNo"] = nil
+	result["txnTime"] = nil
+	if txn := l.transaction(l.schemas[LegacySchemaID(dest, name, version)]); txn != nil {
+		result["data"] = txn.Data["data"]
+		result["seqNo"] = txn.SeqNo
+		result["txnTime"] = txn.TxnTime
+	}
+	return result, nil
+}
+
+/// @dev Answers GET_CLAIM_DEF
+func (l *MockLedger) getCredentialDefinition(req *Request) (map[string]interface{}, error) {
+	origin, _ := req.Operation["origin"].(string)
+	tag, _ := req.Operation["tag"].(string)
+	ref := int64Field(req.Operation, "ref")
+
+	result := readResult(req)
+	result["origin"] = origin
+	result["ref"] = ref
+	result["signature_type"] = req.Operation["signature_type"]
+	result["tag"] = tag
+	result["data"] = nil
+	result["seqNo"] = nil
+	result["txnTime"] = nil
+	if txn := l.transaction(l.credDefs[LegacyCredentialDefinitionID(origin, ref, tag)]); txn != nil {
+		result["data"] = txn.Data["data"]
+		result["seqNo"] = txn.SeqNo
+		result["txnTime"] = txn.TxnTime
+	}
+	return result, nil
+}
+
+/// @dev Answers GET_REVOC_REG_DEF
+func (l *MockLedger) getRevocationRegistryDefinition(req *Request) (map[string]interface{}, error) {
+	id, _ := req.Operation["id"].(string)
+
+	result := readResult(req)
+	result["id"] = id
+	result["data"] = nil
+	result["seqNo"] = nil
+	result["txnTime"] = nil
+	if txn := l.transaction(l.revRegDefs[id]); txn != nil {
+		data := make(map[string]interface{}, len(txn.Data)+1)
+		for key, value := range txn.Data {
+			data[key] = value
+		}
+		data["ver"] = "1.0"
+		result["data"] = data
+		result["seqNo"] = txn.SeqNo
+		result["txnTime"] = txn.TxnTime
+	}
+	return result, nil
+}
+
+/// @dev Answers GET_REVOC_REG_DELTA by folding every entry up to the requested timestamps
+func (l *MockLedger) getRevocationRegistryDelta(req *Request) (map[string]interface{}, error) {
+	revRegDefID, _ := req.Operation["revocRegDefId"].(string)
+	to := int64Field(req.Operation, "to")
+
+	result := readResult(req)
+	result["revocRegDefId"] = revRegDefID
+	result["to"] = to
+	result["from"] = req.Operation["from"]
+	result["data"] = nil
+	result["seqNo"] = nil
+	result["txnTime"] = nil
+
+	toTxn, toRevoked := l.revocationStateAt(revRegDefID, to)
+	if toTxn == nil {
+		return result, nil
+	}
+
+	issued := map[int64]bool{}
+	revoked := toRevoked
+	value := map[string]interface{}{
+		"accum_to": accumEntry(revRegDefID, toTxn),
+	}
+	if _, hasFrom := req.Operation["from"]; hasFrom {
+		fromTxn, fromRevoked := l.revocationStateAt(revRegDefID, int64Field(req.Operation, "from"))
+		if fromTxn != nil {
+			value["accum_from"] = accumEntry(revRegDefID, fromTxn)
+			revoked = map[int64]bool{}
+			for idx := range toRevoked {
+				if !fromRevoked[idx] {
+					revoked[idx] = true
+				}
+			}
+			for idx := range fromRevoked {
+				if !toRevoked[idx] {
+					issued[idx] = true
+				}
+			}
+		}
+	}
+	value["issued"] = sortedIndices(issued)
+	value["revoked"] = sortedIndices(revoked)
+
+	result["data"] = map[string]interface{}{
+		"revocDefType":  RevocationDefTypeCLAccum,
+		"revocRegDefId": revRegDefID,
+		"value":         value,
+	}
+	result["seqNo"] = toTxn.SeqNo
+	result["txnTime"] = toTxn.TxnTime
+	return result, nil
+}
+
+/// @dev Answers GET_TXN for the domain ledger
+func (l *MockLedger) getTransaction(req *Request) (map[string]interface{}, error) {
+	if ledgerID := int64Field(req.Operation, "ledgerId"); ledgerID != LedgerDomain {
+		return nil, fmt.Errorf("mock ledger only serves the domain ledger, got %d", ledgerID)
+	}
+	seqNo := int64Field(req.Operation, "data")
+
+	result := readResult(req)
+	result["seqNo"] = seqNo
+	result["data"] = nil
+	if txn := l.transaction(seqNo); txn != nil {
+		result["data"] = txn.ledgerForm()
+	}
+	return result, nil
+}
+
+/// @dev Returns the last entry at or before the timestamp and the revoked set at that point
+func (l *MockLedger) revocationStateAt(revRegDefID string, timestamp int64) (*MockTransaction, map[int64]bool) {
+	var last *MockTransaction
+	revoked := map[int64]bool{}
+	for _, seqNo := range l.revEntries[revRegDefID] {
+		txn := l.transaction(seqNo)
+		if txn.TxnTime > timestamp {
+			break
+		}
+		value, _ := txn.Data["value"].(map[string]interface{})
+		issuedList, _ := int64List(value["issued"])
+		revokedList, _ := int64List(value["revoked"])
+		for _, idx := range issuedList {
+			delete(revoked, idx)
+		}
+		for _, idx := range revokedList {
+			revoked[idx] = true
+		}
+		last = txn
+	}
+	return last, revoked
+}
+
+/// @dev Looks up a transaction by sequence number; callers must hold the lock
+func (l *MockLedger) transaction(seqNo int64) *MockTransaction {
+	if seqNo <= 0 || seqNo > int64(len(l.txns)) {
+		return nil
+	}
+	return l.txns[seqNo-1]
+}
+
+/// @dev Returns the current ledger time, never going backwards
+func (l *MockLedger) now() int64 {
+	var now int64
+	if l.Now != nil {
+		now = l.Now()
+	} else {
+		now = time.Now().Unix()
+	}
+	if len(l.txns) > 0 {
+		if last := l.txns[len(l.txns)-1].TxnTime; now < last {
+			now = last
+		}
+	}
+	return now
+}
+
+/// @dev Renders a transaction the way Indy nodes return it from writes and GET_TXN
+func (t *MockTransaction) ledgerForm() map[string]interface{} {
+	return map[string]interface{}{
+		"txn": map[string]interface{}{
+			"type":            t.Type,
+			"data":            t.Data,
+			"protocolVersion": ProtocolVersion,
+			"metadata": map[string]interface{}{
+				"from":  t.From,
+				"reqId": t.ReqID,
+			},
+		},
+		"txnMetadata": map[string]interface{}{
+			"seqNo":   t.SeqNo,
+			"txnTime": t.TxnTime,
+		},
+		"reqSignature": map[string]interface{}{},
+		"ver":          "1",
+	}
+}
+
+/// @dev Common fields of every read reply
+func readResult(req *Request) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       req.Type(),
+		"identifier": req.Identifier,
+		"reqId":      req.ReqID,
+	}
+}
+
+/// @dev Renders an accumulator reference inside a delta reply
+func accumEntry(revRegDefID string, txn *MockTransaction) map[string]interface{} {
+	value, _ := txn.Data["value"].(map[string]interface{})
+	return map[string]interface{}{
+		"revocDefType":  RevocationDefTypeCLAccum,
+		"revocRegDefId": revRegDefID,
+		"seqNo":         txn.SeqNo,
+		"txnTime":       txn.TxnTime,
+		"value": map[string]interface{}{
+			"accum": value["accum"],
+		},
+	}
+}
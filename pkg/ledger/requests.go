@@ -0,0 +1,265 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Ledger Request Builders
+/// @dev Write requests are built from the objects produced by pkg/anoncreds, read requests from legacy IDs
+
+/// @notice Builds a SCHEMA write request
+/// @param submitterDID The unqualified DID that will sign the request
+/// @param schema The schema created with anoncreds.CreateSchema
+/// @return The unsigned request and any error encountered
+func BuildSchemaRequest(submitterDID string, schema *anoncreds.Schema) (*Request, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("schema is required")
+	}
+	schemaJSON, err := schema.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := schemaJSON["name"].(string)
+	version, _ := schemaJSON["version"].(string)
+	attrNames, _ := schemaJSON["attrNames"].([]interface{})
+	if name == "" || version == "" {
+		return nil, fmt.Errorf("schema is missing name or version")
+	}
+
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type": TxnTypeSchema,
+		"data": map[string]interface{}{
+			"name":       name,
+			"version":    version,
+			"attr_names": attrNames,
+		},
+	}), nil
+}
+
+/// @notice Builds a CRED_DEF write request
+/// @param submitterDID The unqualified DID that will sign the request
+/// @param schemaSeqNo The ledger sequence number of the schema the definition is based on
+/// @param credDef The public credential definition created with anoncreds.CreateCredentialDefinition
+/// @return The unsigned request and any error encountered
+func BuildCredentialDefinitionRequest(submitterDID string, schemaSeqNo int64, credDef *anoncreds.CredentialDefinition) (*Request, error) {
+	if credDef == nil {
+		return nil, fmt.Errorf("credential definition is required")
+	}
+	if schemaSeqNo <= 0 {
+		return nil, fmt.Errorf("schema sequence number is required")
+	}
+	credDefJSON, err := credDef.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	signatureType, _ := credDefJSON["type"].(string)
+	if signatureType == "" {
+		signatureType = "CL"
+	}
+	tag, _ := credDefJSON["tag"].(string)
+	value, ok := credDefJSON["value"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("credential definition is missing value")
+	}
+
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type":           TxnTypeCredDef,
+		"ref":            schemaSeqNo,
+		"signature_type": signatureType,
+		"tag":            tag,
+		"data":           value,
+	}), nil
+}
+
+/// @notice Builds a REVOC_REG_DEF write request
+/// @param submitterDID The unqualified DID that will sign the request
+/// @param revRegDefID The legacy ID under which the registry will be published
+/// @param revRegDef The revocation registry definition
+/// @return The unsigned request and any error encountered
+/// @dev Registries created by anoncreds-rs always use ISSUANCE_BY_DEFAULT
+func BuildRevocationRegistryDefinitionRequest(submitterDID, revRegDefID string, revRegDef *anoncreds.RevocationRegistryDefinition) (*Request, error) {
+	if revRegDef == nil {
+		return nil, fmt.Errorf("revocation registry definition is required")
+	}
+	if revRegDefID == "" {
+		return nil, fmt.Errorf("revocation registry definition id is required")
+	}
+	defJSON, err := revRegDef.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := defJSON["value"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("revocation registry definition is missing value")
+	}
+	revocDefType, _ := defJSON["revocDefType"].(string)
+	if revocDefType == "" {
+		revocDefType = RevocationDefTypeCLAccum
+	}
+
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type":         TxnTypeRevRegDef,
+		"id":           revRegDefID,
+		"revocDefType": revocDefType,
+		"tag":          defJSON["tag"],
+		"credDefId":    defJSON["credDefId"],
+		"value": map[string]interface{}{
+			"issuanceType":  IssuanceByDefault,
+			"maxCredNum":    value["maxCredNum"],
+			"publicKeys":    value["publicKeys"],
+			"tailsHash":     value["tailsHash"],
+			"tailsLocation": value["tailsLocation"],
+		},
+	}), nil
+}
+
+/// @notice Builds a REVOC_REG_ENTRY write request
+/// @param submitterDID The unqualified DID that will sign the request
+/// @param revRegDefID The legacy ID of the registry
+/// @param previous The status list last published to the ledger, or nil for the initial entry
+/// @param current The status list to publish
+/// @return The unsigned request and any error encountered
+/// @dev The issued and revoked sets are the indices whose status changed between the two lists
+func BuildRevocationRegistryEntryRequest(submitterDID, revRegDefID string, previous, current *anoncreds.RevocationStatusList) (*Request, error) {
+	if current == nil {
+		return nil, fmt.Errorf("revocation status list is required")
+	}
+	currentJSON, err := current.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	currentBitmap, err := anoncreds.RevocationBitmapFromJSON(currentJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	value := map[string]interface{}{
+		"accum": currentJSON["currentAccumulator"],
+	}
+
+	issued := []int64{}
+	revoked := []int64{}
+	if previous == nil {
+		for _, idx := range currentBitmap.RevokedIndices() {
+			revoked = append(revoked, int64(idx))
+		}
+	} else {
+		previousJSON, err := previous.ToJSON()
+		if err != nil {
+			return nil, err
+		}
+		previousBitmap, err := anoncreds.RevocationBitmapFromJSON(previousJSON)
+		if err != nil {
+			return nil, err
+		}
+		diff, err := anoncreds.DiffBitmaps(previousBitmap, currentBitmap)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range diff.Issued {
+			issued = append(issued, int64(idx))
+		}
+		for _, idx := range diff.Revoked {
+			revoked = append(revoked, int64(idx))
+		}
+		value["prevAccum"] = previousJSON["currentAccumulator"]
+	}
+	value["issued"] = issued
+	value["revoked"] = revoked
+
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type":          TxnTypeRevRegEntry,
+		"revocRegDefId": revRegDefID,
+		"revocDefType":  RevocationDefTypeCLAccum,
+		"value":         value,
+	}), nil
+}
+
+/// @notice Builds a GET_SCHEMA read request from a legacy schema ID
+func BuildGetSchemaRequest(submitterDID, schemaID string) (*Request, error) {
+	did, name, version, err := ParseLegacySchemaID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type": TxnTypeGetSchema,
+		"dest": did,
+		"data": map[string]interface{}{
+			"name":    name,
+			"version": version,
+		},
+	}), nil
+}
+
+/// @notice Builds a GET_CLAIM_DEF read request from a legacy credential definition ID
+func BuildGetCredentialDefinitionRequest(submitterDID, credDefID string) (*Request, error) {
+	did, schemaSeqNo, tag, err := ParseLegacyCredentialDefinitionID(credDefID)
+	if err != nil {
+		return nil, err
+	}
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type":           TxnTypeGetCredDef,
+		"ref":            schemaSeqNo,
+		"signature_type": "CL",
+		"origin":         did,
+		"tag":            tag,
+	}), nil
+}
+
+/// @notice Builds a GET_REVOC_REG_DEF read request
+func BuildGetRevocationRegistryDefinitionRequest(submitterDID, revRegDefID string) (*Request, error) {
+	if revRegDefID == "" {
+		return nil, fmt.Errorf("revocation registry definition id is required")
+	}
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type": TxnTypeGetRevRegDef,
+		"id":   revRegDefID,
+	}), nil
+}
+
+/// @notice Builds a GET_REVOC_REG_DELTA read request
+/// @param from Optional start of the interval; nil requests the full state at `to`
+/// @param to End of the interval as a unix timestamp
+func BuildGetRevocationRegistryDeltaRequest(submitterDID, revRegDefID string, from *int64, to int64) (*Request, error) {
+	if revRegDefID == "" {
+		return nil, fmt.Errorf("revocation registry definition id is required")
+	}
+	operation := map[string]interface{}{
+		"type":          TxnTypeGetRevRegDelta,
+		"revocRegDefId": revRegDefID,
+		"to":            to,
+	}
+	if from != nil {
+		operation["from"] = *from
+	}
+	return NewRequest(submitterDID, operation), nil
+}
+
+/// @notice Builds a GET_TXN read request for a domain ledger sequence number
+/// @dev Used to resolve the schema behind the seqNo embedded in a legacy credential definition ID
+func BuildGetTransactionRequest(submitterDID string, seqNo int64) (*Request, error) {
+	if seqNo <= 0 {
+		return nil, fmt.Errorf("sequence number is required")
+	}
+	return NewRequest(submitterDID, map[string]interface{}{
+		"type":     TxnTypeGetTransaction,
+		"ledgerId": LedgerDomain,
+		"data":     seqNo,
+	}), nil
+}
+
+/// @dev Returns the sorted members of an index set
+func sortedIndices(set map[int64]bool) []int64 {
+	indices := make([]int64, 0, len(set))
+	for idx := range set {
+		indices = append(indices, idx)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return indices
+}
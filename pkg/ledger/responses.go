@@ -0,0 +1,350 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/// @title Ledger Response Parsers
+/// @dev Converts Indy ledger replies into the JSON accepted by the anoncreds FromJSON constructors
+
+/// @notice Metadata returned by the ledger for an accepted write request
+type WriteResult struct {
+	Type    string /// @notice Transaction type that was written
+	SeqNo   int64  /// @notice Sequence number assigned by the ledger
+	TxnTime int64  /// @notice Ledger timestamp of the transaction
+	TxnID   string /// @notice Transaction ID, if the ledger assigned one
+}
+
+/// @notice A schema read from the ledger
+/// @dev Schema can be passed directly to anoncreds.SchemaFromJSON
+type SchemaResponse struct {
+	SchemaID string
+	SeqNo    int64
+	TxnTime  int64
+	Schema   map[string]interface{}
+}
+
+/// @notice A credential definition read from the ledger
+/// @dev CredentialDefinition can be passed directly to anoncreds.CredentialDefinitionFromJSON
+type CredentialDefinitionResponse struct {
+	CredentialDefinitionID string
+	SchemaSeqNo            int64
+	SeqNo                  int64
+	TxnTime                int64
+	CredentialDefinition   map[string]interface{}
+}
+
+/// @notice A revocation registry definition read from the ledger
+/// @dev RevocationRegistryDefinition can be passed directly to anoncreds.RevocationRegistryDefinitionFromJSON
+type RevocationRegistryDefinitionResponse struct {
+	RevocationRegistryDefinitionID string
+	IssuanceType                   string
+	SeqNo                          int64
+	TxnTime                        int64
+	RevocationRegistryDefinition   map[string]interface{}
+}
+
+/// @notice A revocation registry delta read from the ledger
+/// @dev Use StatusList to turn the delta into anoncreds.RevocationStatusListFromJSON input
+type RevocationRegistryDeltaResponse struct {
+	RevocationRegistryDefinitionID string
+	Accumulator                    string
+	Timestamp                      int64
+	Issued                         []int64
+	Revoked                        []int64
+}
+
+/// @notice Parses the reply to any write request
+func ParseWriteResponse(resp []byte) (*WriteResult, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	txn, _ := result["txn"].(map[string]interface{})
+	txnMetadata, _ := result["txnMetadata"].(map[string]interface{})
+	if txn == nil || txnMetadata == nil {
+		return nil, fmt.Errorf("write reply is missing transaction data")
+	}
+	txnType, _ := txn["type"].(string)
+	txnID, _ := txnMetadata["txnId"].(string)
+	return &WriteResult{
+		Type:    txnType,
+		SeqNo:   int64Field(txnMetadata, "seqNo"),
+		TxnTime: int64Field(txnMetadata, "txnTime"),
+		TxnID:   txnID,
+	}, nil
+}
+
+/// @notice Parses a GET_SCHEMA reply
+/// @return The schema in AnonCreds JSON form along with its legacy ID and sequence number
+func ParseGetSchemaResponse(resp []byte) (*SchemaResponse, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	seqNo := int64Field(result, "seqNo")
+	data, _ := result["data"].(map[string]interface{})
+	if seqNo == 0 || data == nil || data["attr_names"] == nil {
+		return nil, fmt.Errorf("schema not found on ledger")
+	}
+	did, _ := result["dest"].(string)
+	return newSchemaResponse(did, data, seqNo, int64Field(result, "txnTime"))
+}
+
+/// @notice Parses a GET_TXN reply that is expected to contain a SCHEMA transaction
+/// @dev Pair with BuildGetTransactionRequest to resolve the schema referenced by a credential definition
+func ParseGetTransactionSchemaResponse(resp []byte) (*SchemaResponse, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := result["data"].(map[string]interface{})
+	if data == nil {
+		return nil, fmt.Errorf("transaction not found on ledger")
+	}
+	txn, _ := data["txn"].(map[string]interface{})
+	txnMetadata, _ := data["txnMetadata"].(map[string]interface{})
+	if txn == nil || txnMetadata == nil {
+		return nil, fmt.Errorf("transaction reply is missing transaction data")
+	}
+	if txnType, _ := txn["type"].(string); txnType != TxnTypeSchema {
+		return nil, fmt.Errorf("transaction %d is not a schema (type %s)", int64Field(txnMetadata, "seqNo"), txnType)
+	}
+	txnData, _ := txn["data"].(map[string]interface{})
+	schemaData, _ := txnData["data"].(map[string]interface{})
+	if schemaData == nil {
+		return nil, fmt.Errorf("schema transaction is missing data")
+	}
+	metadata, _ := txn["metadata"].(map[string]interface{})
+	did, _ := metadata["from"].(string)
+	return newSchemaResponse(did, schemaData, int64Field(txnMetadata, "seqNo"), int64Field(txnMetadata, "txnTime"))
+}
+
+/// @notice Parses a GET_CLAIM_DEF reply
+/// @return The credential definition with its seqNo-based legacy ID
+/// @dev Indy only records the schema sequence number, so schemaId is set to that number until WithSchemaID is called
+func ParseGetCredentialDefinitionResponse(resp []byte) (*CredentialDefinitionResponse, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := result["data"].(map[string]interface{})
+	seqNo := int64Field(result, "seqNo")
+	if seqNo == 0 || data == nil {
+		return nil, fmt.Errorf("credential definition not found on ledger")
+	}
+
+	did, _ := result["origin"].(string)
+	tag, _ := result["tag"].(string)
+	signatureType, _ := result["signature_type"].(string)
+	if signatureType == "" {
+		signatureType = "CL"
+	}
+	schemaSeqNo := int64Field(result, "ref")
+
+	return &CredentialDefinitionResponse{
+		CredentialDefinitionID: LegacyCredentialDefinitionID(did, schemaSeqNo, tag),
+		SchemaSeqNo:            schemaSeqNo,
+		SeqNo:                  seqNo,
+		TxnTime:                int64Field(result, "txnTime"),
+		CredentialDefinition: map[string]interface{}{
+			"issuerId": did,
+			"schemaId": strconv.FormatInt(schemaSeqNo, 10),
+			"type":     signatureType,
+			"tag":      tag,
+			"value":    data,
+		},
+	}, nil
+}
+
+/// @notice Sets the schema ID of the credential definition once it has been resolved
+/// @param schemaID The schema ID, typically SchemaResponse.SchemaID from a GET_TXN lookup
+func (r *CredentialDefinitionResponse) WithSchemaID(schemaID string) *CredentialDefinitionResponse {
+	r.CredentialDefinition["schemaId"] = schemaID
+	return r
+}
+
+/// @notice Parses a GET_REVOC_REG_DEF reply
+func ParseGetRevocationRegistryDefinitionResponse(resp []byte) (*RevocationRegistryDefinitionResponse, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := result["data"].(map[string]interface{})
+	if data == nil {
+		return nil, fmt.Errorf("revocation registry definition not found on ledger")
+	}
+	id, _ := data["id"].(string)
+	did, _, _, err := ParseLegacyRevocationRegistryID(id)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := data["value"].(map[string]interface{})
+	if value == nil {
+		return nil, fmt.Errorf("revocation registry definition is missing value")
+	}
+	issuanceType, _ := value["issuanceType"].(string)
+
+	return &RevocationRegistryDefinitionResponse{
+		RevocationRegistryDefinitionID: id,
+		IssuanceType:                   issuanceType,
+		SeqNo:                          int64Field(result, "seqNo"),
+		TxnTime:                        int64Field(result, "txnTime"),
+		RevocationRegistryDefinition: map[string]interface{}{
+			"issuerId":     did,
+			"revocDefType": data["revocDefType"],
+			"credDefId":    data["credDefId"],
+			"tag":          data["tag"],
+			"value": map[string]interface{}{
+				"maxCredNum":    value["maxCredNum"],
+				"publicKeys":    value["publicKeys"],
+				"tailsHash":     value["tailsHash"],
+				"tailsLocation": value["tailsLocation"],
+			},
+		},
+	}, nil
+}
+
+/// @notice Parses a GET_REVOC_REG_DELTA reply
+func ParseGetRevocationRegistryDeltaResponse(resp []byte) (*RevocationRegistryDeltaResponse, error) {
+	result, err := decodeReply(resp)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := result["data"].(map[string]interface{})
+	if data == nil {
+		return nil, fmt.Errorf("revocation registry delta not found on ledger")
+	}
+	value, _ := data["value"].(map[string]interface{})
+	accumTo, _ := value["accum_to"].(map[string]interface{})
+	if accumTo == nil {
+		return nil, fmt.Errorf("revocation registry delta is missing accum_to")
+	}
+	accumValue, _ := accumTo["value"].(map[string]interface{})
+	accum, _ := accumValue["accum"].(string)
+	revRegDefID, _ := data["revocRegDefId"].(string)
+
+	issued, err := int64List(value["issued"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid issued list: %w", err)
+	}
+	revoked, err := int64List(value["revoked"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid revoked list: %w", err)
+	}
+
+	return &RevocationRegistryDeltaResponse{
+		RevocationRegistryDefinitionID: revRegDefID,
+		Accumulator:                    accum,
+		Timestamp:                      int64Field(accumTo, "txnTime"),
+		Issued:                         issued,
+		Revoked:                        revoked,
+	}, nil
+}
+
+/// @notice Converts a full-state delta into revocation status list JSON
+/// @param maxCredNum The capacity of the registry, taken from its definition
+/// @return Status list JSON suitable for anoncreds.RevocationStatusListFromJSON
+/// @dev Assumes ISSUANCE_BY_DEFAULT and a delta requested without a `from` timestamp
+func (d *RevocationRegistryDeltaResponse) StatusList(maxCredNum int64) (map[string]interface{}, error) {
+	if maxCredNum <= 0 {
+		return nil, fmt.Errorf("max credential number must be positive")
+	}
+	did, _, _, err := ParseLegacyRevocationRegistryID(d.RevocationRegistryDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]int, maxCredNum)
+	for _, idx := range d.Revoked {
+		if idx < 0 || idx >= maxCredNum {
+			return nil, fmt.Errorf("revoked index %d is outside the registry capacity %d", idx, maxCredNum)
+		}
+		list[idx] = 1
+	}
+
+	return map[string]interface{}{
+		"issuerId":           did,
+		"revRegDefId":        d.RevocationRegistryDefinitionID,
+		"revocationList":     list,
+		"currentAccumulator": d.Accumulator,
+		"timestamp":          d.Timestamp,
+	}, nil
+}
+
+/// @dev Checks the reply envelope and returns its result object
+func decodeReply(resp []byte) (map[string]interface{}, error) {
+	var reply map[string]interface{}
+	if err := json.Unmarshal(resp, &reply); err != nil {
+		return nil, fmt.Errorf("invalid ledger reply: %w", err)
+	}
+	op, _ := reply["op"].(string)
+	if op != "REPLY" {
+		reason, _ := reply["reason"].(string)
+		return nil, fmt.Errorf("ledger rejected request (%s): %s", op, reason)
+	}
+	result, ok := reply["result"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ledger reply is missing result")
+	}
+	return result, nil
+}
+
+/// @dev Builds a schema response from ledger schema data
+func newSchemaResponse(did string, data map[string]interface{}, seqNo, txnTime int64) (*SchemaResponse, error) {
+	name, _ := data["name"].(string)
+	version, _ := data["version"].(string)
+	if did == "" || name == "" || version == "" {
+		return nil, fmt.Errorf("schema data is incomplete")
+	}
+	return &SchemaResponse{
+		SchemaID: LegacySchemaID(did, name, version),
+		SeqNo:    seqNo,
+		TxnTime:  txnTime,
+		Schema: map[string]interface{}{
+			"issuerId":  did,
+			"name":      name,
+			"version":   version,
+			"attrNames": data["attr_names"],
+		},
+	}, nil
+}
+
+/// @dev Reads a numeric field that may be encoded as a JSON number or a string
+func int64Field(m map[string]interface{}, key string) int64 {
+	switch v := m[key].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n
+	}
+	return 0
+}
+
+/// @dev Reads a JSON array of numbers
+func int64List(value interface{}) ([]int64, error) {
+	if value == nil {
+		return []int64{}, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array")
+	}
+	list := make([]int64, 0, len(items))
+	for _, item := range items {
+		n, ok := item.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, got %v", item)
+		}
+		list = append(list, int64(n))
+	}
+	return list, nil
+}
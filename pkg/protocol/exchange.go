@@ -0,0 +1,413 @@
+package protocol
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Protocol Exchanges
+/// @dev Persistent state machines for the issue-credential and present-proof exchanges. The
+/// machines are transport agnostic: they consume and produce message payloads and leave
+/// delivering them to the caller. Every exchange is stored after each transition, so it can
+/// resume after a restart
+
+/// @notice Terminal states shared by every exchange
+const (
+	StateDone      = "done"      /// @notice The exchange completed
+	StateAbandoned = "abandoned" /// @notice The exchange ended with a problem report
+)
+
+/// @notice Problem report codes shared by every exchange
+const (
+	ProblemTimeout        = "timeout"         /// @notice The peer did not answer in time
+	ProblemInvalidMessage = "invalid-message" /// @notice A message could not be parsed or did not match the exchange
+)
+
+/// @notice Default time allowed between two messages of an exchange
+const DefaultExchangeTimeout = 24 * time.Hour
+
+/// @notice Errors returned by the state machines
+var (
+	ErrExchangeNotFound  = fmt.Errorf("exchange %w", storage.ErrNotFound)
+	ErrExchangeExists    = errors.New("exchange already exists")
+	ErrExchangeExpired   = errors.New("exchange has timed out")
+	ErrInvalidTransition = errors.New("invalid state transition")
+)
+
+/// @notice Problem report sent or received when an exchange is abandoned
+type ProblemReport struct {
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+}
+
+/// @notice Fields common to every exchange record
+type Exchange struct {
+	ID        string         `json:"id"` /// @notice Thread ID shared with the peer
+	State     string         `json:"state"`
+	Problem   *ProblemReport `json:"problem,omitempty"` /// @notice Set once abandoned
+	CreatedAt int64          `json:"created_at"`
+	UpdatedAt int64          `json:"updated_at"`
+	ExpiresAt int64          `json:"expires_at,omitempty"` /// @notice Deadline for the next message; zero once terminal
+}
+
+/// @notice Reports whether the exchange has ended
+/// @dev Ending an exchange clears its deadline, whichever end state its machine moved it to
+func (e *Exchange) Terminal() bool {
+	return e.ExpiresAt == 0
+}
+
+/// @dev Returns the common fields; promoted to every record type that embeds Exchange
+func (e *Exchange) exchange() *Exchange {
+	return e
+}
+
+/// @dev The states of one role's machine: legal transitions keyed by the current state, and the
+/// states besides StateDone and StateAbandoned in which its exchanges end
+type states struct {
+	transitions map[string][]string
+	final       []string
+}
+
+/// @dev Reports whether an exchange in the given state has ended
+func (s states) terminal(state string) bool {
+	if state == StateDone || state == StateAbandoned {
+		return true
+	}
+	for _, final := range s.final {
+		if final == state {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Reports whether the machine may move from one state to another
+func (s states) allowed(from, to string) bool {
+	if to == StateAbandoned {
+		return !s.terminal(from)
+	}
+	for _, next := range s.transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Moves an exchange to a new state and restarts its timeout
+func (e *Exchange) moveTo(machine states, state string, now time.Time, timeout time.Duration) error {
+	if !machine.allowed(e.State, state) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, e.State, state)
+	}
+	e.State = state
+	e.UpdatedAt = now.Unix()
+	e.ExpiresAt = 0
+	if !machine.terminal(state) {
+		e.ExpiresAt = now.Add(timeout).Unix()
+	}
+	return nil
+}
+
+/// @dev Checks that an exchange is in the expected state and has not timed out
+func (e *Exchange) expect(state string, now time.Time) error {
+	if e.State != state {
+		return fmt.Errorf("%w: exchange %s is %s, not %s", ErrInvalidTransition, e.ID, e.State, state)
+	}
+	if e.expired(now) {
+		return ErrExchangeExpired
+	}
+	return nil
+}
+
+/// @dev Reports whether the deadline for the next message has passed
+func (e *Exchange) expired(now time.Time) bool {
+	return !e.Terminal() && now.Unix() >= e.ExpiresAt
+}
+
+/// @dev Abandons an exchange with a problem report
+func (e *Exchange) abandon(problem ProblemReport, now time.Time) error {
+	if e.Terminal() {
+		return fmt.Errorf("%w: exchange %s is already %s", ErrInvalidTransition, e.ID, e.State)
+	}
+	e.State = StateAbandoned
+	e.Problem = &problem
+	e.UpdatedAt = now.Unix()
+	e.ExpiresAt = 0
+	return nil
+}
+
+/// @dev Creates the common fields of a new exchange
+func newExchange(id, state string, now time.Time, timeout time.Duration) Exchange {
+	return Exchange{
+		ID:        id,
+		State:     state,
+		CreatedAt: now.Unix(),
+		UpdatedAt: now.Unix(),
+		ExpiresAt: now.Add(timeout).Unix(),
+	}
+}
+
+/// @dev A record kept by a machine: a pointer to a struct embedding Exchange
+type exchangeRecord interface {
+	exchange() *Exchange
+}
+
+/// @dev Persistence and bookkeeping shared by every role's machine, parameterised by the
+/// record type it keeps and the states it moves through
+type machine[R exchangeRecord] struct {
+	store     exchangeStore
+	states    states
+	newRecord func() R /// @dev Returns an empty record to decode into
+	timeout   time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+/// @notice Returns a stored exchange
+func (m *machine[R]) Exchange(id string) (R, error) {
+	record := m.newRecord()
+	if err := m.store.get(id, record); err != nil {
+		var none R
+		return none, err
+	}
+	return record, nil
+}
+
+/// @notice Lists stored exchanges ordered by ID
+/// @param state Only exchanges in this state are returned; every exchange when empty
+func (m *machine[R]) Exchanges(state string) ([]R, error) {
+	var records []R
+	err := m.store.list(func(value []byte) error {
+		record := m.newRecord()
+		if err := json.Unmarshal(value, record); err != nil {
+			return err
+		}
+		if state == "" || record.exchange().State == state {
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].exchange().ID < records[j].exchange().ID })
+	return records, nil
+}
+
+/// @notice Abandons an exchange
+/// @param problem The problem report to send to the peer
+/// @return The abandoned exchange and any error encountered
+func (m *machine[R]) Abandon(id string, problem ProblemReport) (R, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, err := m.Exchange(id)
+	if err != nil {
+		return record, err
+	}
+	if err := record.exchange().abandon(problem, m.now()); err != nil {
+		var none R
+		return none, err
+	}
+	return record, m.save(record)
+}
+
+/// @notice Records a problem report received from the peer, abandoning the exchange
+func (m *machine[R]) ReceiveProblemReport(id string, problem ProblemReport) (R, error) {
+	return m.Abandon(id, problem)
+}
+
+/// @notice Abandons every exchange whose peer did not answer in time
+/// @return The abandoned exchanges, whose problem reports may be sent to the peers, and any error encountered
+func (m *machine[R]) ExpireStale() ([]R, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records, err := m.Exchanges("")
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	var expired []R
+	for _, record := range records {
+		exchange := record.exchange()
+		if !exchange.expired(now) {
+			continue
+		}
+		exchange.abandon(timeoutProblem(exchange.State), now)
+		if err := m.save(record); err != nil {
+			return expired, err
+		}
+		expired = append(expired, record)
+	}
+	return expired, nil
+}
+
+/// @dev Loads an exchange in the given state, runs fn on it and saves the result. An expired
+/// exchange is abandoned instead. When fn fails after ending the exchange, the ended record is
+/// saved and returned together with the error; other failures leave the stored record unchanged
+func (m *machine[R]) update(id, state string, fn func(record R, now time.Time) error) (R, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, err := m.Exchange(id)
+	if err != nil {
+		return record, err
+	}
+	return m.apply(record, state, fn)
+}
+
+/// @dev Runs fn on a loaded record; the caller holds the mutex
+func (m *machine[R]) apply(record R, state string, fn func(record R, now time.Time) error) (R, error) {
+	var none R
+	now := m.now()
+	exchange := record.exchange()
+	if err := exchange.expect(state, now); err != nil {
+		if !errors.Is(err, ErrExchangeExpired) {
+			return none, err
+		}
+		exchange.abandon(timeoutProblem(exchange.State), now)
+		if saveErr := m.save(record); saveErr != nil {
+			return none, saveErr
+		}
+		return record, err
+	}
+	if err := fn(record, now); err != nil {
+		if !exchange.Terminal() {
+			return none, err
+		}
+		if saveErr := m.save(record); saveErr != nil {
+			return none, fmt.Errorf("%w (saving exchange: %v)", err, saveErr)
+		}
+		return record, err
+	}
+	return record, m.save(record)
+}
+
+/// @dev Stores a new exchange, failing if its ID is taken
+func (m *machine[R]) create(record R) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exists, err := m.store.exists(record.exchange().ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrExchangeExists, record.exchange().ID)
+	}
+	return m.save(record)
+}
+
+/// @dev Starts a new exchange, or continues an existing one in the given state
+/// @param id Thread ID; a new exchange is started under a generated ID when empty
+/// @param state The state an existing exchange must be in
+/// @param start The state of a new exchange
+func (m *machine[R]) startOrContinue(id, state, start string, fn func(record R, now time.Time) error) (R, error) {
+	var none R
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id != "" {
+		record, err := m.Exchange(id)
+		if err == nil {
+			return m.apply(record, state, fn)
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return none, err
+		}
+	} else {
+		var err error
+		if id, err = newExchangeID(); err != nil {
+			return none, err
+		}
+	}
+	now := m.now()
+	record := m.newRecord()
+	*record.exchange() = newExchange(id, start, now, m.timeout)
+	if err := fn(record, now); err != nil {
+		return none, err
+	}
+	return record, m.save(record)
+}
+
+/// @dev Moves an exchange to the next state
+func (m *machine[R]) advance(record R, state string, now time.Time) error {
+	return record.exchange().moveTo(m.states, state, now, m.timeout)
+}
+
+func (m *machine[R]) save(record R) error {
+	return m.store.put(record.exchange(), record)
+}
+
+/// @dev Problem report for an exchange that timed out in the given state
+func timeoutProblem(state string) ProblemReport {
+	return ProblemReport{Code: ProblemTimeout, Description: "no answer in state " + state}
+}
+
+/// @dev Persists records of one role under a storage category
+type exchangeStore struct {
+	store    storage.Store
+	category string
+}
+
+func (s exchangeStore) get(id string, record interface{}) error {
+	stored, err := s.store.Get(s.category, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrExchangeNotFound
+		}
+		return err
+	}
+	if err := json.Unmarshal(stored.Value, record); err != nil {
+		return fmt.Errorf("corrupt %s record %s: %w", s.category, id, err)
+	}
+	return nil
+}
+
+func (s exchangeStore) exists(id string) (bool, error) {
+	_, err := s.store.Get(s.category, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s exchangeStore) put(exchange *Exchange, record interface{}) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.store.Put(&storage.Record{
+		Category: s.category,
+		ID:       exchange.ID,
+		Value:    value,
+		Tags:     map[string]string{"state": exchange.State},
+	})
+}
+
+/// @dev Passes the value of every record of the category to decode
+func (s exchangeStore) list(decode func(value []byte) error) error {
+	stored, err := s.store.List(s.category)
+	if err != nil {
+		return err
+	}
+	for _, record := range stored {
+		if err := decode(record.Value); err != nil {
+			return fmt.Errorf("corrupt %s record %s: %w", s.category, record.ID, err)
+		}
+	}
+	return nil
+}
+
+/// @dev Returns a random 128-bit hex thread ID
+func newExchangeID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,443 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+/// @title Issue-Credential Exchange
+/// @dev Issuer and holder state machines for offer → request → credential → ack
+
+/// @notice Storage categories holding issuance exchanges
+const (
+	CategoryIssuerExchange = "issuance_issuer"
+	CategoryHolderExchange = "issuance_holder"
+)
+
+/// @notice States of an issuance exchange
+const (
+	IssuanceStateOfferSent          = "offer-sent"          /// @notice Issuer: waiting for a credential request
+	IssuanceStateRequestReceived    = "request-received"    /// @notice Issuer: ready to issue
+	IssuanceStateCredentialIssued   = "credential-issued"   /// @notice Issuer: waiting for the ack
+	IssuanceStateOfferReceived      = "offer-received"      /// @notice Holder: ready to request
+	IssuanceStateRequestSent        = "request-sent"        /// @notice Holder: waiting for the credential
+	IssuanceStateCredentialReceived = "credential-received" /// @notice Holder: stored, ready to ack
+)
+
+/// @notice Problem report code for an issuance that cannot continue
+const ProblemIssuanceAbandoned = "issuance-abandoned"
+
+/// @dev States of the issuer machine
+var issuerStates = states{transitions: map[string][]string{
+	IssuanceStateOfferSent:        {IssuanceStateRequestReceived},
+	IssuanceStateRequestReceived:  {IssuanceStateCredentialIssued},
+	IssuanceStateCredentialIssued: {StateDone},
+}}
+
+/// @dev States of the holder machine
+var holderStates = states{transitions: map[string][]string{
+	IssuanceStateOfferReceived:      {IssuanceStateRequestSent},
+	IssuanceStateRequestSent:        {IssuanceStateCredentialReceived},
+	IssuanceStateCredentialReceived: {StateDone},
+}}
+
+/// @notice A persisted issuance exchange
+/// @dev Message payloads are kept only while they are needed: attribute values until the
+/// credential is issued, the issued credential until it is acknowledged and the request
+/// metadata until the credential arrives
+type IssuanceRecord struct {
+	Exchange
+	CredentialDefinitionID string            `json:"cred_def_id"`
+	SchemaID               string            `json:"schema_id"`
+	OfferID                string            `json:"offer_id,omitempty"` /// @notice Issuer: the issuer.Service offer
+	Offer                  json.RawMessage   `json:"offer"`
+	Request                json.RawMessage   `json:"request,omitempty"`
+	RequestMetadata        json.RawMessage   `json:"request_metadata,omitempty"` /// @notice Holder: kept until the credential arrives
+	Values                 map[string]string `json:"values,omitempty"`           /// @notice Issuer: raw values to issue
+	Credential             json.RawMessage   `json:"credential,omitempty"`       /// @notice Issuer: kept until acknowledged
+	CredentialID           string            `json:"credential_id,omitempty"`    /// @notice Issuance record ID or wallet referent
+	LinkSecretID           string            `json:"link_secret_id,omitempty"`   /// @notice Holder: the secret the request was made with
+}
+
+/// @dev Abandons an exchange because of a local failure and returns the failure
+func failIssuance(record *IssuanceRecord, code string, err error, now time.Time) error {
+	record.abandon(ProblemReport{Code: code, Description: err.Error()}, now)
+	return err
+}
+
+/// @notice Configuration of the issuer state machine
+type CredentialIssuerOptions struct {
+	/// @notice Creates offers and credentials
+	Service *issuer.Service
+
+	/// @notice Where exchanges are kept
+	Store storage.Store
+
+	/// @notice Time allowed between two messages; defaults to DefaultExchangeTimeout
+	Timeout time.Duration
+
+	/// @notice Clock used for timeouts; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Issuer side of the issue-credential exchange
+/// @dev Safe for concurrent use
+type CredentialIssuer struct {
+	machine[*IssuanceRecord]
+	service *issuer.Service
+}
+
+/// @notice Creates an issuer state machine
+func NewCredentialIssuer(options CredentialIssuerOptions) (*CredentialIssuer, error) {
+	if options.Service == nil {
+		return nil, fmt.Errorf("issuer service is required")
+	}
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultExchangeTimeout
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &CredentialIssuer{
+		machine: machine[*IssuanceRecord]{
+			store:     exchangeStore{store: options.Store, category: CategoryIssuerExchange},
+			states:    issuerStates,
+			newRecord: func() *IssuanceRecord { return &IssuanceRecord{} },
+			timeout:   options.Timeout,
+			now:       options.Now,
+		},
+		service: options.Service,
+	}, nil
+}
+
+/// @notice Starts an exchange by offering a credential
+/// @param credDefID The credential definition to offer
+/// @param values Raw attribute values to issue once the holder requests the credential
+/// @return The exchange in offer-sent; its Offer field is the payload to send
+func (i *CredentialIssuer) Offer(credDefID string, values map[string]string) (*IssuanceRecord, error) {
+	offer, err := i.service.Offer(credDefID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newExchangeID()
+	if err != nil {
+		return nil, err
+	}
+	record := &IssuanceRecord{
+		Exchange:               newExchange(id, IssuanceStateOfferSent, i.now(), i.timeout),
+		CredentialDefinitionID: offer.CredentialDefinitionID,
+		SchemaID:               offer.SchemaID,
+		OfferID:                offer.ID,
+		Offer:                  offer.Offer,
+		Values:                 values,
+	}
+	if err := i.create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Records the holder's credential request
+/// @param id The exchange's thread ID
+/// @param request The credential request payload
+/// @return The exchange in request-received, or abandoned with ErrExchangeExpired
+/// @dev A request for another credential definition abandons the exchange with invalid-message
+func (i *CredentialIssuer) ReceiveRequest(id string, request []byte) (*IssuanceRecord, error) {
+	return i.update(id, IssuanceStateOfferSent, func(record *IssuanceRecord, now time.Time) error {
+		var contents struct {
+			CredentialDefinitionID string `json:"cred_def_id"`
+		}
+		if err := json.Unmarshal(request, &contents); err != nil {
+			return failIssuance(record, ProblemInvalidMessage, fmt.Errorf("invalid credential request: %w", err), now)
+		}
+		if contents.CredentialDefinitionID != record.CredentialDefinitionID {
+			return failIssuance(record, ProblemInvalidMessage, fmt.Errorf("%w: requested %s, offered %s", issuer.ErrRequestMismatch, contents.CredentialDefinitionID, record.CredentialDefinitionID), now)
+		}
+		record.Request = append(json.RawMessage(nil), request...)
+		return i.advance(record, IssuanceStateRequestReceived, now)
+	})
+}
+
+/// @notice Issues the credential for a received request
+/// @return The exchange in credential-issued, whose Credential field is the payload to send
+/// @dev A failed issuance abandons the exchange with issuance-abandoned
+func (i *CredentialIssuer) Issue(id string) (*IssuanceRecord, error) {
+	return i.update(id, IssuanceStateRequestReceived, func(record *IssuanceRecord, now time.Time) error {
+		request, err := anoncreds.CredentialRequestFromJSON([]byte(record.Request))
+		if err != nil {
+			return failIssuance(record, ProblemInvalidMessage, err, now)
+		}
+		defer request.Clear()
+		credential, issued, err := i.service.Issue(record.OfferID, request, record.Values)
+		if err != nil {
+			return failIssuance(record, ProblemIssuanceAbandoned, err, now)
+		}
+		defer credential.Clear()
+		credentialJSON, err := credential.ToJSONString()
+		if err != nil {
+			return failIssuance(record, ProblemIssuanceAbandoned, err, now)
+		}
+		record.Credential = json.RawMessage(credentialJSON)
+		record.CredentialID = issued.ID
+		record.Values = nil
+		return i.advance(record, IssuanceStateCredentialIssued, now)
+	})
+}
+
+/// @notice Records the holder's acknowledgement, completing the exchange
+func (i *CredentialIssuer) ReceiveAck(id string) (*IssuanceRecord, error) {
+	return i.update(id, IssuanceStateCredentialIssued, func(record *IssuanceRecord, now time.Time) error {
+		record.Credential = nil
+		return i.advance(record, StateDone, now)
+	})
+}
+
+/// @notice Configuration of the holder state machine
+type CredentialHolderOptions struct {
+	/// @notice Where exchanges are kept
+	Store storage.Store
+
+	/// @notice Provides the link secrets requests are made with
+	LinkSecrets *wallet.LinkSecretManager
+
+	/// @notice Where received credentials are stored
+	Credentials wallet.CredentialStore
+
+	/// @notice Resolves the credential definition of an offer, e.g. from a ledger
+	CredentialDefinition func(credDefID string) (*anoncreds.CredentialDefinition, error)
+
+	/// @notice Resolves the registry definition of a revocable credential; optional
+	RevocationRegistryDefinition func(revRegID string) (*anoncreds.RevocationRegistryDefinition, error)
+
+	/// @notice Legacy prover DID sent with requests; random entropy is used when empty
+	ProverDID string
+
+	/// @notice Time allowed between two messages; defaults to DefaultExchangeTimeout
+	Timeout time.Duration
+
+	/// @notice Clock used for timeouts; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Holder side of the issue-credential exchange
+/// @dev Safe for concurrent use
+type CredentialHolder struct {
+	machine[*IssuanceRecord]
+	options CredentialHolderOptions
+}
+
+/// @notice Creates a holder state machine
+func NewCredentialHolder(options CredentialHolderOptions) (*CredentialHolder, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.LinkSecrets == nil {
+		return nil, fmt.Errorf("link secret manager is required")
+	}
+	if options.Credentials == nil {
+		return nil, fmt.Errorf("credential store is required")
+	}
+	if options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("credential definition resolver is required")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultExchangeTimeout
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &CredentialHolder{
+		machine: machine[*IssuanceRecord]{
+			store:     exchangeStore{store: options.Store, category: CategoryHolderExchange},
+			states:    holderStates,
+			newRecord: func() *IssuanceRecord { return &IssuanceRecord{} },
+			timeout:   options.Timeout,
+			now:       options.Now,
+		},
+		options: options,
+	}, nil
+}
+
+/// @notice Records a credential offer
+/// @param id The issuer's thread ID; generated when empty
+/// @param offer The credential offer payload
+/// @return The exchange in offer-received
+func (h *CredentialHolder) ReceiveOffer(id string, offer []byte) (*IssuanceRecord, error) {
+	var contents struct {
+		SchemaID               string `json:"schema_id"`
+		CredentialDefinitionID string `json:"cred_def_id"`
+		Nonce                  string `json:"nonce"`
+	}
+	if err := json.Unmarshal(offer, &contents); err != nil {
+		return nil, fmt.Errorf("invalid credential offer: %w", err)
+	}
+	if contents.SchemaID == "" || contents.CredentialDefinitionID == "" || contents.Nonce == "" {
+		return nil, fmt.Errorf("credential offer is missing schema_id, cred_def_id or nonce")
+	}
+	if id == "" {
+		var err error
+		if id, err = newExchangeID(); err != nil {
+			return nil, err
+		}
+	}
+	record := &IssuanceRecord{
+		Exchange:               newExchange(id, IssuanceStateOfferReceived, h.now(), h.timeout),
+		CredentialDefinitionID: contents.CredentialDefinitionID,
+		SchemaID:               contents.SchemaID,
+		Offer:                  append(json.RawMessage(nil), offer...),
+	}
+	if err := h.create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Creates the credential request for a received offer
+/// @param id The exchange's thread ID
+/// @param linkSecretID The link secret to bind the credential to; the default when empty
+/// @return The exchange in request-sent, whose Request field is the payload to send
+func (h *CredentialHolder) Request(id, linkSecretID string) (*IssuanceRecord, error) {
+	return h.update(id, IssuanceStateOfferReceived, func(record *IssuanceRecord, now time.Time) error {
+		if linkSecretID == "" {
+			var err error
+			if linkSecretID, err = h.options.LinkSecrets.DefaultID(); err != nil {
+				return err
+			}
+		}
+		credDef, err := h.options.CredentialDefinition(record.CredentialDefinitionID)
+		if err != nil {
+			return err
+		}
+		defer credDef.Clear()
+		offer, err := anoncreds.CredentialOfferFromJSON([]byte(record.Offer))
+		if err != nil {
+			return failIssuance(record, ProblemInvalidMessage, err, now)
+		}
+		defer offer.Clear()
+
+		options := anoncreds.CreateCredentialRequestOptions{
+			CredentialDefinition: credDef,
+			LinkSecretID:         linkSecretID,
+			CredentialOffer:      offer,
+		}
+		if h.options.ProverDID != "" {
+			options.ProverDID = &h.options.ProverDID
+		} else if options.Entropy, err = newExchangeID(); err != nil {
+			return err
+		}
+		var result *anoncreds.CreateCredentialRequestResult
+		err = h.options.LinkSecrets.Use(linkSecretID, func(secret *anoncreds.LinkSecret) error {
+			options.LinkSecret = secret
+			result, err = anoncreds.CreateCredentialRequest(options)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		defer result.CredentialRequest.Clear()
+		defer result.CredentialRequestMetadata.Clear()
+
+		requestJSON, err := result.CredentialRequest.ToJSONString()
+		if err != nil {
+			return err
+		}
+		metadataJSON, err := result.CredentialRequestMetadata.ToJSONString()
+		if err != nil {
+			return err
+		}
+		record.Request = json.RawMessage(requestJSON)
+		record.RequestMetadata = json.RawMessage(metadataJSON)
+		record.LinkSecretID = linkSecretID
+		return h.advance(record, IssuanceStateRequestSent, now)
+	})
+}
+
+/// @notice Processes and stores the issued credential
+/// @param id The exchange's thread ID
+/// @param credential The credential payload
+/// @param metadata Details stored with the credential; its link secret ID is set from the exchange
+/// @return The exchange in credential-received, with CredentialID set to the wallet referent
+/// @dev A credential that fails processing abandons the exchange with issuance-abandoned
+func (h *CredentialHolder) ReceiveCredential(id string, credential []byte, metadata wallet.CredentialMetadata) (*IssuanceRecord, error) {
+	return h.update(id, IssuanceStateRequestSent, func(record *IssuanceRecord, now time.Time) error {
+		var contents struct {
+			CredentialDefinitionID string `json:"cred_def_id"`
+			RevocationRegistryID   string `json:"rev_reg_id"`
+		}
+		if err := json.Unmarshal(credential, &contents); err != nil {
+			return failIssuance(record, ProblemInvalidMessage, fmt.Errorf("invalid credential: %w", err), now)
+		}
+		if contents.CredentialDefinitionID != record.CredentialDefinitionID {
+			return failIssuance(record, ProblemInvalidMessage, fmt.Errorf("credential is for %s, not %s", contents.CredentialDefinitionID, record.CredentialDefinitionID), now)
+		}
+
+		received, err := anoncreds.CredentialFromJSON(credential)
+		if err != nil {
+			return failIssuance(record, ProblemInvalidMessage, err, now)
+		}
+		defer received.Clear()
+		requestMetadata, err := anoncreds.CredentialRequestMetadataFromJSON([]byte(record.RequestMetadata))
+		if err != nil {
+			return err
+		}
+		defer requestMetadata.Clear()
+		credDef, err := h.options.CredentialDefinition(record.CredentialDefinitionID)
+		if err != nil {
+			return err
+		}
+		defer credDef.Clear()
+		options := anoncreds.ProcessCredentialOptions{
+			Credential:                received,
+			CredentialRequestMetadata: requestMetadata,
+			CredentialDefinition:      credDef,
+		}
+		if contents.RevocationRegistryID != "" {
+			if h.options.RevocationRegistryDefinition == nil {
+				return fmt.Errorf("credential is revocable but no registry definition resolver is configured")
+			}
+			revRegDef, err := h.options.RevocationRegistryDefinition(contents.RevocationRegistryID)
+			if err != nil {
+				return err
+			}
+			defer revRegDef.Clear()
+			options.RevocationRegistryDefinition = revRegDef
+		}
+
+		var processed *anoncreds.Credential
+		err = h.options.LinkSecrets.Use(record.LinkSecretID, func(secret *anoncreds.LinkSecret) error {
+			options.LinkSecret = secret
+			processed, err = anoncreds.ProcessCredential(options)
+			return err
+		})
+		if err != nil {
+			return failIssuance(record, ProblemIssuanceAbandoned, err, now)
+		}
+		defer processed.Clear()
+
+		metadata.LinkSecretID = record.LinkSecretID
+		stored, err := wallet.StoreCredential(h.options.Credentials, processed, metadata)
+		if err != nil {
+			return err
+		}
+		record.CredentialID = stored.Referent
+		record.RequestMetadata = nil
+		return h.advance(record, IssuanceStateCredentialReceived, now)
+	})
+}
+
+/// @notice Completes the exchange once the credential is stored
+/// @return The exchange in done; the caller sends the ack to the issuer
+func (h *CredentialHolder) Ack(id string) (*IssuanceRecord, error) {
+	return h.update(id, IssuanceStateCredentialReceived, func(record *IssuanceRecord, now time.Time) error {
+		return h.advance(record, StateDone, now)
+	})
+}
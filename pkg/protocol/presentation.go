@@ -0,0 +1,527 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/verifier"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+/// @title Present-Proof Exchange
+/// @dev Prover and verifier state machines for proposal → request → presentation → verification.
+/// Either side may answer with a counter-proposal or counter-request before a presentation is sent
+
+/// @notice Storage categories holding presentation exchanges
+const (
+	CategoryProverExchange   = "presentation_prover"
+	CategoryVerifierExchange = "presentation_verifier"
+)
+
+/// @notice States of a presentation exchange
+const (
+	PresentationStateProposalSent         = "proposal-sent"         /// @notice Prover: waiting for a request
+	PresentationStateRequestReceived      = "request-received"      /// @notice Prover: ready to present, decline or counter-propose
+	PresentationStatePresentationSent     = "presentation-sent"     /// @notice Prover: waiting for the ack
+	PresentationStateProposalReceived     = "proposal-received"     /// @notice Verifier: ready to request
+	PresentationStateRequestSent          = "request-sent"          /// @notice Verifier: waiting for a presentation or counter-proposal
+	PresentationStatePresentationReceived = "presentation-received" /// @notice Verifier: ready to verify
+	PresentationStateVerified             = "verified"              /// @notice Verifier: the presentation verified
+	PresentationStateDeclined             = "declined"              /// @notice The prover declined, or the verifier rejected the presentation
+)
+
+/// @notice Problem report codes of presentation exchanges
+const (
+	ProblemPresentationAbandoned = "presentation-abandoned" /// @notice The prover cannot or will not present
+	ProblemPresentationRejected  = "presentation-rejected"  /// @notice The presentation did not verify
+)
+
+/// @dev States of the prover machine
+var proverStates = states{
+	transitions: map[string][]string{
+		PresentationStateProposalSent:     {PresentationStateRequestReceived},
+		PresentationStateRequestReceived:  {PresentationStatePresentationSent, PresentationStateProposalSent, PresentationStateDeclined},
+		PresentationStatePresentationSent: {StateDone},
+	},
+	final: []string{PresentationStateDeclined},
+}
+
+/// @dev States of the verifier machine
+var verifierStates = states{
+	transitions: map[string][]string{
+		PresentationStateProposalReceived:     {PresentationStateRequestSent},
+		PresentationStateRequestSent:          {PresentationStatePresentationReceived, PresentationStateProposalReceived},
+		PresentationStatePresentationReceived: {PresentationStateVerified, PresentationStateDeclined},
+	},
+	final: []string{PresentationStateVerified, PresentationStateDeclined},
+}
+
+/// @notice A persisted presentation exchange
+type PresentationRecord struct {
+	Exchange
+	Proposal            json.RawMessage               `json:"proposal,omitempty"`         /// @notice The latest proposal
+	Request             json.RawMessage               `json:"request,omitempty"`          /// @notice The latest presentation request
+	Nonce               string                        `json:"nonce,omitempty"`            /// @notice Nonce of the latest request
+	NonceRequestID      string                        `json:"nonce_request_id,omitempty"` /// @notice Verifier: the NonceManager request ID of the nonce
+	Rounds              int                           `json:"rounds"`                     /// @notice Number of requests sent or received
+	Presentation        json.RawMessage               `json:"presentation,omitempty"`
+	CredentialReferents []string                      `json:"credential_referents,omitempty"` /// @notice Prover: the wallet credentials presented
+	Report              *anoncreds.VerificationReport `json:"report,omitempty"`               /// @notice Verifier: the verification outcome
+}
+
+/// @dev Records a presentation request received by or sent to the peer
+func setRequest(record *PresentationRecord, request []byte) error {
+	contents, err := anoncreds.ParsePresentationRequest(request)
+	if err != nil {
+		return err
+	}
+	if contents.Nonce == "" {
+		return fmt.Errorf("presentation request has no nonce")
+	}
+	record.Request = append(json.RawMessage(nil), request...)
+	record.Nonce = contents.Nonce
+	record.Presentation = nil
+	record.Rounds++
+	return nil
+}
+
+/// @notice Configuration of the prover state machine
+type PresentationProverOptions struct {
+	/// @notice Where exchanges are kept
+	Store storage.Store
+
+	/// @notice Provides the link secret the presented credentials were issued against
+	LinkSecrets *wallet.LinkSecretManager
+
+	/// @notice The credentials to present from
+	Credentials wallet.CredentialStore
+
+	/// @notice Ranking hooks used when Present selects credentials itself
+	Select wallet.SelectOptions
+
+	/// @notice Resolves the schemas of presented credentials
+	Schema func(schemaID string) (*anoncreds.Schema, error)
+
+	/// @notice Resolves the credential definitions of presented credentials
+	CredentialDefinition func(credDefID string) (*anoncreds.CredentialDefinition, error)
+
+	/// @notice Builds the revocation state of a credential that must prove non-revocation; optional
+	RevocationState func(entry *wallet.SelectedEntry) (*anoncreds.CredentialRevocationState, error)
+
+	/// @notice Time allowed between two messages; defaults to DefaultExchangeTimeout
+	Timeout time.Duration
+
+	/// @notice Clock used for timeouts; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Prover side of the present-proof exchange
+/// @dev Safe for concurrent use
+type PresentationProver struct {
+	machine[*PresentationRecord]
+	options PresentationProverOptions
+}
+
+/// @notice Creates a prover state machine
+func NewPresentationProver(options PresentationProverOptions) (*PresentationProver, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.LinkSecrets == nil {
+		return nil, fmt.Errorf("link secret manager is required")
+	}
+	if options.Credentials == nil {
+		return nil, fmt.Errorf("credential store is required")
+	}
+	if options.Schema == nil || options.CredentialDefinition == nil {
+		return nil, fmt.Errorf("schema and credential definition resolvers are required")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultExchangeTimeout
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &PresentationProver{
+		machine: machine[*PresentationRecord]{
+			store:     exchangeStore{store: options.Store, category: CategoryProverExchange},
+			states:    proverStates,
+			newRecord: func() *PresentationRecord { return &PresentationRecord{} },
+			timeout:   options.Timeout,
+			now:       options.Now,
+		},
+		options: options,
+	}, nil
+}
+
+/// @notice Starts an exchange by proposing a presentation
+/// @param proposal The proposal payload
+/// @return The exchange in proposal-sent
+func (p *PresentationProver) Propose(proposal []byte) (*PresentationRecord, error) {
+	return p.startOrContinue("", "", PresentationStateProposalSent, func(record *PresentationRecord, now time.Time) error {
+		record.Proposal = append(json.RawMessage(nil), proposal...)
+		return nil
+	})
+}
+
+/// @notice Records a presentation request
+/// @param id The verifier's thread ID; a request on an unknown thread starts a new exchange
+/// @param request The presentation request payload
+/// @return The exchange in request-received
+func (p *PresentationProver) ReceiveRequest(id string, request []byte) (*PresentationRecord, error) {
+	return p.startOrContinue(id, PresentationStateProposalSent, PresentationStateRequestReceived, func(record *PresentationRecord, now time.Time) error {
+		if err := setRequest(record, request); err != nil {
+			return err
+		}
+		if record.State == PresentationStateRequestReceived {
+			return nil
+		}
+		return p.advance(record, PresentationStateRequestReceived, now)
+	})
+}
+
+/// @notice Selects credentials for a received request
+/// @return A selection the caller may adjust, e.g. by filling SelfAttestedAttributes, before Present
+func (p *PresentationProver) Select(id string) (*wallet.CredentialSelection, error) {
+	record, err := p.Exchange(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.State != PresentationStateRequestReceived {
+		return nil, fmt.Errorf("%w: exchange %s is %s, not %s", ErrInvalidTransition, id, record.State, PresentationStateRequestReceived)
+	}
+	request, err := anoncreds.PresentationRequestFromJSON([]byte(record.Request))
+	if err != nil {
+		return nil, err
+	}
+	defer request.Clear()
+	return wallet.SelectCredentials(request, p.options.Credentials, p.options.Select)
+}
+
+/// @notice Creates the presentation for a received request
+/// @param id The exchange's thread ID
+/// @param selection The credentials to present; selected automatically when nil
+/// @return The exchange in presentation-sent, whose Presentation field is the payload to send
+/// @dev The presented credentials must all be bound to the same link secret
+func (p *PresentationProver) Present(id string, selection *wallet.CredentialSelection) (*PresentationRecord, error) {
+	if selection == nil {
+		var err error
+		if selection, err = p.Select(id); err != nil {
+			return nil, err
+		}
+	}
+	return p.update(id, PresentationStateRequestReceived, func(record *PresentationRecord, now time.Time) error {
+		presentation, err := p.present(record, selection)
+		if err != nil {
+			return err
+		}
+		record.Presentation = presentation
+		record.CredentialReferents = record.CredentialReferents[:0]
+		for _, entry := range selection.Entries {
+			record.CredentialReferents = append(record.CredentialReferents, entry.Record.Referent)
+		}
+		return p.advance(record, PresentationStatePresentationSent, now)
+	})
+}
+
+/// @notice Answers a request with a counter-proposal
+/// @return The exchange in proposal-sent
+func (p *PresentationProver) CounterPropose(id string, proposal []byte) (*PresentationRecord, error) {
+	return p.update(id, PresentationStateRequestReceived, func(record *PresentationRecord, now time.Time) error {
+		record.Proposal = append(json.RawMessage(nil), proposal...)
+		return p.advance(record, PresentationStateProposalSent, now)
+	})
+}
+
+/// @notice Declines a received request
+/// @param reason Description sent to the verifier in the problem report
+/// @return The exchange in declined, whose Problem field is the report to send
+func (p *PresentationProver) Decline(id, reason string) (*PresentationRecord, error) {
+	return p.update(id, PresentationStateRequestReceived, func(record *PresentationRecord, now time.Time) error {
+		record.Problem = &ProblemReport{Code: ProblemPresentationAbandoned, Description: reason}
+		return p.advance(record, PresentationStateDeclined, now)
+	})
+}
+
+/// @notice Records the verifier's acknowledgement, completing the exchange
+func (p *PresentationProver) ReceiveAck(id string) (*PresentationRecord, error) {
+	return p.update(id, PresentationStatePresentationSent, func(record *PresentationRecord, now time.Time) error {
+		return p.advance(record, StateDone, now)
+	})
+}
+
+/// @dev Creates the presentation JSON for a selection
+func (p *PresentationProver) present(record *PresentationRecord, selection *wallet.CredentialSelection) (json.RawMessage, error) {
+	request, err := anoncreds.PresentationRequestFromJSON([]byte(record.Request))
+	if err != nil {
+		return nil, err
+	}
+	defer request.Clear()
+
+	linkSecretID := ""
+	for _, entry := range selection.Entries {
+		switch {
+		case entry.Record.LinkSecretID == "":
+		case linkSecretID == "":
+			linkSecretID = entry.Record.LinkSecretID
+		case linkSecretID != entry.Record.LinkSecretID:
+			return nil, fmt.Errorf("selected credentials are bound to different link secrets")
+		}
+	}
+
+	credentials, err := selection.PresentCredentials()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, credential := range credentials {
+			credential.Credential.Clear()
+			if credential.RevocationState != nil {
+				credential.RevocationState.Clear()
+			}
+		}
+	}()
+	for i, entry := range selection.Entries {
+		if entry.NonRevoked == nil {
+			continue
+		}
+		if p.options.RevocationState == nil {
+			return nil, fmt.Errorf("credential %s must prove non-revocation but no revocation state builder is configured", entry.Record.Referent)
+		}
+		if credentials[i].RevocationState, err = p.options.RevocationState(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	schemas := make(map[string]*anoncreds.Schema)
+	credDefs := make(map[string]*anoncreds.CredentialDefinition)
+	defer func() {
+		for _, schema := range schemas {
+			schema.Clear()
+		}
+		for _, credDef := range credDefs {
+			credDef.Clear()
+		}
+	}()
+	for _, schemaID := range selection.SchemaIDs() {
+		if schemas[schemaID], err = p.options.Schema(schemaID); err != nil {
+			delete(schemas, schemaID)
+			return nil, err
+		}
+	}
+	for _, credDefID := range selection.CredentialDefinitionIDs() {
+		if credDefs[credDefID], err = p.options.CredentialDefinition(credDefID); err != nil {
+			delete(credDefs, credDefID)
+			return nil, err
+		}
+	}
+
+	var presentation *anoncreds.Presentation
+	err = p.options.LinkSecrets.Use(linkSecretID, func(secret *anoncreds.LinkSecret) error {
+		presentation, err = anoncreds.CreatePresentation(anoncreds.CreatePresentationOptions{
+			PresentationRequest:    request,
+			Credentials:            credentials,
+			CredentialsProve:       selection.Prove,
+			SelfAttestedAttributes: selection.SelfAttestedAttributes,
+			LinkSecret:             secret,
+			Schemas:                schemas,
+			CredentialDefinitions:  credDefs,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer presentation.Clear()
+	presentationJSON, err := presentation.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(presentationJSON), nil
+}
+
+/// @notice Configuration of the verifier state machine
+type PresentationVerifierOptions struct {
+	/// @notice Where exchanges are kept
+	Store storage.Store
+
+	/// @notice Issues request nonces and consumes them once a presentation verifies; defaults to
+	/// a nonce manager over Store whose nonces live as long as Timeout
+	Nonces *verifier.NonceManager
+
+	/// @notice Supplies the schemas, credential definitions and revocation data a presentation
+	/// refers to. The machine sets Presentation and PresentationRequest; the caller keeps
+	/// ownership of the objects it returns
+	Resolve func(presentation *anoncreds.Presentation) (anoncreds.VerifyPresentationOptions, error)
+
+	/// @notice Time allowed between two messages; defaults to DefaultExchangeTimeout
+	Timeout time.Duration
+
+	/// @notice Clock used for timeouts; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Verifier side of the present-proof exchange
+/// @dev Safe for concurrent use
+type PresentationVerifier struct {
+	machine[*PresentationRecord]
+	options PresentationVerifierOptions
+}
+
+/// @notice Creates a verifier state machine
+func NewPresentationVerifier(options PresentationVerifierOptions) (*PresentationVerifier, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.Resolve == nil {
+		return nil, fmt.Errorf("resolver is required")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultExchangeTimeout
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.Nonces == nil {
+		options.Nonces = verifier.NewNonceManager(verifier.NonceManagerOptions{Store: options.Store, TTL: options.Timeout, Now: options.Now})
+	}
+	return &PresentationVerifier{
+		machine: machine[*PresentationRecord]{
+			store:     exchangeStore{store: options.Store, category: CategoryVerifierExchange},
+			states:    verifierStates,
+			newRecord: func() *PresentationRecord { return &PresentationRecord{} },
+			timeout:   options.Timeout,
+			now:       options.Now,
+		},
+		options: options,
+	}, nil
+}
+
+/// @notice Records a proposal from the prover
+/// @param id The prover's thread ID; a proposal on an unknown thread starts a new exchange
+/// @param proposal The proposal payload
+/// @return The exchange in proposal-received
+/// @dev A proposal on an exchange in request-sent is a counter-proposal to that request
+func (v *PresentationVerifier) ReceiveProposal(id string, proposal []byte) (*PresentationRecord, error) {
+	return v.startOrContinue(id, PresentationStateRequestSent, PresentationStateProposalReceived, func(record *PresentationRecord, now time.Time) error {
+		if !json.Valid(proposal) {
+			return fmt.Errorf("invalid presentation proposal")
+		}
+		record.Proposal = append(json.RawMessage(nil), proposal...)
+		if record.State == PresentationStateProposalReceived {
+			return nil
+		}
+		return v.advance(record, PresentationStateProposalReceived, now)
+	})
+}
+
+/// @notice Sends a presentation request with a fresh nonce
+/// @param id Empty to start a new exchange, or the thread ID of a proposal to answer
+/// @param request The request; its nonce is replaced with one from the nonce manager
+/// @return The exchange in request-sent, whose Request field is the payload to send
+/// @dev A request that differs from the received proposal is a counter-request
+func (v *PresentationVerifier) RequestPresentation(id string, request *anoncreds.PresentationRequestContents) (*PresentationRecord, error) {
+	if request == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	return v.startOrContinue(id, PresentationStateProposalReceived, PresentationStateRequestSent, func(record *PresentationRecord, now time.Time) error {
+		nonceRequestID := fmt.Sprintf("%s/%d", record.ID, record.Rounds+1)
+		nonce, err := v.options.Nonces.Issue(nonceRequestID)
+		if err != nil {
+			return err
+		}
+		contents := *request
+		contents.Nonce = nonce.Nonce
+		if contents.RequestedAttributes == nil {
+			contents.RequestedAttributes = map[string]anoncreds.RequestedAttribute{}
+		}
+		if contents.RequestedPredicates == nil {
+			contents.RequestedPredicates = map[string]anoncreds.RequestedPredicate{}
+		}
+		requestJSON, err := json.Marshal(contents)
+		if err != nil {
+			return err
+		}
+		if err := setRequest(record, requestJSON); err != nil {
+			return err
+		}
+		record.NonceRequestID = nonceRequestID
+		if record.State == PresentationStateRequestSent {
+			return nil
+		}
+		return v.advance(record, PresentationStateRequestSent, now)
+	})
+}
+
+/// @notice Records the prover's presentation
+/// @return The exchange in presentation-received
+func (v *PresentationVerifier) ReceivePresentation(id string, presentation []byte) (*PresentationRecord, error) {
+	return v.update(id, PresentationStateRequestSent, func(record *PresentationRecord, now time.Time) error {
+		if !json.Valid(presentation) {
+			return fmt.Errorf("invalid presentation")
+		}
+		record.Presentation = append(json.RawMessage(nil), presentation...)
+		return v.advance(record, PresentationStatePresentationReceived, now)
+	})
+}
+
+/// @notice Verifies the received presentation against the stored request and nonce
+/// @return The exchange in verified, or in declined with a problem report; Report holds the outcome
+/// @dev A presentation whose nonce was already used or has expired is declined with the nonce error
+func (v *PresentationVerifier) Verify(id string) (*PresentationRecord, error) {
+	return v.update(id, PresentationStatePresentationReceived, func(record *PresentationRecord, now time.Time) error {
+		presentation, err := anoncreds.PresentationFromJSON([]byte(record.Presentation))
+		if err != nil {
+			return v.reject(record, ProblemInvalidMessage, err, now)
+		}
+		defer presentation.Clear()
+		request, err := anoncreds.PresentationRequestFromJSON([]byte(record.Request))
+		if err != nil {
+			return err
+		}
+		defer request.Clear()
+
+		options, err := v.options.Resolve(presentation)
+		if err != nil {
+			return err
+		}
+		options.Presentation = presentation
+		options.PresentationRequest = request
+		report, err := v.options.Nonces.VerifyPresentation(record.NonceRequestID, options)
+		if err != nil {
+			if isNonceError(err) {
+				return v.reject(record, ProblemPresentationRejected, err, now)
+			}
+			return err
+		}
+		record.Report = report
+		if !report.Verified {
+			record.Problem = &ProblemReport{Code: ProblemPresentationRejected, Description: report.Reason}
+			return v.advance(record, PresentationStateDeclined, now)
+		}
+		return v.advance(record, PresentationStateVerified, now)
+	})
+}
+
+/// @dev Declines a presentation and returns the reason
+func (v *PresentationVerifier) reject(record *PresentationRecord, code string, err error, now time.Time) error {
+	record.Problem = &ProblemReport{Code: code, Description: err.Error()}
+	if moveErr := v.advance(record, PresentationStateDeclined, now); moveErr != nil {
+		return moveErr
+	}
+	return err
+}
+
+/// @dev Reports whether an error means the request's nonce cannot be used
+func isNonceError(err error) bool {
+	for _, target := range []error{verifier.ErrNonceNotFound, verifier.ErrNonceMismatch, verifier.ErrNonceExpired, verifier.ErrNonceConsumed} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wql"
+)
+
+/// @title Holder Endpoints
+/// @dev Link secrets, credential requests, stored credentials and presentations of the holder
+/// wallet. Link secret values and request metadata never leave the server
+
+/// @notice A link secret as returned by the API
+type linkSecretResponse struct {
+	ID        string `json:"id"`
+	Default   bool   `json:"default"`
+	CreatedAt int64  `json:"created_at"`
+	RetiredAt int64  `json:"retired_at,omitempty"`
+}
+
+func newLinkSecretResponse(record *wallet.LinkSecretRecord) linkSecretResponse {
+	return linkSecretResponse{ID: record.ID, Default: record.Default, CreatedAt: record.CreatedAt, RetiredAt: record.RetiredAt}
+}
+
+/// @notice Body of POST /wallet/link-secrets
+type createLinkSecretRequest struct {
+	ID string `json:"id,omitempty"` /// @notice Generated when empty
+}
+
+/// @dev IDs clients may choose for link secrets: at most 64 characters that need no escaping in
+/// URL paths, file names or logs
+var linkSecretIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+func (s *Server) createLinkSecret(r *http.Request) (int, interface{}, error) {
+	var body createLinkSecretRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.ID != "" && !linkSecretIDPattern.MatchString(body.ID) {
+		return 0, nil, badRequest("id must be at most 64 letters, digits, '.', '_' or '-'")
+	}
+	record, err := s.options.LinkSecrets.Create(body.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, newLinkSecretResponse(record), nil
+}
+
+func (s *Server) listLinkSecrets(r *http.Request) (int, interface{}, error) {
+	records, err := s.options.LinkSecrets.List()
+	if err != nil {
+		return 0, nil, err
+	}
+	secrets := make([]linkSecretResponse, len(records))
+	for i, record := range records {
+		secrets[i] = newLinkSecretResponse(record)
+	}
+	return http.StatusOK, secrets, nil
+}
+
+/// @notice Body of POST /wallet/credential-requests
+type createCredentialRequestRequest struct {
+	Offer        json.RawMessage `json:"offer"`
+	LinkSecretID string          `json:"link_secret_id,omitempty"` /// @notice Defaults to the default link secret
+}
+
+/// @notice Response of POST /wallet/credential-requests
+type credentialRequestResponse struct {
+	ExchangeID             string          `json:"exchange_id"` /// @notice Passed with the credential to POST /wallet/credentials
+	CredentialDefinitionID string          `json:"cred_def_id"`
+	LinkSecretID           string          `json:"link_secret_id"`
+	Request                json.RawMessage `json:"request"` /// @notice The request to send to the issuer
+}
+
+/// @dev The request metadata is kept in the exchange until the credential arrives
+func (s *Server) createCredentialRequest(r *http.Request) (int, interface{}, error) {
+	var body createCredentialRequestRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if err := requireObject("offer", body.Offer); err != nil {
+		return 0, nil, err
+	}
+	exchange, err := s.holder.ReceiveOffer("", []byte(body.Offer))
+	if err != nil {
+		return 0, nil, badRequest("%v", err)
+	}
+	if exchange, err = s.holder.Request(exchange.ID, body.LinkSecretID); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, credentialRequestResponse{
+		ExchangeID:             exchange.ID,
+		CredentialDefinitionID: exchange.CredentialDefinitionID,
+		LinkSecretID:           exchange.LinkSecretID,
+		Request:                exchange.Request,
+	}, nil
+}
+
+/// @notice Body of POST /wallet/credentials
+type storeCredentialRequest struct {
+	ExchangeID string          `json:"exchange_id"` /// @notice Returned by POST /wallet/credential-requests
+	Credential json.RawMessage `json:"credential"`  /// @notice The credential sent by the issuer
+	Referent   string          `json:"referent,omitempty"`
+}
+
+func (s *Server) storeCredential(r *http.Request) (int, interface{}, error) {
+	var body storeCredentialRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.ExchangeID == "" {
+		return 0, nil, badRequest("exchange_id is required")
+	}
+	if err := requireObject("credential", body.Credential); err != nil {
+		return 0, nil, err
+	}
+	exchange, err := s.holder.ReceiveCredential(body.ExchangeID, []byte(body.Credential), wallet.CredentialMetadata{Referent: body.Referent})
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := s.holder.Ack(exchange.ID); err != nil {
+		return 0, nil, err
+	}
+	record, err := s.options.Credentials.Get(exchange.CredentialID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, newCredentialResponse(record), nil
+}
+
+/// @notice A stored credential as returned by the API
+/// @dev The credential itself is only included by GET /wallet/credentials/{referent}
+type credentialResponse struct {
+	Referent                string            `json:"referent"`
+	SchemaID                string            `json:"schema_id"`
+	CredentialDefinitionID  string            `json:"cred_def_id"`
+	RevocationRegistryID    string            `json:"rev_reg_id,omitempty"`
+	RevocationRegistryIndex *uint32           `json:"rev_reg_index,omitempty"`
+	LinkSecretID            string            `json:"link_secret_id,omitempty"`
+	Attributes              map[string]string `json:"attributes"`
+	Credential              json.RawMessage   `json:"credential,omitempty"`
+	CreatedAt               int64             `json:"created_at"`
+}
+
+func newCredentialResponse(record *wallet.CredentialRecord) credentialResponse {
+	return credentialResponse{
+		Referent:                record.Referent,
+		SchemaID:                record.SchemaID,
+		CredentialDefinitionID:  record.CredentialDefinitionID,
+		RevocationRegistryID:    record.RevocationRegistryID,
+		RevocationRegistryIndex: record.RevocationRegistryIndex,
+		LinkSecretID:            record.LinkSecretID,
+		Attributes:              record.Attributes,
+		CreatedAt:               record.CreatedAt,
+	}
+}
+
+/// @dev The optional wql query parameter filters on wallet tags, e.g. {"attr::name::value":"Alice"}
+func (s *Server) listCredentials(r *http.Request) (int, interface{}, error) {
+	var query wql.Query
+	if raw := r.URL.Query().Get("wql"); raw != "" {
+		var err error
+		if query, err = wql.Parse([]byte(raw)); err != nil {
+			return 0, nil, badRequest("invalid wql: %v", err)
+		}
+	}
+	records, err := wallet.Search(s.options.Credentials, query)
+	if err != nil {
+		return 0, nil, err
+	}
+	credentials := make([]credentialResponse, len(records))
+	for i, record := range records {
+		credentials[i] = newCredentialResponse(record)
+	}
+	return http.StatusOK, credentials, nil
+}
+
+func (s *Server) getCredential(r *http.Request) (int, interface{}, error) {
+	record, err := s.options.Credentials.Get(r.PathValue("referent"))
+	if err != nil {
+		return 0, nil, err
+	}
+	response := newCredentialResponse(record)
+	response.Credential = record.Value
+	return http.StatusOK, response, nil
+}
+
+func (s *Server) deleteCredential(r *http.Request) (int, interface{}, error) {
+	referent := r.PathValue("referent")
+	if _, err := s.options.Credentials.Get(referent); err != nil {
+		return 0, nil, err
+	}
+	if err := s.options.Credentials.Delete(referent); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+/// @notice Body of POST /presentations/create
+type createPresentationRequest struct {
+	Request                json.RawMessage   `json:"request"` /// @notice The verifier's presentation request
+	SelfAttestedAttributes map[string]string `json:"self_attested_attributes,omitempty"`
+}
+
+/// @notice Response of POST /presentations/create
+type presentationResponse struct {
+	ExchangeID          string          `json:"exchange_id"`
+	Presentation        json.RawMessage `json:"presentation"`
+	CredentialReferents []string        `json:"credential_referents"`
+}
+
+/// @dev Credentials are chosen from the wallet automatically; schemas and credential definitions
+/// come from the configured resolvers
+func (s *Server) createPresentation(r *http.Request) (int, interface{}, error) {
+	var body createPresentationRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if err := requireObject("request", body.Request); err != nil {
+		return 0, nil, err
+	}
+	exchange, err := s.prover.ReceiveRequest("", []byte(body.Request))
+	if err != nil {
+		return 0, nil, badRequest("%v", err)
+	}
+	selection, err := s.prover.Select(exchange.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(body.SelfAttestedAttributes) > 0 {
+		selection.SelfAttestedAttributes = body.SelfAttestedAttributes
+	}
+	if exchange, err = s.prover.Present(exchange.ID, selection); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, presentationResponse{
+		ExchangeID:          exchange.ID,
+		Presentation:        exchange.Presentation,
+		CredentialReferents: exchange.CredentialReferents,
+	}, nil
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+)
+
+/// @title Issuer Endpoints
+/// @dev Schemas, credential definitions, offers, issuance and revocation through issuer.Service.
+/// Private key material never leaves the server
+
+/// @notice Body of POST /schemas
+type createSchemaRequest struct {
+	ID             string   `json:"id,omitempty"` /// @notice Defaults to the legacy Indy schema ID
+	Name           string   `json:"name"`
+	Version        string   `json:"version"`
+	IssuerID       string   `json:"issuer_id"`
+	AttributeNames []string `json:"attr_names"`
+}
+
+func (s *Server) createSchema(r *http.Request) (int, interface{}, error) {
+	var body createSchemaRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.Name == "" || body.Version == "" || body.IssuerID == "" || len(body.AttributeNames) == 0 {
+		return 0, nil, badRequest("name, version, issuer_id and attr_names are required")
+	}
+	record, err := s.options.Issuer.CreateSchema(body.ID, anoncreds.CreateSchemaOptions{
+		Name:           body.Name,
+		Version:        body.Version,
+		IssuerID:       body.IssuerID,
+		AttributeNames: body.AttributeNames,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, record, nil
+}
+
+func (s *Server) getSchema(r *http.Request) (int, interface{}, error) {
+	record, err := s.options.Issuer.Schema(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, record, nil
+}
+
+/// @notice Body of POST /credential-definitions
+type createCredentialDefinitionRequest struct {
+	ID                string `json:"id"`
+	SchemaID          string `json:"schema_id"`
+	IssuerID          string `json:"issuer_id"`
+	Tag               string `json:"tag"`
+	SignatureType     string `json:"signature_type,omitempty"`
+	SupportRevocation bool   `json:"support_revocation,omitempty"`
+	MaxCredNum        uint32 `json:"max_cred_num,omitempty"`
+}
+
+/// @notice A credential definition as returned by the API, without its private part
+type credentialDefinitionResponse struct {
+	ID                   string          `json:"id"`
+	SchemaID             string          `json:"schema_id"`
+	IssuerID             string          `json:"issuer_id"`
+	Tag                  string          `json:"tag"`
+	SupportRevocation    bool            `json:"support_revocation"`
+	MaxCredNum           uint32          `json:"max_cred_num,omitempty"`
+	CredentialDefinition json.RawMessage `json:"cred_def"`
+	CreatedAt            int64           `json:"created_at"`
+}
+
+func newCredentialDefinitionResponse(record *issuer.CredentialDefinitionRecord) credentialDefinitionResponse {
+	return credentialDefinitionResponse{
+		ID:                   record.ID,
+		SchemaID:             record.SchemaID,
+		IssuerID:             record.IssuerID,
+		Tag:                  record.Tag,
+		SupportRevocation:    record.SupportRevocation,
+		MaxCredNum:           record.MaxCredNum,
+		CredentialDefinition: record.CredentialDefinition,
+		CreatedAt:            record.CreatedAt,
+	}
+}
+
+func (s *Server) createCredentialDefinition(r *http.Request) (int, interface{}, error) {
+	var body createCredentialDefinitionRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.ID == "" || body.SchemaID == "" || body.IssuerID == "" || body.Tag == "" {
+		return 0, nil, badRequest("id, schema_id, issuer_id and tag are required")
+	}
+	if body.SupportRevocation && body.MaxCredNum == 0 {
+		return 0, nil, badRequest("max_cred_num is required with support_revocation")
+	}
+	record, err := s.options.Issuer.CreateCredentialDefinition(issuer.CredentialDefinitionOptions{
+		ID:                body.ID,
+		SchemaID:          body.SchemaID,
+		IssuerID:          body.IssuerID,
+		Tag:               body.Tag,
+		SignatureType:     body.SignatureType,
+		SupportRevocation: body.SupportRevocation,
+		MaxCredNum:        body.MaxCredNum,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, newCredentialDefinitionResponse(record), nil
+}
+
+func (s *Server) listCredentialDefinitions(r *http.Request) (int, interface{}, error) {
+	records, err := s.options.Issuer.CredentialDefinitions()
+	if err != nil {
+		return 0, nil, err
+	}
+	credDefs := make([]credentialDefinitionResponse, len(records))
+	for i, record := range records {
+		credDefs[i] = newCredentialDefinitionResponse(record)
+	}
+	return http.StatusOK, credDefs, nil
+}
+
+func (s *Server) getCredentialDefinition(r *http.Request) (int, interface{}, error) {
+	record, err := s.options.Issuer.CredentialDefinition(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, newCredentialDefinitionResponse(record), nil
+}
+
+/// @notice Body of POST /offers
+type createOfferRequest struct {
+	CredentialDefinitionID string `json:"cred_def_id"`
+}
+
+func (s *Server) createOffer(r *http.Request) (int, interface{}, error) {
+	var body createOfferRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.CredentialDefinitionID == "" {
+		return 0, nil, badRequest("cred_def_id is required")
+	}
+	record, err := s.options.Issuer.Offer(body.CredentialDefinitionID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, record, nil
+}
+
+func (s *Server) getOffer(r *http.Request) (int, interface{}, error) {
+	record, err := s.options.Issuer.CredentialOffer(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, record, nil
+}
+
+/// @notice Body of POST /offers/{id}/issue
+type issueCredentialRequest struct {
+	Request json.RawMessage   `json:"request"` /// @notice The holder's credential request
+	Values  map[string]string `json:"values"`  /// @notice Raw attribute values
+}
+
+/// @notice Response of POST /offers/{id}/issue
+type issueCredentialResponse struct {
+	Credential json.RawMessage                `json:"credential"` /// @notice The credential to send to the holder
+	Issued     *issuer.IssuedCredentialRecord `json:"issued"`
+}
+
+func (s *Server) issueCredential(r *http.Request) (int, interface{}, error) {
+	var body issueCredentialRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if err := requireObject("request", body.Request); err != nil {
+		return 0, nil, err
+	}
+	request, err := anoncreds.CredentialRequestFromJSON([]byte(body.Request))
+	if err != nil {
+		return 0, nil, badRequest("invalid credential request: %v", err)
+	}
+	defer request.Clear()
+	credential, issued, err := s.options.Issuer.Issue(r.PathValue("id"), request, body.Values)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer credential.Clear()
+	credentialJSON, err := credential.ToJSONString()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, issueCredentialResponse{Credential: json.RawMessage(credentialJSON), Issued: issued}, nil
+}
+
+func (s *Server) getIssuedCredential(r *http.Request) (int, interface{}, error) {
+	record, err := s.options.Issuer.IssuedCredential(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, record, nil
+}
+
+/// @notice A revocation registry as returned by the API, without its private part
+type revocationRegistryResponse struct {
+	ID                           string          `json:"id"`
+	CredentialDefinitionID       string          `json:"cred_def_id"`
+	Tag                          string          `json:"tag"`
+	State                        string          `json:"state"`
+	MaxCredNum                   uint32          `json:"max_cred_num"`
+	NextIndex                    uint32          `json:"next_index"`
+	Issued                       []uint32        `json:"issued"`
+	Revoked                      []uint32        `json:"revoked"`
+	RevocationRegistryDefinition json.RawMessage `json:"rev_reg_def"`
+	StatusList                   json.RawMessage `json:"status_list"`
+	CreatedAt                    int64           `json:"created_at"`
+}
+
+func (s *Server) listRevocationRegistries(r *http.Request) (int, interface{}, error) {
+	manager, err := s.options.Issuer.RevocationManager(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	registries := manager.Registries()
+	response := make([]revocationRegistryResponse, len(registries))
+	for i, registry := range registries {
+		response[i] = revocationRegistryResponse{
+			ID:                           registry.ID,
+			CredentialDefinitionID:       registry.CredentialDefinitionID,
+			Tag:                          registry.Tag,
+			State:                        registry.State,
+			MaxCredNum:                   registry.MaxCredNum,
+			NextIndex:                    registry.NextIndex,
+			Issued:                       registry.Issued,
+			Revoked:                      registry.Revoked,
+			RevocationRegistryDefinition: registry.RevocationRegistryDefinition,
+			StatusList:                   registry.StatusList,
+			CreatedAt:                    registry.CreatedAt,
+		}
+	}
+	return http.StatusOK, response, nil
+}
+
+/// @dev Queues the revocation; it takes effect once the registry's revocations are published
+func (s *Server) revokeCredential(r *http.Request) (int, interface{}, error) {
+	issued, err := s.options.Issuer.IssuedCredential(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if issued.RevocationRegistryID == "" {
+		return 0, nil, badRequest("credential %s is not revocable", issued.ID)
+	}
+	queue, err := s.options.Issuer.RevocationQueue(issued.CredentialDefinitionID)
+	if err != nil {
+		return 0, nil, err
+	}
+	pending, err := queue.Revoke(issued.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusAccepted, pending, nil
+}
+
+func (s *Server) listPendingRevocations(r *http.Request) (int, interface{}, error) {
+	queue, err := s.options.Issuer.RevocationQueue(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	pending, err := queue.Pending(r.URL.Query().Get("rev_reg_def_id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, pending, nil
+}
+
+/// @notice Body of POST /credential-definitions/{id}/publish-revocations
+type publishRevocationsRequest struct {
+	RevocationRegistryDefinitionID string `json:"rev_reg_def_id"`
+	Timestamp                      *int64 `json:"timestamp,omitempty"`
+	DryRun                         bool   `json:"dry_run,omitempty"`
+}
+
+/// @notice Response of POST /credential-definitions/{id}/publish-revocations
+type publicationResponse struct {
+	RevocationRegistryDefinitionID string          `json:"rev_reg_def_id"`
+	Timestamp                      int64           `json:"timestamp"`
+	Revoked                        []uint32        `json:"revoked"`
+	CredentialIDs                  []string        `json:"credential_ids"`
+	StatusList                     json.RawMessage `json:"status_list"` /// @notice The status list to publish
+	DryRun                         bool            `json:"dry_run"`
+}
+
+func (s *Server) publishRevocations(r *http.Request) (int, interface{}, error) {
+	var body publishRevocationsRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.RevocationRegistryDefinitionID == "" {
+		return 0, nil, badRequest("rev_reg_def_id is required")
+	}
+	queue, err := s.options.Issuer.RevocationQueue(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	publication, err := queue.Publish(body.RevocationRegistryDefinitionID, issuer.PublishOptions{Timestamp: body.Timestamp, DryRun: body.DryRun})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer publication.StatusList.Clear()
+	listJSON, err := publication.StatusList.ToJSONString()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, publicationResponse{
+		RevocationRegistryDefinitionID: publication.RevocationRegistryDefinitionID,
+		Timestamp:                      publication.Timestamp,
+		Revoked:                        publication.Revoked,
+		CredentialIDs:                  publication.CredentialIDs,
+		StatusList:                     json.RawMessage(listJSON),
+		DryRun:                         publication.DryRun,
+	}, nil
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/protocol"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+/// @title Admin API Server
+/// @dev Exposes issuer, holder and verifier operations as JSON endpoints for services that are
+/// not written in Go, in the manner of the ACA-Py admin API. The endpoints are described by the
+/// OpenAPI document served at /openapi.json. IDs in paths must be URL-encoded, since qualified
+/// identifiers contain slashes
+
+/// @notice Header carrying the API key
+const APIKeyHeader = "X-API-Key"
+
+/// @notice Largest request body accepted
+const MaxBodyBytes = 4 << 20
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+/// @notice Returns the OpenAPI document describing the endpoints
+func OpenAPI() []byte {
+	return append([]byte(nil), openAPIDocument...)
+}
+
+/// @notice Configuration of the server
+type Options struct {
+	/// @notice Issuer state: schemas, credential definitions, offers and revocation
+	Issuer *issuer.Service
+
+	/// @notice Link secrets of the holder wallet
+	LinkSecrets *wallet.LinkSecretManager
+
+	/// @notice Credentials of the holder wallet
+	Credentials wallet.CredentialStore
+
+	/// @notice Where holder and prover exchanges are kept, e.g. the issuer's store
+	Store storage.Store
+
+	/// @notice Keys accepted in the X-API-Key header; required unless Insecure is set
+	APIKeys []string
+
+	/// @notice Serves requests without an API key, e.g. behind an authenticating proxy
+	Insecure bool
+
+	/// @notice Resolves schemas of received credentials and presentations; defaults to the issuer's
+	Schema func(schemaID string) (*anoncreds.Schema, error)
+
+	/// @notice Resolves credential definitions; defaults to the issuer's
+	CredentialDefinition func(credDefID string) (*anoncreds.CredentialDefinition, error)
+
+	/// @notice Resolves revocation registry definitions; defaults to the issuer's
+	RevocationRegistryDefinition func(revRegDefID string) (*anoncreds.RevocationRegistryDefinition, error)
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice An http.Handler serving the admin API
+/// @dev Safe for concurrent use
+type Server struct {
+	options Options
+	holder  *protocol.CredentialHolder
+	prover  *protocol.PresentationProver
+	mux     *http.ServeMux
+}
+
+/// @notice Creates a server
+func New(options Options) (*Server, error) {
+	if options.Issuer == nil {
+		return nil, fmt.Errorf("issuer service is required")
+	}
+	if options.LinkSecrets == nil || options.Credentials == nil {
+		return nil, fmt.Errorf("link secret manager and credential store are required")
+	}
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if len(options.APIKeys) == 0 && !options.Insecure {
+		return nil, fmt.Errorf("an API key is required unless the server is insecure")
+	}
+	for _, key := range options.APIKeys {
+		if key == "" {
+			return nil, fmt.Errorf("API keys must not be empty")
+		}
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	s := &Server{options: options}
+	if s.options.Schema == nil {
+		s.options.Schema = s.issuerSchema
+	}
+	if s.options.CredentialDefinition == nil {
+		s.options.CredentialDefinition = s.issuerCredentialDefinition
+	}
+	if s.options.RevocationRegistryDefinition == nil {
+		s.options.RevocationRegistryDefinition = s.issuerRevocationRegistryDefinition
+	}
+
+	var err error
+	s.holder, err = protocol.NewCredentialHolder(protocol.CredentialHolderOptions{
+		Store:                        options.Store,
+		LinkSecrets:                  options.LinkSecrets,
+		Credentials:                  options.Credentials,
+		CredentialDefinition:         s.options.CredentialDefinition,
+		RevocationRegistryDefinition: s.options.RevocationRegistryDefinition,
+		Now:                          options.Now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.prover, err = protocol.NewPresentationProver(protocol.PresentationProverOptions{
+		Store:                options.Store,
+		LinkSecrets:          options.LinkSecrets,
+		Credentials:          options.Credentials,
+		Schema:               s.options.Schema,
+		CredentialDefinition: s.options.CredentialDefinition,
+		Now:                  options.Now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.routes()
+	return s, nil
+}
+
+func (s *Server) routes() {
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDocument)
+	})
+	s.mux.Handle("GET /status", s.handle(s.status))
+	s.mux.Handle("GET /nonce", s.authenticated(s.nonce))
+
+	s.mux.Handle("POST /schemas", s.authenticated(s.createSchema))
+	s.mux.Handle("GET /schemas/{id}", s.authenticated(s.getSchema))
+	s.mux.Handle("POST /credential-definitions", s.authenticated(s.createCredentialDefinition))
+	s.mux.Handle("GET /credential-definitions", s.authenticated(s.listCredentialDefinitions))
+	s.mux.Handle("GET /credential-definitions/{id}", s.authenticated(s.getCredentialDefinition))
+	s.mux.Handle("GET /credential-definitions/{id}/revocation-registries", s.authenticated(s.listRevocationRegistries))
+	s.mux.Handle("GET /credential-definitions/{id}/pending-revocations", s.authenticated(s.listPendingRevocations))
+	s.mux.Handle("POST /credential-definitions/{id}/publish-revocations", s.authenticated(s.publishRevocations))
+	s.mux.Handle("POST /offers", s.authenticated(s.createOffer))
+	s.mux.Handle("GET /offers/{id}", s.authenticated(s.getOffer))
+	s.mux.Handle("POST /offers/{id}/issue", s.authenticated(s.issueCredential))
+	s.mux.Handle("GET /issued-credentials/{id}", s.authenticated(s.getIssuedCredential))
+	s.mux.Handle("POST /issued-credentials/{id}/revoke", s.authenticated(s.revokeCredential))
+
+	s.mux.Handle("POST /wallet/link-secrets", s.authenticated(s.createLinkSecret))
+	s.mux.Handle("GET /wallet/link-secrets", s.authenticated(s.listLinkSecrets))
+	s.mux.Handle("POST /wallet/credential-requests", s.authenticated(s.createCredentialRequest))
+	s.mux.Handle("POST /wallet/credentials", s.authenticated(s.storeCredential))
+	s.mux.Handle("GET /wallet/credentials", s.authenticated(s.listCredentials))
+	s.mux.Handle("GET /wallet/credentials/{referent}", s.authenticated(s.getCredential))
+	s.mux.Handle("DELETE /wallet/credentials/{referent}", s.authenticated(s.deleteCredential))
+
+	s.mux.Handle("POST /presentations/create", s.authenticated(s.createPresentation))
+	s.mux.Handle("POST /presentations/verify", s.authenticated(s.verifyPresentation))
+}
+
+/// @notice Serves a request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+/// @dev An endpoint; it returns the status and body of a successful response
+type handlerFunc func(r *http.Request) (int, interface{}, error)
+
+/// @dev Writes a handler's result or error as JSON
+func (s *Server) handle(fn handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, body, err := fn(r)
+		if err != nil {
+			status, body = errorStatus(err), errorBody{Error: err.Error()}
+		}
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+/// @dev Like handle, but rejects requests without a configured API key
+func (s *Server) authenticated(fn handlerFunc) http.Handler {
+	return s.handle(func(r *http.Request) (int, interface{}, error) {
+		if !s.authorized(r.Header.Get(APIKeyHeader)) {
+			return 0, nil, &statusError{status: http.StatusUnauthorized, err: errors.New("missing or invalid API key")}
+		}
+		return fn(r)
+	})
+}
+
+func (s *Server) authorized(key string) bool {
+	if s.options.Insecure {
+		return true
+	}
+	authorized := false
+	for _, accepted := range s.options.APIKeys {
+		// Every key is compared so the time taken does not reveal which one matched
+		if subtle.ConstantTimeCompare([]byte(key), []byte(accepted)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+/// @notice Body of error responses
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+/// @dev An error with the HTTP status it is reported with
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func (e *statusError) Unwrap() error { return e.err }
+
+/// @dev Reports an invalid request body or parameter
+func badRequest(format string, args ...interface{}) error {
+	return &statusError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+/// @dev Maps errors of the underlying components to HTTP statuses. Other failures of an
+/// operation are reported as 422, since the request itself was well-formed
+func errorStatus(err error) int {
+	var statusErr *statusError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.status
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, issuer.ErrOfferExpired), errors.Is(err, issuer.ErrOfferConsumed),
+		errors.Is(err, wallet.ErrLinkSecretExists), errors.Is(err, protocol.ErrInvalidTransition),
+		errors.Is(err, protocol.ErrExchangeExpired):
+		return http.StatusConflict
+	case errors.Is(err, issuer.ErrRequestMismatch):
+		return http.StatusBadRequest
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+/// @dev Decodes a JSON request body, rejecting unknown fields
+func decode(r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, MaxBodyBytes+1))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return badRequest("request body is empty or truncated")
+		}
+		return badRequest("invalid request body: %v", err)
+	}
+	if decoder.InputOffset() > MaxBodyBytes {
+		return &statusError{status: http.StatusRequestEntityTooLarge, err: fmt.Errorf("request body exceeds %d bytes", MaxBodyBytes)}
+	}
+	return nil
+}
+
+/// @dev Checks that a request field holding an AnonCreds object is set
+func requireObject(name string, value json.RawMessage) error {
+	if len(value) == 0 || string(value) == "null" {
+		return badRequest("%s is required", name)
+	}
+	return nil
+}
+
+/// @notice Body of GET /status
+type statusResponse struct {
+	Status string `json:"status"`
+	Time   int64  `json:"time"`
+}
+
+func (s *Server) status(r *http.Request) (int, interface{}, error) {
+	return http.StatusOK, statusResponse{Status: "ok", Time: s.options.Now().Unix()}, nil
+}
+
+/// @dev Loads a schema stored by the issuer
+func (s *Server) issuerSchema(schemaID string) (*anoncreds.Schema, error) {
+	record, err := s.options.Issuer.Schema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.SchemaFromJSON([]byte(record.Schema))
+}
+
+/// @dev Loads a credential definition stored by the issuer
+func (s *Server) issuerCredentialDefinition(credDefID string) (*anoncreds.CredentialDefinition, error) {
+	record, err := s.options.Issuer.CredentialDefinition(credDefID)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.CredentialDefinitionFromJSON([]byte(record.CredentialDefinition))
+}
+
+/// @dev Loads a revocation registry definition from the issuer's revocable credential definitions
+func (s *Server) issuerRevocationRegistryDefinition(revRegDefID string) (*anoncreds.RevocationRegistryDefinition, error) {
+	registry, err := s.issuerRegistry(revRegDefID)
+	if err != nil {
+		return nil, err
+	}
+	return anoncreds.RevocationRegistryDefinitionFromJSON([]byte(registry.RevocationRegistryDefinition))
+}
+
+func (s *Server) issuerRegistry(revRegDefID string) (*issuer.RevocationRegistryRecord, error) {
+	credDefs, err := s.options.Issuer.CredentialDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	for _, credDef := range credDefs {
+		if !credDef.SupportRevocation {
+			continue
+		}
+		manager, err := s.options.Issuer.RevocationManager(credDef.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, registry := range manager.Registries() {
+			if registry.ID == revRegDefID {
+				return registry, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("revocation registry %s %w", revRegDefID, storage.ErrNotFound)
+}
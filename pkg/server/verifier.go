@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Verifier Endpoints
+
+/// @notice Response of GET /nonce
+type nonceResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+func (s *Server) nonce(r *http.Request) (int, interface{}, error) {
+	nonce, err := anoncreds.New().GenerateNonce()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, nonceResponse{Nonce: nonce}, nil
+}
+
+/// @notice Body of POST /presentations/verify
+/// @dev Schemas and credential definitions the presentation refers to but the body does not
+/// include are resolved through the server's resolvers, as are revocation registry definitions
+type verifyPresentationRequest struct {
+	Presentation                  json.RawMessage            `json:"presentation"`
+	Request                       json.RawMessage            `json:"request"`
+	Schemas                       map[string]json.RawMessage `json:"schemas,omitempty"`
+	CredentialDefinitions         map[string]json.RawMessage `json:"credential_definitions,omitempty"`
+	RevocationRegistryDefinitions map[string]json.RawMessage `json:"rev_reg_defs,omitempty"`
+	RevocationStatusLists         []json.RawMessage          `json:"status_lists,omitempty"`
+}
+
+/// @dev Responds 200 with the verification report whether or not the presentation verified
+func (s *Server) verifyPresentation(r *http.Request) (int, interface{}, error) {
+	var body verifyPresentationRequest
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if err := requireObject("presentation", body.Presentation); err != nil {
+		return 0, nil, err
+	}
+	if err := requireObject("request", body.Request); err != nil {
+		return 0, nil, err
+	}
+	presentation, err := anoncreds.PresentationFromJSON([]byte(body.Presentation))
+	if err != nil {
+		return 0, nil, badRequest("invalid presentation: %v", err)
+	}
+	defer presentation.Clear()
+	request, err := anoncreds.PresentationRequestFromJSON([]byte(body.Request))
+	if err != nil {
+		return 0, nil, badRequest("invalid presentation request: %v", err)
+	}
+	defer request.Clear()
+	revealed, err := presentation.Revealed()
+	if err != nil {
+		return 0, nil, badRequest("invalid presentation: %v", err)
+	}
+
+	options := anoncreds.VerifyPresentationOptions{
+		Presentation:                  presentation,
+		PresentationRequest:           request,
+		Schemas:                       map[string]*anoncreds.Schema{},
+		CredentialDefinitions:         map[string]*anoncreds.CredentialDefinition{},
+		RevocationRegistryDefinitions: map[string]*anoncreds.RevocationRegistryDefinition{},
+	}
+	defer func() {
+		for _, schema := range options.Schemas {
+			schema.Clear()
+		}
+		for _, credDef := range options.CredentialDefinitions {
+			credDef.Clear()
+		}
+		for _, revRegDef := range options.RevocationRegistryDefinitions {
+			revRegDef.Clear()
+		}
+		for _, statusList := range options.RevocationStatusLists {
+			statusList.Clear()
+		}
+	}()
+	for id, raw := range body.Schemas {
+		if options.Schemas[id], err = anoncreds.SchemaFromJSON([]byte(raw)); err != nil {
+			delete(options.Schemas, id)
+			return 0, nil, badRequest("invalid schema %s: %v", id, err)
+		}
+	}
+	for id, raw := range body.CredentialDefinitions {
+		if options.CredentialDefinitions[id], err = anoncreds.CredentialDefinitionFromJSON([]byte(raw)); err != nil {
+			delete(options.CredentialDefinitions, id)
+			return 0, nil, badRequest("invalid credential definition %s: %v", id, err)
+		}
+	}
+	for id, raw := range body.RevocationRegistryDefinitions {
+		if options.RevocationRegistryDefinitions[id], err = anoncreds.RevocationRegistryDefinitionFromJSON([]byte(raw)); err != nil {
+			delete(options.RevocationRegistryDefinitions, id)
+			return 0, nil, badRequest("invalid revocation registry definition %s: %v", id, err)
+		}
+	}
+	for _, raw := range body.RevocationStatusLists {
+		statusList, err := anoncreds.RevocationStatusListFromJSON([]byte(raw))
+		if err != nil {
+			return 0, nil, badRequest("invalid status list: %v", err)
+		}
+		options.RevocationStatusLists = append(options.RevocationStatusLists, statusList)
+	}
+
+	for _, identifier := range revealed.Identifiers {
+		if _, ok := options.Schemas[identifier.SchemaID]; !ok {
+			schema, err := s.options.Schema(identifier.SchemaID)
+			if err != nil {
+				return 0, nil, err
+			}
+			options.Schemas[identifier.SchemaID] = schema
+		}
+		if _, ok := options.CredentialDefinitions[identifier.CredentialDefinitionID]; !ok {
+			credDef, err := s.options.CredentialDefinition(identifier.CredentialDefinitionID)
+			if err != nil {
+				return 0, nil, err
+			}
+			options.CredentialDefinitions[identifier.CredentialDefinitionID] = credDef
+		}
+		if identifier.RevocationRegistryID == "" {
+			continue
+		}
+		if _, ok := options.RevocationRegistryDefinitions[identifier.RevocationRegistryID]; !ok {
+			revRegDef, err := s.options.RevocationRegistryDefinition(identifier.RevocationRegistryID)
+			if err != nil {
+				return 0, nil, err
+			}
+			options.RevocationRegistryDefinitions[identifier.RevocationRegistryID] = revRegDef
+		}
+	}
+
+	report, err := anoncreds.VerifyPresentationWithReport(options)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, report, nil
+}
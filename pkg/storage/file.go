@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/// @title File-Backed Store
+/// @dev One JSON file per record under <dir>/<category>/, written atomically via rename
+
+/// @notice A Store that keeps each record in its own file
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+/// @notice Opens a file store rooted at dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("storage directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+/// @notice Returns the root directory of the store
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+/// @notice Reads the record with the given category and ID
+func (s *FileStore) Get(category, id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(s.path(category, id))
+}
+
+/// @notice Writes the record, replacing any previous version
+func (s *FileStore) Put(record *Record) error {
+	if err := validateRecord(record); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categoryDir := filepath.Join(s.dir, encodeName(record.Category))
+	if err := os.MkdirAll(categoryDir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(categoryDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(record.Category, record.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+/// @notice Removes a record
+func (s *FileStore) Delete(category, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(category, id))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+/// @notice Reads every record in a category, ordered by ID
+func (s *FileStore) List(category string) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, encodeName(category)))
+	if os.IsNotExist(err) {
+		return []*Record{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		record, err := s.read(filepath.Join(s.dir, encodeName(category), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+/// @dev Returns the file path of a record
+func (s *FileStore) path(category, id string) string {
+	return filepath.Join(s.dir, encodeName(category), encodeName(id)+".json")
+}
+
+/// @dev Reads and decodes a record file
+func (s *FileStore) read(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("corrupt record %s: %w", path, err)
+	}
+	return &record, nil
+}
+
+/// @dev Encodes an arbitrary name into a portable file name
+func encodeName(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
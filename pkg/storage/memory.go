@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/// @title In-Memory Store
+/// @dev Keeps records in a map; useful for tests and short-lived processes
+
+/// @notice A Store that keeps records in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]map[string]*Record
+}
+
+/// @notice Creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]map[string]*Record)}
+}
+
+/// @notice Returns a copy of the record with the given category and ID
+func (s *MemoryStore) Get(category, id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[category][id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record.Clone(), nil
+}
+
+/// @notice Stores a copy of the record
+func (s *MemoryStore) Put(record *Record) error {
+	if err := validateRecord(record); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[record.Category] == nil {
+		s.records[record.Category] = make(map[string]*Record)
+	}
+	s.records[record.Category][record.ID] = record.Clone()
+	return nil
+}
+
+/// @notice Removes a record
+func (s *MemoryStore) Delete(category, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[category][id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records[category], id)
+	return nil
+}
+
+/// @notice Returns copies of every record in a category, ordered by ID
+func (s *MemoryStore) List(category string) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]*Record, 0, len(s.records[category]))
+	for _, record := range s.records[category] {
+		records = append(records, record.Clone())
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+/// @dev Checks the fields every store requires
+func validateRecord(record *Record) error {
+	if record == nil {
+		return fmt.Errorf("record is required")
+	}
+	if record.Category == "" || record.ID == "" {
+		return fmt.Errorf("record category and id are required")
+	}
+	return nil
+}
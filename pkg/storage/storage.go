@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"errors"
+)
+
+/// @title Record Storage
+/// @dev A small category/ID keyed record store shared by the issuer, holder and verifier components
+
+/// @notice Returned when a record does not exist
+var ErrNotFound = errors.New("record not found")
+
+/// @notice A stored record
+/// @dev Tags are plain string pairs that callers can use for lookups without decoding Value
+type Record struct {
+	Category string            `json:"category"`
+	ID       string            `json:"id"`
+	Value    []byte            `json:"value"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+/// @notice Persistent storage for records
+/// @dev Implementations must be safe for concurrent use
+type Store interface {
+	/// @notice Returns the record with the given category and ID, or ErrNotFound
+	Get(category, id string) (*Record, error)
+	/// @notice Inserts the record, replacing any existing record with the same category and ID
+	Put(record *Record) error
+	/// @notice Removes a record; returns ErrNotFound if it does not exist
+	Delete(category, id string) error
+	/// @notice Returns every record in a category, ordered by ID
+	List(category string) ([]*Record, error)
+}
+
+/// @notice Returns a deep copy of a record
+func (r *Record) Clone() *Record {
+	if r == nil {
+		return nil
+	}
+	clone := &Record{
+		Category: r.Category,
+		ID:       r.ID,
+		Value:    append([]byte(nil), r.Value...),
+	}
+	if r.Tags != nil {
+		clone.Tags = make(map[string]string, len(r.Tags))
+		for key, value := range r.Tags {
+			clone.Tags[key] = value
+		}
+	}
+	return clone
+}
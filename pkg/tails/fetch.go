@@ -0,0 +1,133 @@
+package tails
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Tails Fetching
+/// @dev Holder-side download of tails files with hash verification and a Store as cache
+
+/// @notice Downloads tails files into a Store, verifying each against its tailsHash
+type Fetcher struct {
+	Store  *Store
+	Client *http.Client /// @notice HTTP client to use; http.DefaultClient when nil
+
+	/// @notice Accept tails locations that are local paths or file:// URLs. Off by default, as
+	/// registry definitions and their tails locations come from peers
+	AllowLocalFiles bool
+
+	mu       sync.Mutex
+	inflight map[string]*sync.Mutex
+}
+
+/// @notice Creates a fetcher that caches into the given store
+func NewFetcher(store *Store) *Fetcher {
+	return &Fetcher{Store: store}
+}
+
+/// @notice Returns the local path of a tails file, downloading it if it is not cached
+/// @param tailsLocation An http(s) URL, or a local file path when AllowLocalFiles is set
+/// @param tailsHash The expected tails hash
+/// @dev Concurrent fetches of the same hash are coalesced into a single download. The download
+/// is not size-limited; FetchForRegistry bounds it by the registry's maxCredNum
+func (f *Fetcher) Fetch(ctx context.Context, tailsLocation, tailsHash string) (string, error) {
+	return f.fetch(ctx, tailsLocation, tailsHash, 0)
+}
+
+/// @dev Fetches a tails file, reading at most maxSize bytes of a download when maxSize is positive
+func (f *Fetcher) fetch(ctx context.Context, tailsLocation, tailsHash string, maxSize int64) (string, error) {
+	path, err := f.Store.Path(tailsHash)
+	if err != nil {
+		return "", err
+	}
+
+	lock := f.lockFor(tailsHash)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if f.Store.Has(tailsHash) {
+		return path, nil
+	}
+
+	if !strings.HasPrefix(tailsLocation, "http://") && !strings.HasPrefix(tailsLocation, "https://") {
+		if !f.AllowLocalFiles {
+			return "", fmt.Errorf("tails location %q is not an http(s) URL", tailsLocation)
+		}
+		if _, err := f.Store.PutFile(strings.TrimPrefix(tailsLocation, "file://"), tailsHash); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tailsLocation, nil)
+	if err != nil {
+		return "", err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching tails file from %s: unexpected status %s", tailsLocation, resp.Status)
+	}
+
+	// A larger file is cut short and then fails hash verification
+	var body io.Reader = resp.Body
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize)
+	}
+	if _, err := f.Store.Put(body, tailsHash); err != nil {
+		return "", fmt.Errorf("fetching tails file from %s: %w", tailsLocation, err)
+	}
+	return path, nil
+}
+
+/// @notice Fetches the tails file referenced by a revocation registry definition
+/// @dev Downloads are limited to the largest tails file the registry's maxCredNum allows
+func (f *Fetcher) FetchForRegistry(ctx context.Context, revRegDef *anoncreds.RevocationRegistryDefinition) (string, error) {
+	if revRegDef == nil {
+		return "", fmt.Errorf("revocation registry definition is required")
+	}
+	defJSON, err := revRegDef.ToJSON()
+	if err != nil {
+		return "", err
+	}
+	value, _ := defJSON["value"].(map[string]interface{})
+	tailsLocation, _ := value["tailsLocation"].(string)
+	tailsHash, _ := value["tailsHash"].(string)
+	if tailsLocation == "" || tailsHash == "" {
+		return "", fmt.Errorf("revocation registry definition has no tails location or hash")
+	}
+	maxCredNum, _ := value["maxCredNum"].(float64)
+	if maxCredNum < 1 || maxCredNum > float64(^uint32(0)) {
+		return "", fmt.Errorf("revocation registry definition has an invalid maxCredNum")
+	}
+	return f.fetch(ctx, tailsLocation, tailsHash, MaxFileSize(uint32(maxCredNum)))
+}
+
+/// @dev Returns the lock serializing fetches of one hash
+func (f *Fetcher) lockFor(tailsHash string) *sync.Mutex {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.inflight == nil {
+		f.inflight = make(map[string]*sync.Mutex)
+	}
+	lock, ok := f.inflight[tailsHash]
+	if !ok {
+		lock = &sync.Mutex{}
+		f.inflight[tailsHash] = lock
+	}
+	return lock
+}
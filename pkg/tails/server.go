@@ -0,0 +1,213 @@
+package tails
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+/// @title Local Tails Server
+/// @dev net/http handler compatible with the Aries tails server API:
+/// GET and PUT on /{revocRegId} and on /hash/{tailsHash}, with uploads sent as the multipart field "tails"
+
+/// @notice Default upload limit, large enough for a registry of 2^20 credentials
+const DefaultMaxUploadSize = 256 << 20
+
+/// @notice Serves and accepts tails files backed by a Store
+type Server struct {
+	Store *Store
+
+	/// @notice Lookup of the tailsHash a registry publishes; uploads by registry ID must match it
+	/// @dev Without it only uploads by hash are accepted, since an upload by ID could not be verified
+	ResolveTailsHash func(revRegID string) (string, error)
+
+	/// @notice Maximum accepted upload size in bytes; DefaultMaxUploadSize when zero
+	MaxUploadSize int64
+
+	mu       sync.RWMutex
+	registry map[string]string
+}
+
+/// @notice Creates a tails server on top of a store
+func NewServer(store *Store) *Server {
+	return &Server{Store: store, registry: make(map[string]string)}
+}
+
+/// @notice Records the tails hash for a registry ID so it can be served by ID
+func (s *Server) Register(revRegID, tailsHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registry == nil {
+		s.registry = make(map[string]string)
+	}
+	s.registry[revRegID] = tailsHash
+}
+
+/// @dev Registers the tails hash for a registry ID unless it is already registered
+func (s *Server) claim(revRegID, tailsHash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registry == nil {
+		s.registry = make(map[string]string)
+	}
+	if _, exists := s.registry[revRegID]; exists {
+		return false
+	}
+	s.registry[revRegID] = tailsHash
+	return true
+}
+
+/// @notice Returns the tails hash registered for a registry ID
+func (s *Server) Lookup(revRegID string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tailsHash, ok := s.registry[revRegID]
+	return tailsHash, ok
+}
+
+/// @notice Dispatches tails server requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+	if path == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	byHash := strings.HasPrefix(path, "hash/")
+	key, err := url.PathUnescape(strings.TrimPrefix(path, "hash/"))
+	if err != nil || key == "" {
+		http.Error(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		tailsHash := key
+		if !byHash {
+			var ok bool
+			if tailsHash, ok = s.Lookup(key); !ok {
+				http.Error(w, "tails file not found", http.StatusNotFound)
+				return
+			}
+		}
+		s.serveFile(w, r, tailsHash)
+	case http.MethodPut:
+		if byHash {
+			s.upload(w, r, "", key)
+		} else {
+			s.upload(w, r, key, "")
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+/// @dev Streams a stored tails file
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, tailsHash string) {
+	file, err := s.Store.Open(tailsHash)
+	if err != nil {
+		http.Error(w, "tails file not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, tailsHash, info.ModTime(), file)
+}
+
+/// @dev Accepts a multipart upload, verifies it and stores it by hash
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, revRegID, expectedHash string) {
+	if revRegID != "" {
+		if _, exists := s.Lookup(revRegID); exists {
+			http.Error(w, "tails file already exists", http.StatusConflict)
+			return
+		}
+		if s.ResolveTailsHash == nil {
+			http.Error(w, "uploads by registry ID are disabled; upload by hash", http.StatusForbidden)
+			return
+		}
+		tailsHash, err := s.ResolveTailsHash(revRegID)
+		if err != nil || tailsHash == "" {
+			http.Error(w, fmt.Sprintf("cannot resolve registry: %v", err), http.StatusBadRequest)
+			return
+		}
+		expectedHash = tailsHash
+	}
+	if expectedHash != "" && s.Store.Has(expectedHash) {
+		http.Error(w, "tails file already exists", http.StatusConflict)
+		return
+	}
+
+	maxSize := s.MaxUploadSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxUploadSize
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "expected multipart upload", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "missing tails field", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "tails" {
+			part.Close()
+			continue
+		}
+
+		tailsHash, err := s.Store.Put(part, expectedHash)
+		part.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.validate(tailsHash); err != nil {
+			s.Store.Delete(tailsHash)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The file matches the registry's hash, so a concurrent upload that registered first
+		// stored the same content and the file must be kept
+		if revRegID != "" && !s.claim(revRegID, tailsHash) {
+			http.Error(w, "tails file already exists", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, tailsHash)
+		return
+	}
+}
+
+/// @dev Checks that a stored file is a well-formed tails file
+func (s *Server) validate(tailsHash string) error {
+	path, err := s.Store.Path(tailsHash)
+	if err != nil {
+		return err
+	}
+	reader, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if reader.Count() == 0 {
+		return fmt.Errorf("tails file holds no tails")
+	}
+	return nil
+}
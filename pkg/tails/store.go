@@ -0,0 +1,145 @@
+package tails
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+/// @title Content-Addressed Tails Store
+/// @dev Keeps tails files in a single directory, each named after its tails hash
+
+/// @notice A directory of tails files keyed by tails hash
+type Store struct {
+	Dir string /// @notice Directory holding the tails files
+}
+
+/// @notice Opens a store rooted at dir, creating the directory if needed
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("tails directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+/// @notice Returns the path a tails file with the given hash is stored at
+func (s *Store) Path(tailsHash string) (string, error) {
+	if !isBase58(tailsHash) {
+		return "", fmt.Errorf("invalid tails hash: %q", tailsHash)
+	}
+	return filepath.Join(s.Dir, tailsHash), nil
+}
+
+/// @notice Reports whether a tails file with the given hash is present
+func (s *Store) Has(tailsHash string) bool {
+	path, err := s.Path(tailsHash)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+/// @notice Opens a stored tails file for reading
+func (s *Store) Open(tailsHash string) (*os.File, error) {
+	path, err := s.Path(tailsHash)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+/// @notice Stores the content of r and returns its tails hash
+/// @param expectedHash If non-empty, the content is rejected unless it hashes to this value
+func (s *Store) Put(r io.Reader, expectedHash string) (string, error) {
+	writer, err := s.NewWriter()
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Abort()
+		return "", err
+	}
+	return writer.Commit(expectedHash)
+}
+
+/// @notice Copies an existing tails file into the store
+/// @dev Use this to import the file written by anoncreds_create_revocation_registry_def
+func (s *Store) PutFile(path, expectedHash string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return s.Put(file, expectedHash)
+}
+
+/// @notice Removes a tails file from the store
+func (s *Store) Delete(tailsHash string) error {
+	path, err := s.Path(tailsHash)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+/// @notice Streams content into the store, hashing it as it is written
+/// @dev The content only becomes visible under its hash once Commit succeeds
+type Writer struct {
+	store  *Store
+	file   *os.File
+	hasher hash.Hash
+}
+
+/// @notice Starts a new write into the store
+func (s *Store) NewWriter() (*Writer, error) {
+	file, err := os.CreateTemp(s.Dir, ".incoming-*")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{store: s, file: file, hasher: sha256.New()}, nil
+}
+
+/// @notice Writes a chunk of tails file content
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hasher.Write(p[:n])
+	return n, err
+}
+
+/// @notice Finishes the write and moves the file to its content address
+/// @param expectedHash If non-empty, the file is discarded unless it hashes to this value
+/// @return The tails hash of the stored file and any error encountered
+func (w *Writer) Commit(expectedHash string) (string, error) {
+	tailsHash := encodeBase58(w.hasher.Sum(nil))
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return "", err
+	}
+	if expectedHash != "" && tailsHash != expectedHash {
+		os.Remove(w.file.Name())
+		return "", fmt.Errorf("tails hash mismatch: expected %s, got %s", expectedHash, tailsHash)
+	}
+	path, err := w.store.Path(tailsHash)
+	if err != nil {
+		os.Remove(w.file.Name())
+		return "", err
+	}
+	if err := os.Rename(w.file.Name(), path); err != nil {
+		os.Remove(w.file.Name())
+		return "", err
+	}
+	return tailsHash, nil
+}
+
+/// @notice Discards a write in progress
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
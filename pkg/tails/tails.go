@@ -0,0 +1,186 @@
+package tails
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Tails Files
+/// @dev Reading, writing and hash verification of CL_ACCUM tails files as produced by
+/// anoncreds_create_revocation_registry_def
+
+/// @notice Size of the version tag at the start of every tails file
+const VersionSize = 2
+
+/// @notice Size of a single tail (a serialized G2 point)
+const TailSize = 128
+
+/// @notice Tails file format version written by anoncreds-rs
+const Version uint16 = 2
+
+/// @notice Returns the largest tails file a registry of maxCredNum credentials can have
+/// @dev The version tag followed by at most 2*maxCredNum+1 tails
+func MaxFileSize(maxCredNum uint32) int64 {
+	return VersionSize + TailSize*(2*int64(maxCredNum)+1)
+}
+
+/// @notice Computes the tails hash of a stream
+/// @return The base58-encoded SHA-256 digest of the content, as used in a registry's tailsHash
+func Hash(r io.Reader) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", err
+	}
+	return encodeBase58(hasher.Sum(nil)), nil
+}
+
+/// @notice Computes the tails hash of a file on disk
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return Hash(file)
+}
+
+/// @notice Checks that a tails file matches the expected hash
+/// @param path Path to the tails file
+/// @param expectedHash The tailsHash published in the registry definition
+func VerifyFile(path, expectedHash string) error {
+	hash, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	if hash != expectedHash {
+		return fmt.Errorf("tails hash mismatch: expected %s, got %s", expectedHash, hash)
+	}
+	return nil
+}
+
+/// @notice Checks a tails file against a revocation registry definition
+/// @dev Also checks that the file holds at least maxCredNum tails
+func VerifyRegistryDefinition(path string, revRegDef *anoncreds.RevocationRegistryDefinition) error {
+	if revRegDef == nil {
+		return fmt.Errorf("revocation registry definition is required")
+	}
+	tailsHash, maxCredNum, err := RegistryTailsInfo(revRegDef)
+	if err != nil {
+		return err
+	}
+	if err := VerifyFile(path, tailsHash); err != nil {
+		return err
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if int64(reader.Count()) < maxCredNum {
+		return fmt.Errorf("tails file holds %d tails, registry needs %d", reader.Count(), maxCredNum)
+	}
+	return nil
+}
+
+/// @notice Extracts the tails hash and capacity from a revocation registry definition
+func RegistryTailsInfo(revRegDef *anoncreds.RevocationRegistryDefinition) (string, int64, error) {
+	defJSON, err := revRegDef.ToJSON()
+	if err != nil {
+		return "", 0, err
+	}
+	value, _ := defJSON["value"].(map[string]interface{})
+	tailsHash, _ := value["tailsHash"].(string)
+	if tailsHash == "" {
+		return "", 0, fmt.Errorf("revocation registry definition has no tailsHash")
+	}
+	maxCredNum, _ := value["maxCredNum"].(float64)
+	return tailsHash, int64(maxCredNum), nil
+}
+
+/// @notice Writes a complete tails file: the version tag followed by every tail
+/// @param w Destination for the file content
+/// @param tails Serialized tails, each exactly TailSize bytes
+/// @return The tails hash of the written content and any error encountered
+func WriteFile(w io.Writer, tails [][]byte) (string, error) {
+	hasher := sha256.New()
+	out := io.MultiWriter(w, hasher)
+
+	version := make([]byte, VersionSize)
+	binary.BigEndian.PutUint16(version, Version)
+	if _, err := out.Write(version); err != nil {
+		return "", err
+	}
+	for idx, tail := range tails {
+		if len(tail) != TailSize {
+			return "", fmt.Errorf("tail %d has %d bytes, expected %d", idx, len(tail), TailSize)
+		}
+		if _, err := out.Write(tail); err != nil {
+			return "", err
+		}
+	}
+	return encodeBase58(hasher.Sum(nil)), nil
+}
+
+/// @notice Random access reader over a tails file
+type Reader struct {
+	file  *os.File
+	count int
+}
+
+/// @notice Opens a tails file and validates its header and size
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]byte, VersionSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("tails file is too short: %w", err)
+	}
+	if version := binary.BigEndian.Uint16(header); version != Version {
+		file.Close()
+		return nil, fmt.Errorf("unsupported tails file version %d", version)
+	}
+	body := info.Size() - VersionSize
+	if body%TailSize != 0 {
+		file.Close()
+		return nil, fmt.Errorf("tails file size %d is not a whole number of tails", info.Size())
+	}
+
+	return &Reader{file: file, count: int(body / TailSize)}, nil
+}
+
+/// @notice Returns the number of tails in the file
+func (r *Reader) Count() int {
+	return r.count
+}
+
+/// @notice Returns the tail at the given zero-based index
+func (r *Reader) Tail(index int) ([]byte, error) {
+	if index < 0 || index >= r.count {
+		return nil, fmt.Errorf("tail index %d out of range [0, %d)", index, r.count)
+	}
+	tail := make([]byte, TailSize)
+	if _, err := r.file.ReadAt(tail, int64(VersionSize+index*TailSize)); err != nil {
+		return nil, err
+	}
+	return tail, nil
+}
+
+/// @notice Closes the underlying file
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
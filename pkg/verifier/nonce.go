@@ -0,0 +1,256 @@
+package verifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Nonce Manager
+/// @dev Issues presentation request nonces and rejects presentations that replay them
+
+/// @notice Storage category holding issued nonces, keyed by request ID
+const CategoryNonce = "verifier_nonce"
+
+/// @notice Default lifetime of an issued nonce
+const DefaultNonceTTL = 10 * time.Minute
+
+/// @notice Errors returned when a nonce cannot be used
+var (
+	ErrNonceNotFound = fmt.Errorf("nonce %w", storage.ErrNotFound)
+	ErrNonceExists   = errors.New("a nonce was already issued for this request")
+	ErrNonceMismatch = errors.New("nonce does not match the request")
+	ErrNonceExpired  = errors.New("nonce has expired")
+	ErrNonceConsumed = errors.New("nonce was already used")
+)
+
+/// @notice A nonce issued for one presentation request
+type NonceRecord struct {
+	RequestID  string `json:"request_id"`
+	Nonce      string `json:"nonce"`
+	IssuedAt   int64  `json:"issued_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	ConsumedAt *int64 `json:"consumed_at,omitempty"`
+}
+
+/// @notice Configuration of a nonce manager
+type NonceManagerOptions struct {
+	/// @notice Where nonces are kept; defaults to an in-memory store
+	Store storage.Store
+
+	/// @notice Lifetime of issued nonces; defaults to DefaultNonceTTL
+	TTL time.Duration
+
+	/// @notice Clock used for expiry; defaults to the wall clock
+	Now func() time.Time
+
+	/// @notice Nonce source; defaults to anoncreds nonce generation
+	Generate func() (string, error)
+}
+
+/// @notice Issues and consumes presentation request nonces
+/// @dev Safe for concurrent use within one process; processes must not share a file store
+type NonceManager struct {
+	options NonceManagerOptions
+	mu      sync.Mutex
+}
+
+/// @notice Creates a nonce manager
+func NewNonceManager(options NonceManagerOptions) *NonceManager {
+	if options.Store == nil {
+		options.Store = storage.NewMemoryStore()
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultNonceTTL
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if options.Generate == nil {
+		options.Generate = anoncreds.New().GenerateNonce
+	}
+	return &NonceManager{options: options}
+}
+
+/// @notice Creates a nonce manager that keeps nonces in memory
+func NewMemoryNonceManager(options NonceManagerOptions) *NonceManager {
+	options.Store = storage.NewMemoryStore()
+	return NewNonceManager(options)
+}
+
+/// @notice Opens a nonce manager that keeps each nonce in a file under dir
+func NewFileNonceManager(dir string, options NonceManagerOptions) (*NonceManager, error) {
+	store, err := storage.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	options.Store = store
+	return NewNonceManager(options), nil
+}
+
+/// @notice Issues a fresh nonce bound to a request ID
+/// @param requestID Identifies the presentation request, e.g. a protocol thread ID
+/// @return The issued nonce and ErrNonceExists if the request already has one
+func (m *NonceManager) Issue(requestID string) (*NonceRecord, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("request ID is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.get(requestID); err == nil {
+		return nil, ErrNonceExists
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	nonce, err := m.options.Generate()
+	if err != nil {
+		return nil, err
+	}
+	now := m.options.Now()
+	record := &NonceRecord{
+		RequestID: requestID,
+		Nonce:     nonce,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.options.TTL).Unix(),
+	}
+	if err := m.put(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Returns the nonce issued for a request
+func (m *NonceManager) Get(requestID string) (*NonceRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.get(requestID)
+}
+
+/// @notice Checks that a nonce is live for the request without consuming it
+/// @return nil, or one of the ErrNonce errors
+func (m *NonceManager) Check(requestID, nonce string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.check(requestID, nonce)
+	return err
+}
+
+/// @notice Marks a nonce used so that it cannot be accepted again
+/// @return nil, or one of the ErrNonce errors
+func (m *NonceManager) Consume(requestID, nonce string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, err := m.check(requestID, nonce)
+	if err != nil {
+		return err
+	}
+	consumedAt := m.options.Now().Unix()
+	record.ConsumedAt = &consumedAt
+	return m.put(record)
+}
+
+/// @notice Verifies a presentation and consumes its nonce if it verifies
+/// @param requestID The request the presentation answers
+/// @param options Configuration options for verification
+/// @return The verification report and an error if the nonce is not usable
+/// @dev A failed verification leaves the nonce live; of two concurrent successes only one consumes it
+func (m *NonceManager) VerifyPresentation(requestID string, options anoncreds.VerifyPresentationOptions) (*anoncreds.VerificationReport, error) {
+	if options.PresentationRequest == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	request, err := options.PresentationRequest.Contents()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Check(requestID, request.Nonce); err != nil {
+		return nil, err
+	}
+	report, err := anoncreds.VerifyPresentationWithReport(options)
+	if err != nil || !report.Verified {
+		return report, err
+	}
+	if err := m.Consume(requestID, request.Nonce); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+/// @notice Removes expired nonces
+/// @return The number of nonces removed and any error encountered
+/// @dev A purged nonce is rejected as not found, so replays stay blocked
+func (m *NonceManager) Purge() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records, err := m.options.Store.List(CategoryNonce)
+	if err != nil {
+		return 0, err
+	}
+	now := m.options.Now().Unix()
+	purged := 0
+	for _, stored := range records {
+		var record NonceRecord
+		if err := json.Unmarshal(stored.Value, &record); err != nil {
+			return purged, fmt.Errorf("invalid nonce record %s: %w", stored.ID, err)
+		}
+		if record.ExpiresAt > now {
+			continue
+		}
+		if err := m.options.Store.Delete(CategoryNonce, stored.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+/// @dev Loads the request's nonce and checks it is live; the caller holds m.mu
+func (m *NonceManager) check(requestID, nonce string) (*NonceRecord, error) {
+	record, err := m.get(requestID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case record.Nonce != nonce:
+		return nil, ErrNonceMismatch
+	case record.ConsumedAt != nil:
+		return nil, ErrNonceConsumed
+	case m.options.Now().Unix() >= record.ExpiresAt:
+		return nil, ErrNonceExpired
+	}
+	return record, nil
+}
+
+func (m *NonceManager) get(requestID string) (*NonceRecord, error) {
+	stored, err := m.options.Store.Get(CategoryNonce, requestID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNonceNotFound
+		}
+		return nil, err
+	}
+	var record NonceRecord
+	if err := json.Unmarshal(stored.Value, &record); err != nil {
+		return nil, fmt.Errorf("invalid nonce record %s: %w", requestID, err)
+	}
+	return &record, nil
+}
+
+func (m *NonceManager) put(record *NonceRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return m.options.Store.Put(&storage.Record{
+		Category: CategoryNonce,
+		ID:       record.RequestID,
+		Value:    value,
+		Tags:     map[string]string{"nonce": record.Nonce},
+	})
+}
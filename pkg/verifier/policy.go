@@ -0,0 +1,585 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+	"gopkg.in/yaml.v3"
+)
+
+/// @title Verifier Policy
+/// @dev Declarative trust rules evaluated on top of cryptographic verification
+
+/// @notice Rules a policy violation can break
+const (
+	RuleReferent             = "referent"           /// @notice The policy names a referent the presentation does not prove
+	RuleIssuer               = "issuer"             /// @notice The issuer is not trusted for the referent
+	RuleCredentialDefinition = "cred_def"           /// @notice The credential definition is not allowed for the referent
+	RuleSchemaName           = "schema_name"        /// @notice The schema name does not match
+	RuleSchemaVersion        = "schema_version"     /// @notice The schema version is outside the allowed range
+	RuleNonRevocationAge     = "non_revocation_age" /// @notice The non-revocation proof is too old or missing
+	RuleRequiredPredicate    = "required_predicate" /// @notice A required predicate was not requested or not proven
+	RuleSelfAttested         = "self_attested"      /// @notice A self-attested value cannot satisfy issuer rules
+)
+
+/// @notice A length of time, written as a Go duration ("36h"), a number of days ("7d") or seconds
+type Duration time.Duration
+
+/// @notice Decodes a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var seconds int64
+		if err := json.Unmarshal(data, &seconds); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", text)
+		}
+		*d = Duration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+/// @notice Encodes the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+/// @notice A schema version constraint such as "1.0", ">=1.0" or ">=1.0 <2.0"
+/// @dev Constraints are separated by spaces and must all hold; a bare version means equality
+type VersionRange string
+
+/// @notice Decodes a version range from a string or an unquoted number
+func (v *VersionRange) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("invalid version range %s", data)
+		}
+		text = number.String()
+	}
+	if _, err := VersionRange(text).constraints(); err != nil {
+		return err
+	}
+	*v = VersionRange(text)
+	return nil
+}
+
+type versionConstraint struct {
+	op      string
+	version []int
+}
+
+func (v VersionRange) constraints() ([]versionConstraint, error) {
+	var constraints []versionConstraint
+	for _, field := range strings.Fields(string(v)) {
+		op := "="
+		for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+			if strings.HasPrefix(field, candidate) {
+				op, field = candidate, field[len(candidate):]
+				break
+			}
+		}
+		version, err := parseVersion(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", string(v), err)
+		}
+		constraints = append(constraints, versionConstraint{op: op, version: version})
+	}
+	return constraints, nil
+}
+
+/// @notice Reports whether a version satisfies every constraint in the range
+/// @param version A dotted numeric version such as "1.2"
+/// @return Whether it matches and an error if either side is malformed
+func (v VersionRange) Contains(version string) (bool, error) {
+	constraints, err := v.constraints()
+	if err != nil {
+		return false, err
+	}
+	parsed, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	for _, constraint := range constraints {
+		cmp := compareVersions(parsed, constraint.version)
+		var ok bool
+		switch constraint.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func parseVersion(version string) ([]int, error) {
+	if version == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	parts := strings.Split(version, ".")
+	parsed := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("version %q is not dotted numbers", version)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+/// @dev Compares versions component by component; missing components count as zero
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+/// @notice Rules for the credential behind one referent
+/// @dev Empty fields impose no constraint
+type ReferentPolicy struct {
+	IssuerIDs               []string     `json:"issuer_ids,omitempty"`
+	CredentialDefinitionIDs []string     `json:"cred_def_ids,omitempty"`
+	SchemaName              string       `json:"schema_name,omitempty"`
+	SchemaVersion           VersionRange `json:"schema_version,omitempty"`
+	MaxNonRevocationAge     Duration     `json:"max_non_revocation_age,omitempty"` /// @notice Oldest accepted status list, relative to evaluation time
+}
+
+/// @notice A predicate the presentation request must ask for and the presentation must prove
+/// @dev A requested predicate satisfies the rule when it is at least as strict
+type RequiredPredicate struct {
+	Referent string `json:"referent,omitempty"` /// @notice Matches any referent when empty
+	Name     string `json:"name"`
+	PType    string `json:"p_type"`
+	PValue   int64  `json:"p_value"`
+}
+
+/// @notice Trust rules applied to verified presentations
+type VerifierPolicy struct {
+	Name               string                    `json:"name,omitempty"`
+	Default            *ReferentPolicy           `json:"default,omitempty"`   /// @notice Applies to referents without their own entry
+	Referents          map[string]ReferentPolicy `json:"referents,omitempty"` /// @notice Per-referent rules, replacing the default
+	RequiredPredicates []RequiredPredicate       `json:"required_predicates,omitempty"`
+}
+
+/// @notice Loads a policy from JSON or YAML
+/// @param data The policy document; JSON is detected by a leading '{'
+/// @return The policy and any error encountered
+func ParsePolicy(data []byte) (*VerifierPolicy, error) {
+	var policy VerifierPolicy
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&policy); err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+	} else if err := unmarshalYAML(trimmed, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+/// @dev Decodes YAML into v by way of its JSON encoding, so JSON struct tags and unmarshalers apply
+/// and unknown fields are rejected as with JSON policies
+func unmarshalYAML(data []byte, v interface{}) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	value, err := yamlValue(&document)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+/// @dev Converts a YAML node to JSON-compatible values. Numbers keep their source text, so an
+/// unquoted schema version such as 1.10 is not read as 1.1
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.SequenceNode:
+		values := make([]interface{}, len(node.Content))
+		for i, child := range node.Content {
+			value, err := yamlValue(child)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			values[key.Value] = value
+		}
+		return values, nil
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!int", "!!float":
+		if !json.Valid([]byte(node.Value)) {
+			return nil, fmt.Errorf("line %d: unsupported number %q", node.Line, node.Value)
+		}
+		return json.Number(node.Value), nil
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return node.Value, nil
+}
+
+/// @notice Loads a policy file in JSON or YAML
+/// @param path The file path
+/// @return The policy and any error encountered
+func LoadPolicy(path string) (*VerifierPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+/// @notice Checks that the policy's rules are well formed
+/// @return An error describing the first malformed rule
+func (p *VerifierPolicy) Validate() error {
+	for _, predicate := range p.RequiredPredicates {
+		if predicate.Name == "" {
+			return fmt.Errorf("required predicate is missing a name")
+		}
+		switch predicate.PType {
+		case anoncreds.PredicateGE, anoncreds.PredicateGT, anoncreds.PredicateLE, anoncreds.PredicateLT:
+		default:
+			return fmt.Errorf("required predicate on %s has unsupported p_type %q", predicate.Name, predicate.PType)
+		}
+	}
+	return nil
+}
+
+/// @notice A rule one referent breaks
+type PolicyViolation struct {
+	Referent string `json:"referent"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+/// @notice The outcome of evaluating a policy
+type PolicyResult struct {
+	Satisfied  bool              `json:"satisfied"`
+	Violations []PolicyViolation `json:"violations"`
+}
+
+/// @notice Returns the violations recorded against one referent
+func (r *PolicyResult) ForReferent(referent string) []PolicyViolation {
+	var violations []PolicyViolation
+	for _, violation := range r.Violations {
+		if violation.Referent == referent {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+/// @notice Inputs for evaluating a policy beyond the verification report
+/// @dev Schemas and credential definitions supply names and issuers; legacy identifiers are parsed otherwise
+type EvaluateOptions struct {
+	PresentationRequest   *anoncreds.PresentationRequest
+	Schemas               map[string]*anoncreds.Schema
+	CredentialDefinitions map[string]*anoncreds.CredentialDefinition
+	Now                   time.Time /// @notice Defaults to the current time
+}
+
+/// @notice Evaluates the policy against a verification report
+/// @param report The report from VerifyPresentationWithReport
+/// @param options The request and objects used for verification
+/// @return The result and an error only if the inputs cannot be read
+func (p *VerifierPolicy) Evaluate(report *anoncreds.VerificationReport, options EvaluateOptions) (*PolicyResult, error) {
+	if report == nil {
+		return nil, fmt.Errorf("verification report is required")
+	}
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	result := &PolicyResult{Violations: []PolicyViolation{}}
+	violate := func(referent, rule, format string, args ...interface{}) {
+		result.Violations = append(result.Violations, PolicyViolation{Referent: referent, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	reported := make(map[string]anoncreds.ReferentReport, len(report.Referents))
+	for _, entry := range report.Referents {
+		reported[entry.Referent] = entry
+	}
+	for referent := range p.Referents {
+		if _, ok := reported[referent]; !ok {
+			violate(referent, RuleReferent, "referent is not part of the presentation request")
+		}
+	}
+
+	for _, entry := range report.Referents {
+		rules, ok := p.Referents[entry.Referent]
+		if !ok {
+			if p.Default == nil {
+				continue
+			}
+			rules = *p.Default
+		}
+		if entry.Kind == anoncreds.ReferentKindSelfAttested {
+			if len(rules.IssuerIDs) > 0 || len(rules.CredentialDefinitionIDs) > 0 || rules.SchemaName != "" || rules.SchemaVersion != "" {
+				violate(entry.Referent, RuleSelfAttested, "self-attested value cannot satisfy credential rules")
+			}
+			continue
+		}
+		if entry.Identifier == nil {
+			continue
+		}
+		if err := p.evaluateReferent(entry, rules, options, now, violate); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.RequiredPredicates) > 0 {
+		if options.PresentationRequest == nil {
+			return nil, fmt.Errorf("presentation request is required to check required predicates")
+		}
+		request, err := options.PresentationRequest.Contents()
+		if err != nil {
+			return nil, err
+		}
+		for _, required := range p.RequiredPredicates {
+			label := required.Referent
+			if label == "" {
+				label = required.Name
+			}
+			if !requiredPredicateMet(required, request, reported) {
+				violate(label, RuleRequiredPredicate, "no proven predicate %s %s %d", required.Name, required.PType, required.PValue)
+			}
+		}
+	}
+
+	// Referent rules are kept in a map, so order violations independently of its iteration order
+	sort.SliceStable(result.Violations, func(i, j int) bool {
+		if result.Violations[i].Referent != result.Violations[j].Referent {
+			return result.Violations[i].Referent < result.Violations[j].Referent
+		}
+		return result.Violations[i].Rule < result.Violations[j].Rule
+	})
+	result.Satisfied = len(result.Violations) == 0
+	return result, nil
+}
+
+func (p *VerifierPolicy) evaluateReferent(entry anoncreds.ReferentReport, rules ReferentPolicy, options EvaluateOptions, now time.Time, violate func(referent, rule, format string, args ...interface{})) error {
+	identifier := entry.Identifier
+
+	if len(rules.CredentialDefinitionIDs) > 0 && !contains(rules.CredentialDefinitionIDs, identifier.CredentialDefinitionID) {
+		violate(entry.Referent, RuleCredentialDefinition, "credential definition %s is not allowed", identifier.CredentialDefinitionID)
+	}
+	if len(rules.IssuerIDs) > 0 {
+		issuerID, err := credentialIssuer(identifier.CredentialDefinitionID, options.CredentialDefinitions)
+		if err != nil {
+			return err
+		}
+		switch {
+		case issuerID == "":
+			violate(entry.Referent, RuleIssuer, "issuer of %s is unknown", identifier.CredentialDefinitionID)
+		case !contains(rules.IssuerIDs, issuerID):
+			violate(entry.Referent, RuleIssuer, "issuer %s is not trusted", issuerID)
+		}
+	}
+	if rules.SchemaName != "" || rules.SchemaVersion != "" {
+		name, version, err := schemaNameVersion(identifier.SchemaID, options.Schemas)
+		if err != nil {
+			return err
+		}
+		if rules.SchemaName != "" && name != rules.SchemaName {
+			violate(entry.Referent, RuleSchemaName, "schema name %q does not match %q", name, rules.SchemaName)
+		}
+		if rules.SchemaVersion != "" {
+			ok, err := rules.SchemaVersion.Contains(version)
+			if err != nil || !ok {
+				violate(entry.Referent, RuleSchemaVersion, "schema version %q is not in %s", version, rules.SchemaVersion)
+			}
+		}
+	}
+	if rules.MaxNonRevocationAge > 0 && identifier.RevocationRegistryID != "" {
+		if identifier.Timestamp == nil {
+			violate(entry.Referent, RuleNonRevocationAge, "credential is revocable but no non-revocation proof was given")
+		} else if age := now.Sub(time.Unix(*identifier.Timestamp, 0)); age > time.Duration(rules.MaxNonRevocationAge) {
+			violate(entry.Referent, RuleNonRevocationAge, "non-revocation timestamp is %s old, more than %s", age.Round(time.Second), time.Duration(rules.MaxNonRevocationAge))
+		}
+	}
+	return nil
+}
+
+/// @dev Reports whether the request asks for a predicate at least as strict as required and it was proven
+func requiredPredicateMet(required RequiredPredicate, request *anoncreds.PresentationRequestContents, reported map[string]anoncreds.ReferentReport) bool {
+	for referent, predicate := range request.RequestedPredicates {
+		if required.Referent != "" && referent != required.Referent {
+			continue
+		}
+		if normalizeName(predicate.Name) != normalizeName(required.Name) || predicate.PType != required.PType {
+			continue
+		}
+		strict := predicate.PValue == required.PValue
+		switch required.PType {
+		case anoncreds.PredicateGE, anoncreds.PredicateGT:
+			strict = predicate.PValue >= required.PValue
+		case anoncreds.PredicateLE, anoncreds.PredicateLT:
+			strict = predicate.PValue <= required.PValue
+		}
+		if strict && reported[referent].Status == anoncreds.ReferentVerified {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Finds the issuer of a credential definition from the object or its legacy ID
+func credentialIssuer(credDefID string, credDefs map[string]*anoncreds.CredentialDefinition) (string, error) {
+	if credDef := credDefs[credDefID]; credDef != nil {
+		contents, err := credDef.ToJSON()
+		if err != nil {
+			return "", err
+		}
+		if issuerID, _ := contents["issuerId"].(string); issuerID != "" {
+			return issuerID, nil
+		}
+	}
+	if did, _, _, err := ledger.ParseLegacyCredentialDefinitionID(credDefID); err == nil {
+		return did, nil
+	}
+	return "", nil
+}
+
+/// @dev Finds a schema's name and version from the object or its legacy ID
+func schemaNameVersion(schemaID string, schemas map[string]*anoncreds.Schema) (string, string, error) {
+	if schema := schemas[schemaID]; schema != nil {
+		contents, err := schema.ToJSON()
+		if err != nil {
+			return "", "", err
+		}
+		name, _ := contents["name"].(string)
+		version, _ := contents["version"].(string)
+		if name != "" {
+			return name, version, nil
+		}
+	}
+	if _, name, version, err := ledger.ParseLegacySchemaID(schemaID); err == nil {
+		return name, version, nil
+	}
+	return "", "", nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+/// @dev Matches the attribute name normalization used by anoncreds
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+}
+
+/// @notice Verifies a presentation and evaluates the policy on the result
+/// @param policy The policy to apply
+/// @param options Configuration options for verification
+/// @param now The evaluation time; the zero value means now
+/// @return The verification report, the policy result and any error encountered
+/// @dev A presentation is acceptable only when report.Verified and result.Satisfied both hold
+func VerifyWithPolicy(policy *VerifierPolicy, options anoncreds.VerifyPresentationOptions, now time.Time) (*anoncreds.VerificationReport, *PolicyResult, error) {
+	report, err := anoncreds.VerifyPresentationWithReport(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := policy.Evaluate(report, EvaluateOptions{
+		PresentationRequest:   options.PresentationRequest,
+		Schemas:               options.Schemas,
+		CredentialDefinitions: options.CredentialDefinitions,
+		Now:                   now,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, result, nil
+}
@@ -0,0 +1,270 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title indy-sdk Wallet Import
+/// @dev Migrates credentials and master secrets exported from indy-sdk wallets. indy-sdk master
+/// secrets are the same big integers anoncreds-rs uses as link secrets, and its credentials are
+/// legacy AnonCreds credentials, so both are imported unchanged
+
+/// @notice indy-sdk wallet record types
+const (
+	LegacyRecordTypeCredential   = "Indy::Credential"
+	LegacyRecordTypeMasterSecret = "Indy::MasterSecret"
+)
+
+/// @notice An indy-sdk export
+/// @dev Credentials may be listed with their cred_info or as raw wallet records; both are read
+type LegacyExport struct {
+	MasterSecrets []LegacyMasterSecret `json:"master_secrets,omitempty"`
+	Credentials   []LegacyCredential   `json:"credentials,omitempty"`
+	Records       []LegacyRecord       `json:"records,omitempty"` /// @notice Raw Indy::Credential and Indy::MasterSecret records
+
+	/// @notice Raw records ParseLegacyExport could not read; ImportLegacy reports them as failures
+	Failures []LegacyImportFailure `json:"-"`
+}
+
+/// @notice A master secret; Value is the decimal secret, {"ms": ...} or {"value": {"ms": ...}}
+type LegacyMasterSecret struct {
+	ID    string          `json:"id"`
+	Value json.RawMessage `json:"value"`
+}
+
+/// @notice A credential with the cred_info prover_get_credentials returned for it
+type LegacyCredential struct {
+	CredentialInfo LegacyCredentialInfo `json:"cred_info"`
+	Credential     json.RawMessage      `json:"credential"`
+	MasterSecretID string               `json:"master_secret_id,omitempty"` /// @notice Defaults to the import's default link secret
+}
+
+/// @notice The cred_info of an indy-sdk credential
+type LegacyCredentialInfo struct {
+	Referent  string            `json:"referent"`
+	Attrs     map[string]string `json:"attrs"`
+	SchemaID  string            `json:"schema_id"`
+	CredDefID string            `json:"cred_def_id"`
+	RevRegID  *string           `json:"rev_reg_id"`
+	CredRevID *string           `json:"cred_rev_id"`
+}
+
+/// @notice A raw indy-sdk wallet record
+type LegacyRecord struct {
+	Type  string            `json:"type"`
+	ID    string            `json:"id"`
+	Value json.RawMessage   `json:"value"` /// @notice The record value, as JSON or as a string holding JSON
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+/// @notice Configuration of an indy-sdk import
+type LegacyImportOptions struct {
+	/// @notice Where credentials are stored
+	Credentials CredentialStore
+
+	/// @notice Where master secrets are stored as link secrets; when nil they are skipped
+	LinkSecrets *LinkSecretManager
+
+	/// @notice Link secret of credentials that name none; defaults to the first master secret in the export
+	DefaultLinkSecretID string
+
+	/// @notice Replaces credentials whose referent is already stored instead of reporting them
+	Overwrite bool
+}
+
+/// @notice An object that could not be imported
+type LegacyImportFailure struct {
+	Type   string /// @notice LegacyRecordTypeCredential or LegacyRecordTypeMasterSecret
+	ID     string /// @notice Referent or master secret ID
+	Reason string
+}
+
+/// @notice Outcome of an indy-sdk import
+type LegacyImportReport struct {
+	Credentials []*CredentialRecord /// @notice Stored credentials
+	LinkSecrets []string            /// @notice IDs of the stored link secrets
+	Failures    []LegacyImportFailure
+}
+
+/// @notice Parses an indy-sdk export
+/// @dev A raw record whose value cannot be read is listed in Failures rather than failing the parse
+func ParseLegacyExport(data []byte) (*LegacyExport, error) {
+	var export LegacyExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid indy-sdk export: %w", err)
+	}
+	for _, record := range export.Records {
+		if record.Type != LegacyRecordTypeCredential && record.Type != LegacyRecordTypeMasterSecret {
+			continue
+		}
+		value, err := legacyRecordValue(record.Value)
+		if err != nil {
+			export.Failures = append(export.Failures, LegacyImportFailure{Type: record.Type, ID: record.ID, Reason: err.Error()})
+			continue
+		}
+		switch record.Type {
+		case LegacyRecordTypeCredential:
+			export.Credentials = append(export.Credentials, LegacyCredential{
+				CredentialInfo: LegacyCredentialInfo{Referent: record.ID},
+				Credential:     value,
+			})
+		case LegacyRecordTypeMasterSecret:
+			export.MasterSecrets = append(export.MasterSecrets, LegacyMasterSecret{ID: record.ID, Value: value})
+		}
+	}
+	export.Records = nil
+	return &export, nil
+}
+
+/// @notice Imports an indy-sdk export
+/// @param data The export JSON
+/// @param options Where to store the imported objects
+/// @return A report listing what was stored and every object that failed, with the reason
+/// @dev Objects that fail are reported rather than aborting the import; the error is reserved for
+/// unreadable exports and storage failures
+func ImportLegacy(data []byte, options LegacyImportOptions) (*LegacyImportReport, error) {
+	if options.Credentials == nil {
+		return nil, fmt.Errorf("credential store is required")
+	}
+	export, err := ParseLegacyExport(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LegacyImportReport{Failures: export.Failures}
+	fail := func(recordType, id string, err error) {
+		report.Failures = append(report.Failures, LegacyImportFailure{Type: recordType, ID: id, Reason: err.Error()})
+	}
+	defaultID := options.DefaultLinkSecretID
+	for _, masterSecret := range export.MasterSecrets {
+		if defaultID == "" {
+			defaultID = masterSecret.ID
+		}
+		if options.LinkSecrets == nil {
+			continue
+		}
+		secret, err := legacyMasterSecretValue(masterSecret)
+		if err != nil {
+			fail(LegacyRecordTypeMasterSecret, masterSecret.ID, err)
+			continue
+		}
+		_, err = options.LinkSecrets.Import(masterSecret.ID, secret)
+		secret.Wipe()
+		if errors.Is(err, ErrLinkSecretExists) {
+			fail(LegacyRecordTypeMasterSecret, masterSecret.ID, err)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.LinkSecrets = append(report.LinkSecrets, masterSecret.ID)
+	}
+
+	for _, legacy := range export.Credentials {
+		record, err := legacyCredentialRecord(legacy, firstNonEmpty(legacy.MasterSecretID, defaultID))
+		if err != nil {
+			fail(LegacyRecordTypeCredential, legacy.CredentialInfo.Referent, err)
+			continue
+		}
+		if !options.Overwrite {
+			if _, err := options.Credentials.Get(record.Referent); err == nil {
+				fail(LegacyRecordTypeCredential, record.Referent, fmt.Errorf("credential %s is already stored", record.Referent))
+				continue
+			} else if !errors.Is(err, storage.ErrNotFound) {
+				return report, err
+			}
+		}
+		if err := options.Credentials.Put(record); err != nil {
+			return report, err
+		}
+		report.Credentials = append(report.Credentials, record)
+	}
+	return report, nil
+}
+
+/// @dev Validates a credential against its cred_info and builds the tagged record
+func legacyCredentialRecord(legacy LegacyCredential, linkSecretID string) (*CredentialRecord, error) {
+	if len(legacy.Credential) == 0 {
+		return nil, fmt.Errorf("credential is missing")
+	}
+	credential, err := anoncreds.CredentialFromJSON([]byte(legacy.Credential))
+	if err != nil {
+		return nil, fmt.Errorf("CredentialFromJSON: %w", err)
+	}
+	defer credential.Clear()
+	record, err := NewCredentialRecord(credential, CredentialMetadata{Referent: legacy.CredentialInfo.Referent, LinkSecretID: linkSecretID})
+	if err != nil {
+		return nil, err
+	}
+
+	info := legacy.CredentialInfo
+	if info.SchemaID != "" && info.SchemaID != record.SchemaID {
+		return nil, fmt.Errorf("cred_info schema_id %s does not match credential schema_id %s", info.SchemaID, record.SchemaID)
+	}
+	if info.CredDefID != "" && info.CredDefID != record.CredentialDefinitionID {
+		return nil, fmt.Errorf("cred_info cred_def_id %s does not match credential cred_def_id %s", info.CredDefID, record.CredentialDefinitionID)
+	}
+	if info.RevRegID != nil && *info.RevRegID != record.RevocationRegistryID {
+		return nil, fmt.Errorf("cred_info rev_reg_id %s does not match credential rev_reg_id %s", *info.RevRegID, record.RevocationRegistryID)
+	}
+	for name, value := range info.Attrs {
+		if actual, ok := record.Attribute(name); !ok || actual != value {
+			return nil, fmt.Errorf("cred_info attribute %s does not match the credential", name)
+		}
+	}
+	if info.CredRevID != nil {
+		index, err := strconv.ParseUint(*info.CredRevID, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cred_rev_id %s", *info.CredRevID)
+		}
+		if record.RevocationRegistryIndex != nil && uint64(*record.RevocationRegistryIndex) != index {
+			return nil, fmt.Errorf("cred_info cred_rev_id %s does not match credential index %d", *info.CredRevID, *record.RevocationRegistryIndex)
+		}
+		revocationIndex := uint32(index)
+		record.RevocationRegistryIndex = &revocationIndex
+	}
+	return record, nil
+}
+
+/// @dev Reads a master secret in any of the forms indy-sdk exports it
+func legacyMasterSecretValue(masterSecret LegacyMasterSecret) (*anoncreds.LinkSecret, error) {
+	var value string
+	if raw := strings.TrimSpace(string(masterSecret.Value)); raw != "" && raw[0] >= '0' && raw[0] <= '9' {
+		value = raw
+	} else if err := json.Unmarshal(masterSecret.Value, &value); err != nil {
+		var wrapped struct {
+			MS    string `json:"ms"`
+			Value struct {
+				MS string `json:"ms"`
+			} `json:"value"`
+		}
+		if err := json.Unmarshal(masterSecret.Value, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid master secret value")
+		}
+		value = firstNonEmpty(wrapped.MS, wrapped.Value.MS)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Trim(value, "0123456789") != "" {
+		return nil, fmt.Errorf("master secret is not a decimal integer")
+	}
+	return anoncreds.LinkSecretFromValue(value), nil
+}
+
+/// @dev Returns a record value that is JSON or a string holding JSON as JSON
+func legacyRecordValue(value json.RawMessage) (json.RawMessage, error) {
+	var encoded string
+	if err := json.Unmarshal(value, &encoded); err != nil {
+		return value, nil
+	}
+	if !json.Valid([]byte(encoded)) {
+		return nil, fmt.Errorf("record value is not JSON")
+	}
+	return json.RawMessage(encoded), nil
+}
@@ -0,0 +1,364 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wql"
+)
+
+/// @title Link Secret Manager
+/// @dev Keeps named link secrets sealed in a keystore.KeyStore, tracks the default one and
+/// finds the credentials issued against each. Secrets are only decrypted for the duration of a call
+
+/// @notice Storage categories holding link secret metadata and the default pointer
+const (
+	CategoryLinkSecret        = "link_secret"
+	CategoryDefaultLinkSecret = "link_secret_default"
+)
+
+/// @dev ID of the record naming the default link secret
+const defaultLinkSecretRecordID = "default"
+
+/// @notice Returned when a link secret does not exist; matches storage.ErrNotFound with errors.Is
+var ErrLinkSecretNotFound = fmt.Errorf("link secret %w", storage.ErrNotFound)
+
+/// @notice Returned when no default link secret has been set; matches storage.ErrNotFound with errors.Is
+var ErrNoDefaultLinkSecret = fmt.Errorf("default link secret %w", storage.ErrNotFound)
+
+/// @notice Returned when creating a link secret under an ID that is already taken
+var ErrLinkSecretExists = errors.New("link secret already exists")
+
+/// @notice Returned when deleting a link secret that is the default or still backs credentials
+var ErrLinkSecretInUse = errors.New("link secret is in use")
+
+/// @notice Metadata of a stored link secret; the value itself is sealed separately
+type LinkSecretRecord struct {
+	ID        string `json:"id"`
+	Default   bool   `json:"-"` /// @notice Whether new credentials are requested with this secret
+	CreatedAt int64  `json:"created_at"`
+	RetiredAt int64  `json:"retired_at,omitempty"` /// @notice Set when a rotation replaced it as the default
+}
+
+/// @notice Configuration of a link secret manager
+type LinkSecretManagerOptions struct {
+	/// @notice Where link secret metadata and the default pointer are kept
+	Store storage.Store
+
+	/// @notice Seals the secret values; required so secrets are never stored in plaintext
+	KeyStore *keystore.KeyStore
+
+	/// @notice Credentials searched by Credentials and Delete; optional
+	Credentials CredentialStore
+
+	/// @notice Clock used for timestamps; defaults to the wall clock
+	Now func() time.Time
+}
+
+/// @notice Manages named link secrets
+/// @dev Safe for concurrent use
+type LinkSecretManager struct {
+	options LinkSecretManagerOptions
+	mu      sync.Mutex
+}
+
+/// @notice Creates a link secret manager
+func NewLinkSecretManager(options LinkSecretManagerOptions) (*LinkSecretManager, error) {
+	if options.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	if options.KeyStore == nil {
+		return nil, fmt.Errorf("key store is required")
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return &LinkSecretManager{options: options}, nil
+}
+
+/// @notice Generates and stores a new link secret
+/// @param id Name of the secret; generated when empty
+/// @return The record and any error encountered
+/// @dev The first secret stored becomes the default
+func (m *LinkSecretManager) Create(id string) (*LinkSecretRecord, error) {
+	secret, err := anoncreds.CreateLinkSecret()
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Wipe()
+	return m.Import(id, secret)
+}
+
+/// @notice Stores an existing link secret, e.g. one migrated from another wallet
+/// @param id Name of the secret; generated when empty
+/// @param secret The secret; the caller still owns it and may wipe it afterwards
+/// @return The record and any error encountered
+func (m *LinkSecretManager) Import(id string, secret *anoncreds.LinkSecret) (*LinkSecretRecord, error) {
+	if id == "" {
+		var err error
+		if id, err = NewReferent(); err != nil {
+			return nil, err
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.add(id, secret)
+}
+
+/// @notice Returns the metadata of a link secret
+func (m *LinkSecretManager) Record(id string) (*LinkSecretRecord, error) {
+	record, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	defaultID, err := m.defaultID()
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	record.Default = record.ID == defaultID
+	return record, nil
+}
+
+/// @notice Lists the stored link secrets ordered by ID
+func (m *LinkSecretManager) List() ([]*LinkSecretRecord, error) {
+	stored, err := m.options.Store.List(CategoryLinkSecret)
+	if err != nil {
+		return nil, err
+	}
+	defaultID, err := m.defaultID()
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	records := make([]*LinkSecretRecord, 0, len(stored))
+	for _, item := range stored {
+		record, err := decodeLinkSecretRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		record.Default = record.ID == defaultID
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+/// @notice Decrypts a link secret
+/// @param id Name of the secret; the default when empty
+/// @return A link secret the caller must wipe when done and any error encountered
+/// @dev Prefer Use, which wipes the secret itself
+func (m *LinkSecretManager) Get(id string) (*anoncreds.LinkSecret, error) {
+	if id == "" {
+		var err error
+		if id, err = m.DefaultID(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := m.get(id); err != nil {
+		return nil, err
+	}
+	return m.options.KeyStore.OpenLinkSecret(id)
+}
+
+/// @notice Decrypts a link secret for the duration of fn and wipes it afterwards
+/// @param id Name of the secret; the default when empty
+/// @param fn Called with the secret, which must not be retained
+func (m *LinkSecretManager) Use(id string, fn func(secret *anoncreds.LinkSecret) error) error {
+	secret, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe()
+	return fn(secret)
+}
+
+/// @notice Returns the ID of the default link secret, or ErrNoDefaultLinkSecret
+func (m *LinkSecretManager) DefaultID() (string, error) {
+	return m.defaultID()
+}
+
+/// @notice Makes a stored link secret the default
+/// @dev A retired secret becomes active again
+func (m *LinkSecretManager) SetDefault(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if record.RetiredAt != 0 {
+		record.RetiredAt = 0
+		if err := m.put(record); err != nil {
+			return err
+		}
+	}
+	return m.setDefault(id)
+}
+
+/// @notice Generates a new link secret and makes it the default
+/// @param id Name of the new secret; generated when empty
+/// @return The new record and any error encountered
+/// @dev The previous default is marked retired but kept, since presentations of credentials
+/// issued against it still need it
+func (m *LinkSecretManager) Rotate(id string) (*LinkSecretRecord, error) {
+	if id == "" {
+		var err error
+		if id, err = NewReferent(); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := anoncreds.CreateLinkSecret()
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Wipe()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previousID, err := m.defaultID()
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	record, err := m.add(id, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.setDefault(id); err != nil {
+		return nil, err
+	}
+	record.Default = true
+	if previousID != "" {
+		previous, err := m.get(previousID)
+		if err != nil {
+			return nil, err
+		}
+		previous.RetiredAt = m.options.Now().Unix()
+		if err := m.put(previous); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+/// @notice Returns the credentials issued against a link secret
+/// @param id Name of the secret
+/// @return The matching records ordered by referent and any error encountered
+func (m *LinkSecretManager) Credentials(id string) ([]*CredentialRecord, error) {
+	if m.options.Credentials == nil {
+		return nil, fmt.Errorf("credential store is not configured")
+	}
+	return Search(m.options.Credentials, wql.Compare{Op: wql.OpEq, Tag: TagLinkSecretID, Value: id})
+}
+
+/// @notice Removes a link secret
+/// @dev Fails with ErrLinkSecretInUse for the default secret and, when a credential store is
+/// configured, for a secret that still backs stored credentials
+func (m *LinkSecretManager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.get(id); err != nil {
+		return err
+	}
+	defaultID, err := m.defaultID()
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	if id == defaultID {
+		return fmt.Errorf("%w: %s is the default", ErrLinkSecretInUse, id)
+	}
+	if m.options.Credentials != nil {
+		credentials, err := m.Credentials(id)
+		if err != nil {
+			return err
+		}
+		if len(credentials) > 0 {
+			return fmt.Errorf("%w: %s backs %d credentials", ErrLinkSecretInUse, id, len(credentials))
+		}
+	}
+	if err := m.options.KeyStore.Delete(id, keystore.ObjectTypeLinkSecret); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return m.options.Store.Delete(CategoryLinkSecret, id)
+}
+
+/// @dev Seals and records a secret; the caller holds the mutex
+func (m *LinkSecretManager) add(id string, secret *anoncreds.LinkSecret) (*LinkSecretRecord, error) {
+	if _, err := m.get(id); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLinkSecretExists, id)
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	// A sealed secret without a record is left over from an interrupted add or delete; keep it
+	// rather than replace a value that credentials may have been issued against
+	if exists, err := m.options.KeyStore.Has(id, keystore.ObjectTypeLinkSecret); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%w: %s is already sealed in the key store", ErrLinkSecretExists, id)
+	}
+	if err := m.options.KeyStore.SealLinkSecret(id, secret); err != nil {
+		return nil, err
+	}
+	record := &LinkSecretRecord{ID: id, CreatedAt: m.options.Now().Unix()}
+	if err := m.put(record); err != nil {
+		return nil, err
+	}
+	if _, err := m.defaultID(); errors.Is(err, storage.ErrNotFound) {
+		if err := m.setDefault(id); err != nil {
+			return nil, err
+		}
+		record.Default = true
+	} else if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (m *LinkSecretManager) get(id string) (*LinkSecretRecord, error) {
+	stored, err := m.options.Store.Get(CategoryLinkSecret, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrLinkSecretNotFound
+		}
+		return nil, err
+	}
+	return decodeLinkSecretRecord(stored)
+}
+
+func (m *LinkSecretManager) put(record *LinkSecretRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	tags := map[string]string{}
+	if record.RetiredAt != 0 {
+		tags["retired"] = "1"
+	}
+	return m.options.Store.Put(&storage.Record{Category: CategoryLinkSecret, ID: record.ID, Value: value, Tags: tags})
+}
+
+func (m *LinkSecretManager) defaultID() (string, error) {
+	stored, err := m.options.Store.Get(CategoryDefaultLinkSecret, defaultLinkSecretRecordID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", ErrNoDefaultLinkSecret
+		}
+		return "", err
+	}
+	return string(stored.Value), nil
+}
+
+func (m *LinkSecretManager) setDefault(id string) error {
+	return m.options.Store.Put(&storage.Record{Category: CategoryDefaultLinkSecret, ID: defaultLinkSecretRecordID, Value: []byte(id)})
+}
+
+func decodeLinkSecretRecord(record *storage.Record) (*LinkSecretRecord, error) {
+	var linkSecret LinkSecretRecord
+	if err := json.Unmarshal(record.Value, &linkSecret); err != nil {
+		return nil, fmt.Errorf("corrupt link secret record %s: %w", record.ID, err)
+	}
+	return &linkSecret, nil
+}
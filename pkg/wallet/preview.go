@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+/// @title Presentation Preview
+/// @dev Describes what a presentation built from a selection discloses, for consent screens
+
+/// @notice An attribute or attribute group proven from a credential
+type AttributePreview struct {
+	Referent               string            `json:"referent"`
+	Names                  []string          `json:"names"`
+	Revealed               bool              `json:"revealed"`
+	Values                 map[string]string `json:"values,omitempty"` /// @notice Raw values; only set when revealed
+	CredentialReferent     string            `json:"credential_referent"`
+	SchemaID               string            `json:"schema_id"`
+	CredentialDefinitionID string            `json:"cred_def_id"`
+	IssuerID               string            `json:"issuer_id,omitempty"`
+
+	/// @notice Set when a requested predicate already proves what is needed about the attribute
+	Unnecessary bool   `json:"unnecessary,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+/// @notice A predicate proven without disclosing the attribute value
+type PredicatePreview struct {
+	Referent               string `json:"referent"`
+	Name                   string `json:"name"`
+	PType                  string `json:"p_type"`
+	PValue                 int64  `json:"p_value"`
+	CredentialReferent     string `json:"credential_referent"`
+	SchemaID               string `json:"schema_id"`
+	CredentialDefinitionID string `json:"cred_def_id"`
+	IssuerID               string `json:"issuer_id,omitempty"`
+}
+
+/// @notice A value the holder attests to without a credential
+type SelfAttestedPreview struct {
+	Referent string `json:"referent"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+}
+
+/// @notice The non-revocation proof for one credential
+type NonRevocationPreview struct {
+	CredentialReferent string                        `json:"credential_referent"`
+	Interval           *anoncreds.NonRevokedInterval `json:"interval"`
+	Timestamp          *int64                        `json:"timestamp,omitempty"` /// @notice Unset until the holder picks a status list
+}
+
+/// @notice Everything a presentation built from a selection discloses
+type PresentationPreview struct {
+	Attributes    []AttributePreview     `json:"attributes"`
+	Predicates    []PredicatePreview     `json:"predicates"`
+	SelfAttested  []SelfAttestedPreview  `json:"self_attested"`
+	Issuers       []string               `json:"issuers"`
+	NonRevocation []NonRevocationPreview `json:"non_revocation"`
+}
+
+/// @notice Describes what presenting a selection would disclose
+/// @param presReq The presentation request
+/// @param selection The credentials chosen for the request, including self-attested values
+/// @return The preview and any error encountered
+func PreviewPresentation(presReq *anoncreds.PresentationRequest, selection *CredentialSelection) (*PresentationPreview, error) {
+	if presReq == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	if selection == nil {
+		return nil, fmt.Errorf("selection is required")
+	}
+	request, err := presReq.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &PresentationPreview{
+		Attributes:    []AttributePreview{},
+		Predicates:    []PredicatePreview{},
+		SelfAttested:  []SelfAttestedPreview{},
+		Issuers:       []string{},
+		NonRevocation: []NonRevocationPreview{},
+	}
+	issuers := map[string]bool{}
+	used := map[int]bool{}
+
+	// Predicates by credential entry and attribute name, to spot needless reveals
+	predicates := map[int]map[string]string{}
+	for _, prove := range selection.Prove {
+		if !prove.IsPredicate {
+			continue
+		}
+		predicate, ok := request.RequestedPredicates[prove.Referent]
+		if !ok {
+			return nil, fmt.Errorf("selection proves unknown predicate %s", prove.Referent)
+		}
+		if predicates[prove.EntryIndex] == nil {
+			predicates[prove.EntryIndex] = map[string]string{}
+		}
+		predicates[prove.EntryIndex][NormalizeAttributeName(predicate.Name)] = prove.Referent
+	}
+
+	for _, prove := range selection.Prove {
+		if prove.EntryIndex < 0 || prove.EntryIndex >= len(selection.Entries) {
+			return nil, fmt.Errorf("referent %s points to missing credential entry %d", prove.Referent, prove.EntryIndex)
+		}
+		record := selection.Entries[prove.EntryIndex].Record
+		used[prove.EntryIndex] = true
+		if record.IssuerID != "" {
+			issuers[record.IssuerID] = true
+		}
+
+		if prove.IsPredicate {
+			predicate := request.RequestedPredicates[prove.Referent]
+			preview.Predicates = append(preview.Predicates, PredicatePreview{
+				Referent:               prove.Referent,
+				Name:                   predicate.Name,
+				PType:                  predicate.PType,
+				PValue:                 predicate.PValue,
+				CredentialReferent:     record.Referent,
+				SchemaID:               record.SchemaID,
+				CredentialDefinitionID: record.CredentialDefinitionID,
+				IssuerID:               record.IssuerID,
+			})
+			continue
+		}
+
+		attribute, ok := request.RequestedAttributes[prove.Referent]
+		if !ok {
+			return nil, fmt.Errorf("selection proves unknown attribute %s", prove.Referent)
+		}
+		attributePreview := AttributePreview{
+			Referent:               prove.Referent,
+			Names:                  attribute.AttributeNames(),
+			Revealed:               prove.Reveal,
+			CredentialReferent:     record.Referent,
+			SchemaID:               record.SchemaID,
+			CredentialDefinitionID: record.CredentialDefinitionID,
+			IssuerID:               record.IssuerID,
+		}
+		if prove.Reveal {
+			attributePreview.Values = make(map[string]string, len(attributePreview.Names))
+			for _, name := range attributePreview.Names {
+				value, ok := record.Attribute(name)
+				if !ok {
+					return nil, fmt.Errorf("credential %s has no attribute %s", record.Referent, name)
+				}
+				attributePreview.Values[name] = value
+				if predicateReferent, ok := predicates[prove.EntryIndex][NormalizeAttributeName(name)]; ok {
+					attributePreview.Unnecessary = true
+					attributePreview.Reason = fmt.Sprintf("predicate %s already proves %s without revealing it", predicateReferent, name)
+				}
+			}
+		}
+		preview.Attributes = append(preview.Attributes, attributePreview)
+	}
+
+	for _, referent := range request.AttributeReferents() {
+		value, ok := selection.SelfAttestedAttributes[referent]
+		if !ok {
+			continue
+		}
+		preview.SelfAttested = append(preview.SelfAttested, SelfAttestedPreview{
+			Referent: referent,
+			Name:     request.RequestedAttributes[referent].Name,
+			Value:    value,
+		})
+	}
+
+	for index, entry := range selection.Entries {
+		if used[index] && entry.NonRevoked != nil {
+			preview.NonRevocation = append(preview.NonRevocation, NonRevocationPreview{
+				CredentialReferent: entry.Record.Referent,
+				Interval:           entry.NonRevoked,
+				Timestamp:          entry.Timestamp,
+			})
+		}
+	}
+
+	for issuer := range issuers {
+		preview.Issuers = append(preview.Issuers, issuer)
+	}
+	sort.Strings(preview.Issuers)
+	return preview, nil
+}
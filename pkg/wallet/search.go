@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/wql"
+)
+
+/// @title Credential Search
+/// @dev WQL search over stored credentials and presentation request restrictions
+
+/// @dev Restriction names that differ from the tags the wallet stores
+var legacyTagNames = map[string]string{
+	"issuer_did":        TagIssuerID,
+	"schema_issuer_did": TagSchemaIssuerID,
+}
+
+/// @notice Returns the stored credentials whose tags match a WQL query
+/// @param store The store to search
+/// @param query The parsed query; nil matches every credential
+/// @return The matching records ordered by referent and any error encountered
+func Search(store CredentialStore, query wql.Query) ([]*CredentialRecord, error) {
+	records, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return records, nil
+	}
+	matches := make([]*CredentialRecord, 0, len(records))
+	for _, record := range records {
+		if query.Match(record.Tags) {
+			matches = append(matches, record)
+		}
+	}
+	return matches, nil
+}
+
+/// @notice Parses presentation request restrictions into a query over wallet tags
+/// @param restrictions The decoded restrictions value of a requested attribute or predicate
+/// @return The query and any error encountered
+/// @dev Legacy names like issuer_did are mapped to wallet tags and attribute names are normalized
+func RestrictionQuery(restrictions interface{}) (wql.Query, error) {
+	query, err := wql.ParseRestrictions(restrictions)
+	if err != nil {
+		return nil, err
+	}
+	return wql.RenameTags(query, TagName), nil
+}
+
+/// @notice Maps a restriction or query tag name to the name the wallet stores it under
+func TagName(name string) string {
+	if mapped, ok := legacyTagNames[name]; ok {
+		return mapped
+	}
+	if strings.HasPrefix(name, "attr::") {
+		parts := strings.Split(name, "::")
+		if len(parts) == 3 {
+			return "attr::" + NormalizeAttributeName(parts[1]) + "::" + parts[2]
+		}
+	}
+	return name
+}
@@ -0,0 +1,265 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wql"
+)
+
+/// @title Credential Selection
+/// @dev Finds stored credentials that satisfy a presentation request and assembles the
+/// entry and prove lists for anoncreds.CreatePresentation
+
+/// @notice Options for credential selection
+type SelectOptions struct {
+	/// @notice Reports whether a credential is known to be revoked; revoked credentials are ranked last
+	IsRevoked func(record *CredentialRecord) (bool, error)
+}
+
+/// @notice A credential that satisfies a requested attribute or predicate
+type Candidate struct {
+	Record  *CredentialRecord
+	Revoked bool
+}
+
+/// @notice Candidate credentials for every referent of a presentation request, best first
+type RequestCandidates struct {
+	Request    *anoncreds.PresentationRequestContents
+	Attributes map[string][]*Candidate
+	Predicates map[string][]*Candidate
+}
+
+/// @notice A credential chosen for a presentation
+type SelectedEntry struct {
+	Record     *CredentialRecord
+	NonRevoked *anoncreds.NonRevokedInterval /// @notice Interval to prove non-revocation for, if requested
+	Timestamp  *int64                        /// @notice Status list timestamp the holder proves non-revocation at
+}
+
+/// @notice The credentials chosen for a presentation request
+/// @dev Entries and Prove line up with CreatePresentationOptions.Credentials and CredentialsProve
+type CredentialSelection struct {
+	Candidates *RequestCandidates
+	Entries    []*SelectedEntry
+	Prove      []anoncreds.CredentialProve
+
+	/// @notice Attribute referents without restrictions or candidates, left for self-attestation
+	SelfAttestable []string
+
+	/// @notice Values the holder attests to, keyed by referent
+	SelfAttestedAttributes map[string]string
+}
+
+/// @notice Finds candidate credentials for every requested attribute and predicate
+/// @param presReq The presentation request
+/// @param store The credentials to choose from
+/// @param options Optional ranking hooks
+/// @return Candidates per referent; referents nothing satisfies have an empty list
+func FindCandidates(presReq *anoncreds.PresentationRequest, store CredentialStore, options ...SelectOptions) (*RequestCandidates, error) {
+	if presReq == nil {
+		return nil, fmt.Errorf("presentation request is required")
+	}
+	request, err := presReq.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return findCandidates(request, store, selectOptions(options))
+}
+
+/// @notice Chooses one credential for every requested attribute and predicate
+/// @param presReq The presentation request
+/// @param store The credentials to choose from
+/// @param options Optional ranking hooks
+/// @return The selection and an error naming any referent no credential satisfies
+/// @dev Prefers non-revoked credentials, then the most recently stored ones
+func SelectCredentials(presReq *anoncreds.PresentationRequest, store CredentialStore, options ...SelectOptions) (*CredentialSelection, error) {
+	candidates, err := FindCandidates(presReq, store, options...)
+	if err != nil {
+		return nil, err
+	}
+	return candidates.Select()
+}
+
+/// @notice Chooses the best candidate for every referent
+/// @return The selection and an error naming any referent no credential satisfies
+func (c *RequestCandidates) Select() (*CredentialSelection, error) {
+	selection := &CredentialSelection{Candidates: c, SelfAttestedAttributes: map[string]string{}}
+	entries := map[string]int{}
+	missing := []string{}
+
+	use := func(record *CredentialRecord, nonRevoked *anoncreds.NonRevokedInterval) int {
+		if index, ok := entries[record.Referent]; ok {
+			if selection.Entries[index].NonRevoked == nil {
+				selection.Entries[index].NonRevoked = nonRevoked
+			}
+			return index
+		}
+		entries[record.Referent] = len(selection.Entries)
+		selection.Entries = append(selection.Entries, &SelectedEntry{Record: record, NonRevoked: nonRevoked})
+		return len(selection.Entries) - 1
+	}
+
+	for _, referent := range c.Request.AttributeReferents() {
+		attribute := c.Request.RequestedAttributes[referent]
+		candidates := c.Attributes[referent]
+		if len(candidates) == 0 {
+			if attribute.Restrictions == nil {
+				selection.SelfAttestable = append(selection.SelfAttestable, referent)
+			} else {
+				missing = append(missing, referent)
+			}
+			continue
+		}
+		index := use(candidates[0].Record, c.Request.EffectiveNonRevoked(attribute.NonRevoked))
+		selection.Prove = append(selection.Prove, anoncreds.CredentialProve{
+			EntryIndex: index,
+			Referent:   referent,
+			Reveal:     true,
+		})
+	}
+	for _, referent := range c.Request.PredicateReferents() {
+		predicate := c.Request.RequestedPredicates[referent]
+		candidates := c.Predicates[referent]
+		if len(candidates) == 0 {
+			missing = append(missing, referent)
+			continue
+		}
+		index := use(candidates[0].Record, c.Request.EffectiveNonRevoked(predicate.NonRevoked))
+		selection.Prove = append(selection.Prove, anoncreds.CredentialProve{
+			EntryIndex:  index,
+			Referent:    referent,
+			IsPredicate: true,
+		})
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no credential satisfies referents %s", strings.Join(missing, ", "))
+	}
+	return selection, nil
+}
+
+/// @notice Loads the selected credentials in entry order
+/// @return Entries for CreatePresentationOptions.Credentials; the caller must Clear each credential
+/// @dev RevocationState is left for the caller to fill in for entries with NonRevoked set
+func (s *CredentialSelection) PresentCredentials() ([]anoncreds.PresentCredential, error) {
+	credentials := make([]anoncreds.PresentCredential, 0, len(s.Entries))
+	for _, entry := range s.Entries {
+		credential, err := entry.Record.Credential()
+		if err != nil {
+			for _, loaded := range credentials {
+				loaded.Credential.Clear()
+			}
+			return nil, fmt.Errorf("credential %s: %w", entry.Record.Referent, err)
+		}
+		credentials = append(credentials, anoncreds.PresentCredential{Credential: credential, Timestamp: entry.Timestamp})
+	}
+	return credentials, nil
+}
+
+/// @notice Returns the schema IDs of the selected credentials
+func (s *CredentialSelection) SchemaIDs() []string {
+	return s.uniqueIDs(func(record *CredentialRecord) string { return record.SchemaID })
+}
+
+/// @notice Returns the credential definition IDs of the selected credentials
+func (s *CredentialSelection) CredentialDefinitionIDs() []string {
+	return s.uniqueIDs(func(record *CredentialRecord) string { return record.CredentialDefinitionID })
+}
+
+/// @dev Collects distinct, sorted IDs of the selected credentials
+func (s *CredentialSelection) uniqueIDs(id func(record *CredentialRecord) string) []string {
+	seen := map[string]bool{}
+	ids := []string{}
+	for _, entry := range s.Entries {
+		if value := id(entry.Record); !seen[value] {
+			seen[value] = true
+			ids = append(ids, value)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+/// @dev Evaluates every referent of the request against the store
+func findCandidates(request *anoncreds.PresentationRequestContents, store CredentialStore, options SelectOptions) (*RequestCandidates, error) {
+	records, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	revoked := map[string]bool{}
+	if options.IsRevoked != nil {
+		for _, record := range records {
+			isRevoked, err := options.IsRevoked(record)
+			if err != nil {
+				return nil, fmt.Errorf("credential %s: %w", record.Referent, err)
+			}
+			revoked[record.Referent] = isRevoked
+		}
+	}
+
+	candidates := &RequestCandidates{
+		Request:    request,
+		Attributes: make(map[string][]*Candidate, len(request.RequestedAttributes)),
+		Predicates: make(map[string][]*Candidate, len(request.RequestedPredicates)),
+	}
+	for referent, attribute := range request.RequestedAttributes {
+		query, err := RestrictionQuery(attribute.Restrictions)
+		if err != nil {
+			return nil, fmt.Errorf("requested attribute %s: %w", referent, err)
+		}
+		candidates.Attributes[referent] = matchRecords(records, query, revoked, func(record *CredentialRecord) bool {
+			for _, name := range attribute.AttributeNames() {
+				if _, ok := record.Attribute(name); !ok {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	for referent, predicate := range request.RequestedPredicates {
+		query, err := RestrictionQuery(predicate.Restrictions)
+		if err != nil {
+			return nil, fmt.Errorf("requested predicate %s: %w", referent, err)
+		}
+		candidates.Predicates[referent] = matchRecords(records, query, revoked, func(record *CredentialRecord) bool {
+			raw, ok := record.Attribute(predicate.Name)
+			if !ok {
+				return false
+			}
+			satisfied, err := predicate.SatisfiedBy(raw)
+			return err == nil && satisfied
+		})
+	}
+	return candidates, nil
+}
+
+/// @dev Filters records by query and predicate, ranked non-revoked first and newest first
+func matchRecords(records []*CredentialRecord, query wql.Query, revoked map[string]bool, accept func(*CredentialRecord) bool) []*Candidate {
+	matches := []*Candidate{}
+	for _, record := range records {
+		if query.Match(record.Tags) && accept(record) {
+			matches = append(matches, &Candidate{Record: record, Revoked: revoked[record.Referent]})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Revoked != matches[j].Revoked {
+			return !matches[i].Revoked
+		}
+		if matches[i].Record.CreatedAt != matches[j].Record.CreatedAt {
+			return matches[i].Record.CreatedAt > matches[j].Record.CreatedAt
+		}
+		return matches[i].Record.Referent < matches[j].Record.Referent
+	})
+	return matches
+}
+
+/// @dev Returns the first options value or the zero value
+func selectOptions(options []SelectOptions) SelectOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return SelectOptions{}
+}
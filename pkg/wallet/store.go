@@ -0,0 +1,106 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+/// @title Record-Backed Credential Store
+/// @dev Keeps credential records in a storage.Store, mirroring their tags onto the storage record
+
+/// @notice Storage category holding credential records
+const CategoryCredential = "credential"
+
+/// @notice Returned when a credential does not exist; matches storage.ErrNotFound with errors.Is
+var ErrCredentialNotFound = fmt.Errorf("credential %w", storage.ErrNotFound)
+
+/// @notice A CredentialStore on top of a generic record store
+type RecordStore struct {
+	store storage.Store
+}
+
+/// @notice Creates a credential store backed by the given record store
+func NewRecordStore(store storage.Store) *RecordStore {
+	return &RecordStore{store: store}
+}
+
+/// @notice Creates a credential store that keeps records in memory
+func NewMemoryStore() *RecordStore {
+	return NewRecordStore(storage.NewMemoryStore())
+}
+
+/// @notice Opens a credential store that keeps each record in a file under dir
+func NewFileStore(dir string) (*RecordStore, error) {
+	store, err := storage.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecordStore(store), nil
+}
+
+/// @notice Inserts or replaces a credential record
+func (s *RecordStore) Put(record *CredentialRecord) error {
+	if record == nil || record.Referent == "" {
+		return fmt.Errorf("credential record with a referent is required")
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.store.Put(&storage.Record{
+		Category: CategoryCredential,
+		ID:       record.Referent,
+		Value:    value,
+		Tags:     record.Tags,
+	})
+}
+
+/// @notice Returns the credential record with the given referent
+func (s *RecordStore) Get(referent string) (*CredentialRecord, error) {
+	record, err := s.store.Get(CategoryCredential, referent)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	return decodeCredentialRecord(record)
+}
+
+/// @notice Removes a credential record
+func (s *RecordStore) Delete(referent string) error {
+	err := s.store.Delete(CategoryCredential, referent)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrCredentialNotFound
+	}
+	return err
+}
+
+/// @notice Returns every credential record, ordered by referent
+func (s *RecordStore) List() ([]*CredentialRecord, error) {
+	records, err := s.store.List(CategoryCredential)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]*CredentialRecord, 0, len(records))
+	for _, record := range records {
+		credential, err := decodeCredentialRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+/// @dev Decodes a storage record into a credential record
+func decodeCredentialRecord(record *storage.Record) (*CredentialRecord, error) {
+	var credential CredentialRecord
+	if err := json.Unmarshal(record.Value, &credential); err != nil {
+		return nil, fmt.Errorf("corrupt credential record %s: %w", record.ID, err)
+	}
+	return &credential, nil
+}
@@ -0,0 +1,267 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+)
+
+/// @title Holder Credential Wallet
+/// @dev Stores processed credentials together with the tags Askar and indy-sdk index them by
+
+/// @notice Tag names indexed for every credential
+const (
+	TagSchemaID               = "schema_id"
+	TagSchemaName             = "schema_name"
+	TagSchemaVersion          = "schema_version"
+	TagSchemaIssuerID         = "schema_issuer_id"
+	TagIssuerID               = "issuer_id"
+	TagCredentialDefinitionID = "cred_def_id"
+	TagRevocationRegistryID   = "rev_reg_id"
+	TagLinkSecretID           = "link_secret_id"
+)
+
+/// @notice A stored credential
+/// @dev Value holds the credential JSON as returned by anoncreds.Credential.ToJSONString
+type CredentialRecord struct {
+	Referent                string            `json:"referent"`
+	SchemaID                string            `json:"schema_id"`
+	SchemaName              string            `json:"schema_name,omitempty"`
+	SchemaVersion           string            `json:"schema_version,omitempty"`
+	SchemaIssuerID          string            `json:"schema_issuer_id,omitempty"`
+	IssuerID                string            `json:"issuer_id,omitempty"`
+	CredentialDefinitionID  string            `json:"cred_def_id"`
+	RevocationRegistryID    string            `json:"rev_reg_id,omitempty"`
+	RevocationRegistryIndex *uint32           `json:"rev_reg_index,omitempty"`
+	LinkSecretID            string            `json:"link_secret_id,omitempty"`
+	Attributes              map[string]string `json:"attributes"`
+	Tags                    map[string]string `json:"tags"`
+	Value                   json.RawMessage   `json:"value"`
+	CreatedAt               int64             `json:"created_at"`
+}
+
+/// @notice Storage for holder credentials
+/// @dev Implementations must be safe for concurrent use
+type CredentialStore interface {
+	/// @notice Inserts the record, replacing any existing record with the same referent
+	Put(record *CredentialRecord) error
+	/// @notice Returns the record with the given referent
+	Get(referent string) (*CredentialRecord, error)
+	/// @notice Removes a record
+	Delete(referent string) error
+	/// @notice Returns every record, ordered by referent
+	List() ([]*CredentialRecord, error)
+}
+
+/// @notice Details of a credential that cannot be read from the credential itself
+/// @dev Schema and issuer fields are derived from legacy Indy identifiers when left empty
+type CredentialMetadata struct {
+	Referent       string /// @notice Referent to store the credential under; generated when empty
+	SchemaName     string
+	SchemaVersion  string
+	SchemaIssuerID string
+	IssuerID       string
+	LinkSecretID   string
+	Tags           map[string]string /// @notice Extra tags to index
+}
+
+/// @notice Builds a tagged record from a processed credential
+/// @param credential The processed credential
+/// @param metadata Details that are not part of the credential JSON
+/// @return The record and any error encountered
+func NewCredentialRecord(credential *anoncreds.Credential, metadata CredentialMetadata) (*CredentialRecord, error) {
+	if credential == nil {
+		return nil, fmt.Errorf("credential is required")
+	}
+	credentialJSON, err := credential.ToJSONString()
+	if err != nil {
+		return nil, err
+	}
+	return NewCredentialRecordFromJSON([]byte(credentialJSON), metadata)
+}
+
+/// @notice Builds a tagged record from credential JSON
+/// @param credentialJSON The credential as JSON
+/// @param metadata Details that are not part of the credential JSON
+/// @return The record and any error encountered
+func NewCredentialRecordFromJSON(credentialJSON []byte, metadata CredentialMetadata) (*CredentialRecord, error) {
+	var credential struct {
+		SchemaID               string  `json:"schema_id"`
+		CredentialDefinitionID string  `json:"cred_def_id"`
+		RevocationRegistryID   string  `json:"rev_reg_id"`
+		RevocationRegistryIdx  *uint32 `json:"rev_reg_index"`
+		Values                 map[string]struct {
+			Raw string `json:"raw"`
+		} `json:"values"`
+		Signature struct {
+			RevocationCredential *struct {
+				Index *uint32 `json:"i"`
+			} `json:"r_credential"`
+		} `json:"signature"`
+	}
+	if err := json.Unmarshal(credentialJSON, &credential); err != nil {
+		return nil, fmt.Errorf("invalid credential JSON: %w", err)
+	}
+	if credential.SchemaID == "" || credential.CredentialDefinitionID == "" {
+		return nil, fmt.Errorf("credential is missing schema_id or cred_def_id")
+	}
+
+	referent := metadata.Referent
+	if referent == "" {
+		var err error
+		if referent, err = NewReferent(); err != nil {
+			return nil, err
+		}
+	}
+
+	record := &CredentialRecord{
+		Referent:                referent,
+		SchemaID:                credential.SchemaID,
+		SchemaName:              metadata.SchemaName,
+		SchemaVersion:           metadata.SchemaVersion,
+		SchemaIssuerID:          metadata.SchemaIssuerID,
+		IssuerID:                metadata.IssuerID,
+		CredentialDefinitionID:  credential.CredentialDefinitionID,
+		RevocationRegistryID:    credential.RevocationRegistryID,
+		RevocationRegistryIndex: credential.RevocationRegistryIdx,
+		LinkSecretID:            metadata.LinkSecretID,
+		Attributes:              make(map[string]string, len(credential.Values)),
+		Value:                   append(json.RawMessage(nil), credentialJSON...),
+		CreatedAt:               time.Now().Unix(),
+	}
+	for name, value := range credential.Values {
+		record.Attributes[name] = value.Raw
+	}
+	// anoncreds-rs keeps the index inside the revocation signature
+	if record.RevocationRegistryIndex == nil && credential.Signature.RevocationCredential != nil {
+		record.RevocationRegistryIndex = credential.Signature.RevocationCredential.Index
+	}
+
+	if did, name, version, err := ledger.ParseLegacySchemaID(record.SchemaID); err == nil {
+		record.SchemaName = firstNonEmpty(record.SchemaName, name)
+		record.SchemaVersion = firstNonEmpty(record.SchemaVersion, version)
+		record.SchemaIssuerID = firstNonEmpty(record.SchemaIssuerID, did)
+	}
+	if did, _, _, err := ledger.ParseLegacyCredentialDefinitionID(record.CredentialDefinitionID); err == nil {
+		record.IssuerID = firstNonEmpty(record.IssuerID, did)
+	}
+
+	record.Tags = record.indexTags(metadata.Tags)
+	return record, nil
+}
+
+/// @notice Stores a processed credential, generating a referent unless metadata names one
+/// @param store The store to write to
+/// @param credential The processed credential
+/// @param metadata Details that are not part of the credential JSON
+/// @return The stored record and any error encountered
+func StoreCredential(store CredentialStore, credential *anoncreds.Credential, metadata CredentialMetadata) (*CredentialRecord, error) {
+	record, err := NewCredentialRecord(credential, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Put(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/// @notice Loads the stored credential
+/// @return A credential object owned by the caller and any error encountered
+func (r *CredentialRecord) Credential() (*anoncreds.Credential, error) {
+	return anoncreds.CredentialFromJSON([]byte(r.Value))
+}
+
+/// @notice Returns the attribute names of the credential in sorted order
+func (r *CredentialRecord) AttributeNames() []string {
+	names := make([]string, 0, len(r.Attributes))
+	for name := range r.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/// @notice Returns the raw value of an attribute, matching names the way tags do
+func (r *CredentialRecord) Attribute(name string) (string, bool) {
+	if value, ok := r.Attributes[name]; ok {
+		return value, true
+	}
+	normalized := NormalizeAttributeName(name)
+	for attr, value := range r.Attributes {
+		if NormalizeAttributeName(attr) == normalized {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+/// @notice Returns the tag holding an attribute's raw value
+func AttributeValueTag(name string) string {
+	return "attr::" + NormalizeAttributeName(name) + "::value"
+}
+
+/// @notice Returns the tag marking that a credential has an attribute
+func AttributeMarkerTag(name string) string {
+	return "attr::" + NormalizeAttributeName(name) + "::marker"
+}
+
+/// @notice Normalizes an attribute name the way indy-sdk and Askar do: lower case without spaces
+func NormalizeAttributeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
+}
+
+/// @notice Generates a random referent in UUID v4 format
+func NewReferent() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+/// @dev Computes the indexed tags of the record
+func (r *CredentialRecord) indexTags(extra map[string]string) map[string]string {
+	tags := make(map[string]string, len(extra)+8+2*len(r.Attributes))
+	for key, value := range extra {
+		tags[key] = value
+	}
+	setTag(tags, TagSchemaID, r.SchemaID)
+	setTag(tags, TagSchemaName, r.SchemaName)
+	setTag(tags, TagSchemaVersion, r.SchemaVersion)
+	setTag(tags, TagSchemaIssuerID, r.SchemaIssuerID)
+	setTag(tags, TagIssuerID, r.IssuerID)
+	setTag(tags, TagCredentialDefinitionID, r.CredentialDefinitionID)
+	setTag(tags, TagRevocationRegistryID, r.RevocationRegistryID)
+	setTag(tags, TagLinkSecretID, r.LinkSecretID)
+	for name, value := range r.Attributes {
+		tags[AttributeValueTag(name)] = value
+		tags[AttributeMarkerTag(name)] = "1"
+	}
+	return tags
+}
+
+/// @dev Sets a tag unless the value is empty
+func setTag(tags map[string]string, key, value string) {
+	if value != "" {
+		tags[key] = value
+	}
+}
+
+/// @dev Returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
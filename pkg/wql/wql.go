@@ -0,0 +1,396 @@
+package wql
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/// @title Wallet Query Language
+/// @dev Parser and evaluator for the WQL dialect used by indy-sdk and Askar to search tagged records.
+/// Values are compared as strings, as Askar does. A leading "~" on a tag name, which marks an
+/// unencrypted tag in indy-sdk, is ignored since tags here are always plain text
+
+/// @notice Comparison operators
+const (
+	OpEq   = "$eq"
+	OpNeq  = "$neq"
+	OpGt   = "$gt"
+	OpGte  = "$gte"
+	OpLt   = "$lt"
+	OpLte  = "$lte"
+	OpLike = "$like"
+	OpIn   = "$in"
+)
+
+/// @notice Logical operators
+const (
+	OpAnd   = "$and"
+	OpOr    = "$or"
+	OpNot   = "$not"
+	OpExist = "$exist"
+)
+
+/// @notice A parsed WQL query
+type Query interface {
+	/// @notice Reports whether a record with the given tags satisfies the query
+	Match(tags map[string]string) bool
+}
+
+/// @notice Matches when every subquery matches; an empty And matches everything
+type And []Query
+
+/// @notice Matches when any subquery matches; an empty Or matches nothing
+type Or []Query
+
+/// @notice Matches when the subquery does not
+type Not struct {
+	Query Query
+}
+
+/// @notice Compares a tag against a value
+/// @dev A missing tag never matches, not even $neq
+type Compare struct {
+	Op    string
+	Tag   string
+	Value string
+}
+
+/// @notice Matches when a tag equals one of the values
+type In struct {
+	Tag    string
+	Values []string
+}
+
+/// @notice Matches when every listed tag is present
+type Exist struct {
+	Tags []string
+}
+
+/// @notice Parses a WQL query from JSON
+/// @param data The query JSON; an empty input is the empty query, which matches everything
+/// @return The parsed query and any error encountered
+func Parse(data []byte) (Query, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return And{}, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("wql: invalid JSON: %w", err)
+	}
+	return ParseValue(value)
+}
+
+/// @notice Parses a WQL query from decoded JSON
+/// @param value A map as produced by encoding/json; nil is the empty query
+/// @return The parsed query and any error encountered
+func ParseValue(value interface{}) (Query, error) {
+	if value == nil {
+		return And{}, nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wql: query must be an object, got %s", typeName(value))
+	}
+	return parseObject(object)
+}
+
+/// @notice Parses presentation request restrictions into a query
+/// @param value Either an array of objects, any of which may match, or a single WQL object
+/// @return The parsed query and any error encountered
+/// @dev Missing restrictions match every record
+func ParseRestrictions(value interface{}) (Query, error) {
+	switch restrictions := value.(type) {
+	case nil:
+		return And{}, nil
+	case []interface{}:
+		if len(restrictions) == 0 {
+			return And{}, nil
+		}
+		or := make(Or, 0, len(restrictions))
+		for i, restriction := range restrictions {
+			query, err := ParseValue(restriction)
+			if err != nil {
+				return nil, fmt.Errorf("restriction %d: %w", i, err)
+			}
+			or = append(or, query)
+		}
+		return or, nil
+	default:
+		return ParseValue(value)
+	}
+}
+
+/// @notice Returns a copy of the query with every tag name passed through rename
+/// @dev Useful to map legacy restriction names such as issuer_did onto stored tag names
+func RenameTags(query Query, rename func(tag string) string) Query {
+	switch q := query.(type) {
+	case And:
+		out := make(And, len(q))
+		for i, sub := range q {
+			out[i] = RenameTags(sub, rename)
+		}
+		return out
+	case Or:
+		out := make(Or, len(q))
+		for i, sub := range q {
+			out[i] = RenameTags(sub, rename)
+		}
+		return out
+	case Not:
+		return Not{Query: RenameTags(q.Query, rename)}
+	case Compare:
+		q.Tag = rename(q.Tag)
+		return q
+	case In:
+		q.Tag = rename(q.Tag)
+		return q
+	case Exist:
+		tags := make([]string, len(q.Tags))
+		for i, tag := range q.Tags {
+			tags[i] = rename(tag)
+		}
+		return Exist{Tags: tags}
+	default:
+		return query
+	}
+}
+
+/// @notice Reports whether every subquery matches
+func (q And) Match(tags map[string]string) bool {
+	for _, sub := range q {
+		if !sub.Match(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+/// @notice Reports whether any subquery matches
+func (q Or) Match(tags map[string]string) bool {
+	for _, sub := range q {
+		if sub.Match(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+/// @notice Reports whether the subquery does not match
+func (q Not) Match(tags map[string]string) bool {
+	return !q.Query.Match(tags)
+}
+
+/// @notice Reports whether the tag satisfies the comparison
+func (q Compare) Match(tags map[string]string) bool {
+	value, ok := lookup(tags, q.Tag)
+	if !ok {
+		return false
+	}
+	switch q.Op {
+	case OpEq:
+		return value == q.Value
+	case OpNeq:
+		return value != q.Value
+	case OpGt:
+		return value > q.Value
+	case OpGte:
+		return value >= q.Value
+	case OpLt:
+		return value < q.Value
+	case OpLte:
+		return value <= q.Value
+	case OpLike:
+		return likePattern(q.Value).MatchString(value)
+	default:
+		return false
+	}
+}
+
+/// @notice Reports whether the tag equals one of the values
+func (q In) Match(tags map[string]string) bool {
+	value, ok := lookup(tags, q.Tag)
+	if !ok {
+		return false
+	}
+	for _, candidate := range q.Values {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+/// @notice Reports whether every tag is present
+func (q Exist) Match(tags map[string]string) bool {
+	for _, tag := range q.Tags {
+		if _, ok := lookup(tags, tag); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+/// @dev Parses an object: every key is a condition and all of them must hold
+func parseObject(object map[string]interface{}) (Query, error) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	and := make(And, 0, len(keys))
+	for _, key := range keys {
+		query, err := parseCondition(key, object[key])
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, query)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+/// @dev Parses a single key of a query object
+func parseCondition(key string, value interface{}) (Query, error) {
+	switch key {
+	case OpAnd, OpOr:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("wql: %s expects an array of queries, got %s", key, typeName(value))
+		}
+		queries := make([]Query, 0, len(list))
+		for i, item := range list {
+			query, err := ParseValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("wql: %s[%d]: %w", key, i, err)
+			}
+			queries = append(queries, query)
+		}
+		if key == OpAnd {
+			return And(queries), nil
+		}
+		return Or(queries), nil
+	case OpNot:
+		query, err := ParseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("wql: %s: %w", key, err)
+		}
+		return Not{Query: query}, nil
+	case OpExist:
+		switch tags := value.(type) {
+		case string:
+			return Exist{Tags: []string{tags}}, nil
+		case []interface{}:
+			names, err := stringList(key, tags)
+			if err != nil {
+				return nil, err
+			}
+			return Exist{Tags: names}, nil
+		default:
+			return nil, fmt.Errorf("wql: %s expects a tag name or an array of tag names, got %s", key, typeName(value))
+		}
+	}
+
+	if strings.HasPrefix(key, "$") {
+		return nil, fmt.Errorf("wql: unknown operator %s", key)
+	}
+
+	switch condition := value.(type) {
+	case string:
+		return Compare{Op: OpEq, Tag: key, Value: condition}, nil
+	case map[string]interface{}:
+		if len(condition) != 1 {
+			return nil, fmt.Errorf("wql: condition on %s must have exactly one operator", key)
+		}
+		for op, operand := range condition {
+			return parseOperator(key, op, operand)
+		}
+	}
+	return nil, fmt.Errorf("wql: value of %s must be a string or an operator object, got %s", key, typeName(value))
+}
+
+/// @dev Parses an operator object such as {"$neq": "value"} applied to a tag
+func parseOperator(tag, op string, operand interface{}) (Query, error) {
+	switch op {
+	case OpEq, OpNeq, OpGt, OpGte, OpLt, OpLte, OpLike:
+		value, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("wql: %s on %s expects a string, got %s", op, tag, typeName(operand))
+		}
+		return Compare{Op: op, Tag: tag, Value: value}, nil
+	case OpIn:
+		list, ok := operand.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("wql: %s on %s expects an array of strings, got %s", op, tag, typeName(operand))
+		}
+		values, err := stringList(op, list)
+		if err != nil {
+			return nil, err
+		}
+		return In{Tag: tag, Values: values}, nil
+	default:
+		return nil, fmt.Errorf("wql: unknown operator %s on %s", op, tag)
+	}
+}
+
+/// @dev Converts a JSON array into strings
+func stringList(op string, list []interface{}) ([]string, error) {
+	values := make([]string, 0, len(list))
+	for i, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("wql: %s[%d] must be a string, got %s", op, i, typeName(item))
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+/// @dev Looks up a tag, ignoring the plaintext marker
+func lookup(tags map[string]string, tag string) (string, bool) {
+	value, ok := tags[strings.TrimPrefix(tag, "~")]
+	return value, ok
+}
+
+/// @dev Compiles a SQL LIKE pattern, where % matches any run of characters and _ a single one
+func likePattern(pattern string) *regexp.Regexp {
+	var builder strings.Builder
+	builder.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			builder.WriteString(".*")
+		case '_':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	builder.WriteString("$")
+	return regexp.MustCompile(builder.String())
+}
+
+/// @dev Describes a decoded JSON value for error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/backup"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+// backupKDF keeps Argon2id cheap in tests
+var backupKDF = keystore.Argon2id{Time: 1, Memory: 1024, Threads: 1}
+
+// newBackupLinkSecrets returns a link secret manager sealing under a passphrase KEK
+func newBackupLinkSecrets(t *testing.T, store storage.Store) *wallet.LinkSecretManager {
+	t.Helper()
+	kek, err := keystore.PassphraseKEK(store, "holder", []byte("kek passphrase"), keystore.PBKDF2{Iterations: 1000})
+	if err != nil {
+		t.Fatalf("Failed to derive kek: %v", err)
+	}
+	linkSecrets, _ := newTestLinkSecretManager(t, store, kek, nil)
+	return linkSecrets
+}
+
+func TestBackupExportImport(t *testing.T) {
+	source := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(source)
+	record, err := wallet.NewCredentialRecordFromJSON([]byte(`{"schema_id":"55GkHamhTU1ZbTbV2ab9DE:2:employee:1.0","cred_def_id":"55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default","values":{"name":{"raw":"Alice","encoded":"1"}}}`), wallet.CredentialMetadata{Referent: "cred-1", LinkSecretID: "main"})
+	if err != nil {
+		t.Fatalf("Failed to build record: %v", err)
+	}
+	if err := credentials.Put(record); err != nil {
+		t.Fatalf("Failed to store credential: %v", err)
+	}
+	if _, err := newBackupLinkSecrets(t, source).Import("main", anoncreds.LinkSecretFromValue(testLinkSecretValue)); err != nil {
+		t.Fatalf("Failed to import link secret: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	passphrase := []byte("correct horse battery staple")
+	manifest, err := backup.Export(source, &buffer, backup.ExportOptions{
+		Passphrase: passphrase,
+		KDF:        backupKDF,
+		Now:        func() time.Time { return time.Unix(1700000000, 0) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	// The credential, the link secret record, the default pointer, the sealed secret and its KEK
+	counts := manifest.Counts()
+	if counts[backup.ScopeCredentials] != 1 || counts[backup.ScopeLinkSecrets] != 4 || len(manifest.Entries) != 5 {
+		t.Errorf("Unexpected manifest %+v", manifest.Entries)
+	}
+	if bytes.Contains(buffer.Bytes(), []byte("Alice")) || bytes.Contains(buffer.Bytes(), []byte("cred-1")) {
+		t.Error("Expected the bundle contents to be encrypted")
+	}
+	bundle := buffer.Bytes()
+
+	if _, err := backup.Inspect(bytes.NewReader(bundle), []byte("wrong")); !errors.Is(err, backup.ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a wrong passphrase, got %v", err)
+	}
+	tampered := append([]byte(nil), bundle...)
+	tampered[bytes.Index(tampered, []byte(`"created_at":`))+len(`"created_at":`)] = '2'
+	if _, err := backup.Inspect(bytes.NewReader(tampered), passphrase); !errors.Is(err, backup.ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a modified header, got %v", err)
+	}
+	// A header asking for an excessive cost is rejected before deriving a key
+	for _, kdf := range []string{"argon2id:1:4294967295:255", "argon2id:4294967295:1024:1"} {
+		expensive := bytes.Replace(bundle, []byte(`"kdf":"`+backupKDF.Name()+`"`), []byte(`"kdf":"`+kdf+`"`), 1)
+		if _, err := backup.Inspect(bytes.NewReader(expensive), passphrase); err == nil || errors.Is(err, backup.ErrDecrypt) {
+			t.Errorf("Expected %s to be rejected, got %v", kdf, err)
+		}
+	}
+	if _, err := backup.Export(source, io.Discard, backup.ExportOptions{Passphrase: passphrase, KDF: keystore.Argon2id{Time: 1, Memory: backup.MaxArgon2idMemory + 1, Threads: 1}}); err == nil {
+		t.Error("Expected an export above the maximum cost to be rejected")
+	}
+
+	// The destination opens the link secret with the same KEK passphrase
+	destination := storage.NewMemoryStore()
+	report, err := backup.Import(bytes.NewReader(bundle), destination, backup.ImportOptions{Passphrase: passphrase})
+	if err != nil || report.Imported != 5 {
+		t.Fatalf("Expected 5 imported records, got %+v (%v)", report, err)
+	}
+	if imported, err := wallet.NewRecordStore(destination).Get("cred-1"); err != nil || imported.Attributes["name"] != "Alice" {
+		t.Errorf("Expected the credential to be imported, got %+v (%v)", imported, err)
+	}
+	secret, err := newBackupLinkSecrets(t, destination).Get("")
+	if err != nil || string(secret.Bytes()) != testLinkSecretValue {
+		t.Errorf("Expected the default link secret to be imported (%v)", err)
+	}
+
+	// Importing again is a no-op; changed records conflict
+	if report, err := backup.Import(bytes.NewReader(bundle), destination, backup.ImportOptions{Passphrase: passphrase}); err != nil || report.Unchanged != 5 {
+		t.Errorf("Expected identical records to be left alone, got %+v (%v)", report, err)
+	}
+	record.Attributes["name"] = "Mallory"
+	if err := wallet.NewRecordStore(destination).Put(record); err != nil {
+		t.Fatalf("Failed to change credential: %v", err)
+	}
+	if _, err := backup.Import(bytes.NewReader(bundle), destination, backup.ImportOptions{Passphrase: passphrase}); !errors.Is(err, backup.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	report, err = backup.Import(bytes.NewReader(bundle), destination, backup.ImportOptions{Passphrase: passphrase, Conflict: backup.ConflictSkip})
+	if err != nil || len(report.Skipped) != 1 || report.Skipped[0].ID != "cred-1" {
+		t.Errorf("Expected the changed credential to be skipped, got %+v (%v)", report, err)
+	}
+	report, err = backup.Import(bytes.NewReader(bundle), destination, backup.ImportOptions{
+		Passphrase: passphrase,
+		Scopes:     []backup.Scope{backup.ScopeCredentials},
+		Conflict:   backup.ConflictOverwrite,
+	})
+	if err != nil || report.Overwritten != 1 || report.Imported != 1 {
+		t.Errorf("Expected the credential to be overwritten, got %+v (%v)", report, err)
+	}
+	if restored, _ := wallet.NewRecordStore(destination).Get("cred-1"); restored == nil || restored.Attributes["name"] != "Alice" {
+		t.Errorf("Expected the credential to be restored, got %+v", restored)
+	}
+
+	// Selective exports only carry their scopes
+	buffer.Reset()
+	manifest, err = backup.Export(source, &buffer, backup.ExportOptions{Passphrase: passphrase, KDF: backupKDF, Scopes: []backup.Scope{backup.ScopeCredentials}})
+	if err != nil || len(manifest.Entries) != 1 {
+		t.Fatalf("Expected a single record, got %+v (%v)", manifest, err)
+	}
+	if _, err := backup.Import(&buffer, storage.NewMemoryStore(), backup.ImportOptions{Passphrase: passphrase, Scopes: []backup.Scope{backup.ScopeIssuer}}); err == nil {
+		t.Error("Expected importing a scope the bundle lacks to fail")
+	}
+}
+
+func TestBackupImportKEKConflict(t *testing.T) {
+	source := storage.NewMemoryStore()
+	if _, err := newBackupLinkSecrets(t, source).Import("main", anoncreds.LinkSecretFromValue(testLinkSecretValue)); err != nil {
+		t.Fatalf("Failed to import link secret: %v", err)
+	}
+	var buffer bytes.Buffer
+	passphrase := []byte("correct horse battery staple")
+	if _, err := backup.Export(source, &buffer, backup.ExportOptions{Passphrase: passphrase, KDF: backupKDF}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	// The destination derived its own KEK under the same ID with another salt
+	destination := storage.NewMemoryStore()
+	keys := newBackupLinkSecrets(t, destination)
+	if _, err := keys.Import("other", anoncreds.LinkSecretFromValue("42")); err != nil {
+		t.Fatalf("Failed to import link secret: %v", err)
+	}
+	for _, policy := range []backup.ConflictPolicy{backup.ConflictFail, backup.ConflictSkip, backup.ConflictOverwrite} {
+		if _, err := backup.Import(bytes.NewReader(buffer.Bytes()), destination, backup.ImportOptions{Passphrase: passphrase, Conflict: policy}); !errors.Is(err, backup.ErrConflict) {
+			t.Errorf("Expected ErrConflict for a differing kek with policy %s, got %v", policy, err)
+		}
+	}
+	if secret, err := keys.Get("other"); err != nil || string(secret.Bytes()) != "42" {
+		t.Errorf("Expected the destination's sealed secret to stay readable (%v)", err)
+	}
+}
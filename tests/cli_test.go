@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/cli"
+)
+
+// runCLI runs the command-line tool and returns its exit code and output
+func runCLI(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := cli.Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI(t *testing.T) {
+	if code, _, stderr := runCLI(""); code != cli.ExitUsage || !strings.Contains(stderr, "credential issue") {
+		t.Errorf("Expected usage listing the commands, got %d %q", code, stderr)
+	}
+	if code, _, _ := runCLI("", "help"); code != cli.ExitOK {
+		t.Errorf("Expected help to succeed, got %d", code)
+	}
+	if code, _, stderr := runCLI("", "schema", "delete"); code != cli.ExitUsage || !strings.Contains(stderr, `unknown command "schema delete"`) {
+		t.Errorf("Expected an unknown command, got %d %q", code, stderr)
+	}
+	if code, _, stderr := runCLI("", "offer", "create", "-h"); code != cli.ExitOK || !strings.Contains(stderr, "-kcp") {
+		t.Errorf("Expected the offer flags, got %d %q", code, stderr)
+	}
+	if code, _, stderr := runCLI("", "schema", "create", "-name", "employee"); code != cli.ExitUsage || !strings.Contains(stderr, "-attr, -issuer, -version required") {
+		t.Errorf("Expected missing flags to be reported, got %d %q", code, stderr)
+	}
+
+	code, stdout, stderr := runCLI("", "nonce")
+	if code != cli.ExitOK || strings.TrimSpace(stdout) == "" {
+		t.Errorf("Expected a nonce, got %d %q %q", code, stdout, stderr)
+	}
+
+	// Credentials named by the requested credentials file must be supplied
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "link_secret")
+	requested := filepath.Join(dir, "requested.json")
+	if err := os.WriteFile(secret, []byte(testLinkSecretValue+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(requested, []byte(`{"requested_attributes":{"attr1_referent":{"cred_id":"employee","revealed":true}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	request := `{"nonce":"1234","name":"proof","version":"1.0","requested_attributes":{"attr1_referent":{"name":"name"}},"requested_predicates":{}}`
+	code, _, stderr = runCLI(request, "presentation", "create", "-request", "-", "-requested", requested, "-link-secret", secret)
+	if code != cli.ExitUsage || !strings.Contains(stderr, "no -credential employee=FILE") {
+		t.Errorf("Expected the missing credential to be reported, got %d %q", code, stderr)
+	}
+	code, _, stderr = runCLI("", "presentation", "verify", "-presentation", filepath.Join(dir, "missing.json"), "-request", requested)
+	if code != cli.ExitFailure || !strings.Contains(stderr, "missing.json") {
+		t.Errorf("Expected a missing file to fail, got %d %q", code, stderr)
+	}
+}
+
+func TestCLILinkSecretFileMode(t *testing.T) {
+	// An existing world-readable file is restricted before the secret is written
+	out := filepath.Join(t.TempDir(), "link_secret")
+	if err := os.WriteFile(out, []byte("placeholder\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runCLI("", "link-secret", "create", "-o", out); code != cli.ExitOK {
+		t.Fatalf("Failed to create link secret: %d %q", code, stderr)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatalf("Failed to stat link secret: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+	if data, err := os.ReadFile(out); err != nil || bytes.Contains(data, []byte("placeholder")) {
+		t.Errorf("Expected the file to be replaced, got %q (%v)", data, err)
+	}
+}
+
+func TestCLIInspect(t *testing.T) {
+	code, stdout, stderr := runCLI("", "inspect", "-format", "json", "testdata/interop/credo-ts/offer.json")
+	if code != cli.ExitOK {
+		t.Fatalf("Expected a valid offer, got %d %q %q", code, stdout, stderr)
+	}
+	var inspection cli.Inspection
+	if err := json.Unmarshal([]byte(stdout), &inspection); err != nil {
+		t.Fatalf("Failed to decode inspection: %v", err)
+	}
+	if inspection.Type != "CredentialOffer" || !inspection.Parsed || inspection.Nonce != "108651863445038696785864" {
+		t.Errorf("Unexpected inspection %+v", inspection)
+	}
+	if strings.Join(inspection.Attributes, ",") != "age,name" || !strings.HasSuffix(inspection.IDs["cred_def_id"], "/CLAIM_DEF/15/default") {
+		t.Errorf("Unexpected offer summary %+v", inspection)
+	}
+
+	// Flags may follow the file; text is the default format
+	code, stdout, _ = runCLI("", "inspect", "testdata/interop/credo-ts/cred_def.json", "--format", "text")
+	if code != cli.ExitOK || !strings.Contains(stdout, "Type:        CredentialDefinition") || !strings.Contains(stdout, "Revocation:  not supported") {
+		t.Errorf("Unexpected text output %d %q", code, stdout)
+	}
+
+	// A snake-cased issuer field is reported
+	schema := []byte(`{"issuer_id":"55GkHamhTU1ZbTbV2ab9DE","name":"employee","version":"1.0","attrNames":["name","age"]}`)
+	if inspection := cli.Inspect(schema); inspection.Type != "Schema" || len(inspection.Errors) != 1 || inspection.Errors[0].Path != "$.issuerId" || !strings.Contains(inspection.Errors[0].Message, "issuer_id") {
+		t.Errorf("Expected the issuer field to be reported, got %+v", inspection)
+	}
+
+	malformed := `{"schema_id":"s","cred_def_id":"c","key_correctness_proof":{"c":"1","xz_cap":12,"xr_cap":[]},"nonce":123}`
+	code, stdout, _ = runCLI(malformed, "inspect", "-format", "json", "-")
+	if code != cli.ExitFailure {
+		t.Errorf("Expected a malformed offer to fail, got %d", code)
+	}
+	inspection = cli.Inspection{}
+	if err := json.Unmarshal([]byte(stdout), &inspection); err != nil {
+		t.Fatalf("Failed to decode inspection: %v", err)
+	}
+	var paths []string
+	for _, problem := range inspection.Errors {
+		paths = append(paths, problem.Path)
+	}
+	if inspection.Type != "CredentialOffer" || strings.Join(paths, " ") != "$.key_correctness_proof.xz_cap $.nonce" {
+		t.Errorf("Unexpected malformed offer inspection %+v", inspection)
+	}
+
+	if inspection := cli.Inspect([]byte(`{"name":"proof","version":"1.0","nonce":"1","requested_attributes":{"attr 1":{}},"requested_predicates":{"age":{"name":"age","p_type":"=>","p_value":18}}}`)); inspection.Type != "PresentationRequest" || len(inspection.Errors) != 2 || inspection.Errors[0].Path != `$.requested_attributes["attr 1"]` || inspection.Errors[1].Path != "$.requested_predicates.age.p_type" {
+		t.Errorf("Unexpected presentation request inspection %+v", inspection)
+	}
+	if inspection := cli.Inspect([]byte(`{"records":[]}`)); inspection.Type != "" || len(inspection.Errors) != 1 {
+		t.Errorf("Expected an unknown object, got %+v", inspection)
+	}
+	if inspection := cli.Inspect([]byte(`{"name":`)); inspection.Valid() || !strings.HasPrefix(inspection.Errors[0].Message, "invalid JSON") {
+		t.Errorf("Expected invalid JSON to be reported, got %+v", inspection)
+	}
+}
@@ -52,7 +52,8 @@ func TestFullCredentialFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create link secret: %v", err)
 	}
-	// Link secret is a string, no need to clear
+	// Each call wipes the link secret it is given, so processing uses a copy
+	processSecret := anoncreds.LinkSecretFromBytes(linkSecret.Bytes())
 
 	// 5. Holder creates credential request
 	credReqResult, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{
@@ -68,6 +69,9 @@ func TestFullCredentialFlow(t *testing.T) {
 	}
 	defer credReqResult.CredentialRequest.Clear()
 	defer credReqResult.CredentialRequestMetadata.Clear()
+	if !linkSecret.Wiped() {
+		t.Error("Expected the link secret to be wiped after the request")
+	}
 
 	// 6. Issuer creates credential
 	credential, err := anoncreds.CreateCredential(anoncreds.CreateCredentialOptions{
@@ -90,7 +94,7 @@ func TestFullCredentialFlow(t *testing.T) {
 	processedCred, err := anoncreds.ProcessCredential(anoncreds.ProcessCredentialOptions{
 		Credential:                credential,
 		CredentialRequestMetadata: credReqResult.CredentialRequestMetadata,
-		LinkSecret:                linkSecret,
+		LinkSecret:                processSecret,
 		CredentialDefinition:      credDefResult.CredentialDefinition,
 	})
 	if err != nil {
@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/didcomm"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+const didcommOfferJSON = `{"schema_id":"55GkHamhTU1ZbTbV2ab9DE:2:employee:1.0","cred_def_id":"55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default","key_correctness_proof":{"c":"1","xz_cap":"2","xr_cap":[["name","3"]]},"nonce":"1234"}`
+
+func TestAttachmentEncodings(t *testing.T) {
+	for _, encoding := range []didcomm.Encoding{didcomm.EncodingBase64, didcomm.EncodingJSON} {
+		attachment, err := didcomm.NewAttachment("offer-0", []byte(didcommOfferJSON), encoding)
+		if err != nil {
+			t.Fatalf("Failed to create %s attachment: %v", encoding, err)
+		}
+		if attachment.Encoding() != encoding || attachment.MimeType != didcomm.MimeTypeJSON {
+			t.Errorf("Unexpected %s attachment %+v", encoding, attachment)
+		}
+		encoded, _ := json.Marshal(attachment)
+		var decoded didcomm.Attachment
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("Failed to decode attachment: %v", err)
+		}
+		payload, err := decoded.Payload()
+		if err != nil || string(payload) != didcommOfferJSON {
+			t.Errorf("Expected the %s payload to round-trip, got %s (%v)", encoding, payload, err)
+		}
+	}
+
+	// Agents send unpadded and URL-safe base64 too
+	raw := []byte(`{"nonce":"1234~?"}`)
+	for _, data := range []string{
+		base64.RawStdEncoding.EncodeToString(raw),
+		base64.URLEncoding.EncodeToString(raw),
+		base64.RawURLEncoding.EncodeToString(raw),
+	} {
+		attachment := didcomm.Attachment{ID: "a", Data: didcomm.AttachmentData{Base64: data}}
+		if payload, err := attachment.Payload(); err != nil || string(payload) != string(raw) {
+			t.Errorf("Failed to decode %s: %s (%v)", data, payload, err)
+		}
+	}
+	if _, err := (&didcomm.Attachment{ID: "a"}).Payload(); err == nil {
+		t.Error("Expected an attachment without data to be rejected")
+	}
+	if _, err := didcomm.NewAttachment("a", []byte("not json"), didcomm.EncodingJSON); err == nil {
+		t.Error("Expected a non-JSON payload to be rejected")
+	}
+}
+
+func TestAttachmentFormats(t *testing.T) {
+	kind, family, err := didcomm.ParseFormat("hlindy/cred-abstract@v2.0")
+	if err != nil || kind != didcomm.KindCredentialOffer || family != didcomm.FamilyIndy {
+		t.Errorf("Unexpected format %s %s (%v)", kind, family, err)
+	}
+	if format, err := didcomm.FormatOf(didcomm.KindProofRequest, didcomm.FamilyAnonCreds); err != nil || format != "anoncreds/proof-request@v1.0" {
+		t.Errorf("Unexpected format %s (%v)", format, err)
+	}
+	if _, _, err := didcomm.ParseFormat("aries/ld-proof-vc@v1.0"); err == nil {
+		t.Error("Expected an unsupported format to be rejected")
+	}
+
+	// An offer message listing an unsupported format first
+	var message struct {
+		Formats     []didcomm.AttachmentFormat `json:"formats"`
+		Attachments []didcomm.Attachment       `json:"offers~attach"`
+	}
+	data := `{
+		"formats": [
+			{"attach_id": "ld", "format": "aries/ld-proof-vc-detail@v1.0"},
+			{"attach_id": "indy", "format": "hlindy/cred-abstract@v2.0"}
+		],
+		"offers~attach": [
+			{"@id": "ld", "data": {"json": {}}},
+			{"@id": "indy", "mime-type": "application/json", "data": {"base64": "` + base64.StdEncoding.EncodeToString([]byte(didcommOfferJSON)) + `"}}
+		]
+	}`
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	format, attachment, err := didcomm.FindAttachment(message.Formats, message.Attachments, didcomm.Accept(didcomm.KindCredentialOffer))
+	if err != nil || attachment.ID != "indy" {
+		t.Fatalf("Expected the hlindy attachment, got %+v (%v)", attachment, err)
+	}
+	if _, err := didcomm.DecodeCredentialRequest(format, attachment); err == nil {
+		t.Error("Expected an offer attachment not to decode as a request")
+	}
+
+	offer, err := didcomm.DecodeCredentialOffer(format, attachment)
+	if err != nil {
+		t.Fatalf("Failed to decode offer: %v", err)
+	}
+	defer offer.Clear()
+
+	// Re-encoding for an AnonCreds peer carries the same object
+	reformat, reattached, err := didcomm.EncodeCredentialOffer("offer-0", offer, didcomm.FamilyAnonCreds, didcomm.EncodingJSON)
+	if err != nil {
+		t.Fatalf("Failed to encode offer: %v", err)
+	}
+	if reformat.Format != didcomm.FormatAnonCredsCredentialOffer || reformat.AttachID != reattached.ID {
+		t.Errorf("Unexpected formats entry %+v", reformat)
+	}
+	roundTripped, err := didcomm.DecodeCredentialOffer(reformat, reattached)
+	if err != nil {
+		t.Fatalf("Failed to decode re-encoded offer: %v", err)
+	}
+	defer roundTripped.Clear()
+	original, _ := offer.ToJSON()
+	again, _ := roundTripped.ToJSON()
+	if original["nonce"] != again["nonce"] || original["cred_def_id"] != again["cred_def_id"] {
+		t.Errorf("Expected the offer to survive re-encoding, got %v", again)
+	}
+}
+
+func TestCredentialPreview(t *testing.T) {
+	values := map[string]string{"name": "Alice", "age": "28"}
+	preview := didcomm.NewCredentialPreview(values)
+	encoded, _ := json.Marshal(preview)
+	expected := `{"@type":"https://didcomm.org/issue-credential/2.0/credential-preview","attributes":[{"name":"age","mime-type":"text/plain","value":"28"},{"name":"name","mime-type":"text/plain","value":"Alice"}]}`
+	if string(encoded) != expected {
+		t.Errorf("Unexpected preview %s", encoded)
+	}
+	raw, err := preview.RawValues()
+	if err != nil || len(raw) != 2 || raw["name"] != "Alice" || raw["age"] != "28" {
+		t.Errorf("Expected the raw values to round-trip, got %v (%v)", raw, err)
+	}
+
+	schema, err := anoncreds.SchemaFromJSON(`{"name":"employee","version":"1.0","issuerId":"did:example:issuer","attrNames":["name","age"]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	defer schema.Clear()
+	if err := preview.Validate(schema); err != nil {
+		t.Errorf("Expected the preview to match the schema: %v", err)
+	}
+
+	mismatched := didcomm.NewCredentialPreview(map[string]string{"Name": "Alice", "department": "Engineering"})
+	mismatched.Attributes = append(mismatched.Attributes, didcomm.PreviewAttribute{Name: "department", Value: "Sales"})
+	var mismatch *didcomm.PreviewMismatchError
+	if err := mismatched.Validate(schema); !errors.As(err, &mismatch) {
+		t.Fatalf("Expected a PreviewMismatchError, got %v", err)
+	}
+	if len(mismatch.Missing) != 1 || mismatch.Missing[0] != "age" ||
+		len(mismatch.Extra) != 1 || mismatch.Extra[0] != "department" ||
+		len(mismatch.CaseMismatch) != 1 || mismatch.CaseMismatch[0] != "Name" ||
+		len(mismatch.Duplicate) != 1 || mismatch.Duplicate[0] != "department" {
+		t.Errorf("Unexpected mismatch %+v", mismatch)
+	}
+	if _, err := mismatched.RawValues(); err == nil {
+		t.Error("Expected a duplicate attribute to be rejected")
+	}
+}
+
+func TestCredentialProposalMatching(t *testing.T) {
+	store := storage.NewMemoryStore()
+	put := func(category, id string, value interface{}) {
+		encoded, _ := json.Marshal(value)
+		if err := store.Put(&storage.Record{Category: category, ID: id, Value: encoded}); err != nil {
+			t.Fatalf("Failed to store %s: %v", id, err)
+		}
+	}
+	put(issuer.CategorySchema, "schema:employee", issuer.SchemaRecord{ID: "schema:employee", Name: "employee", Version: "1.0", IssuerID: "did:example:issuer", AttributeNames: []string{"name", "age"}})
+	put(issuer.CategorySchema, "schema:badge", issuer.SchemaRecord{ID: "schema:badge", Name: "badge", Version: "2.0", IssuerID: "did:example:other", AttributeNames: []string{"name", "level"}})
+	put(issuer.CategoryCredentialDefinition, "creddef:employee", issuer.CredentialDefinitionRecord{ID: "creddef:employee", SchemaID: "schema:employee", IssuerID: "did:example:issuer"})
+	put(issuer.CategoryCredentialDefinition, "creddef:badge", issuer.CredentialDefinitionRecord{ID: "creddef:badge", SchemaID: "schema:badge", IssuerID: "did:example:issuer"})
+
+	service, err := issuer.NewService(issuer.ServiceOptions{Store: store})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	candidates, err := didcomm.OfferableCredentialDefinitions(service)
+	if err != nil || len(candidates) != 2 {
+		t.Fatalf("Expected 2 candidates, got %d (%v)", len(candidates), err)
+	}
+
+	// An Indy filter naming the schema issuer under its legacy field
+	format, attachment, err := didcomm.EncodeCredentialFilter("filter-0", &didcomm.CredentialFilter{SchemaIssuerID: "did:example:other"}, didcomm.FamilyIndy, didcomm.EncodingJSON)
+	if err != nil {
+		t.Fatalf("Failed to encode filter: %v", err)
+	}
+	if string(attachment.Data.JSON) != `{"schema_issuer_did":"did:example:other"}` {
+		t.Errorf("Unexpected Indy filter %s", attachment.Data.JSON)
+	}
+	filter, err := didcomm.DecodeCredentialFilter(format, attachment)
+	if err != nil {
+		t.Fatalf("Failed to decode filter: %v", err)
+	}
+	proposal := didcomm.CredentialProposal{Filter: *filter}
+	matched, err := proposal.Match(candidates)
+	if err != nil || len(matched) != 1 || matched[0].CredentialDefinitionID != "creddef:badge" {
+		t.Errorf("Expected the badge credential definition, got %v (%v)", matched, err)
+	}
+
+	// The issuer filter matches both, the preview narrows it down
+	proposal = didcomm.CredentialProposal{
+		Filter:            didcomm.CredentialFilter{IssuerID: "did:example:issuer"},
+		CredentialPreview: didcomm.NewCredentialPreview(map[string]string{"name": "Alice", "age": "28"}),
+	}
+	matched, err = proposal.Match(candidates)
+	if err != nil || len(matched) != 1 || matched[0].CredentialDefinitionID != "creddef:employee" || matched[0].SchemaName != "employee" {
+		t.Errorf("Expected the employee credential definition, got %v (%v)", matched, err)
+	}
+	proposal.Filter.SchemaVersion = "2.0"
+	var mismatch *didcomm.PreviewMismatchError
+	if _, err := proposal.Match(candidates); !errors.As(err, &mismatch) {
+		t.Errorf("Expected the preview mismatch to be reported, got %v", err)
+	}
+}
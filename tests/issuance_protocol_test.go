@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/protocol"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+// newTestLinkSecrets returns a link secret manager holding a single default secret
+func newTestLinkSecrets(t *testing.T, store storage.Store, credentials wallet.CredentialStore) *wallet.LinkSecretManager {
+	t.Helper()
+	linkSecrets, _ := newTestLinkSecretManager(t, store, testKEK(t), credentials)
+	if _, err := linkSecrets.Import("default", anoncreds.LinkSecretFromValue(testLinkSecretValue)); err != nil {
+		t.Fatalf("Failed to import link secret: %v", err)
+	}
+	return linkSecrets
+}
+
+func TestIssuanceProtocol(t *testing.T) {
+	issuerStore := storage.NewMemoryStore()
+	service, err := issuer.NewService(issuer.ServiceOptions{Store: issuerStore})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	defer service.Close()
+	schema, err := service.CreateSchema("", anoncreds.CreateSchemaOptions{
+		Name:           "employee",
+		Version:        "1.0",
+		IssuerID:       "55GkHamhTU1ZbTbV2ab9DE",
+		AttributeNames: []string{"name", "age"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	credDef, err := service.CreateCredentialDefinition(issuer.CredentialDefinitionOptions{
+		ID:       "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+		SchemaID: schema.ID,
+		IssuerID: "55GkHamhTU1ZbTbV2ab9DE",
+		Tag:      "default",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential definition: %v", err)
+	}
+	issuerMachine, err := protocol.NewCredentialIssuer(protocol.CredentialIssuerOptions{Service: service, Store: issuerStore})
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+
+	holderStore := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(holderStore)
+	holder, err := protocol.NewCredentialHolder(protocol.CredentialHolderOptions{
+		Store:       holderStore,
+		LinkSecrets: newTestLinkSecrets(t, holderStore, credentials),
+		Credentials: credentials,
+		CredentialDefinition: func(credDefID string) (*anoncreds.CredentialDefinition, error) {
+			return anoncreds.CredentialDefinitionFromJSON([]byte(credDef.CredentialDefinition))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create holder: %v", err)
+	}
+
+	offered, err := issuerMachine.Offer(credDef.ID, map[string]string{"name": "Alice", "age": "28"})
+	if err != nil {
+		t.Fatalf("Failed to offer: %v", err)
+	}
+	received, err := holder.ReceiveOffer(offered.ID, offered.Offer)
+	if err != nil {
+		t.Fatalf("Failed to receive offer: %v", err)
+	}
+	requested, err := holder.Request(received.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to request: %v", err)
+	}
+	if len(requested.RequestMetadata) == 0 || requested.LinkSecretID != "default" {
+		t.Errorf("Expected the request metadata to be kept, got %+v", requested)
+	}
+	if _, err := issuerMachine.ReceiveRequest(offered.ID, requested.Request); err != nil {
+		t.Fatalf("Failed to receive request: %v", err)
+	}
+	issued, err := issuerMachine.Issue(offered.ID)
+	if err != nil {
+		t.Fatalf("Failed to issue: %v", err)
+	}
+	stored, err := holder.ReceiveCredential(requested.ID, issued.Credential, wallet.CredentialMetadata{})
+	if err != nil {
+		t.Fatalf("Failed to receive credential: %v", err)
+	}
+	if stored.RequestMetadata != nil || stored.CredentialID == "" {
+		t.Errorf("Expected the metadata to be dropped once stored, got %+v", stored)
+	}
+	if _, err := holder.Ack(stored.ID); err != nil {
+		t.Fatalf("Failed to ack: %v", err)
+	}
+	done, err := issuerMachine.ReceiveAck(issued.ID)
+	if err != nil || done.State != protocol.StateDone || done.Credential != nil {
+		t.Errorf("Expected the issuer exchange to be done, got %+v (%v)", done, err)
+	}
+	if record, err := credentials.Get(stored.CredentialID); err != nil || record.LinkSecretID != "default" {
+		t.Errorf("Expected the credential to be stored against the link secret, got %+v (%v)", record, err)
+	}
+}
+
+func TestIssuanceHolderTransitions(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(store)
+	options := protocol.CredentialHolderOptions{
+		Store:       store,
+		LinkSecrets: newTestLinkSecrets(t, store, credentials),
+		Credentials: credentials,
+		CredentialDefinition: func(credDefID string) (*anoncreds.CredentialDefinition, error) {
+			return nil, fmt.Errorf("unknown credential definition %s", credDefID)
+		},
+		Timeout: time.Hour,
+		Now:     func() time.Time { return now },
+	}
+	holder, err := protocol.NewCredentialHolder(options)
+	if err != nil {
+		t.Fatalf("Failed to create holder: %v", err)
+	}
+
+	offer := []byte(`{"schema_id":"55GkHamhTU1ZbTbV2ab9DE:2:employee:1.0","cred_def_id":"55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default","nonce":"1234"}`)
+	if _, err := holder.ReceiveOffer("thread-1", []byte(`{"nonce":"1"}`)); err == nil {
+		t.Error("Expected an incomplete offer to be rejected")
+	}
+	record, err := holder.ReceiveOffer("thread-1", offer)
+	if err != nil {
+		t.Fatalf("Failed to receive offer: %v", err)
+	}
+	if record.State != protocol.IssuanceStateOfferReceived || record.ExpiresAt != now.Add(time.Hour).Unix() {
+		t.Errorf("Unexpected exchange %+v", record)
+	}
+	if _, err := holder.ReceiveOffer("thread-1", offer); !errors.Is(err, protocol.ErrExchangeExists) {
+		t.Errorf("Expected ErrExchangeExists, got %v", err)
+	}
+
+	// Messages out of order are rejected without changing the state
+	if _, err := holder.ReceiveCredential("thread-1", []byte(`{}`), wallet.CredentialMetadata{}); !errors.Is(err, protocol.ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+	if _, err := holder.Ack("thread-1"); !errors.Is(err, protocol.ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+	// A local failure that is not the peer's fault leaves the exchange open
+	if _, err := holder.Request("thread-1", ""); err == nil {
+		t.Error("Expected the unresolvable credential definition to fail the request")
+	}
+
+	// Exchanges survive a restart
+	restarted, err := protocol.NewCredentialHolder(options)
+	if err != nil {
+		t.Fatalf("Failed to create holder: %v", err)
+	}
+	if record, err := restarted.Exchange("thread-1"); err != nil || record.State != protocol.IssuanceStateOfferReceived {
+		t.Errorf("Expected the exchange to persist, got %+v (%v)", record, err)
+	}
+
+	// Timeouts abandon the exchange
+	now = now.Add(2 * time.Hour)
+	expired, err := restarted.Request("thread-1", "")
+	if !errors.Is(err, protocol.ErrExchangeExpired) || expired == nil || expired.State != protocol.StateAbandoned {
+		t.Fatalf("Expected the exchange to time out, got %+v (%v)", expired, err)
+	}
+	if expired.Problem == nil || expired.Problem.Code != protocol.ProblemTimeout {
+		t.Errorf("Expected a timeout problem report, got %+v", expired.Problem)
+	}
+
+	// Stale exchanges are swept, and problem reports from the peer abandon an exchange
+	if _, err := restarted.ReceiveOffer("thread-2", offer); err != nil {
+		t.Fatalf("Failed to receive offer: %v", err)
+	}
+	if _, err := restarted.ReceiveOffer("thread-3", offer); err != nil {
+		t.Fatalf("Failed to receive offer: %v", err)
+	}
+	reported, err := restarted.ReceiveProblemReport("thread-3", protocol.ProblemReport{Code: protocol.ProblemIssuanceAbandoned, Description: "cancelled"})
+	if err != nil || reported.State != protocol.StateAbandoned {
+		t.Errorf("Expected the problem report to abandon the exchange, got %+v (%v)", reported, err)
+	}
+	if _, err := restarted.Abandon("thread-3", protocol.ProblemReport{Code: protocol.ProblemIssuanceAbandoned}); !errors.Is(err, protocol.ErrInvalidTransition) {
+		t.Errorf("Expected an abandoned exchange to stay abandoned, got %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	swept, err := restarted.ExpireStale()
+	if err != nil || len(swept) != 1 || swept[0].ID != "thread-2" {
+		t.Errorf("Expected thread-2 to be swept, got %v (%v)", swept, err)
+	}
+	abandoned, err := restarted.Exchanges(protocol.StateAbandoned)
+	if err != nil || len(abandoned) != 3 {
+		t.Errorf("Expected 3 abandoned exchanges, got %d (%v)", len(abandoned), err)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+// requestCredential answers an offer record the way a holder would
+func requestCredential(t *testing.T, credDef *issuer.CredentialDefinitionRecord, offer *issuer.CredentialOfferRecord, linkSecret *anoncreds.LinkSecret) *anoncreds.CreateCredentialRequestResult {
+	t.Helper()
+	credentialDefinition, err := anoncreds.CredentialDefinitionFromJSON([]byte(credDef.CredentialDefinition))
+	if err != nil {
+		t.Fatalf("Failed to load credential definition: %v", err)
+	}
+	defer credentialDefinition.Clear()
+	credentialOffer, err := anoncreds.CredentialOfferFromJSON([]byte(offer.Offer))
+	if err != nil {
+		t.Fatalf("Failed to load offer: %v", err)
+	}
+	defer credentialOffer.Clear()
+
+	request, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{
+		Entropy:              "holder-entropy",
+		CredentialDefinition: credentialDefinition,
+		LinkSecret:           linkSecret,
+		LinkSecretID:         "default",
+		CredentialOffer:      credentialOffer,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential request: %v", err)
+	}
+	return request
+}
+
+func TestIssuerService(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	service, err := issuer.NewService(issuer.ServiceOptions{
+		Store:    storage.NewMemoryStore(),
+		OfferTTL: time.Hour,
+		Now:      func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	defer service.Close()
+
+	schema, err := service.CreateSchema("", anoncreds.CreateSchemaOptions{
+		Name:           "employee",
+		Version:        "1.0",
+		IssuerID:       "55GkHamhTU1ZbTbV2ab9DE",
+		AttributeNames: []string{"name", "age"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	if schema.ID != "55GkHamhTU1ZbTbV2ab9DE:2:employee:1.0" {
+		t.Errorf("Expected a legacy schema ID, got %s", schema.ID)
+	}
+	credDef, err := service.CreateCredentialDefinition(issuer.CredentialDefinitionOptions{
+		ID:       "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+		SchemaID: schema.ID,
+		IssuerID: "55GkHamhTU1ZbTbV2ab9DE",
+		Tag:      "default",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential definition: %v", err)
+	}
+	if len(credDef.CredentialDefinitionPrivate) == 0 || len(credDef.KeyCorrectnessProof) == 0 {
+		t.Error("Expected the private part and key correctness proof to be stored")
+	}
+
+	offer, err := service.Offer(credDef.ID)
+	if err != nil {
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	if offer.Nonce == "" || offer.State != issuer.OfferStateOffered {
+		t.Errorf("Unexpected offer %+v", offer)
+	}
+
+	linkSecret, err := anoncreds.CreateLinkSecret()
+	if err != nil {
+		t.Fatalf("Failed to create link secret: %v", err)
+	}
+	request := requestCredential(t, credDef, offer, linkSecret)
+	defer request.CredentialRequest.Clear()
+	defer request.CredentialRequestMetadata.Clear()
+
+	if _, _, err := service.Issue(offer.ID, request.CredentialRequest, map[string]string{"name": "Alice"}); err == nil {
+		t.Error("Expected missing attribute values to be rejected")
+	}
+	credential, issued, err := service.Issue(offer.ID, request.CredentialRequest, map[string]string{"name": "Alice", "age": "28"})
+	if err != nil {
+		t.Fatalf("Failed to issue credential: %v", err)
+	}
+	defer credential.Clear()
+	if issued.OfferID != offer.ID || issued.CredentialDefinitionID != credDef.ID {
+		t.Errorf("Unexpected issuance record %+v", issued)
+	}
+	if stored, err := service.IssuedCredential(issued.ID); err != nil || stored.OfferID != offer.ID {
+		t.Errorf("Expected the issuance to be recorded, got %+v (%v)", stored, err)
+	}
+
+	// An offer can only be answered once
+	if _, _, err := service.Issue(offer.ID, request.CredentialRequest, map[string]string{"name": "Alice", "age": "28"}); !errors.Is(err, issuer.ErrOfferConsumed) {
+		t.Errorf("Expected ErrOfferConsumed, got %v", err)
+	}
+
+	// Offers expire
+	expiring, err := service.Offer(credDef.ID)
+	if err != nil {
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, _, err := service.Issue(expiring.ID, request.CredentialRequest, map[string]string{"name": "Alice", "age": "28"}); !errors.Is(err, issuer.ErrOfferExpired) {
+		t.Errorf("Expected ErrOfferExpired, got %v", err)
+	}
+	if _, _, err := service.Issue("unknown", request.CredentialRequest, nil); !errors.Is(err, issuer.ErrOfferNotFound) {
+		t.Errorf("Expected ErrOfferNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+func TestKeyStoreSealAndRotate(t *testing.T) {
+	store := storage.NewMemoryStore()
+	fastKDF := keystore.PBKDF2{Iterations: 1000}
+
+	kek, err := keystore.PassphraseKEK(store, "primary", []byte("correct horse"), fastKDF)
+	if err != nil {
+		t.Fatalf("Failed to derive kek: %v", err)
+	}
+	if _, err := keystore.PassphraseKEK(store, "primary", []byte("wrong horse"), fastKDF); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := keystore.PassphraseKEK(store, "primary", []byte("correct horse"), keystore.PBKDF2{Iterations: 2000}); err == nil {
+		t.Error("Expected a different KDF cost to be rejected")
+	}
+
+	keys, err := keystore.NewKeyStore(keystore.KeyStoreOptions{Store: store, KEK: kek})
+	if err != nil {
+		t.Fatalf("Failed to create key store: %v", err)
+	}
+	secret := []byte(`{"p_key":{"p":"123","q":"456"}}`)
+	if err := keys.Seal("creddef-1", keystore.ObjectTypeCredentialDefinitionPrivate, secret); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if err := keys.Seal("revreg-1", keystore.ObjectTypeRevocationRegistryDefinitionPrivate, []byte(`{"value":{"gamma":"1"}}`)); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+
+	stored, _ := store.Get(keystore.CategorySealedObject, keystore.ObjectTypeCredentialDefinitionPrivate+"/creddef-1")
+	if bytes.Contains(stored.Value, []byte("p_key")) {
+		t.Error("Expected the stored record not to contain plaintext")
+	}
+	opened, err := keys.Open("creddef-1", keystore.ObjectTypeCredentialDefinitionPrivate)
+	if err != nil || !bytes.Equal(opened, secret) {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	if _, err := keys.Open("creddef-1", keystore.ObjectTypeRevocationRegistryDefinitionPrivate); err == nil {
+		t.Error("Expected a type mismatch to be rejected")
+	}
+
+	// A record copied under another ID does not decrypt
+	moved := stored.Clone()
+	moved.ID = keystore.ObjectTypeCredentialDefinitionPrivate + "/creddef-2"
+	moved.Value = bytes.Replace(moved.Value, []byte(`"id":"creddef-1"`), []byte(`"id":"creddef-2"`), 1)
+	store.Put(moved)
+	if _, err := keys.Open("creddef-2", keystore.ObjectTypeCredentialDefinitionPrivate); err == nil {
+		t.Error("Expected a moved record to fail authentication")
+	}
+	keys.Delete("creddef-2", keystore.ObjectTypeCredentialDefinitionPrivate)
+
+	// Rotation re-wraps every data key under the new KEK
+	rotated, err := keystore.NewLocalKEK("kms-2", bytes.Repeat([]byte{7}, keystore.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create kek: %v", err)
+	}
+	count, err := keys.Rotate(rotated)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 objects re-wrapped, got %d (%v)", count, err)
+	}
+	audit, err := keys.Audit()
+	if err != nil || len(audit) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %v (%v)", audit, err)
+	}
+	for _, entry := range audit {
+		if entry.KEKID != "kms-2" || entry.RotatedAt == 0 {
+			t.Errorf("Expected %s to be re-wrapped, got %+v", entry.ID, entry)
+		}
+	}
+
+	// Only the new KEK is needed afterwards
+	reopened, err := keystore.NewKeyStore(keystore.KeyStoreOptions{Store: store, KEK: rotated})
+	if err != nil {
+		t.Fatalf("Failed to create key store: %v", err)
+	}
+	if opened, err := reopened.Open("creddef-1", keystore.ObjectTypeCredentialDefinitionPrivate); err != nil || !bytes.Equal(opened, secret) {
+		t.Errorf("Failed to open after rotation: %v", err)
+	}
+	if _, err := reopened.Open("missing", keystore.ObjectTypeCredentialDefinitionPrivate); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestKeyStoreObjectTypes(t *testing.T) {
+	store := storage.NewMemoryStore()
+	kek, err := keystore.NewLocalKEK("local", bytes.Repeat([]byte{4}, keystore.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create kek: %v", err)
+	}
+	keys, err := keystore.NewKeyStore(keystore.KeyStoreOptions{Store: store, KEK: kek})
+	if err != nil {
+		t.Fatalf("Failed to create key store: %v", err)
+	}
+
+	// Objects of different types may share an ID without replacing each other
+	id := "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default"
+	credDefPrivate := []byte(`{"p_key":{"p":"123","q":"456"}}`)
+	if err := keys.Seal(id, keystore.ObjectTypeCredentialDefinitionPrivate, credDefPrivate); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if err := keys.Seal(id, keystore.ObjectTypeRevocationRegistryDefinitionPrivate, []byte(`{"value":{"gamma":"1"}}`)); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if opened, err := keys.Open(id, keystore.ObjectTypeCredentialDefinitionPrivate); err != nil || !bytes.Equal(opened, credDefPrivate) {
+		t.Fatalf("Expected the credential definition private to survive, got %v", err)
+	}
+	if err := keys.Delete(id, keystore.ObjectTypeRevocationRegistryDefinitionPrivate); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := keys.Open(id, keystore.ObjectTypeCredentialDefinitionPrivate); err != nil {
+		t.Errorf("Expected deleting one type to keep the other, got %v", err)
+	}
+	if _, err := keys.Open(id, keystore.ObjectTypeRevocationRegistryDefinitionPrivate); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the deleted object to be gone, got %v", err)
+	}
+	if audit, err := keys.Audit(); err != nil || len(audit) != 1 {
+		t.Errorf("Expected 1 audit entry, got %v (%v)", audit, err)
+	}
+}
+
+func TestPassphraseKDFs(t *testing.T) {
+	store := storage.NewMemoryStore()
+	cheap := keystore.Argon2id{Time: 1, Memory: 1024, Threads: 1}
+	if _, err := keystore.PassphraseKEK(store, "argon", []byte("correct horse"), cheap); err != nil {
+		t.Fatalf("Failed to derive kek: %v", err)
+	}
+	if _, err := keystore.PassphraseKEK(store, "argon", []byte("wrong horse"), cheap); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := keystore.PassphraseKEK(store, "argon", []byte("correct horse"), keystore.PBKDF2{Iterations: 1000}); err == nil {
+		t.Error("Expected a KEK derived with Argon2id to reject PBKDF2")
+	}
+
+	for _, kdf := range []keystore.KDF{cheap, keystore.Argon2id{}, keystore.PBKDF2{Iterations: 1000}} {
+		parsed, err := keystore.ParseKDF(kdf.Name())
+		if err != nil || parsed.Name() != kdf.Name() {
+			t.Errorf("Expected %s to round-trip, got %v %v", kdf.Name(), parsed, err)
+		}
+	}
+	if name := (keystore.Argon2id{}).Name(); name != "argon2id:3:65536:4" {
+		t.Errorf("Unexpected default Argon2id cost %s", name)
+	}
+	for _, name := range []string{"scrypt:1", "argon2id:0:1024:1", "argon2id:1:1024", "pbkdf2-sha256:-5", "argon2id:01:1024:1"} {
+		if _, err := keystore.ParseKDF(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
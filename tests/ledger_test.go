@@ -0,0 +1,353 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/ledger"
+)
+
+const ledgerIssuerDID = "55GkHamhTU1ZbTbV2ab9DE"
+
+func submitToLedger(t *testing.T, l *ledger.MockLedger, req *ledger.Request) []byte {
+	t.Helper()
+	reqJSON, err := req.ToJSON()
+	if err != nil {
+		t.Fatalf("Failed to serialize request: %v", err)
+	}
+	resp, err := l.Submit(reqJSON)
+	if err != nil {
+		t.Fatalf("Failed to submit request: %v", err)
+	}
+	return resp
+}
+
+func TestMockLedgerSequenceNumbers(t *testing.T) {
+	l := ledger.NewMockLedger()
+	l.Now = func() int64 { return 1700000000 }
+
+	for i, version := range []string{"1.0", "1.1", "2.0"} {
+		req := ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+			"type": ledger.TxnTypeSchema,
+			"data": map[string]interface{}{
+				"name":       "employee",
+				"version":    version,
+				"attr_names": []string{"name", "age"},
+			},
+		})
+		result, err := ledger.ParseWriteResponse(submitToLedger(t, l, req))
+		if err != nil {
+			t.Fatalf("Failed to write schema %s: %v", version, err)
+		}
+		if result.SeqNo != int64(i+1) {
+			t.Errorf("Expected seqNo %d, got %d", i+1, result.SeqNo)
+		}
+	}
+
+	// Writing the same schema twice is rejected
+	dup := ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeSchema,
+		"data": map[string]interface{}{"name": "employee", "version": "1.0", "attr_names": []string{"name"}},
+	})
+	if _, err := ledger.ParseWriteResponse(submitToLedger(t, l, dup)); err == nil {
+		t.Error("Expected duplicate schema to be rejected")
+	}
+
+	getReq, err := ledger.BuildGetSchemaRequest(ledgerIssuerDID, ledger.LegacySchemaID(ledgerIssuerDID, "employee", "1.1"))
+	if err != nil {
+		t.Fatalf("Failed to build GET_SCHEMA: %v", err)
+	}
+	schemaResp, err := ledger.ParseGetSchemaResponse(submitToLedger(t, l, getReq))
+	if err != nil {
+		t.Fatalf("Failed to parse GET_SCHEMA reply: %v", err)
+	}
+	if schemaResp.SeqNo != 2 {
+		t.Errorf("Expected seqNo 2, got %d", schemaResp.SeqNo)
+	}
+	if schemaResp.Schema["issuerId"] != ledgerIssuerDID || schemaResp.Schema["version"] != "1.1" {
+		t.Errorf("Unexpected schema JSON: %v", schemaResp.Schema)
+	}
+
+	missing, _ := ledger.BuildGetSchemaRequest(ledgerIssuerDID, ledger.LegacySchemaID(ledgerIssuerDID, "employee", "9.9"))
+	if _, err := ledger.ParseGetSchemaResponse(submitToLedger(t, l, missing)); err == nil {
+		t.Error("Expected missing schema to fail")
+	}
+}
+
+func TestMockLedgerRevocationDelta(t *testing.T) {
+	l := ledger.NewMockLedger()
+	now := int64(1700000000)
+	l.Now = func() int64 { return now }
+
+	submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeSchema,
+		"data": map[string]interface{}{"name": "employee", "version": "1.0", "attr_names": []string{"name"}},
+	}))
+	credDefID := ledger.LegacyCredentialDefinitionID(ledgerIssuerDID, 1, "default")
+	submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeCredDef, "ref": 1, "signature_type": "CL", "tag": "default",
+		"data": map[string]interface{}{"primary": map[string]interface{}{}},
+	}))
+	revRegID := ledger.LegacyRevocationRegistryID(ledgerIssuerDID, credDefID, "0")
+	defResp := submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeRevRegDef, "id": revRegID, "revocDefType": ledger.RevocationDefTypeCLAccum,
+		"tag": "0", "credDefId": credDefID,
+		"value": map[string]interface{}{"issuanceType": ledger.IssuanceByDefault, "maxCredNum": 8, "tailsHash": "hash", "tailsLocation": "/tails"},
+	}))
+	if _, err := ledger.ParseWriteResponse(defResp); err != nil {
+		t.Fatalf("Failed to write revocation registry definition: %v", err)
+	}
+
+	entries := []map[string]interface{}{
+		{"accum": "a1", "issued": []int{}, "revoked": []int{}},
+		{"accum": "a2", "prevAccum": "a1", "issued": []int{}, "revoked": []int{2, 5}},
+		{"accum": "a3", "prevAccum": "a2", "issued": []int{2}, "revoked": []int{7}},
+	}
+	for _, value := range entries {
+		now += 100
+		resp := submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+			"type": ledger.TxnTypeRevRegEntry, "revocRegDefId": revRegID,
+			"revocDefType": ledger.RevocationDefTypeCLAccum, "value": value,
+		}))
+		if _, err := ledger.ParseWriteResponse(resp); err != nil {
+			t.Fatalf("Failed to write revocation entry: %v", err)
+		}
+	}
+
+	// An entry that does not chain from the current accumulator is rejected
+	stale := submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeRevRegEntry, "revocRegDefId": revRegID,
+		"value": map[string]interface{}{"accum": "a4", "prevAccum": "a1"},
+	}))
+	if _, err := ledger.ParseWriteResponse(stale); err == nil {
+		t.Error("Expected stale entry to be rejected")
+	}
+
+	deltaReq, _ := ledger.BuildGetRevocationRegistryDeltaRequest(ledgerIssuerDID, revRegID, nil, now)
+	delta, err := ledger.ParseGetRevocationRegistryDeltaResponse(submitToLedger(t, l, deltaReq))
+	if err != nil {
+		t.Fatalf("Failed to parse delta: %v", err)
+	}
+	if delta.Accumulator != "a3" || len(delta.Revoked) != 2 || delta.Revoked[0] != 5 || delta.Revoked[1] != 7 {
+		t.Errorf("Unexpected delta: %+v", delta)
+	}
+
+	statusList, err := delta.StatusList(8)
+	if err != nil {
+		t.Fatalf("Failed to build status list: %v", err)
+	}
+	list := statusList["revocationList"].([]int)
+	if list[5] != 1 || list[7] != 1 || list[2] != 0 {
+		t.Errorf("Unexpected revocation list: %v", list)
+	}
+
+	// A delta between two points only reports the changes in between
+	from := now - 100
+	rangeReq, _ := ledger.BuildGetRevocationRegistryDeltaRequest(ledgerIssuerDID, revRegID, &from, now)
+	rangeDelta, err := ledger.ParseGetRevocationRegistryDeltaResponse(submitToLedger(t, l, rangeReq))
+	if err != nil {
+		t.Fatalf("Failed to parse ranged delta: %v", err)
+	}
+	if len(rangeDelta.Issued) != 1 || rangeDelta.Issued[0] != 2 || len(rangeDelta.Revoked) != 1 || rangeDelta.Revoked[0] != 7 {
+		t.Errorf("Unexpected ranged delta: %+v", rangeDelta)
+	}
+}
+
+// TestMockLedgerSubmitRequest writes revocation entries built by the request builders without
+// serializing them first, as a caller holding a *ledger.Request does
+func TestMockLedgerSubmitRequest(t *testing.T) {
+	l := ledger.NewMockLedger()
+	now := int64(1700000000)
+	l.Now = func() int64 { return now }
+
+	submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeSchema,
+		"data": map[string]interface{}{"name": "employee", "version": "1.0", "attr_names": []string{"name"}},
+	}))
+	credDefID := ledger.LegacyCredentialDefinitionID(ledgerIssuerDID, 1, "default")
+	submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeCredDef, "ref": 1, "signature_type": "CL", "tag": "default",
+		"data": map[string]interface{}{"primary": map[string]interface{}{}},
+	}))
+	revRegID := ledger.LegacyRevocationRegistryID(ledgerIssuerDID, credDefID, "0")
+	defResp := submitToLedger(t, l, ledger.NewRequest(ledgerIssuerDID, map[string]interface{}{
+		"type": ledger.TxnTypeRevRegDef, "id": revRegID, "revocDefType": ledger.RevocationDefTypeCLAccum,
+		"tag": "0", "credDefId": credDefID,
+		"value": map[string]interface{}{"issuanceType": ledger.IssuanceByDefault, "maxCredNum": 4, "tailsHash": "hash", "tailsLocation": "/tails"},
+	}))
+	if _, err := ledger.ParseWriteResponse(defResp); err != nil {
+		t.Fatalf("Failed to write revocation registry definition: %v", err)
+	}
+
+	statusList := func(accum string, bits []int) *anoncreds.RevocationStatusList {
+		list, err := anoncreds.RevocationStatusListFromJSON(map[string]interface{}{
+			"issuerId": ledgerIssuerDID, "revRegDefId": revRegID, "revocationList": bits,
+			"currentAccumulator": accum, "timestamp": now,
+		})
+		if err != nil {
+			t.Fatalf("Failed to load status list: %v", err)
+		}
+		return list
+	}
+	initial := statusList("a1", []int{0, 0, 0, 0})
+	defer initial.Clear()
+	updated := statusList("a2", []int{0, 1, 0, 1})
+	defer updated.Clear()
+
+	for _, pair := range [][2]*anoncreds.RevocationStatusList{{nil, initial}, {initial, updated}} {
+		now += 100
+		req, err := ledger.BuildRevocationRegistryEntryRequest(ledgerIssuerDID, revRegID, pair[0], pair[1])
+		if err != nil {
+			t.Fatalf("Failed to build entry: %v", err)
+		}
+		resp, err := l.SubmitRequest(req)
+		if err != nil {
+			t.Fatalf("Failed to submit entry: %v", err)
+		}
+		if _, err := ledger.ParseWriteResponse(resp); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+	}
+
+	deltaReq, _ := ledger.BuildGetRevocationRegistryDeltaRequest(ledgerIssuerDID, revRegID, nil, now)
+	resp, err := l.SubmitRequest(deltaReq)
+	if err != nil {
+		t.Fatalf("Failed to submit delta request: %v", err)
+	}
+	delta, err := ledger.ParseGetRevocationRegistryDeltaResponse(resp)
+	if err != nil {
+		t.Fatalf("Failed to parse delta: %v", err)
+	}
+	if delta.Accumulator != "a2" || len(delta.Revoked) != 2 || delta.Revoked[0] != 1 || delta.Revoked[1] != 3 {
+		t.Errorf("Unexpected delta: %+v", delta)
+	}
+}
+
+func TestLedgerIssuanceRoundTrip(t *testing.T) {
+	l := ledger.NewMockLedger()
+
+	schema, err := anoncreds.CreateSchema(anoncreds.CreateSchemaOptions{
+		Name:           "employee",
+		Version:        "1.0",
+		IssuerID:       ledgerIssuerDID,
+		AttributeNames: []string{"name", "age"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	defer schema.Clear()
+
+	schemaReq, err := ledger.BuildSchemaRequest(ledgerIssuerDID, schema)
+	if err != nil {
+		t.Fatalf("Failed to build SCHEMA request: %v", err)
+	}
+	schemaWrite, err := ledger.ParseWriteResponse(submitToLedger(t, l, schemaReq))
+	if err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	schemaID := ledger.LegacySchemaID(ledgerIssuerDID, "employee", "1.0")
+
+	credDefResult, err := anoncreds.CreateCredentialDefinition(anoncreds.CreateCredentialDefinitionOptions{
+		SchemaID:      schemaID,
+		Schema:        schema,
+		IssuerID:      ledgerIssuerDID,
+		Tag:           "default",
+		SignatureType: "CL",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential definition: %v", err)
+	}
+	defer credDefResult.CredentialDefinition.Clear()
+	defer credDefResult.CredentialDefinitionPrivate.Clear()
+	defer credDefResult.KeyCorrectnessProof.Clear()
+
+	credDefReq, err := ledger.BuildCredentialDefinitionRequest(ledgerIssuerDID, schemaWrite.SeqNo, credDefResult.CredentialDefinition)
+	if err != nil {
+		t.Fatalf("Failed to build CRED_DEF request: %v", err)
+	}
+	if _, err := ledger.ParseWriteResponse(submitToLedger(t, l, credDefReq)); err != nil {
+		t.Fatalf("Failed to write credential definition: %v", err)
+	}
+	credDefID := ledger.LegacyCredentialDefinitionID(ledgerIssuerDID, schemaWrite.SeqNo, "default")
+
+	// Resolve the credential definition and its schema the way a verifier would
+	getCredDef, _ := ledger.BuildGetCredentialDefinitionRequest(ledgerIssuerDID, credDefID)
+	credDefResp, err := ledger.ParseGetCredentialDefinitionResponse(submitToLedger(t, l, getCredDef))
+	if err != nil {
+		t.Fatalf("Failed to parse GET_CLAIM_DEF reply: %v", err)
+	}
+	if credDefResp.CredentialDefinitionID != credDefID {
+		t.Errorf("Expected credential definition id %s, got %s", credDefID, credDefResp.CredentialDefinitionID)
+	}
+	getTxn, _ := ledger.BuildGetTransactionRequest(ledgerIssuerDID, credDefResp.SchemaSeqNo)
+	schemaResp, err := ledger.ParseGetTransactionSchemaResponse(submitToLedger(t, l, getTxn))
+	if err != nil {
+		t.Fatalf("Failed to resolve schema by seqNo: %v", err)
+	}
+	if schemaResp.SchemaID != schemaID {
+		t.Errorf("Expected schema id %s, got %s", schemaID, schemaResp.SchemaID)
+	}
+
+	ledgerSchema, err := anoncreds.SchemaFromJSON(schemaResp.Schema)
+	if err != nil {
+		t.Fatalf("Failed to load schema from ledger: %v", err)
+	}
+	defer ledgerSchema.Clear()
+	ledgerCredDef, err := anoncreds.CredentialDefinitionFromJSON(credDefResp.WithSchemaID(schemaResp.SchemaID).CredentialDefinition)
+	if err != nil {
+		t.Fatalf("Failed to load credential definition from ledger: %v", err)
+	}
+	defer ledgerCredDef.Clear()
+
+	// Issue against the ledger copy of the credential definition
+	offer, err := anoncreds.CreateCredentialOffer(anoncreds.CreateCredentialOfferOptions{
+		SchemaID:               schemaID,
+		CredentialDefinitionID: credDefID,
+		KeyCorrectnessProof:    credDefResult.KeyCorrectnessProof,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	defer offer.Clear()
+
+	linkSecret, err := anoncreds.CreateLinkSecret()
+	if err != nil {
+		t.Fatalf("Failed to create link secret: %v", err)
+	}
+	processSecret := anoncreds.LinkSecretFromBytes(linkSecret.Bytes())
+	credReq, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{
+		Entropy:              "entropy",
+		CredentialDefinition: ledgerCredDef,
+		LinkSecret:           linkSecret,
+		LinkSecretID:         "default",
+		CredentialOffer:      offer,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential request: %v", err)
+	}
+	defer credReq.CredentialRequest.Clear()
+	defer credReq.CredentialRequestMetadata.Clear()
+
+	credential, err := anoncreds.CreateCredential(anoncreds.CreateCredentialOptions{
+		CredentialDefinition:        credDefResult.CredentialDefinition,
+		CredentialDefinitionPrivate: credDefResult.CredentialDefinitionPrivate,
+		CredentialOffer:             offer,
+		CredentialRequest:           credReq.CredentialRequest,
+		AttributeRawValues:          map[string]string{"name": "Alice", "age": "28"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential: %v", err)
+	}
+	defer credential.Clear()
+
+	processed, err := anoncreds.ProcessCredential(anoncreds.ProcessCredentialOptions{
+		Credential:                credential,
+		CredentialRequestMetadata: credReq.CredentialRequestMetadata,
+		LinkSecret:                processSecret,
+		CredentialDefinition:      ledgerCredDef,
+	})
+	if err != nil {
+		t.Fatalf("Failed to process credential with ledger credential definition: %v", err)
+	}
+	defer processed.Clear()
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+func TestImportLegacyWallet(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "legacy", "indy_wallet_export.json"))
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	store := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(store)
+	linkSecrets := newTestLinkSecrets(t, store, credentials)
+
+	report, err := wallet.ImportLegacy(data, wallet.LegacyImportOptions{Credentials: credentials, LinkSecrets: linkSecrets})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(report.LinkSecrets) != 1 || report.LinkSecrets[0] != "indy-ms" {
+		t.Errorf("Expected the indy-ms master secret to be imported, got %v", report.LinkSecrets)
+	}
+	if len(report.Credentials) != 2 {
+		t.Fatalf("Expected 2 imported credentials, got %d (failures %+v)", len(report.Credentials), report.Failures)
+	}
+
+	// The existing default secret, the mismatching cred_info and the credential without IDs are reported
+	failures := map[string]string{}
+	for _, failure := range report.Failures {
+		failures[failure.ID] = failure.Reason
+	}
+	if len(failures) != 3 {
+		t.Errorf("Expected 3 failures, got %+v", report.Failures)
+	}
+	if !strings.Contains(failures["default"], "already exists") {
+		t.Errorf("Expected the default master secret to be reported as existing, got %q", failures["default"])
+	}
+	if !strings.Contains(failures["0b8c6f52-7d0e-4c4a-8f6d-5b2f3e9a1c02"], "attribute name") {
+		t.Errorf("Expected the attribute mismatch to be reported, got %q", failures["0b8c6f52-7d0e-4c4a-8f6d-5b2f3e9a1c02"])
+	}
+	if !strings.Contains(failures["c4a1e0d9-2b7f-4e5a-9c3d-8f1e6a2b4d03"], "schema_id") {
+		t.Errorf("Expected the incomplete credential to be reported, got %q", failures["c4a1e0d9-2b7f-4e5a-9c3d-8f1e6a2b4d03"])
+	}
+
+	record, err := credentials.Get("6f1a0ff3-3c3b-4b2e-9a8e-2a9c1d3b7e01")
+	if err != nil {
+		t.Fatalf("Failed to load imported credential: %v", err)
+	}
+	if record.RevocationRegistryIndex == nil || *record.RevocationRegistryIndex != 7 || record.LinkSecretID != "default" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if record.Tags[wallet.TagSchemaName] != "employee" || record.Tags[wallet.AttributeValueTag("age")] != "28" {
+		t.Errorf("Expected the record to be tagged, got %v", record.Tags)
+	}
+	credential, err := record.Credential()
+	if err != nil {
+		t.Fatalf("Failed to load credential: %v", err)
+	}
+	credential.Clear()
+
+	// Credentials backed by the default secret are found through the link secret manager
+	backed, err := linkSecrets.Credentials("default")
+	if err != nil || len(backed) != 2 {
+		t.Errorf("Expected 2 credentials backed by the default secret, got %d (%v)", len(backed), err)
+	}
+
+	// A second import reports the already stored credentials
+	again, err := wallet.ImportLegacy(data, wallet.LegacyImportOptions{Credentials: credentials})
+	if err != nil || len(again.Credentials) != 0 || len(again.Failures) != 4 {
+		t.Errorf("Expected stored credentials to be reported, got %+v (%v)", again, err)
+	}
+}
+
+func TestImportLegacyMalformedRecord(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "legacy", "indy_wallet_export.json"))
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var export map[string]interface{}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	records := export["records"].([]interface{})
+	export["records"] = append([]interface{}{map[string]interface{}{"type": "Indy::Credential", "id": "broken", "value": "not json"}}, records...)
+	if data, err = json.Marshal(export); err != nil {
+		t.Fatalf("Failed to encode export: %v", err)
+	}
+
+	store := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(store)
+	report, err := wallet.ImportLegacy(data, wallet.LegacyImportOptions{Credentials: credentials, LinkSecrets: newTestLinkSecrets(t, store, credentials)})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(report.Credentials) != 2 || len(report.LinkSecrets) != 1 {
+		t.Errorf("Expected the valid records to be imported, got %+v", report)
+	}
+	var broken *wallet.LegacyImportFailure
+	for i := range report.Failures {
+		if report.Failures[i].ID == "broken" {
+			broken = &report.Failures[i]
+		}
+	}
+	if broken == nil || broken.Type != wallet.LegacyRecordTypeCredential || !strings.Contains(broken.Reason, "not JSON") {
+		t.Errorf("Expected the malformed record to be reported, got %+v", report.Failures)
+	}
+}
@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+const testLinkSecretValue = "104932712389234957891234"
+
+func TestLinkSecretRedaction(t *testing.T) {
+	secret := anoncreds.LinkSecretFromValue(testLinkSecretValue)
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%x", "%d", "%q"} {
+		if out := fmt.Sprintf(format, secret); strings.Contains(out, testLinkSecretValue) || strings.Contains(out, "3130") {
+			t.Errorf("%s leaked the secret: %s", format, out)
+		}
+	}
+	options := anoncreds.CreateCredentialRequestOptions{LinkSecret: secret, LinkSecretID: "default"}
+	if out := fmt.Sprintf("%+v", options); strings.Contains(out, testLinkSecretValue) {
+		t.Errorf("Options leaked the secret: %s", out)
+	}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("holder", "link_secret", secret)
+	if strings.Contains(logged.String(), testLinkSecretValue) {
+		t.Errorf("slog leaked the secret: %s", logged.String())
+	}
+	encoded, _ := json.Marshal(map[string]interface{}{"secret": secret})
+	if strings.Contains(string(encoded), testLinkSecretValue) {
+		t.Errorf("JSON leaked the secret: %s", encoded)
+	}
+
+	// A link secret held by value, directly or embedded in a struct, is redacted too
+	type holder struct {
+		ID     string
+		Secret anoncreds.LinkSecret
+	}
+	value := *secret
+	leaked := func(out string) bool {
+		return strings.Contains(out, testLinkSecretValue) || strings.Contains(out, "49 48 52") || strings.Contains(out, "0x31, 0x30")
+	}
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		for _, arg := range []interface{}{value, holder{ID: "main", Secret: value}} {
+			if out := fmt.Sprintf(format, arg); leaked(out) {
+				t.Errorf("%s leaked the secret held by value: %s", format, out)
+			}
+		}
+	}
+	logged.Reset()
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("holder", "link_secret", value, "holder", holder{ID: "main", Secret: value})
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("holder", "link_secret", value, "holder", holder{ID: "main", Secret: value})
+	if leaked(logged.String()) {
+		t.Errorf("slog leaked the secret held by value: %s", logged.String())
+	}
+
+	copied := secret.Bytes()
+	secret.Wipe()
+	if !secret.Wiped() || string(copied) != testLinkSecretValue {
+		t.Error("Expected Wipe to clear the secret but not earlier copies")
+	}
+	if _, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{LinkSecret: secret}); err == nil {
+		t.Error("Expected a wiped link secret to be rejected")
+	}
+}
+
+// testKEK returns a local KEK with a fixed key
+func testKEK(t *testing.T) keystore.KeyEncryptionKey {
+	t.Helper()
+	kek, err := keystore.NewLocalKEK("local", bytes.Repeat([]byte{9}, keystore.KeySize))
+	if err != nil {
+		t.Fatalf("Failed to create kek: %v", err)
+	}
+	return kek
+}
+
+// newTestLinkSecretManager returns an empty link secret manager and the key store it seals into
+func newTestLinkSecretManager(t *testing.T, store storage.Store, kek keystore.KeyEncryptionKey, credentials wallet.CredentialStore) (*wallet.LinkSecretManager, *keystore.KeyStore) {
+	t.Helper()
+	keys, err := keystore.NewKeyStore(keystore.KeyStoreOptions{Store: store, KEK: kek})
+	if err != nil {
+		t.Fatalf("Failed to create key store: %v", err)
+	}
+	manager, err := wallet.NewLinkSecretManager(wallet.LinkSecretManagerOptions{Store: store, KeyStore: keys, Credentials: credentials})
+	if err != nil {
+		t.Fatalf("Failed to create link secret manager: %v", err)
+	}
+	return manager, keys
+}
+
+func TestLinkSecretManager(t *testing.T) {
+	store := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(store)
+	manager, keys := newTestLinkSecretManager(t, store, testKEK(t), credentials)
+
+	if _, err := manager.Get(""); !errors.Is(err, wallet.ErrNoDefaultLinkSecret) {
+		t.Errorf("Expected ErrNoDefaultLinkSecret, got %v", err)
+	}
+	record, err := manager.Import("main", anoncreds.LinkSecretFromValue(testLinkSecretValue))
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if !record.Default {
+		t.Error("Expected the first secret to become the default")
+	}
+	if _, err := manager.Import("main", anoncreds.LinkSecretFromValue("1")); !errors.Is(err, wallet.ErrLinkSecretExists) {
+		t.Errorf("Expected ErrLinkSecretExists, got %v", err)
+	}
+
+	// Nothing in storage holds the plaintext
+	for _, category := range []string{wallet.CategoryLinkSecret, keystore.CategorySealedObject} {
+		records, _ := store.List(category)
+		for _, stored := range records {
+			if bytes.Contains(stored.Value, []byte(testLinkSecretValue)) {
+				t.Errorf("Expected %s/%s not to contain the secret", category, stored.ID)
+			}
+		}
+	}
+
+	var used *anoncreds.LinkSecret
+	err = manager.Use("", func(secret *anoncreds.LinkSecret) error {
+		used = secret
+		if string(secret.Bytes()) != testLinkSecretValue {
+			return fmt.Errorf("unexpected secret")
+		}
+		return nil
+	})
+	if err != nil || !used.Wiped() {
+		t.Errorf("Expected Use to decrypt then wipe the secret, got %v", err)
+	}
+
+	// Credentials are tracked by the link secret they were issued against
+	credential := []byte(`{"schema_id":"55GkHamhTU1ZbTbV2ab9DE:2:employee:1.0","cred_def_id":"55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default","values":{"name":{"raw":"Alice","encoded":"1"}}}`)
+	stored, err := wallet.NewCredentialRecordFromJSON(credential, wallet.CredentialMetadata{Referent: "cred-1", LinkSecretID: "main"})
+	if err != nil {
+		t.Fatalf("Failed to build record: %v", err)
+	}
+	if err := credentials.Put(stored); err != nil {
+		t.Fatalf("Failed to store credential: %v", err)
+	}
+	if _, err := manager.Import("spare", anoncreds.LinkSecretFromValue("42")); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if tracked, err := manager.Credentials("main"); err != nil || len(tracked) != 1 || tracked[0].Referent != "cred-1" {
+		t.Errorf("Expected cred-1 to be tracked against main, got %v (%v)", tracked, err)
+	}
+	if tracked, err := manager.Credentials("spare"); err != nil || len(tracked) != 0 {
+		t.Errorf("Expected no credentials for spare, got %v (%v)", tracked, err)
+	}
+
+	if err := manager.SetDefault("spare"); err != nil {
+		t.Fatalf("Failed to set default: %v", err)
+	}
+	if err := manager.Delete("spare"); !errors.Is(err, wallet.ErrLinkSecretInUse) {
+		t.Errorf("Expected the default secret to be kept, got %v", err)
+	}
+	if err := manager.Delete("main"); !errors.Is(err, wallet.ErrLinkSecretInUse) {
+		t.Errorf("Expected a secret backing credentials to be kept, got %v", err)
+	}
+	if err := manager.SetDefault("main"); err != nil {
+		t.Fatalf("Failed to set default: %v", err)
+	}
+	if err := manager.Delete("spare"); err != nil {
+		t.Errorf("Failed to delete: %v", err)
+	}
+	if _, err := manager.Get("spare"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	if audit, _ := keys.Audit(); len(audit) != 1 {
+		t.Errorf("Expected the sealed secret to be removed, got %v", audit)
+	}
+}
+
+func TestLinkSecretManagerKeepsOtherObjects(t *testing.T) {
+	store := storage.NewMemoryStore()
+	manager, keys := newTestLinkSecretManager(t, store, testKEK(t), nil)
+
+	// An issuer key sealed in the same key store is untouched by a link secret with its ID
+	credDefID := "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default"
+	private := []byte(`{"p_key":{"p":"123","q":"456"}}`)
+	if err := keys.Seal(credDefID, keystore.ObjectTypeCredentialDefinitionPrivate, private); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if _, err := manager.Import("main", anoncreds.LinkSecretFromValue(testLinkSecretValue)); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if _, err := manager.Import(credDefID, anoncreds.LinkSecretFromValue("42")); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if err := manager.Delete(credDefID); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if opened, err := keys.Open(credDefID, keystore.ObjectTypeCredentialDefinitionPrivate); err != nil || !bytes.Equal(opened, private) {
+		t.Errorf("Expected the credential definition private to survive, got %v", err)
+	}
+
+	// A sealed secret left without a record is not replaced
+	if err := keys.SealLinkSecret("orphan", anoncreds.LinkSecretFromValue("7")); err != nil {
+		t.Fatalf("Failed to seal link secret: %v", err)
+	}
+	if _, err := manager.Import("orphan", anoncreds.LinkSecretFromValue("8")); !errors.Is(err, wallet.ErrLinkSecretExists) {
+		t.Errorf("Expected ErrLinkSecretExists for an orphaned sealed secret, got %v", err)
+	}
+}
+
+func TestLinkSecretManagerRotate(t *testing.T) {
+	store := storage.NewMemoryStore()
+	manager, _ := newTestLinkSecretManager(t, store, testKEK(t), nil)
+
+	first, err := manager.Create("first")
+	if err != nil {
+		t.Fatalf("Failed to create link secret: %v", err)
+	}
+	second, err := manager.Rotate("second")
+	if err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if defaultID, _ := manager.DefaultID(); defaultID != second.ID || !second.Default {
+		t.Errorf("Expected %s to be the default, got %s", second.ID, defaultID)
+	}
+	retired, err := manager.Record(first.ID)
+	if err != nil || retired.RetiredAt == 0 || retired.Default {
+		t.Errorf("Expected %s to be retired, got %+v (%v)", first.ID, retired, err)
+	}
+
+	// Retired secrets stay usable for credentials issued against them
+	a, err := manager.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Failed to open retired secret: %v", err)
+	}
+	defer a.Wipe()
+	b, err := manager.Get("")
+	if err != nil {
+		t.Fatalf("Failed to open default secret: %v", err)
+	}
+	defer b.Wipe()
+	if bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("Expected rotation to generate a new secret")
+	}
+	if list, _ := manager.List(); len(list) != 2 {
+		t.Errorf("Expected 2 link secrets, got %d", len(list))
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/verifier"
+)
+
+// sequentialNonces returns a nonce generator that does not depend on the native library
+func sequentialNonces() func() (string, error) {
+	var counter int64
+	return func() (string, error) {
+		return fmt.Sprintf("%d", 1000+atomic.AddInt64(&counter, 1)), nil
+	}
+}
+
+func TestNonceManager(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	dir := t.TempDir()
+	fileManager, err := verifier.NewFileNonceManager(dir, verifier.NonceManagerOptions{TTL: time.Minute, Now: clock, Generate: sequentialNonces()})
+	if err != nil {
+		t.Fatalf("Failed to open nonce manager: %v", err)
+	}
+
+	for name, manager := range map[string]*verifier.NonceManager{
+		"memory": verifier.NewMemoryNonceManager(verifier.NonceManagerOptions{TTL: time.Minute, Now: clock, Generate: sequentialNonces()}),
+		"file":   fileManager,
+	} {
+		now = time.Unix(1700000000, 0)
+
+		issued, err := manager.Issue("thread-1")
+		if err != nil {
+			t.Fatalf("%s: Failed to issue nonce: %v", name, err)
+		}
+		if issued.ExpiresAt != now.Add(time.Minute).Unix() {
+			t.Errorf("%s: Unexpected expiry %d", name, issued.ExpiresAt)
+		}
+		if _, err := manager.Issue("thread-1"); !errors.Is(err, verifier.ErrNonceExists) {
+			t.Errorf("%s: Expected ErrNonceExists, got %v", name, err)
+		}
+		if err := manager.Consume("thread-1", "other"); !errors.Is(err, verifier.ErrNonceMismatch) {
+			t.Errorf("%s: Expected ErrNonceMismatch, got %v", name, err)
+		}
+		if err := manager.Consume("thread-1", issued.Nonce); err != nil {
+			t.Fatalf("%s: Failed to consume nonce: %v", name, err)
+		}
+		if err := manager.Consume("thread-1", issued.Nonce); !errors.Is(err, verifier.ErrNonceConsumed) {
+			t.Errorf("%s: Expected ErrNonceConsumed on reuse, got %v", name, err)
+		}
+		if err := manager.Check("unknown", issued.Nonce); !errors.Is(err, verifier.ErrNonceNotFound) {
+			t.Errorf("%s: Expected ErrNonceNotFound, got %v", name, err)
+		}
+
+		expiring, err := manager.Issue("thread-2")
+		if err != nil {
+			t.Fatalf("%s: Failed to issue nonce: %v", name, err)
+		}
+		now = now.Add(2 * time.Minute)
+		if err := manager.Consume("thread-2", expiring.Nonce); !errors.Is(err, verifier.ErrNonceExpired) {
+			t.Errorf("%s: Expected ErrNonceExpired, got %v", name, err)
+		}
+
+		purged, err := manager.Purge()
+		if err != nil || purged != 2 {
+			t.Errorf("%s: Expected to purge 2 nonces, got %d (%v)", name, purged, err)
+		}
+		if err := manager.Consume("thread-1", issued.Nonce); !errors.Is(err, verifier.ErrNonceNotFound) {
+			t.Errorf("%s: Expected a purged nonce to stay rejected, got %v", name, err)
+		}
+	}
+
+	// Nonces survive reopening the file store
+	reopened, err := verifier.NewFileNonceManager(dir, verifier.NonceManagerOptions{TTL: time.Minute, Now: clock, Generate: sequentialNonces()})
+	if err != nil {
+		t.Fatalf("Failed to reopen nonce manager: %v", err)
+	}
+	persisted, err := fileManager.Issue("thread-3")
+	if err != nil {
+		t.Fatalf("Failed to issue nonce: %v", err)
+	}
+	if record, err := reopened.Get("thread-3"); err != nil || record.Nonce != persisted.Nonce {
+		t.Errorf("Expected the nonce to be persisted, got %+v (%v)", record, err)
+	}
+}
+
+func TestNonceManagerConcurrentConsume(t *testing.T) {
+	manager := verifier.NewMemoryNonceManager(verifier.NonceManagerOptions{Generate: sequentialNonces()})
+	issued, err := manager.Issue("thread")
+	if err != nil {
+		t.Fatalf("Failed to issue nonce: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var accepted int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if manager.Consume("thread", issued.Nonce) == nil {
+				atomic.AddInt64(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Errorf("Expected exactly one consumer to succeed, got %d", accepted)
+	}
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/verifier"
+)
+
+const policyYAML = `
+# Employment checks
+name: employment
+default:
+  issuer_ids: [55GkHamhTU1ZbTbV2ab9DE]
+referents:
+  name:
+    cred_def_ids:
+      - "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default"
+    schema_name: Employee ID
+    schema_version: ">=1.0 <2.0"
+    max_non_revocation_age: 1d
+  # May be self-attested
+  nickname: {}
+required_predicates:
+  - name: age
+    p_type: ">="
+    p_value: 18
+`
+
+func TestParsePolicy(t *testing.T) {
+	fromYAML, err := verifier.ParsePolicy([]byte(policyYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML policy: %v", err)
+	}
+	fromJSON, err := verifier.ParsePolicy([]byte(`{
+		"name": "employment",
+		"default": {"issuer_ids": ["55GkHamhTU1ZbTbV2ab9DE"]},
+		"referents": {"name": {
+			"cred_def_ids": ["55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default"],
+			"schema_name": "Employee ID",
+			"schema_version": ">=1.0 <2.0",
+			"max_non_revocation_age": "24h"
+		}},
+		"required_predicates": [{"name": "age", "p_type": ">=", "p_value": 18}]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON policy: %v", err)
+	}
+
+	for _, policy := range []*verifier.VerifierPolicy{fromYAML, fromJSON} {
+		name := policy.Referents["name"]
+		if policy.Default == nil || len(policy.Default.IssuerIDs) != 1 || policy.Default.IssuerIDs[0] != "55GkHamhTU1ZbTbV2ab9DE" {
+			t.Errorf("Unexpected default rules %+v", policy.Default)
+		}
+		if name.CredentialDefinitionIDs[0] != "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default" || name.SchemaName != "Employee ID" {
+			t.Errorf("Unexpected referent rules %+v", name)
+		}
+		if time.Duration(name.MaxNonRevocationAge) != 24*time.Hour {
+			t.Errorf("Expected a one day freshness limit, got %v", time.Duration(name.MaxNonRevocationAge))
+		}
+		if len(policy.RequiredPredicates) != 1 || policy.RequiredPredicates[0].PValue != 18 {
+			t.Errorf("Unexpected required predicates %+v", policy.RequiredPredicates)
+		}
+	}
+
+	// Unquoted versions keep their text
+	policy, err := verifier.ParsePolicy([]byte("default:\n  schema_version: 1.10\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if policy.Default.SchemaVersion != "1.10" {
+		t.Errorf("Expected version 1.10, got %q", policy.Default.SchemaVersion)
+	}
+
+	for _, invalid := range []string{
+		"referents:\n  name:\n    issuer: x\n",
+		"required_predicates:\n  - name: age\n    p_type: \"==\"\n",
+		"default:\n  schema_version: \">=one\"\n",
+		"name: a\n  nested: b\n",
+	} {
+		if _, err := verifier.ParsePolicy([]byte(invalid)); err == nil {
+			t.Errorf("Expected an error for policy %q", invalid)
+		}
+	}
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	policy, err := verifier.ParsePolicy([]byte(policyYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	presReq, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "proof", "version": "1.0", "nonce": "1",
+		"requested_attributes": {"name": {"name": "name"}, "nickname": {"name": "nickname"}},
+		"requested_predicates": {"adult": {"name": "age", "p_type": ">=", "p_value": 21}}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer presReq.Clear()
+
+	now := time.Unix(1700000000, 0)
+	fresh := now.Add(-time.Hour).Unix()
+	stale := now.Add(-48 * time.Hour).Unix()
+	trusted := &anoncreds.PresentationIdentifier{
+		SchemaID:               "55GkHamhTU1ZbTbV2ab9DE:2:Employee ID:1.2",
+		CredentialDefinitionID: "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+		RevocationRegistryID:   "55GkHamhTU1ZbTbV2ab9DE:4:55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default:CL_ACCUM:1",
+		Timestamp:              &fresh,
+	}
+	report := &anoncreds.VerificationReport{
+		Verified: true,
+		Referents: []anoncreds.ReferentReport{
+			{Referent: "name", Kind: anoncreds.ReferentKindAttribute, Status: anoncreds.ReferentVerified, Identifier: trusted},
+			{Referent: "nickname", Kind: anoncreds.ReferentKindSelfAttested, Status: anoncreds.ReferentVerified},
+			{Referent: "adult", Kind: anoncreds.ReferentKindPredicate, Status: anoncreds.ReferentVerified, Identifier: trusted},
+		},
+	}
+	options := verifier.EvaluateOptions{PresentationRequest: presReq, Now: now}
+
+	result, err := policy.Evaluate(report, options)
+	if err != nil {
+		t.Fatalf("Failed to evaluate policy: %v", err)
+	}
+	if !result.Satisfied {
+		t.Fatalf("Expected the policy to be satisfied, got %+v", result.Violations)
+	}
+
+	// An old status list, an untrusted issuer and the wrong schema version are reported per referent
+	untrusted := &anoncreds.PresentationIdentifier{
+		SchemaID:               "OtherIssuer1111111111:2:Employee ID:2.0",
+		CredentialDefinitionID: "OtherIssuer1111111111:3:CL:16:default",
+	}
+	staleID := *trusted
+	staleID.Timestamp = &stale
+	report.Referents[0].Identifier = &staleID
+	report.Referents[2].Identifier = untrusted
+	result, err = policy.Evaluate(report, options)
+	if err != nil {
+		t.Fatalf("Failed to evaluate policy: %v", err)
+	}
+	if result.Satisfied {
+		t.Fatal("Expected policy violations")
+	}
+	if violations := result.ForReferent("name"); len(violations) != 1 || violations[0].Rule != verifier.RuleNonRevocationAge {
+		t.Errorf("Expected a freshness violation on name, got %+v", violations)
+	}
+	if violations := result.ForReferent("adult"); len(violations) != 1 || violations[0].Rule != verifier.RuleIssuer {
+		t.Errorf("Expected an issuer violation on adult, got %+v", violations)
+	}
+
+	// A predicate weaker than required does not count
+	weak, err := verifier.ParsePolicy([]byte("required_predicates:\n  - {name: age, p_type: \">=\", p_value: 25}\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	result, err = weak.Evaluate(report, options)
+	if err != nil {
+		t.Fatalf("Failed to evaluate policy: %v", err)
+	}
+	if violations := result.ForReferent("age"); len(violations) != 1 || violations[0].Rule != verifier.RuleRequiredPredicate {
+		t.Errorf("Expected a required predicate violation, got %+v", result.Violations)
+	}
+
+	// Violations come out in referent order whatever the map order of the policy
+	missing, err := verifier.ParsePolicy([]byte("referents:\n  zeta: {}\n  alpha: {}\n  mu: {}\n  beta: {}\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		result, err = missing.Evaluate(report, options)
+		if err != nil {
+			t.Fatalf("Failed to evaluate policy: %v", err)
+		}
+		var referents []string
+		for _, violation := range result.Violations {
+			referents = append(referents, violation.Referent)
+		}
+		if strings.Join(referents, ",") != "alpha,beta,mu,zeta" {
+			t.Fatalf("Expected violations in referent order, got %v", referents)
+		}
+	}
+}
+
+func TestEvaluatePolicyDefaultSelfAttested(t *testing.T) {
+	policy, err := verifier.ParsePolicy([]byte("default:\n  issuer_ids: [55GkHamhTU1ZbTbV2ab9DE]\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	report := &anoncreds.VerificationReport{
+		Verified: true,
+		Referents: []anoncreds.ReferentReport{
+			{Referent: "name", Kind: anoncreds.ReferentKindSelfAttested, Status: anoncreds.ReferentVerified},
+		},
+	}
+
+	// Self-attesting an attribute does not get around the default issuer rule
+	result, err := policy.Evaluate(report, verifier.EvaluateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to evaluate policy: %v", err)
+	}
+	if violations := result.ForReferent("name"); result.Satisfied || len(violations) != 1 || violations[0].Rule != verifier.RuleSelfAttested {
+		t.Errorf("Expected a self-attested violation, got %+v", result.Violations)
+	}
+
+	// A default without credential constraints leaves self-attested values alone
+	lenient, err := verifier.ParsePolicy([]byte("default:\n  max_non_revocation_age: 1d\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if result, err := lenient.Evaluate(report, verifier.EvaluateOptions{}); err != nil || !result.Satisfied {
+		t.Errorf("Expected the policy to be satisfied, got %+v (%v)", result, err)
+	}
+}
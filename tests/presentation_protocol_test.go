@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/protocol"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/verifier"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+// employmentRequest asks for the name and proof of age of an employee credential
+func employmentRequest() *anoncreds.PresentationRequestContents {
+	return &anoncreds.PresentationRequestContents{
+		Name:    "employment",
+		Version: "1.0",
+		RequestedAttributes: map[string]anoncreds.RequestedAttribute{
+			"name": {Name: "name", Restrictions: []interface{}{map[string]interface{}{"cred_def_id": presentationCredDefID}}},
+		},
+		RequestedPredicates: map[string]anoncreds.RequestedPredicate{
+			"adult": {Name: "age", PType: anoncreds.PredicateGE, PValue: 18},
+		},
+	}
+}
+
+// counterNonces returns a nonce manager whose nonces count up from 1001
+func counterNonces(store storage.Store, now func() time.Time) *verifier.NonceManager {
+	count := 0
+	return verifier.NewNonceManager(verifier.NonceManagerOptions{
+		Store: store,
+		Now:   now,
+		Generate: func() (string, error) {
+			count++
+			return fmt.Sprintf("%d", 1000+count), nil
+		},
+	})
+}
+
+func TestPresentationProtocol(t *testing.T) {
+	fixture := newHolderFixture(t, map[string]string{"name": "Alice", "age": "28", "department": "Engineering"})
+	defer fixture.Clear()
+
+	// The fixture's credentials were issued against its own secret, held here as the default
+	proverStore := storage.NewMemoryStore()
+	linkSecrets, _ := newTestLinkSecretManager(t, proverStore, testKEK(t), fixture.store)
+	if _, err := linkSecrets.Import("default", fixture.linkSecret); err != nil {
+		t.Fatalf("Failed to import link secret: %v", err)
+	}
+	schemaJSON, _ := fixture.schema.ToJSONString()
+	credDefJSON, _ := fixture.credDef.CredentialDefinition.ToJSONString()
+	prover, err := protocol.NewPresentationProver(protocol.PresentationProverOptions{
+		Store:       proverStore,
+		LinkSecrets: linkSecrets,
+		Credentials: fixture.store,
+		Schema: func(schemaID string) (*anoncreds.Schema, error) {
+			return anoncreds.SchemaFromJSON(schemaJSON)
+		},
+		CredentialDefinition: func(credDefID string) (*anoncreds.CredentialDefinition, error) {
+			return anoncreds.CredentialDefinitionFromJSON(credDefJSON)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create prover: %v", err)
+	}
+	verifierMachine, err := protocol.NewPresentationVerifier(protocol.PresentationVerifierOptions{
+		Store: storage.NewMemoryStore(),
+		Resolve: func(presentation *anoncreds.Presentation) (anoncreds.VerifyPresentationOptions, error) {
+			return anoncreds.VerifyPresentationOptions{
+				Schemas:               map[string]*anoncreds.Schema{presentationSchemaID: fixture.schema},
+				CredentialDefinitions: map[string]*anoncreds.CredentialDefinition{presentationCredDefID: fixture.credDef.CredentialDefinition},
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	requested, err := verifierMachine.RequestPresentation("", employmentRequest())
+	if err != nil {
+		t.Fatalf("Failed to request presentation: %v", err)
+	}
+	received, err := prover.ReceiveRequest(requested.ID, requested.Request)
+	if err != nil {
+		t.Fatalf("Failed to receive request: %v", err)
+	}
+	presented, err := prover.Present(received.ID, nil)
+	if err != nil {
+		t.Fatalf("Failed to present: %v", err)
+	}
+	if len(presented.CredentialReferents) == 0 || presented.CredentialReferents[0] != fixture.record.Referent {
+		t.Errorf("Expected the presented credential to be recorded, got %v", presented.CredentialReferents)
+	}
+	if _, err := verifierMachine.ReceivePresentation(requested.ID, presented.Presentation); err != nil {
+		t.Fatalf("Failed to receive presentation: %v", err)
+	}
+	verified, err := verifierMachine.Verify(requested.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if verified.State != protocol.PresentationStateVerified || verified.Report == nil || !verified.Report.Verified {
+		t.Errorf("Expected the presentation to verify, got %+v", verified)
+	}
+	if done, err := prover.ReceiveAck(presented.ID); err != nil || done.State != protocol.StateDone {
+		t.Errorf("Expected the prover exchange to be done, got %+v (%v)", done, err)
+	}
+}
+
+func TestPresentationNegotiation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	store := storage.NewMemoryStore()
+	verifierMachine, err := protocol.NewPresentationVerifier(protocol.PresentationVerifierOptions{
+		Store:  store,
+		Nonces: counterNonces(store, clock),
+		Resolve: func(presentation *anoncreds.Presentation) (anoncreds.VerifyPresentationOptions, error) {
+			return anoncreds.VerifyPresentationOptions{}, fmt.Errorf("not used")
+		},
+		Timeout: time.Hour,
+		Now:     clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	proverStore := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(proverStore)
+	prover, err := protocol.NewPresentationProver(protocol.PresentationProverOptions{
+		Store:       proverStore,
+		LinkSecrets: newTestLinkSecrets(t, proverStore, credentials),
+		Credentials: credentials,
+		Schema: func(schemaID string) (*anoncreds.Schema, error) {
+			return nil, fmt.Errorf("unknown schema %s", schemaID)
+		},
+		CredentialDefinition: func(credDefID string) (*anoncreds.CredentialDefinition, error) {
+			return nil, fmt.Errorf("unknown credential definition %s", credDefID)
+		},
+		Timeout: time.Hour,
+		Now:     clock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create prover: %v", err)
+	}
+
+	// The prover proposes, the verifier answers with a request
+	proposal := []byte(`{"name":"employment","version":"1.0","requested_attributes":{"name":{"name":"name"}},"requested_predicates":{}}`)
+	proposed, err := prover.Propose(proposal)
+	if err != nil {
+		t.Fatalf("Failed to propose: %v", err)
+	}
+	if _, err := verifierMachine.ReceiveProposal(proposed.ID, proposed.Proposal); err != nil {
+		t.Fatalf("Failed to receive proposal: %v", err)
+	}
+	requested, err := verifierMachine.RequestPresentation(proposed.ID, employmentRequest())
+	if err != nil {
+		t.Fatalf("Failed to request presentation: %v", err)
+	}
+	if requested.State != protocol.PresentationStateRequestSent || requested.Nonce != "1001" || requested.Rounds != 1 {
+		t.Errorf("Unexpected exchange %+v", requested)
+	}
+	if _, err := verifierMachine.ReceivePresentation("unknown", []byte(`{}`)); !errors.Is(err, protocol.ErrExchangeNotFound) {
+		t.Errorf("Expected ErrExchangeNotFound, got %v", err)
+	}
+	received, err := prover.ReceiveRequest(proposed.ID, requested.Request)
+	if err != nil || received.State != protocol.PresentationStateRequestReceived || received.Nonce != "1001" {
+		t.Fatalf("Expected the request to be received, got %+v (%v)", received, err)
+	}
+
+	// The prover counter-proposes and the verifier sends a counter-request with a fresh nonce
+	if _, err := prover.CounterPropose(proposed.ID, proposal); err != nil {
+		t.Fatalf("Failed to counter-propose: %v", err)
+	}
+	if _, err := verifierMachine.ReceiveProposal(proposed.ID, proposal); err != nil {
+		t.Fatalf("Failed to receive counter-proposal: %v", err)
+	}
+	counter := employmentRequest()
+	counter.RequestedPredicates = nil
+	requested, err = verifierMachine.RequestPresentation(proposed.ID, counter)
+	if err != nil {
+		t.Fatalf("Failed to send counter-request: %v", err)
+	}
+	if requested.Nonce != "1002" || requested.Rounds != 2 || requested.NonceRequestID != proposed.ID+"/2" {
+		t.Errorf("Expected a fresh nonce for the counter-request, got %+v", requested)
+	}
+	if _, err := prover.ReceiveRequest(proposed.ID, requested.Request); err != nil {
+		t.Fatalf("Failed to receive counter-request: %v", err)
+	}
+
+	// The prover declines and the verifier abandons on the problem report
+	declined, err := prover.Decline(proposed.ID, "not sharing")
+	if err != nil || declined.State != protocol.PresentationStateDeclined || declined.Problem == nil {
+		t.Fatalf("Expected the request to be declined, got %+v (%v)", declined, err)
+	}
+	if _, err := prover.Present(proposed.ID, &wallet.CredentialSelection{}); !errors.Is(err, protocol.ErrInvalidTransition) {
+		t.Errorf("Expected a declined exchange to reject presenting, got %v", err)
+	}
+	if !declined.Terminal() {
+		t.Error("Expected a declined exchange to be terminal")
+	}
+	if _, err := prover.Abandon(proposed.ID, protocol.ProblemReport{Code: protocol.ProblemPresentationAbandoned}); !errors.Is(err, protocol.ErrInvalidTransition) {
+		t.Errorf("Expected a declined exchange not to be abandoned, got %v", err)
+	}
+	abandoned, err := verifierMachine.ReceiveProblemReport(proposed.ID, *declined.Problem)
+	if err != nil || abandoned.State != protocol.StateAbandoned || abandoned.Problem.Description != "not sharing" {
+		t.Errorf("Expected the verifier to abandon, got %+v (%v)", abandoned, err)
+	}
+
+	// A request nobody answers times out
+	stale, err := verifierMachine.RequestPresentation("", employmentRequest())
+	if err != nil {
+		t.Fatalf("Failed to request presentation: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	expired, err := verifierMachine.ReceivePresentation(stale.ID, []byte(`{}`))
+	if !errors.Is(err, protocol.ErrExchangeExpired) || expired.Problem == nil || expired.Problem.Code != protocol.ProblemTimeout {
+		t.Errorf("Expected the exchange to time out, got %+v (%v)", expired, err)
+	}
+	if records, err := verifierMachine.Exchanges(protocol.StateAbandoned); err != nil || len(records) != 2 {
+		t.Errorf("Expected 2 abandoned exchanges, got %d (%v)", len(records), err)
+	}
+}
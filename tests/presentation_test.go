@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+const (
+	presentationSchemaID  = "did:example:issuer/schema/employee"
+	presentationCredDefID = "did:example:issuer/creddef/employee"
+)
+
+// holderFixture is an issued and processed credential kept in a holder wallet
+type holderFixture struct {
+	schema     *anoncreds.Schema
+	credDef    *anoncreds.CreateCredentialDefinitionResult
+	linkSecret *anoncreds.LinkSecret
+	store      wallet.CredentialStore
+	record     *wallet.CredentialRecord
+}
+
+func (f *holderFixture) Clear() {
+	f.credDef.CredentialDefinition.Clear()
+	f.credDef.CredentialDefinitionPrivate.Clear()
+	f.credDef.KeyCorrectnessProof.Clear()
+	f.schema.Clear()
+}
+
+func newHolderFixture(t *testing.T, values map[string]string) *holderFixture {
+	t.Helper()
+	schema, err := anoncreds.CreateSchema(anoncreds.CreateSchemaOptions{
+		Name:           "employee",
+		Version:        "1.0",
+		IssuerID:       "did:example:issuer",
+		AttributeNames: []string{"name", "age", "department"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	credDef, err := anoncreds.CreateCredentialDefinition(anoncreds.CreateCredentialDefinitionOptions{
+		SchemaID:      presentationSchemaID,
+		Schema:        schema,
+		IssuerID:      "did:example:issuer",
+		Tag:           "employee",
+		SignatureType: "CL",
+	})
+	if err != nil {
+		schema.Clear()
+		t.Fatalf("Failed to create credential definition: %v", err)
+	}
+	fixture := &holderFixture{schema: schema, credDef: credDef, store: wallet.NewMemoryStore()}
+
+	offer, err := anoncreds.CreateCredentialOffer(anoncreds.CreateCredentialOfferOptions{
+		SchemaID:               presentationSchemaID,
+		CredentialDefinitionID: presentationCredDefID,
+		KeyCorrectnessProof:    credDef.KeyCorrectnessProof,
+	})
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	defer offer.Clear()
+
+	fixture.linkSecret, err = anoncreds.CreateLinkSecret()
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to create link secret: %v", err)
+	}
+	request, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{
+		Entropy:              "holder-entropy",
+		CredentialDefinition: credDef.CredentialDefinition,
+		LinkSecret:           anoncreds.LinkSecretFromBytes(fixture.linkSecret.Bytes()),
+		LinkSecretID:         "default",
+		CredentialOffer:      offer,
+	})
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to create credential request: %v", err)
+	}
+	defer request.CredentialRequest.Clear()
+	defer request.CredentialRequestMetadata.Clear()
+
+	credential, err := anoncreds.CreateCredential(anoncreds.CreateCredentialOptions{
+		CredentialDefinition:        credDef.CredentialDefinition,
+		CredentialDefinitionPrivate: credDef.CredentialDefinitionPrivate,
+		CredentialOffer:             offer,
+		CredentialRequest:           request.CredentialRequest,
+		AttributeRawValues:          values,
+	})
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to create credential: %v", err)
+	}
+	defer credential.Clear()
+
+	processed, err := anoncreds.ProcessCredential(anoncreds.ProcessCredentialOptions{
+		Credential:                credential,
+		CredentialRequestMetadata: request.CredentialRequestMetadata,
+		LinkSecret:                anoncreds.LinkSecretFromBytes(fixture.linkSecret.Bytes()),
+		CredentialDefinition:      credDef.CredentialDefinition,
+	})
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to process credential: %v", err)
+	}
+	defer processed.Clear()
+
+	fixture.record, err = wallet.StoreCredential(fixture.store, processed, wallet.CredentialMetadata{
+		SchemaName:    "employee",
+		SchemaVersion: "1.0",
+		IssuerID:      "did:example:issuer",
+		LinkSecretID:  "default",
+	})
+	if err != nil {
+		fixture.Clear()
+		t.Fatalf("Failed to store credential: %v", err)
+	}
+	return fixture
+}
+
+// present selects credentials for the request and creates a presentation from them
+func (f *holderFixture) present(t *testing.T, presReq *anoncreds.PresentationRequest, selfAttested map[string]string) *anoncreds.Presentation {
+	t.Helper()
+	selection, err := wallet.SelectCredentials(presReq, f.store)
+	if err != nil {
+		t.Fatalf("Failed to select credentials: %v", err)
+	}
+	credentials, err := selection.PresentCredentials()
+	if err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	defer func() {
+		for _, credential := range credentials {
+			credential.Credential.Clear()
+		}
+	}()
+
+	presentation, err := anoncreds.CreatePresentation(anoncreds.CreatePresentationOptions{
+		PresentationRequest:    presReq,
+		Credentials:            credentials,
+		CredentialsProve:       selection.Prove,
+		SelfAttestedAttributes: selfAttested,
+		LinkSecret:             anoncreds.LinkSecretFromBytes(f.linkSecret.Bytes()),
+		Schemas:                map[string]*anoncreds.Schema{presentationSchemaID: f.schema},
+		CredentialDefinitions:  map[string]*anoncreds.CredentialDefinition{presentationCredDefID: f.credDef.CredentialDefinition},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create presentation: %v", err)
+	}
+	return presentation
+}
+
+func TestCreatePresentationFromSelection(t *testing.T) {
+	fixture := newHolderFixture(t, map[string]string{"name": "Alice", "age": "28", "department": "Engineering"})
+	defer fixture.Clear()
+
+	presReq, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "employment",
+		"version": "1.0",
+		"nonce": "982734598237459827345",
+		"requested_attributes": {
+			"name": {"name": "name", "restrictions": [{"cred_def_id": "` + presentationCredDefID + `"}]},
+			"nickname": {"name": "nickname"}
+		},
+		"requested_predicates": {
+			"adult": {"name": "age", "p_type": ">=", "p_value": 18}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer presReq.Clear()
+
+	presentation := fixture.present(t, presReq, map[string]string{"nickname": "Al"})
+	defer presentation.Clear()
+
+	presentationJSON, err := presentation.ToJSON()
+	if err != nil {
+		t.Fatalf("Failed to read presentation: %v", err)
+	}
+	requestedProof, _ := presentationJSON["requested_proof"].(map[string]interface{})
+	revealed, _ := requestedProof["revealed_attrs"].(map[string]interface{})
+	if _, ok := revealed["name"]; !ok {
+		t.Errorf("Expected name to be revealed, got %v", requestedProof)
+	}
+}
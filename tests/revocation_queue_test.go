@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+// failingDeleteStore simulates a crash by failing deletes of queued revocations while armed
+type failingDeleteStore struct {
+	storage.Store
+	armed atomic.Bool
+}
+
+func (s *failingDeleteStore) Delete(category, id string) error {
+	if s.armed.Load() && category == issuer.CategoryRevocationQueue {
+		return errors.New("simulated crash")
+	}
+	return s.Store.Delete(category, id)
+}
+
+func revokedIndices(t *testing.T, statusList map[string]interface{}) map[int]bool {
+	t.Helper()
+	list, ok := statusList["revocationList"].([]interface{})
+	if !ok {
+		t.Fatalf("Status list has no revocationList: %v", statusList)
+	}
+	revoked := map[int]bool{}
+	for idx, bit := range list {
+		if bit.(float64) != 0 {
+			revoked[idx] = true
+		}
+	}
+	return revoked
+}
+
+func TestRevocationQueuePublish(t *testing.T) {
+	credDef := createRevocableCredDef(t, "did:example:issuer", "did:example:issuer/schema/employee")
+	defer credDef.Clear()
+
+	store := &failingDeleteStore{Store: storage.NewMemoryStore()}
+	managerOptions := issuer.RevocationRegistryManagerOptions{
+		Store:                  store,
+		CredentialDefinitionID: "did:example:issuer/creddef/revocable",
+		CredentialDefinition:   credDef.result.CredentialDefinition,
+		IssuerID:               "did:example:issuer",
+		MaxCredNum:             10,
+		TailsDirectoryPath:     t.TempDir(),
+		RegistryID: func(tag string) (string, error) {
+			return "did:example:issuer/revreg/" + tag, nil
+		},
+	}
+	manager, err := issuer.NewRevocationRegistryManager(managerOptions)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	indices := map[string]uint32{}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("cred-%d", i)
+		allocation, err := manager.Allocate(id)
+		if err != nil {
+			t.Fatalf("Failed to allocate: %v", err)
+		}
+		indices[id] = allocation.RegistryIndex
+	}
+	revRegDefID := manager.ActiveRegistryID()
+
+	queueOptions := issuer.RevocationQueueOptions{
+		Manager:              manager,
+		CredentialDefinition: credDef.result.CredentialDefinition,
+	}
+	queue, err := issuer.NewRevocationQueue(queueOptions)
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	for _, id := range []string{"cred-1", "cred-2", "cred-4"} {
+		if _, err := queue.Revoke(id); err != nil {
+			t.Fatalf("Failed to queue %s: %v", id, err)
+		}
+	}
+	if err := queue.Cancel("cred-2"); err != nil {
+		t.Fatalf("Failed to cancel: %v", err)
+	}
+	if err := queue.Cancel("cred-2"); err == nil {
+		t.Error("Expected cancelling an unqueued credential to fail")
+	}
+
+	// A dry run previews the list without touching the queue or the registry
+	before, _ := manager.Registry(revRegDefID)
+	timestamp := int64(1700000000)
+	preview, err := queue.Publish(revRegDefID, issuer.PublishOptions{Timestamp: &timestamp, DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to preview publication: %v", err)
+	}
+	preview.StatusList.Clear()
+	if len(preview.Revoked) != 2 {
+		t.Errorf("Expected 2 indices in the preview, got %v", preview.Revoked)
+	}
+	after, _ := manager.Registry(revRegDefID)
+	if string(before.StatusList) != string(after.StatusList) {
+		t.Error("Dry run changed the stored status list")
+	}
+	pending, _ := queue.Pending(revRegDefID)
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 pending revocations after dry run, got %d", len(pending))
+	}
+
+	// Crash halfway through applying the publication
+	store.armed.Store(true)
+	if _, err := queue.Publish(revRegDefID, issuer.PublishOptions{Timestamp: &timestamp}); err == nil {
+		t.Fatal("Expected the simulated crash to surface")
+	}
+	store.armed.Store(false)
+
+	// Reopening completes the journaled publication
+	restarted, err := issuer.NewRevocationRegistryManager(managerOptions)
+	if err != nil {
+		t.Fatalf("Failed to restore manager: %v", err)
+	}
+	queueOptions.Manager = restarted
+	queue, err = issuer.NewRevocationQueue(queueOptions)
+	if err != nil {
+		t.Fatalf("Failed to recover queue: %v", err)
+	}
+	pending, _ = queue.Pending("")
+	if len(pending) != 0 {
+		t.Errorf("Expected an empty queue after recovery, got %d entries", len(pending))
+	}
+
+	registry, _ := restarted.Registry(revRegDefID)
+	statusList, err := anoncreds.RevocationStatusListFromJSON([]byte(registry.StatusList))
+	if err != nil {
+		t.Fatalf("Failed to load status list: %v", err)
+	}
+	defer statusList.Clear()
+	listJSON, _ := statusList.ToJSON()
+	revoked := revokedIndices(t, listJSON)
+	if len(revoked) != 2 || !revoked[int(indices["cred-1"])] || !revoked[int(indices["cred-4"])] {
+		t.Errorf("Expected indices %d and %d revoked, got %v", indices["cred-1"], indices["cred-4"], revoked)
+	}
+	if listJSON["timestamp"].(float64) != float64(timestamp) {
+		t.Errorf("Expected timestamp %d, got %v", timestamp, listJSON["timestamp"])
+	}
+	location, _ := restarted.Locate("cred-4")
+	if !location.Revoked {
+		t.Error("Expected cred-4 to be marked revoked")
+	}
+
+	if _, err := queue.Revoke("cred-4"); err == nil {
+		t.Error("Expected queuing an already revoked credential to fail")
+	}
+	if _, err := queue.Publish(revRegDefID, issuer.PublishOptions{}); err == nil {
+		t.Error("Expected publishing an empty queue to fail")
+	}
+}
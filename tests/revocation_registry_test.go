@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+)
+
+type revocableCredDef struct {
+	schema *anoncreds.Schema
+	result *anoncreds.CreateCredentialDefinitionResult
+}
+
+func (c *revocableCredDef) Clear() {
+	c.result.CredentialDefinition.Clear()
+	c.result.CredentialDefinitionPrivate.Clear()
+	c.result.KeyCorrectnessProof.Clear()
+	c.schema.Clear()
+}
+
+func createRevocableCredDef(t *testing.T, issuerID, schemaID string) *revocableCredDef {
+	t.Helper()
+	schema, err := anoncreds.CreateSchema(anoncreds.CreateSchemaOptions{
+		Name:           "employee",
+		Version:        "1.0",
+		IssuerID:       issuerID,
+		AttributeNames: []string{"name", "age"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	result, err := anoncreds.CreateCredentialDefinition(anoncreds.CreateCredentialDefinitionOptions{
+		SchemaID:          schemaID,
+		Schema:            schema,
+		IssuerID:          issuerID,
+		Tag:               "revocable",
+		SignatureType:     "CL",
+		SupportRevocation: true,
+	})
+	if err != nil {
+		schema.Clear()
+		t.Fatalf("Failed to create credential definition: %v", err)
+	}
+	return &revocableCredDef{schema: schema, result: result}
+}
+
+func TestRevocationRegistryManagerRotation(t *testing.T) {
+	credDef := createRevocableCredDef(t, "did:example:issuer", "did:example:issuer/schema/employee")
+	defer credDef.Clear()
+
+	store := storage.NewMemoryStore()
+	options := issuer.RevocationRegistryManagerOptions{
+		Store:                  store,
+		CredentialDefinitionID: "did:example:issuer/creddef/revocable",
+		CredentialDefinition:   credDef.result.CredentialDefinition,
+		IssuerID:               "did:example:issuer",
+		MaxCredNum:             4,
+		TailsDirectoryPath:     t.TempDir(),
+		FillThreshold:          0.5,
+		RegistryID: func(tag string) (string, error) {
+			return "did:example:issuer/revreg/" + tag, nil
+		},
+	}
+	manager, err := issuer.NewRevocationRegistryManager(options)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	// Hand out indices concurrently across several registries
+	var wg sync.WaitGroup
+	allocations := make([]*issuer.RevocationAllocation, 10)
+	errs := make([]error, 10)
+	for i := range allocations {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			allocations[i], errs[i] = manager.Allocate(fmt.Sprintf("cred-%d", i))
+		}(i)
+	}
+	wg.Wait()
+	last, err := manager.Allocate("cred-10")
+	if err != nil {
+		t.Fatalf("Failed to allocate sequentially: %v", err)
+	}
+	allocations = append(allocations, last)
+	errs = append(errs, nil)
+
+	seen := map[string]bool{}
+	for i, allocation := range allocations {
+		if errs[i] != nil {
+			t.Fatalf("Allocation %d failed: %v", i, errs[i])
+		}
+		key := fmt.Sprintf("%s#%d", allocation.RevocationRegistryDefinitionID, allocation.RegistryIndex)
+		if seen[key] {
+			t.Errorf("Index %s handed out twice", key)
+		}
+		seen[key] = true
+		if allocation.RegistryIndex < 1 || allocation.RegistryIndex > 4 {
+			t.Errorf("Index %d outside registry capacity", allocation.RegistryIndex)
+		}
+	}
+
+	registries := manager.Registries()
+	if len(registries) < 3 {
+		t.Fatalf("Expected at least 3 registries for 10 credentials, got %d", len(registries))
+	}
+	if registries[0].State != issuer.RegistryStateFull {
+		t.Errorf("Expected first registry to be full, got %s", registries[0].State)
+	}
+	// The third registry is past the threshold, so the fourth must already be pre-created
+	if manager.PendingRegistryID() == "" {
+		t.Error("Expected the next registry to be pre-created")
+	}
+
+	location, err := manager.MarkRevoked("cred-3")
+	if err != nil {
+		t.Fatalf("Failed to mark credential revoked: %v", err)
+	}
+	registry, _ := manager.Registry(location.RevocationRegistryDefinitionID)
+	if len(registry.Revoked) != 1 || registry.Revoked[0] != location.RegistryIndex {
+		t.Errorf("Expected index %d to be revoked, got %v", location.RegistryIndex, registry.Revoked)
+	}
+
+	// A restarted manager resumes from the persisted state
+	restarted, err := issuer.NewRevocationRegistryManager(options)
+	if err != nil {
+		t.Fatalf("Failed to restore manager: %v", err)
+	}
+	if restarted.ActiveRegistryID() != manager.ActiveRegistryID() {
+		t.Errorf("Expected active registry %s after restart, got %s", manager.ActiveRegistryID(), restarted.ActiveRegistryID())
+	}
+	next, err := restarted.Allocate("cred-after-restart")
+	if err != nil {
+		t.Fatalf("Failed to allocate after restart: %v", err)
+	}
+	if seen[fmt.Sprintf("%s#%d", next.RevocationRegistryDefinitionID, next.RegistryIndex)] {
+		t.Error("Restarted manager reused an index")
+	}
+	if _, err := restarted.Allocate("cred-after-restart"); err == nil {
+		t.Error("Expected a second allocation for the same credential to fail")
+	}
+}
+
+func TestIssueRevocableCredentialWithManager(t *testing.T) {
+	credDef := createRevocableCredDef(t, "did:example:issuer", "did:example:issuer/schema/employee")
+	defer credDef.Clear()
+
+	manager, err := issuer.NewRevocationRegistryManager(issuer.RevocationRegistryManagerOptions{
+		Store:                  storage.NewMemoryStore(),
+		CredentialDefinitionID: "did:example:issuer/creddef/revocable",
+		CredentialDefinition:   credDef.result.CredentialDefinition,
+		IssuerID:               "did:example:issuer",
+		MaxCredNum:             10,
+		TailsDirectoryPath:     t.TempDir(),
+		RegistryID: func(tag string) (string, error) {
+			return "did:example:issuer/revreg/" + tag, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	offer, err := anoncreds.CreateCredentialOffer(anoncreds.CreateCredentialOfferOptions{
+		SchemaID:               "did:example:issuer/schema/employee",
+		CredentialDefinitionID: "did:example:issuer/creddef/revocable",
+		KeyCorrectnessProof:    credDef.result.KeyCorrectnessProof,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	defer offer.Clear()
+
+	linkSecret, _ := anoncreds.CreateLinkSecret()
+	credReq, err := anoncreds.CreateCredentialRequest(anoncreds.CreateCredentialRequestOptions{
+		Entropy:              "entropy",
+		CredentialDefinition: credDef.result.CredentialDefinition,
+		LinkSecret:           linkSecret,
+		LinkSecretID:         "default",
+		CredentialOffer:      offer,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create credential request: %v", err)
+	}
+	defer credReq.CredentialRequest.Clear()
+	defer credReq.CredentialRequestMetadata.Clear()
+
+	allocation, err := manager.Allocate("alice")
+	if err != nil {
+		t.Fatalf("Failed to allocate index: %v", err)
+	}
+	defer allocation.Clear()
+	revocationConfig, err := allocation.RevocationConfig()
+	if err != nil {
+		t.Fatalf("Failed to load revocation config: %v", err)
+	}
+
+	credential, err := anoncreds.CreateCredential(anoncreds.CreateCredentialOptions{
+		CredentialDefinition:        credDef.result.CredentialDefinition,
+		CredentialDefinitionPrivate: credDef.result.CredentialDefinitionPrivate,
+		CredentialOffer:             offer,
+		CredentialRequest:           credReq.CredentialRequest,
+		AttributeRawValues:          map[string]string{"name": "Alice", "age": "28"},
+		RevocationConfig:            revocationConfig,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create revocable credential: %v", err)
+	}
+	defer credential.Clear()
+
+	index, err := credential.GetRevocationRegistryIndex()
+	if err != nil {
+		t.Fatalf("Failed to read revocation index: %v", err)
+	}
+	if index == nil || *index != allocation.RegistryIndex {
+		t.Errorf("Expected credential to carry index %d, got %v", allocation.RegistryIndex, index)
+	}
+}
+
+func TestRevocationRegistryManagerRelease(t *testing.T) {
+	manager, err := issuer.NewRevocationRegistryManager(issuer.RevocationRegistryManagerOptions{
+		Store:                  storage.NewMemoryStore(),
+		CredentialDefinitionID: "did:example:issuer/creddef/revocable",
+		CreateRegistry: func(tag string) (*issuer.NewRevocationRegistry, error) {
+			id := "did:example:issuer/revreg/" + tag
+			regDef, err := anoncreds.RevocationRegistryDefinitionFromJSON(`{"revocDefType":"CL_ACCUM","tag":"` + tag + `","value":{"maxCredNum":3}}`)
+			if err != nil {
+				return nil, err
+			}
+			regDefPrivate, err := anoncreds.RevocationRegistryDefinitionPrivateFromJSON(`{"value":{"gamma":"1"}}`)
+			if err != nil {
+				return nil, err
+			}
+			statusList, err := anoncreds.RevocationStatusListFromJSON(`{"revRegDefId":"` + id + `","revocationList":[0,0,0]}`)
+			if err != nil {
+				return nil, err
+			}
+			return &issuer.NewRevocationRegistry{ID: id, RevocationRegistryDefinition: regDef, RevocationRegistryDefinitionPrivate: regDefPrivate, StatusList: statusList}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	allocations := make([]*issuer.RevocationAllocation, 3)
+	for i := range allocations {
+		if allocations[i], err = manager.Allocate(fmt.Sprintf("cred-%d", i)); err != nil {
+			t.Fatalf("Failed to allocate: %v", err)
+		}
+	}
+	active := manager.ActiveRegistryID()
+
+	// A released index in the middle is reused before the registry rotates
+	if err := manager.Release(allocations[1]); err != nil {
+		t.Fatalf("Failed to release: %v", err)
+	}
+	if _, err := manager.Locate("cred-1"); err == nil {
+		t.Error("Expected the released credential to have no location")
+	}
+	if err := manager.Release(allocations[1]); err == nil {
+		t.Error("Expected a second release to fail")
+	}
+	retried, err := manager.Allocate("cred-1")
+	if err != nil {
+		t.Fatalf("Failed to allocate a released credential again: %v", err)
+	}
+	if retried.RevocationRegistryDefinitionID != active || retried.RegistryIndex != allocations[1].RegistryIndex {
+		t.Errorf("Expected index %d of %s to be reused, got %d of %s", allocations[1].RegistryIndex, active, retried.RegistryIndex, retried.RevocationRegistryDefinitionID)
+	}
+
+	// Releasing the last index rewinds the registry
+	if err := manager.Release(allocations[2]); err != nil {
+		t.Fatalf("Failed to release: %v", err)
+	}
+	registry, _ := manager.Registry(active)
+	if registry.NextIndex != 3 || registry.State != issuer.RegistryStateActive || len(registry.Issued) != 2 || len(registry.Released) != 0 {
+		t.Errorf("Unexpected registry after release %+v", registry)
+	}
+
+	// A revoked credential keeps its index
+	if _, err := manager.MarkRevoked("cred-0"); err != nil {
+		t.Fatalf("Failed to mark credential revoked: %v", err)
+	}
+	if err := manager.Release(allocations[0]); err == nil {
+		t.Error("Expected a revoked credential not to be released")
+	}
+}
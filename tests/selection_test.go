@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+func putTestCredential(t *testing.T, store wallet.CredentialStore, referent, credDefID string, createdAt int64, values map[string]string) {
+	t.Helper()
+	encoded := map[string]interface{}{}
+	for name, raw := range values {
+		encoded[name] = map[string]string{"raw": raw, "encoded": raw}
+	}
+	credentialJSON, _ := json.Marshal(map[string]interface{}{
+		"schema_id":   "55GkHamhTU1ZbTbV2ab9DE:2:Employee ID:1.0",
+		"cred_def_id": credDefID,
+		"values":      encoded,
+	})
+	record, err := wallet.NewCredentialRecordFromJSON(credentialJSON, wallet.CredentialMetadata{Referent: referent})
+	if err != nil {
+		t.Fatalf("Failed to build record: %v", err)
+	}
+	record.CreatedAt = createdAt
+	if err := store.Put(record); err != nil {
+		t.Fatalf("Failed to store record: %v", err)
+	}
+}
+
+func TestSelectCredentials(t *testing.T) {
+	store := wallet.NewMemoryStore()
+	putTestCredential(t, store, "old", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 100, map[string]string{"name": "Alice", "age": "28"})
+	putTestCredential(t, store, "new", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 200, map[string]string{"name": "Alice", "age": "29"})
+	putTestCredential(t, store, "revoked", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 300, map[string]string{"name": "Alice", "age": "30"})
+	putTestCredential(t, store, "other", "OtherIssuer1111111111:3:CL:16:default", 400, map[string]string{"name": "Alice", "age": "17"})
+
+	presReq, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "proof",
+		"version": "1.0",
+		"nonce": "1234567890",
+		"requested_attributes": {
+			"identity": {"names": ["name", "age"], "restrictions": [{"issuer_did": "55GkHamhTU1ZbTbV2ab9DE"}]},
+			"nickname": {"name": "nickname"}
+		},
+		"requested_predicates": {
+			"adult": {"name": "age", "p_type": ">=", "p_value": 18},
+			"minor": {"name": "age", "p_type": "<", "p_value": 18}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer presReq.Clear()
+
+	selection, err := wallet.SelectCredentials(presReq, store, wallet.SelectOptions{
+		IsRevoked: func(record *wallet.CredentialRecord) (bool, error) {
+			return record.Referent == "revoked", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to select credentials: %v", err)
+	}
+
+	identity := selection.Candidates.Attributes["identity"]
+	if len(identity) != 3 || identity[0].Record.Referent != "new" || identity[2].Record.Referent != "revoked" {
+		t.Errorf("Expected newest non-revoked credential first and revoked last, got %v", candidateReferents(identity))
+	}
+	if adult := selection.Candidates.Predicates["adult"]; len(adult) != 3 {
+		t.Errorf("Expected 3 adult candidates, got %v", candidateReferents(adult))
+	}
+	if minor := selection.Candidates.Predicates["minor"]; len(minor) != 1 || minor[0].Record.Referent != "other" {
+		t.Errorf("Expected only the minor credential, got %v", candidateReferents(minor))
+	}
+	if len(selection.SelfAttestable) != 1 || selection.SelfAttestable[0] != "nickname" {
+		t.Errorf("Expected nickname to be self-attestable, got %v", selection.SelfAttestable)
+	}
+
+	if len(selection.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(selection.Entries))
+	}
+	entries := map[string]int{}
+	for _, prove := range selection.Prove {
+		entries[prove.Referent] = prove.EntryIndex
+	}
+	if entries["identity"] != entries["adult"] {
+		t.Error("Expected identity and adult to share one credential entry")
+	}
+	if selection.Entries[entries["minor"]].Record.Referent != "other" {
+		t.Error("Expected minor to use the other credential")
+	}
+	if ids := selection.CredentialDefinitionIDs(); len(ids) != 2 {
+		t.Errorf("Expected 2 credential definitions, got %v", ids)
+	}
+
+	// A restriction nothing satisfies makes selection fail
+	strict, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "proof", "version": "1.0", "nonce": "1",
+		"requested_attributes": {"name": {"name": "name", "restrictions": [{"cred_def_id": "unknown"}]}},
+		"requested_predicates": {}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer strict.Clear()
+	if _, err := wallet.SelectCredentials(strict, store); err == nil {
+		t.Error("Expected selection to fail when no credential matches")
+	}
+}
+
+func candidateReferents(candidates []*wallet.Candidate) []string {
+	referents := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		referents[i] = candidate.Record.Referent
+	}
+	return referents
+}
+
+func TestPreviewPresentation(t *testing.T) {
+	store := wallet.NewMemoryStore()
+	putTestCredential(t, store, "employee", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 100, map[string]string{"name": "Alice", "age": "28"})
+
+	presReq, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "proof",
+		"version": "1.0",
+		"nonce": "1234567890",
+		"non_revoked": {"to": 1700000000},
+		"requested_attributes": {
+			"name": {"name": "name"},
+			"age": {"name": "age"},
+			"nickname": {"name": "nickname"}
+		},
+		"requested_predicates": {
+			"adult": {"name": "age", "p_type": ">=", "p_value": 18}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer presReq.Clear()
+
+	selection, err := wallet.SelectCredentials(presReq, store)
+	if err != nil {
+		t.Fatalf("Failed to select credentials: %v", err)
+	}
+	selection.SelfAttestedAttributes["nickname"] = "Al"
+	timestamp := int64(1699999000)
+	selection.Entries[0].Timestamp = &timestamp
+
+	preview, err := wallet.PreviewPresentation(presReq, selection)
+	if err != nil {
+		t.Fatalf("Failed to preview presentation: %v", err)
+	}
+
+	attributes := map[string]wallet.AttributePreview{}
+	for _, attribute := range preview.Attributes {
+		attributes[attribute.Referent] = attribute
+	}
+	if attributes["name"].Values["name"] != "Alice" || attributes["name"].Unnecessary {
+		t.Errorf("Expected name to be revealed as needed, got %+v", attributes["name"])
+	}
+	if attributes["age"].Values["age"] != "28" || !attributes["age"].Unnecessary {
+		t.Errorf("Expected age to be flagged as revealed unnecessarily, got %+v", attributes["age"])
+	}
+	if len(preview.Predicates) != 1 || preview.Predicates[0].PType != ">=" || preview.Predicates[0].PValue != 18 {
+		t.Errorf("Unexpected predicates %+v", preview.Predicates)
+	}
+	if len(preview.SelfAttested) != 1 || preview.SelfAttested[0].Value != "Al" {
+		t.Errorf("Unexpected self-attested values %+v", preview.SelfAttested)
+	}
+	if len(preview.Issuers) != 1 || preview.Issuers[0] != "55GkHamhTU1ZbTbV2ab9DE" {
+		t.Errorf("Unexpected issuers %v", preview.Issuers)
+	}
+	if len(preview.NonRevocation) != 1 || *preview.NonRevocation[0].Timestamp != timestamp || *preview.NonRevocation[0].Interval.To != 1700000000 {
+		t.Errorf("Unexpected non-revocation details %+v", preview.NonRevocation)
+	}
+}
@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/issuer"
+	"github.com/Ajna-inc/anoncreds-go/pkg/keystore"
+	"github.com/Ajna-inc/anoncreds-go/pkg/server"
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+const testAPIKey = "test-api-key"
+
+// newTestServer returns an admin API server over an in-memory store, its credential store and key store
+func newTestServer(t *testing.T) (*httptest.Server, wallet.CredentialStore, *keystore.KeyStore) {
+	t.Helper()
+	store := storage.NewMemoryStore()
+	credentials := wallet.NewRecordStore(store)
+	linkSecrets, keys := newTestLinkSecretManager(t, store, testKEK(t), credentials)
+	service, err := issuer.NewService(issuer.ServiceOptions{Store: store, KeyStore: keys})
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	t.Cleanup(service.Close)
+	handler, err := server.New(server.Options{
+		Issuer:      service,
+		LinkSecrets: linkSecrets,
+		Credentials: credentials,
+		Store:       store,
+		APIKeys:     []string{testAPIKey},
+		Now:         func() time.Time { return time.Unix(1700000000, 0) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	httpServer := httptest.NewServer(handler)
+	t.Cleanup(httpServer.Close)
+	return httpServer, credentials, keys
+}
+
+// call sends a request with the test API key and decodes the JSON response into out
+func call(t *testing.T, httpServer *httptest.Server, method, path, body string, out interface{}) int {
+	t.Helper()
+	request, err := http.NewRequest(method, httpServer.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	request.Header.Set(server.APIKeyHeader, testAPIKey)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer response.Body.Close()
+	if out != nil && response.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode %s %s: %v", method, path, err)
+		}
+	}
+	return response.StatusCode
+}
+
+func TestServerAuthentication(t *testing.T) {
+	httpServer, _, _ := newTestServer(t)
+
+	response, err := http.Get(httpServer.URL + "/wallet/link-secrets")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", response.StatusCode)
+	}
+	request, _ := http.NewRequest(http.MethodGet, httpServer.URL+"/wallet/link-secrets", nil)
+	request.Header.Set(server.APIKeyHeader, "wrong")
+	if response, err = http.DefaultClient.Do(request); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a wrong key, got %d", response.StatusCode)
+	}
+
+	// Status and the OpenAPI document are public
+	if response, err = http.Get(httpServer.URL + "/status"); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	var status struct {
+		Status string `json:"status"`
+		Time   int64  `json:"time"`
+	}
+	json.NewDecoder(response.Body).Decode(&status)
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || status.Status != "ok" || status.Time != 1700000000 {
+		t.Errorf("Unexpected status %d %+v", response.StatusCode, status)
+	}
+	if response, err = http.Get(httpServer.URL + "/openapi.json"); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	var document struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	json.NewDecoder(response.Body).Decode(&document)
+	response.Body.Close()
+	if document.OpenAPI == "" || document.Paths["/offers/{id}/issue"] == nil {
+		t.Errorf("Unexpected OpenAPI document %+v", document)
+	}
+
+	if _, err := server.New(server.Options{}); err == nil {
+		t.Error("Expected options without an issuer to be rejected")
+	}
+}
+
+func TestServerWallet(t *testing.T) {
+	httpServer, credentials, keys := newTestServer(t)
+
+	var secret struct {
+		ID      string `json:"id"`
+		Default bool   `json:"default"`
+	}
+	if code := call(t, httpServer, http.MethodPost, "/wallet/link-secrets", `{"id":"main"}`, &secret); code != http.StatusCreated || secret.ID != "main" || !secret.Default {
+		t.Errorf("Unexpected link secret %d %+v", code, secret)
+	}
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if code := call(t, httpServer, http.MethodPost, "/wallet/link-secrets", `{"id":"main"}`, &failure); code != http.StatusConflict || failure.Error == "" {
+		t.Errorf("Expected a conflict for a duplicate link secret, got %d %+v", code, failure)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/wallet/link-secrets", `{"name":"main"}`, &failure); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown field to be rejected, got %d", code)
+	}
+	for _, id := range []string{"55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", "a/b", "-main", strings.Repeat("a", 65)} {
+		body, _ := json.Marshal(map[string]string{"id": id})
+		if code := call(t, httpServer, http.MethodPost, "/wallet/link-secrets", string(body), &failure); code != http.StatusBadRequest {
+			t.Errorf("Expected link secret ID %q to be rejected, got %d", id, code)
+		}
+	}
+
+	// A link secret sharing its ID with an issuer key does not replace it
+	private := []byte(`{"p_key":{"p":"123","q":"456"}}`)
+	if err := keys.Seal("shared", keystore.ObjectTypeCredentialDefinitionPrivate, private); err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/wallet/link-secrets", `{"id":"shared"}`, &secret); code != http.StatusCreated {
+		t.Errorf("Expected the link secret to be created, got %d", code)
+	}
+	if opened, err := keys.Open("shared", keystore.ObjectTypeCredentialDefinitionPrivate); err != nil || !bytes.Equal(opened, private) {
+		t.Errorf("Expected the issuer key to survive, got %v", err)
+	}
+	var secrets []map[string]interface{}
+	if code := call(t, httpServer, http.MethodGet, "/wallet/link-secrets", "", &secrets); code != http.StatusOK || len(secrets) != 2 {
+		t.Errorf("Unexpected link secrets %d %v", code, secrets)
+	}
+	for _, listed := range secrets {
+		for _, field := range []string{"value", "secret"} {
+			if listed[field] != nil {
+				t.Errorf("Link secret value leaked in %s", field)
+			}
+		}
+	}
+
+	putTestCredential(t, credentials, "cred-1", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 1, map[string]string{"name": "Alice"})
+	putTestCredential(t, credentials, "cred-2", "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default", 2, map[string]string{"name": "Bob"})
+	var listed []struct {
+		Referent   string            `json:"referent"`
+		Attributes map[string]string `json:"attributes"`
+		Credential json.RawMessage   `json:"credential"`
+	}
+	if code := call(t, httpServer, http.MethodGet, "/wallet/credentials", "", &listed); code != http.StatusOK || len(listed) != 2 || listed[0].Credential != nil {
+		t.Errorf("Unexpected credentials %d %+v", code, listed)
+	}
+	query := url.QueryEscape(`{"attr::name::value":"Bob"}`)
+	if code := call(t, httpServer, http.MethodGet, "/wallet/credentials?wql="+query, "", &listed); code != http.StatusOK || len(listed) != 1 || listed[0].Referent != "cred-2" {
+		t.Errorf("Unexpected search result %d %+v", code, listed)
+	}
+	if code := call(t, httpServer, http.MethodGet, "/wallet/credentials?wql=%7B", "", &failure); code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid query to be rejected, got %d", code)
+	}
+	var single struct {
+		Referent   string          `json:"referent"`
+		Credential json.RawMessage `json:"credential"`
+	}
+	if code := call(t, httpServer, http.MethodGet, "/wallet/credentials/cred-1", "", &single); code != http.StatusOK || single.Referent != "cred-1" || len(single.Credential) == 0 {
+		t.Errorf("Unexpected credential %d %+v", code, single)
+	}
+	if code := call(t, httpServer, http.MethodDelete, "/wallet/credentials/cred-1", "", nil); code != http.StatusNoContent {
+		t.Errorf("Expected the credential to be deleted, got %d", code)
+	}
+	if code := call(t, httpServer, http.MethodDelete, "/wallet/credentials/cred-1", "", &failure); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted credential, got %d", code)
+	}
+}
+
+func TestServerIssuerLookups(t *testing.T) {
+	httpServer, _, _ := newTestServer(t)
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	schemaID := url.PathEscape("did:indy:sovrin:55GkHamhTU1ZbTbV2ab9DE/anoncreds/v0/SCHEMA/employee/1.0")
+	if code := call(t, httpServer, http.MethodGet, "/schemas/"+schemaID, "", &failure); code != http.StatusNotFound || failure.Error == "" {
+		t.Errorf("Expected 404 for an unknown schema, got %d %+v", code, failure)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/schemas", `{"name":"employee"}`, &failure); code != http.StatusBadRequest {
+		t.Errorf("Expected missing fields to be rejected, got %d", code)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/credential-definitions", `{"id":"a","schema_id":"b","issuer_id":"c","tag":"d","support_revocation":true}`, &failure); code != http.StatusBadRequest {
+		t.Errorf("Expected max_cred_num to be required, got %d", code)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/offers", `{"cred_def_id":"unknown"}`, &failure); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown credential definition, got %d", code)
+	}
+	var credDefs []interface{}
+	if code := call(t, httpServer, http.MethodGet, "/credential-definitions", "", &credDefs); code != http.StatusOK || len(credDefs) != 0 {
+		t.Errorf("Unexpected credential definitions %d %v", code, credDefs)
+	}
+	if code := call(t, httpServer, http.MethodPost, "/presentations/verify", `{"presentation":{}}`, &failure); code != http.StatusBadRequest {
+		t.Errorf("Expected a missing request to be rejected, got %d", code)
+	}
+	var nonce struct {
+		Nonce string `json:"nonce"`
+	}
+	if code := call(t, httpServer, http.MethodGet, "/nonce", "", &nonce); code != http.StatusOK || nonce.Nonce == "" {
+		t.Errorf("Unexpected nonce %d %+v", code, nonce)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+func statusListFromJSON(t *testing.T, revRegDefID string, bits []int, timestamp int64) *anoncreds.RevocationStatusList {
+	t.Helper()
+	statusList, err := anoncreds.RevocationStatusListFromJSON(map[string]interface{}{
+		"issuerId":       "did:example:issuer",
+		"revRegDefId":    revRegDefID,
+		"revocationList": bits,
+		"timestamp":      timestamp,
+	})
+	if err != nil {
+		t.Fatalf("Failed to load status list: %v", err)
+	}
+	return statusList
+}
+
+func TestRevocationStatusListBitmap(t *testing.T) {
+	statusList := statusListFromJSON(t, "did:example:issuer/revreg/0", []int{0, 1, 0, 0, 1, 0, 0, 0, 1, 0}, 100)
+	defer statusList.Clear()
+
+	bitmap, err := statusList.Bitmap()
+	if err != nil {
+		t.Fatalf("Failed to decode bitmap: %v", err)
+	}
+	if bitmap.Size() != 10 || bitmap.RevokedCount() != 3 || bitmap.IssuedCount() != 7 {
+		t.Errorf("Unexpected counts: size %d, revoked %d, issued %d", bitmap.Size(), bitmap.RevokedCount(), bitmap.IssuedCount())
+	}
+	if revoked, _ := bitmap.IsRevoked(4); !revoked {
+		t.Error("Expected index 4 to be revoked")
+	}
+	if revoked, _ := bitmap.IsRevoked(5); revoked {
+		t.Error("Expected index 5 to be issued")
+	}
+	if _, err := bitmap.IsRevoked(10); err == nil {
+		t.Error("Expected an out-of-range index to fail")
+	}
+	if !reflect.DeepEqual(bitmap.RevokedIndices(), []uint32{1, 4, 8}) {
+		t.Errorf("Unexpected revoked indices %v", bitmap.RevokedIndices())
+	}
+	if !bytes.Equal(bitmap.Bytes(), []byte{0x48, 0x80}) {
+		t.Errorf("Unexpected packed bitmap %x", bitmap.Bytes())
+	}
+	if bitmap.String() != "0100100010" {
+		t.Errorf("Unexpected bitmap string %s", bitmap.String())
+	}
+	if bitmap.Timestamp == nil || *bitmap.Timestamp != 100 {
+		t.Errorf("Expected timestamp 100, got %v", bitmap.Timestamp)
+	}
+}
+
+func TestDiffStatusLists(t *testing.T) {
+	old := statusListFromJSON(t, "did:example:issuer/revreg/0", []int{0, 1, 1, 0, 0, 0}, 100)
+	defer old.Clear()
+	current := statusListFromJSON(t, "did:example:issuer/revreg/0", []int{0, 1, 0, 1, 1, 0}, 200)
+	defer current.Clear()
+
+	diff, err := anoncreds.DiffStatusLists(old, current)
+	if err != nil {
+		t.Fatalf("Failed to diff status lists: %v", err)
+	}
+	if !reflect.DeepEqual(diff.Revoked, []uint32{3, 4}) {
+		t.Errorf("Expected newly revoked [3 4], got %v", diff.Revoked)
+	}
+	if !reflect.DeepEqual(diff.Issued, []uint32{2}) {
+		t.Errorf("Expected newly issued [2], got %v", diff.Issued)
+	}
+	if *diff.FromTimestamp != 100 || *diff.ToTimestamp != 200 {
+		t.Errorf("Unexpected timestamps %d and %d", *diff.FromTimestamp, *diff.ToTimestamp)
+	}
+
+	other := statusListFromJSON(t, "did:example:issuer/revreg/1", []int{0, 0, 0, 0, 0, 0}, 200)
+	defer other.Clear()
+	if _, err := anoncreds.DiffStatusLists(old, other); err == nil {
+		t.Error("Expected diffing lists of different registries to fail")
+	}
+	bitmap, err := old.Bitmap()
+	if err != nil {
+		t.Fatalf("Failed to decode bitmap: %v", err)
+	}
+	if _, err := anoncreds.DiffBitmaps(bitmap, nil); err == nil {
+		t.Error("Expected diffing against a nil bitmap to fail")
+	}
+	if _, err := anoncreds.DiffBitmaps(nil, bitmap); err == nil {
+		t.Error("Expected diffing from a nil bitmap to fail")
+	}
+}
@@ -0,0 +1,287 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+	"github.com/Ajna-inc/anoncreds-go/pkg/tails"
+)
+
+func writeTestTailsFile(t *testing.T, dir string, count int) (string, string) {
+	t.Helper()
+	tailList := make([][]byte, count)
+	for i := range tailList {
+		tailList[i] = make([]byte, tails.TailSize)
+		rand.Read(tailList[i])
+	}
+	path := filepath.Join(dir, "tails.bin")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create tails file: %v", err)
+	}
+	defer file.Close()
+	tailsHash, err := tails.WriteFile(file, tailList)
+	if err != nil {
+		t.Fatalf("Failed to write tails file: %v", err)
+	}
+	return path, tailsHash
+}
+
+func uploadTails(t *testing.T, url, path string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("tails", "tails.bin")
+	content, _ := os.ReadFile(path)
+	part.Write(content)
+	form.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to upload tails file: %v", err)
+	}
+	return resp
+}
+
+func TestTailsHashAndReader(t *testing.T) {
+	empty, err := tails.Hash(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if empty != "GKot5hBsd81kMupNCXHaqbhv3huEbxAFMLnpcX2hniwn" {
+		t.Errorf("Unexpected hash of empty content: %s", empty)
+	}
+
+	path, tailsHash := writeTestTailsFile(t, t.TempDir(), 5)
+	if err := tails.VerifyFile(path, tailsHash); err != nil {
+		t.Errorf("Expected tails file to verify: %v", err)
+	}
+	if err := tails.VerifyFile(path, empty); err == nil {
+		t.Error("Expected hash mismatch to be reported")
+	}
+
+	reader, err := tails.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open tails file: %v", err)
+	}
+	defer reader.Close()
+	if reader.Count() != 5 {
+		t.Errorf("Expected 5 tails, got %d", reader.Count())
+	}
+	if _, err := reader.Tail(4); err != nil {
+		t.Errorf("Failed to read last tail: %v", err)
+	}
+	if _, err := reader.Tail(5); err == nil {
+		t.Error("Expected out of range tail to fail")
+	}
+}
+
+func TestTailsStoreRejectsMismatch(t *testing.T) {
+	store, err := tails.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	path, tailsHash := writeTestTailsFile(t, t.TempDir(), 3)
+
+	if _, err := store.PutFile(path, "GKot5hBsd81kMupNCXHaqbhv3huEbxAFMLnpcX2hniwn"); err == nil {
+		t.Error("Expected store to reject a file with the wrong hash")
+	}
+	entries, _ := os.ReadDir(store.Dir)
+	if len(entries) != 0 {
+		t.Errorf("Rejected upload left %d files behind", len(entries))
+	}
+
+	stored, err := store.PutFile(path, tailsHash)
+	if err != nil {
+		t.Fatalf("Failed to store tails file: %v", err)
+	}
+	if stored != tailsHash || !store.Has(tailsHash) {
+		t.Errorf("Expected tails file to be stored under %s", tailsHash)
+	}
+	if _, err := store.Path("../escape"); err == nil {
+		t.Error("Expected non-base58 hash to be rejected")
+	}
+}
+
+func TestTailsServerAndFetcher(t *testing.T) {
+	serverStore, _ := tails.NewStore(t.TempDir())
+	server := tails.NewServer(serverStore)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	path, tailsHash := writeTestTailsFile(t, t.TempDir(), 4)
+	revRegID := "55GkHamhTU1ZbTbV2ab9DE:4:55GkHamhTU1ZbTbV2ab9DE:3:CL:1:default:CL_ACCUM:0"
+
+	// Uploads by registry ID cannot be verified without a resolver
+	resp := uploadTails(t, httpServer.URL+"/"+revRegID, path)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected upload by ID without a resolver to be refused, got %d", resp.StatusCode)
+	}
+	server.ResolveTailsHash = func(id string) (string, error) {
+		if id != revRegID {
+			return "", fmt.Errorf("unknown registry %s", id)
+		}
+		return tailsHash, nil
+	}
+
+	resp = uploadTails(t, httpServer.URL+"/"+revRegID, path)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != tailsHash {
+		t.Fatalf("Unexpected upload response %d: %s", resp.StatusCode, body)
+	}
+
+	resp = uploadTails(t, httpServer.URL+"/"+revRegID, path)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected conflict on second upload, got %d", resp.StatusCode)
+	}
+
+	otherPath, _ := writeTestTailsFile(t, t.TempDir(), 2)
+	resp = uploadTails(t, httpServer.URL+"/hash/"+tailsHash+"x", otherPath)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected hash mismatch to be rejected, got %d", resp.StatusCode)
+	}
+
+	holderStore, _ := tails.NewStore(t.TempDir())
+	fetcher := tails.NewFetcher(holderStore)
+	local, err := fetcher.Fetch(context.Background(), httpServer.URL+"/"+revRegID, tailsHash)
+	if err != nil {
+		t.Fatalf("Failed to fetch tails file: %v", err)
+	}
+	if err := tails.VerifyFile(local, tailsHash); err != nil {
+		t.Errorf("Fetched file does not verify: %v", err)
+	}
+
+	// Fetching a location whose content does not match the expected hash fails
+	if _, err := fetcher.Fetch(context.Background(), httpServer.URL+"/hash/"+tailsHash, "GKot5hBsd81kMupNCXHaqbhv3huEbxAFMLnpcX2hniwn"); err == nil {
+		t.Error("Expected fetch with wrong hash to fail")
+	}
+
+	// Cached files are served without contacting the server
+	httpServer.Close()
+	if _, err := fetcher.Fetch(context.Background(), httpServer.URL+"/hash/"+tailsHash, tailsHash); err != nil {
+		t.Errorf("Expected cached fetch to succeed: %v", err)
+	}
+}
+
+func TestTailsServerConcurrentUploads(t *testing.T) {
+	serverStore, _ := tails.NewStore(t.TempDir())
+	server := tails.NewServer(serverStore)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	path, tailsHash := writeTestTailsFile(t, t.TempDir(), 4)
+	otherPath, _ := writeTestTailsFile(t, t.TempDir(), 4)
+	revRegID := "55GkHamhTU1ZbTbV2ab9DE:4:55GkHamhTU1ZbTbV2ab9DE:3:CL:1:default:CL_ACCUM:0"
+	server.ResolveTailsHash = func(string) (string, error) { return tailsHash, nil }
+
+	// A file that does not match the registry's hash is rejected
+	resp := uploadTails(t, httpServer.URL+"/"+revRegID, otherPath)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a mismatching upload to be rejected, got %d", resp.StatusCode)
+	}
+
+	content, _ := os.ReadFile(path)
+	statuses := make(chan int, 8)
+	for i := 0; i < cap(statuses); i++ {
+		go func() {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile("tails", "tails.bin")
+			part.Write(content)
+			form.Close()
+			req, _ := http.NewRequest(http.MethodPut, httpServer.URL+"/"+revRegID, &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	accepted := 0
+	for i := 0; i < cap(statuses); i++ {
+		switch status := <-statuses; status {
+		case http.StatusOK:
+			accepted++
+		case http.StatusConflict:
+		default:
+			t.Errorf("Unexpected upload status %d", status)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("Expected exactly one upload to be accepted, got %d", accepted)
+	}
+	if registered, ok := server.Lookup(revRegID); !ok || registered != tailsHash {
+		t.Errorf("Expected %s to be registered, got %q", tailsHash, registered)
+	}
+	if err := tails.VerifyFile(filepath.Join(serverStore.Dir, tailsHash), tailsHash); err != nil {
+		t.Errorf("Stored file does not verify: %v", err)
+	}
+}
+
+func TestTailsFetchForRegistry(t *testing.T) {
+	path, tailsHash := writeTestTailsFile(t, t.TempDir(), 4)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, path)
+	}))
+	defer httpServer.Close()
+	registry := func(tailsLocation string, maxCredNum int) *anoncreds.RevocationRegistryDefinition {
+		revRegDef, err := anoncreds.RevocationRegistryDefinitionFromJSON(fmt.Sprintf(`{
+			"issuerId": "55GkHamhTU1ZbTbV2ab9DE",
+			"revocDefType": "CL_ACCUM",
+			"credDefId": "55GkHamhTU1ZbTbV2ab9DE:3:CL:1:default",
+			"tag": "default",
+			"value": {"maxCredNum": %d, "publicKeys": {"accumKey": {"z": "1"}}, "tailsHash": %q, "tailsLocation": %q}
+		}`, maxCredNum, tailsHash, tailsLocation))
+		if err != nil {
+			t.Fatalf("Failed to load revocation registry definition: %v", err)
+		}
+		t.Cleanup(revRegDef.Clear)
+		return revRegDef
+	}
+
+	// A registry cannot point the holder at a local file unless the caller allows it
+	store, _ := tails.NewStore(t.TempDir())
+	fetcher := tails.NewFetcher(store)
+	if _, err := fetcher.FetchForRegistry(context.Background(), registry(path, 4)); err == nil {
+		t.Error("Expected a local tails location to be rejected")
+	}
+	fetcher.AllowLocalFiles = true
+	if _, err := fetcher.FetchForRegistry(context.Background(), registry(path, 4)); err != nil {
+		t.Errorf("Expected an allowed local tails location to be read: %v", err)
+	}
+
+	// Downloads are cut off at the largest file the registry's maxCredNum allows
+	store, _ = tails.NewStore(t.TempDir())
+	fetcher = tails.NewFetcher(store)
+	if _, err := fetcher.FetchForRegistry(context.Background(), registry(httpServer.URL, 1)); err == nil {
+		t.Error("Expected a tails file larger than the registry allows to be rejected")
+	}
+	local, err := fetcher.FetchForRegistry(context.Background(), registry(httpServer.URL, 4))
+	if err != nil {
+		t.Fatalf("Failed to fetch tails file: %v", err)
+	}
+	if err := tails.VerifyFile(local, tailsHash); err != nil {
+		t.Errorf("Fetched file does not verify: %v", err)
+	}
+}
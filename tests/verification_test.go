@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/anoncreds"
+)
+
+func TestParseRevealedPresentation(t *testing.T) {
+	revealed, err := anoncreds.ParseRevealedPresentation([]byte(`{
+		"proof": {},
+		"requested_proof": {
+			"revealed_attrs": {"name": {"sub_proof_index": 0, "raw": "Alice", "encoded": "1139481716457488690172217916278103335"}},
+			"revealed_attr_groups": {"identity": {"sub_proof_index": 1, "values": {"department": {"raw": "Engineering", "encoded": "42"}}}},
+			"self_attested_attrs": {"nickname": "Al"},
+			"unrevealed_attrs": {"ssn": {"sub_proof_index": 0}},
+			"predicates": {"adult": {"sub_proof_index": 1}}
+		},
+		"identifiers": [
+			{"schema_id": "schema-a", "cred_def_id": "creddef-a", "rev_reg_id": null, "timestamp": null},
+			{"schema_id": "schema-b", "cred_def_id": "creddef-b", "rev_reg_id": "revreg-b", "timestamp": 1700000000}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse presentation: %v", err)
+	}
+
+	name := revealed.Attributes["name"]
+	if name.Value.Raw != "Alice" || name.Identifier.CredentialDefinitionID != "creddef-a" || name.Identifier.Timestamp != nil {
+		t.Errorf("Unexpected revealed attribute %+v", name)
+	}
+	identity := revealed.AttributeGroups["identity"]
+	if identity.Values["department"].Raw != "Engineering" || identity.Identifier.RevocationRegistryID != "revreg-b" {
+		t.Errorf("Unexpected attribute group %+v", identity)
+	}
+	if revealed.SelfAttested["nickname"] != "Al" {
+		t.Errorf("Unexpected self-attested values %v", revealed.SelfAttested)
+	}
+	if revealed.Unrevealed["ssn"].Identifier.SchemaID != "schema-a" {
+		t.Errorf("Unexpected unrevealed attribute %+v", revealed.Unrevealed["ssn"])
+	}
+	adult := revealed.Predicates["adult"]
+	if adult.Identifier.Timestamp == nil || *adult.Identifier.Timestamp != 1700000000 {
+		t.Errorf("Unexpected predicate %+v", adult)
+	}
+
+	// A referent pointing past the identifiers is malformed
+	if _, err := anoncreds.ParseRevealedPresentation([]byte(`{
+		"requested_proof": {"revealed_attrs": {"name": {"sub_proof_index": 3, "raw": "Alice", "encoded": "1"}}},
+		"identifiers": []
+	}`)); err == nil {
+		t.Error("Expected an error for a missing identifier")
+	}
+}
+
+func TestVerifyPresentationWithReport(t *testing.T) {
+	fixture := newHolderFixture(t, map[string]string{"name": "Alice", "age": "28", "department": "Engineering"})
+	defer fixture.Clear()
+
+	presReq, err := anoncreds.PresentationRequestFromJSON(`{
+		"name": "employment",
+		"version": "1.0",
+		"nonce": "982734598237459827345",
+		"requested_attributes": {
+			"name": {"name": "name", "restrictions": [{"cred_def_id": "` + presentationCredDefID + `"}]}
+		},
+		"requested_predicates": {
+			"adult": {"name": "age", "p_type": ">=", "p_value": 18}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to load presentation request: %v", err)
+	}
+	defer presReq.Clear()
+
+	presentation := fixture.present(t, presReq, nil)
+	defer presentation.Clear()
+
+	options := anoncreds.VerifyPresentationOptions{
+		Presentation:          presentation,
+		PresentationRequest:   presReq,
+		Schemas:               map[string]*anoncreds.Schema{presentationSchemaID: fixture.schema},
+		CredentialDefinitions: map[string]*anoncreds.CredentialDefinition{presentationCredDefID: fixture.credDef.CredentialDefinition},
+	}
+	report, err := anoncreds.VerifyPresentationWithReport(options)
+	if err != nil {
+		t.Fatalf("Failed to verify presentation: %v", err)
+	}
+	if !report.Verified || len(report.Referents) != 2 {
+		t.Fatalf("Expected a verified report with 2 referents, got %+v", report)
+	}
+	for _, referent := range report.Referents {
+		if referent.Status != anoncreds.ReferentVerified || referent.Identifier.SchemaID != presentationSchemaID {
+			t.Errorf("Unexpected referent report %+v", referent)
+		}
+	}
+
+	// Without the credential definition every referent fails before the proof is checked
+	options.CredentialDefinitions = nil
+	report, err = anoncreds.VerifyPresentationWithReport(options)
+	if err != nil {
+		t.Fatalf("Failed to verify presentation: %v", err)
+	}
+	if report.Verified || report.Reason == "" || report.Referents[0].Status != anoncreds.ReferentFailed {
+		t.Errorf("Expected a failed report, got %+v", report)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/storage"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+)
+
+const walletCredentialJSON = `{
+	"schema_id": "55GkHamhTU1ZbTbV2ab9DE:2:Employee ID:1.0",
+	"cred_def_id": "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+	"rev_reg_id": null,
+	"values": {
+		"First Name": {"raw": "Alice", "encoded": "1139481716457488690172217916278103335"},
+		"age": {"raw": "28", "encoded": "28"}
+	},
+	"signature": {},
+	"signature_correctness_proof": {}
+}`
+
+func TestCredentialRecordTags(t *testing.T) {
+	record, err := wallet.NewCredentialRecordFromJSON([]byte(walletCredentialJSON), wallet.CredentialMetadata{
+		LinkSecretID: "default",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build record: %v", err)
+	}
+	if record.Referent == "" {
+		t.Error("Expected a generated referent")
+	}
+
+	expected := map[string]string{
+		"schema_id":               "55GkHamhTU1ZbTbV2ab9DE:2:Employee ID:1.0",
+		"schema_name":             "Employee ID",
+		"schema_version":          "1.0",
+		"schema_issuer_id":        "55GkHamhTU1ZbTbV2ab9DE",
+		"issuer_id":               "55GkHamhTU1ZbTbV2ab9DE",
+		"cred_def_id":             "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+		"attr::firstname::value":  "Alice",
+		"attr::firstname::marker": "1",
+		"attr::age::value":        "28",
+		"attr::age::marker":       "1",
+	}
+	for key, value := range expected {
+		if record.Tags[key] != value {
+			t.Errorf("Expected tag %s=%q, got %q", key, value, record.Tags[key])
+		}
+	}
+	if _, ok := record.Tags["rev_reg_id"]; ok {
+		t.Error("Expected no rev_reg_id tag for a non-revocable credential")
+	}
+	if value, ok := record.Attribute("first name"); !ok || value != "Alice" {
+		t.Errorf("Expected normalized attribute lookup to find Alice, got %q", value)
+	}
+}
+
+func TestCredentialStores(t *testing.T) {
+	fileStore, err := wallet.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	stores := map[string]wallet.CredentialStore{
+		"memory": wallet.NewMemoryStore(),
+		"file":   fileStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			first, err := wallet.NewCredentialRecordFromJSON([]byte(walletCredentialJSON), wallet.CredentialMetadata{})
+			if err != nil {
+				t.Fatalf("Failed to build record: %v", err)
+			}
+			second, err := wallet.NewCredentialRecordFromJSON([]byte(walletCredentialJSON), wallet.CredentialMetadata{Referent: "alice-employee"})
+			if err != nil {
+				t.Fatalf("Failed to build record: %v", err)
+			}
+			if first.Referent == second.Referent {
+				t.Error("Expected distinct referents")
+			}
+			for _, record := range []*wallet.CredentialRecord{first, second} {
+				if err := store.Put(record); err != nil {
+					t.Fatalf("Failed to store credential: %v", err)
+				}
+			}
+
+			loaded, err := store.Get("alice-employee")
+			if err != nil {
+				t.Fatalf("Failed to get credential: %v", err)
+			}
+			if loaded.CredentialDefinitionID != "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default" || loaded.Attributes["age"] != "28" {
+				t.Errorf("Unexpected record %+v", loaded)
+			}
+			if loaded.Tags["attr::age::value"] != "28" {
+				t.Errorf("Expected tags to survive storage, got %v", loaded.Tags)
+			}
+
+			records, err := store.List()
+			if err != nil || len(records) != 2 {
+				t.Fatalf("Expected 2 records, got %d (%v)", len(records), err)
+			}
+
+			if err := store.Delete("alice-employee"); err != nil {
+				t.Fatalf("Failed to delete credential: %v", err)
+			}
+			if _, err := store.Get("alice-employee"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("Expected not found after delete, got %v", err)
+			}
+			if err := store.Delete("alice-employee"); !errors.Is(err, wallet.ErrCredentialNotFound) {
+				t.Errorf("Expected not found on second delete, got %v", err)
+			}
+		})
+	}
+}
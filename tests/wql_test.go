@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Ajna-inc/anoncreds-go/pkg/wallet"
+	"github.com/Ajna-inc/anoncreds-go/pkg/wql"
+)
+
+var wqlTags = map[string]string{
+	"schema_id":          "55GkHamhTU1ZbTbV2ab9DE:2:Employee ID:1.0",
+	"schema_name":        "Employee ID",
+	"schema_version":     "1.0",
+	"issuer_id":          "55GkHamhTU1ZbTbV2ab9DE",
+	"cred_def_id":        "55GkHamhTU1ZbTbV2ab9DE:3:CL:15:default",
+	"attr::name::value":  "Alice",
+	"attr::name::marker": "1",
+	"attr::age::value":   "28",
+	"attr::age::marker":  "1",
+}
+
+func TestWQLCompliance(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		match bool
+	}{
+		{"empty query", `{}`, true},
+		{"implicit eq", `{"attr::name::value": "Alice"}`, true},
+		{"implicit eq mismatch", `{"attr::name::value": "Bob"}`, false},
+		{"explicit eq", `{"schema_name": {"$eq": "Employee ID"}}`, true},
+		{"neq", `{"attr::name::value": {"$neq": "Bob"}}`, true},
+		{"neq on missing tag", `{"attr::salary::value": {"$neq": "1"}}`, false},
+		{"gt", `{"attr::age::value": {"$gt": "27"}}`, true},
+		{"gte", `{"attr::age::value": {"$gte": "28"}}`, true},
+		{"lt", `{"attr::age::value": {"$lt": "28"}}`, false},
+		{"lte", `{"attr::age::value": {"$lte": "28"}}`, true},
+		{"string comparison", `{"attr::age::value": {"$gt": "3"}}`, false},
+		{"like prefix", `{"schema_id": {"$like": "55GkHamhTU1ZbTbV2ab9DE:2:%"}}`, true},
+		{"like single char", `{"attr::name::value": {"$like": "Al_ce"}}`, true},
+		{"like no wildcard", `{"attr::name::value": {"$like": "Ali"}}`, false},
+		{"like escapes regexp", `{"schema_version": {"$like": "1_0"}}`, true},
+		{"like dot is literal", `{"schema_version": {"$like": "1x0"}}`, false},
+		{"in", `{"attr::name::value": {"$in": ["Bob", "Alice"]}}`, true},
+		{"in mismatch", `{"attr::name::value": {"$in": ["Bob", "Carol"]}}`, false},
+		{"implicit and", `{"schema_name": "Employee ID", "attr::age::value": "28"}`, true},
+		{"implicit and mismatch", `{"schema_name": "Employee ID", "attr::age::value": "30"}`, false},
+		{"and", `{"$and": [{"schema_name": "Employee ID"}, {"attr::age::value": "28"}]}`, true},
+		{"empty and", `{"$and": []}`, true},
+		{"or", `{"$or": [{"attr::name::value": "Bob"}, {"attr::age::value": "28"}]}`, true},
+		{"or mismatch", `{"$or": [{"attr::name::value": "Bob"}, {"attr::age::value": "30"}]}`, false},
+		{"empty or", `{"$or": []}`, false},
+		{"not", `{"$not": {"attr::name::value": "Bob"}}`, true},
+		{"not mismatch", `{"$not": {"attr::name::value": "Alice"}}`, false},
+		{"exist", `{"$exist": ["attr::name::marker", "attr::age::marker"]}`, true},
+		{"exist single", `{"$exist": "attr::salary::marker"}`, false},
+		{"plaintext marker", `{"~attr::name::value": "Alice"}`, true},
+		{"nested", `{"$and": [{"$or": [{"issuer_id": "other"}, {"schema_version": {"$like": "1.%"}}]}, {"$not": {"$exist": ["rev_reg_id"]}}]}`, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := wql.Parse([]byte(tc.query))
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", tc.query, err)
+			}
+			if got := query.Match(wqlTags); got != tc.match {
+				t.Errorf("Query %s: expected %v, got %v", tc.query, tc.match, got)
+			}
+		})
+	}
+}
+
+func TestWQLParseErrors(t *testing.T) {
+	invalid := []string{
+		`[]`,
+		`{"$and": {}}`,
+		`{"$or": [1]}`,
+		`{"$unknown": []}`,
+		`{"tag": {"$foo": "x"}}`,
+		`{"tag": {"$eq": 1}}`,
+		`{"tag": {"$in": "x"}}`,
+		`{"tag": {"$in": [1]}}`,
+		`{"tag": {"$eq": "x", "$neq": "y"}}`,
+		`{"tag": 5}`,
+		`{"$exist": 5}`,
+		`{"tag": `,
+	}
+	for _, query := range invalid {
+		if _, err := wql.Parse([]byte(query)); err == nil {
+			t.Errorf("Expected %s to be rejected", query)
+		}
+	}
+}
+
+func TestRestrictionQuery(t *testing.T) {
+	store := wallet.NewMemoryStore()
+	record, err := wallet.NewCredentialRecordFromJSON([]byte(walletCredentialJSON), wallet.CredentialMetadata{Referent: "employee"})
+	if err != nil {
+		t.Fatalf("Failed to build record: %v", err)
+	}
+	if err := store.Put(record); err != nil {
+		t.Fatalf("Failed to store record: %v", err)
+	}
+
+	cases := []struct {
+		restrictions interface{}
+		match        bool
+	}{
+		{nil, true},
+		{[]interface{}{}, true},
+		{[]interface{}{map[string]interface{}{"issuer_did": "55GkHamhTU1ZbTbV2ab9DE"}}, true},
+		{[]interface{}{map[string]interface{}{"schema_issuer_did": "other"}}, false},
+		{[]interface{}{
+			map[string]interface{}{"cred_def_id": "other"},
+			map[string]interface{}{"schema_name": "Employee ID", "attr::First Name::value": "Alice"},
+		}, true},
+		{map[string]interface{}{"$not": map[string]interface{}{"schema_version": "1.0"}}, false},
+	}
+	for i, tc := range cases {
+		query, err := wallet.RestrictionQuery(tc.restrictions)
+		if err != nil {
+			t.Fatalf("Case %d: failed to parse restrictions: %v", i, err)
+		}
+		matches, err := wallet.Search(store, query)
+		if err != nil {
+			t.Fatalf("Case %d: search failed: %v", i, err)
+		}
+		if (len(matches) == 1) != tc.match {
+			t.Errorf("Case %d: expected match %v, got %d results", i, tc.match, len(matches))
+		}
+	}
+}